@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/generator"
+	"github.com/tomfevang/go-test-my-db/internal/mcptools"
+	"github.com/tomfevang/go-test-my-db/internal/sqlscan"
+)
+
+// readStatements are the leading keywords of statements run_query accepts
+// without allow_writes.
+var readStatements = []string{"SELECT", "WITH", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "TABLE", "VALUES"}
+
+// isReadStatement reports whether query starts with a read-only keyword and
+// has no INTO clause. It is a first line of defence: DDL would implicitly
+// commit a READ ONLY transaction, while DML hidden behind WITH is rejected by
+// one. A READ ONLY transaction does not stop SELECT ... INTO OUTFILE or
+// DUMPFILE from writing files on the server, so INTO is rejected outright,
+// along with the harmless INTO @var.
+func isReadStatement(query string) bool {
+	tokens := sqlscan.Tokenize(query)
+	for i, t := range tokens {
+		if t.Kind == sqlscan.Punct && t.Text == "(" {
+			continue // (SELECT ...) UNION (SELECT ...)
+		}
+		if !slices.ContainsFunc(readStatements, t.Is) {
+			return false
+		}
+		return !slices.ContainsFunc(tokens[i+1:], func(t sqlscan.Token) bool { return t.Is("INTO") })
+	}
+	return false
+}
+
+// renderOnce renders the template functions in query once, as a test run
+// would.
+func renderOnce(db *sql.DB, query string) (string, error) {
+	if !strings.Contains(query, "{{") {
+		return query, nil
+	}
+	fm := generator.FuncMap(gofakeit.New(0))
+	fm["SampleRow"] = makeSampleRowFunc(db, nil)
+	tmpl, err := template.New("query").Funcs(fm).Parse(query)
+	if err != nil {
+		return "", fmt.Errorf("invalid query template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return "", fmt.Errorf("rendering query template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// RunQuery runs one statement and returns up to req.Limit rows. Without
+// req.AllowWrites only reads are accepted, inside a READ ONLY transaction.
+func (e *mcpEngine) RunQuery(ctx context.Context, req mcptools.QueryRequest) (*mcptools.QueryRows, error) {
+	db, _, closeDB, err := connectEngine(ctx, req.DSN, false, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	query, err := renderOnce(db, req.Query)
+	if err != nil {
+		return nil, err
+	}
+	read := isReadStatement(query)
+	if !read && !req.AllowWrites {
+		return nil, fmt.Errorf("only SELECT, WITH, SHOW, DESCRIBE, EXPLAIN, TABLE and VALUES statements without INTO are allowed; set allow_writes to run other statements")
+	}
+
+	out := &mcptools.QueryRows{Query: query, Rows: [][]any{}}
+	start := time.Now()
+	if !read {
+		res, err := db.ExecContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		out.DurationMS = durationMS(time.Since(start))
+		out.RowsAffected, _ = res.RowsAffected()
+		return out, nil
+	}
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: !req.AllowWrites})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if out.Columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullString, len(out.Columns))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(out.Rows) == req.Limit {
+			out.Truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]any, len(values))
+		for i, v := range values {
+			if v.Valid {
+				row[i] = v.String
+			}
+		}
+		out.Rows = append(out.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out.DurationMS = durationMS(time.Since(start))
+	return out, nil
+}
+
+// ExplainQuery returns the tabular and JSON plans of one statement with a
+// summary of each table access.
+func (e *mcpEngine) ExplainQuery(ctx context.Context, req mcptools.QueryRequest) (*mcptools.Explanation, error) {
+	db, _, closeDB, err := connectEngine(ctx, req.DSN, false, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	query, err := renderOnce(db, req.Query)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	plan, err := explainQuery(ctx, conn, query)
+	if err != nil {
+		return nil, err
+	}
+	var raw string
+	if err := conn.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+query).Scan(&raw); err != nil {
+		return nil, fmt.Errorf("EXPLAIN FORMAT=JSON: %w", err)
+	}
+	out := &mcptools.Explanation{
+		Query:   query,
+		Plan:    mcptools.QueryPlan{Columns: plan.Columns, Rows: plan.Rows},
+		Summary: summarizePlan(plan),
+	}
+	if err := json.Unmarshal([]byte(raw), &out.JSON); err != nil {
+		return nil, fmt.Errorf("parsing EXPLAIN FORMAT=JSON: %w", err)
+	}
+	return out, nil
+}
+
+// summarizePlan describes each row of a tabular EXPLAIN in one line and
+// flags the usual suspects: full scans, filesorts and temporary tables.
+func summarizePlan(plan *queryPlan) []string {
+	col := make(map[string]int, len(plan.Columns))
+	for i, c := range plan.Columns {
+		col[c] = i
+	}
+	get := func(row []string, name string) string {
+		if i, ok := col[name]; ok && row[i] != "NULL" {
+			return row[i]
+		}
+		return ""
+	}
+
+	lines := make([]string, 0, len(plan.Rows))
+	for _, row := range plan.Rows {
+		table, access := get(row, "table"), get(row, "type")
+		if table == "" {
+			table = "(no table)"
+		}
+		var line string
+		switch {
+		case access == "ALL":
+			line = fmt.Sprintf("%s: FULL TABLE SCAN of ~%s rows", table, get(row, "rows"))
+		case access == "index":
+			line = fmt.Sprintf("%s: full index scan on %s (~%s rows)", table, get(row, "key"), get(row, "rows"))
+		case get(row, "key") != "":
+			line = fmt.Sprintf("%s: %s lookup on %s (~%s rows)", table, access, get(row, "key"), get(row, "rows"))
+		case access != "":
+			line = fmt.Sprintf("%s: %s access (~%s rows)", table, access, get(row, "rows"))
+		default:
+			line = table
+		}
+		if f := get(row, "filtered"); f != "" && f != "100" && f != "100.00" {
+			line += fmt.Sprintf(", %s%% kept by the WHERE clause", f)
+		}
+		extra := get(row, "Extra")
+		for _, flag := range []string{"Using filesort", "Using temporary", "Using index condition", "Using index", "Using join buffer"} {
+			if strings.Contains(extra, flag) {
+				line += "; " + strings.TrimPrefix(flag, "Using ")
+				if flag == "Using index" {
+					line += " (covering)"
+				}
+				extra = strings.ReplaceAll(extra, flag, "")
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// BenchmarkQuery times one statement through the test runner, against the
+// data already in the database. Writes are rolled back after each run.
+func (e *mcpEngine) BenchmarkQuery(ctx context.Context, req mcptools.BenchmarkRequest) (*mcptools.Benchmark, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	db, _, closeDB, err := connectEngine(ctx, req.DSN, false, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	tc := config.TestCase{Name: "benchmark_query", Query: req.Query, Repeat: req.Repeat, Warmup: req.Warmup}
+	if !isReadStatement(req.Query) {
+		if !req.AllowWrites {
+			return nil, fmt.Errorf("only read statements can be benchmarked without allow_writes")
+		}
+		tc.Kind, tc.Rollback = config.TestKindWrite, true
+	}
+	if err := useConfigPercentiles(nil); err != nil {
+		return nil, err
+	}
+
+	results := runTests(ctx, db, []config.TestCase{tc}, true, nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if results[0].Error != nil {
+		return nil, results[0].Error
+	}
+	run := testRun(ConfigResult{Results: results})
+	return &mcptools.Benchmark{Result: run.Results[0], Report: buildTestReport(results)}, nil
+}
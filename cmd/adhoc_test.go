@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsReadStatement(t *testing.T) {
+	for query, want := range map[string]bool{
+		"SELECT 1":                              true,
+		"  select * from t":                     true,
+		"/* hint */ SELECT 1":                   true,
+		"(SELECT 1) UNION (SELECT 2)":           true,
+		"WITH x AS (SELECT 1) SELECT * FROM x":  true,
+		"SHOW INDEX FROM t":                     true,
+		"EXPLAIN SELECT 1":                      true,
+		"UPDATE t SET a = 1":                    false,
+		"DROP TABLE t":                          false,
+		"-- SELECT\nDELETE FROM t":              false,
+		"INSERT INTO t SELECT * FROM u":         false,
+		"SELECT * FROM t INTO OUTFILE '/tmp/t'": false,
+		"SELECT 1 INTO DUMPFILE '/tmp/x'":       false,
+		"SELECT id INTO @id FROM t":             false,
+		"SELECT 'INTO' AS `into` FROM t":        true,
+		"":                                      false,
+	} {
+		if got := isReadStatement(query); got != want {
+			t.Errorf("isReadStatement(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestSummarizePlan(t *testing.T) {
+	plan := &queryPlan{
+		Columns: []string{"id", "select_type", "table", "type", "key", "rows", "filtered", "Extra"},
+		Rows: [][]string{
+			{"1", "SIMPLE", "orders", "ALL", "NULL", "98000", "10.00", "Using where; Using temporary; Using filesort"},
+			{"1", "SIMPLE", "users", "eq_ref", "PRIMARY", "1", "100.00", "NULL"},
+			{"1", "SIMPLE", "items", "ref", "idx_order", "4", "100.00", "Using index condition"},
+			{"1", "SIMPLE", "tags", "index", "idx_name", "50", "100.00", "Using index"},
+		},
+	}
+	want := []string{
+		"orders: FULL TABLE SCAN of ~98000 rows, 10.00% kept by the WHERE clause; filesort; temporary",
+		"users: eq_ref lookup on PRIMARY (~1 rows)",
+		"items: ref lookup on idx_order (~4 rows); index condition",
+		"tags: full index scan on idx_name (~50 rows); index (covering)",
+	}
+	if got := summarizePlan(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizePlan:\n got %q\nwant %q", got, want)
+	}
+}
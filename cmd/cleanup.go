@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tomfevang/go-test-my-db/internal/ephemeral"
+)
+
+var cleanupDryRun bool
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Drop tables and containers kept by test or compare --keep",
+	Long: `The cleanup subcommand removes exactly what earlier runs with --keep
+left behind: the tables they created, and for --ephemeral runs, the MySQL
+container. Kept runs are tracked in a state file in the user cache directory.`,
+	Args: cobra.NoArgs,
+	RunE: runCleanup,
+}
+
+func init() {
+	cleanupCmd.Flags().BoolVar(&cleanupDryRun, "dry-run", false, "List what would be removed without removing it")
+
+	rootCmd.AddCommand(cleanupCmd)
+}
+
+func runCleanup(cmd *cobra.Command, args []string) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	state, err := loadState(path)
+	if err != nil {
+		return fmt.Errorf("loading state: %w", err)
+	}
+	if len(state.Runs) == 0 {
+		fmt.Println("Nothing to clean up.")
+		return nil
+	}
+
+	var remaining []keptRun
+	for _, run := range state.Runs {
+		target := redactDSNPassword(run.DSN)
+		if run.ContainerID != "" {
+			target = fmt.Sprintf("container %s", run.ContainerID)
+		}
+		if cleanupDryRun {
+			fmt.Printf("Would remove %d tables from %s: %v\n", len(run.Tables), target, run.Tables)
+			continue
+		}
+
+		if run.ContainerID != "" {
+			// Removing the container discards its tables with it.
+			ephemeral.Attach(run.ContainerID, run.Runtime).Stop()
+			fmt.Printf("Removed %s\n", target)
+			continue
+		}
+
+		if err := dropKeptTables(run); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not clean up %s: %v\n", target, err)
+			remaining = append(remaining, run)
+			continue
+		}
+		fmt.Printf("Dropped %d tables from %s\n", len(run.Tables), target)
+	}
+
+	if cleanupDryRun {
+		return nil
+	}
+	state.Runs = remaining
+	if err := state.save(path); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	if len(remaining) > 0 {
+		return fmt.Errorf("%d kept run(s) could not be cleaned up", len(remaining))
+	}
+	return nil
+}
+
+// dropKeptTables connects to the run's database and drops its tables.
+func dropKeptTables(run keptRun) error {
+	db, err := sql.Open("mysql", run.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Pin a single connection so SET FOREIGN_KEY_CHECKS applies to the drops.
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		return err
+	}
+	dropTables(db, run.Tables)
+	return nil
+}
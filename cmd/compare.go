@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"database/sql"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -31,6 +34,11 @@ var (
 	compareDeferIndexes bool
 	compareFKSampleSize int
 	compareEphemeral    bool
+	compareReuseData    bool
+	compareKeep         bool
+	compareSeed         int64
+	compareReportPath   string
+	comparePercentiles  []float64
 )
 
 var compareCmd = &cobra.Command{
@@ -75,6 +83,11 @@ func init() {
 	compareCmd.Flags().BoolVar(&compareDeferIndexes, "defer-indexes", false, "Drop secondary indexes before seeding and rebuild after (overrides all configs)")
 	compareCmd.Flags().IntVar(&compareFKSampleSize, "fk-sample-size", 0, "Override max FK parent values to cache per column (0 = use each config's value)")
 	compareCmd.Flags().BoolVar(&compareEphemeral, "ephemeral", false, "Start a temporary MySQL container via Docker or Podman (no DSN needed)")
+	compareCmd.Flags().BoolVar(&compareKeep, "keep", false, "Keep every variant's tables (and --ephemeral container) after the run; use keep: true per config entry to keep only some")
+	compareCmd.Flags().Int64Var(&compareSeed, "seed", 0, "Seed for reproducible data in every config (0 = each config's options.seed)")
+	compareCmd.Flags().Float64SliceVar(&comparePercentiles, "percentiles", defaultPercentiles, "Latency percentiles to report (overrides options.percentiles of the first config that sets it)")
+	compareCmd.Flags().StringVar(&compareReportPath, "report", "", "Write a self-contained HTML report with charts and EXPLAIN plans to this file")
+	compareCmd.Flags().BoolVar(&compareReuseData, "reuse-data", false, "Restore cached snapshots of identical seeded datasets instead of re-seeding (saved on first run)")
 
 	rootCmd.AddCommand(compareCmd)
 }
@@ -84,26 +97,125 @@ type compareEntry struct {
 	cfg   *config.Config
 	label string
 	path  string
+	keep  bool
+}
+
+// compareOverrides are values applied to every config of a comparison. Zero
+// values defer to each config's options.
+type compareOverrides struct {
+	Rows         int
+	BatchSize    int
+	Workers      int
+	MinChildren  int
+	MaxChildren  int
+	MaxRows      int
+	FKSampleSize int
+	LoadData     bool
+	DeferIndexes bool
+	ReuseData    bool
+	Keep         bool
+	Explain      bool
+}
+
+// pipelineOptions resolves the run options of one config: override → config
+// value → default.
+func (o compareOverrides) pipelineOptions(e compareEntry) pipelineOptions {
+	return pipelineOptions{
+		SchemaFile:   e.cfg.Options.Schema,
+		Rows:         resolveOverride(o.Rows, e.cfg.Options.Rows, 1000),
+		BatchSize:    resolveOverride(o.BatchSize, e.cfg.Options.BatchSize, 1000),
+		Workers:      resolveOverride(o.Workers, e.cfg.Options.Workers, 4),
+		MinChildren:  resolveOverride(o.MinChildren, e.cfg.Options.ChildrenPerParent.Min, 10),
+		MaxChildren:  resolveOverride(o.MaxChildren, e.cfg.Options.ChildrenPerParent.Max, 100),
+		MaxRows:      resolveOverride(o.MaxRows, e.cfg.Options.MaxRows, 10_000_000),
+		LoadData:     e.cfg.Options.LoadData || o.LoadData,
+		DeferIndexes: e.cfg.Options.DeferIndexes || o.DeferIndexes,
+		FKSampleSize: resolveOverride(o.FKSampleSize, e.cfg.Options.FKSampleSize, 500_000),
+		SeedTables:   e.cfg.Options.SeedTables,
+		ReuseData:    o.ReuseData,
+		Keep:         o.Keep || e.keep,
+		Explain:      o.Explain,
+	}
 }
 
 func runCompare(cmd *cobra.Command, args []string) error {
-	cc, err := config.LoadCompare(args[0])
+	entries, cc, err := loadCompareEntries(args[0])
+	if err != nil {
+		return err
+	}
+
+	if reportPercentiles, err = resolvePercentiles(cmd, comparePercentiles, comparePercentilesOf(entries)); err != nil {
+		return err
+	}
+
+	if cmd.Flags().Changed("seed") {
+		for _, e := range entries {
+			e.cfg.Options.Seed = compareSeed
+		}
+	} else if cc.HasVerify() {
+		shareSeed(entries)
+	}
+
+	return executeComparison(cmd, entries)
+}
+
+// loadCompareEntries loads a comparison file and the seed configs it lists,
+// giving each config the comparison's tests for its label.
+func loadCompareEntries(path string) ([]compareEntry, *config.CompareConfig, error) {
+	cc, err := config.LoadCompare(path)
 	if err != nil {
-		return fmt.Errorf("loading comparison config %s: %w", args[0], err)
+		return nil, nil, fmt.Errorf("loading comparison config %s: %w", path, err)
 	}
 
 	entries := make([]compareEntry, len(cc.Configs))
 	for i, entry := range cc.Configs {
-		cfg, err := config.Load(entry.File)
+		cfg, err := config.Load(entry.File, entry.Profile)
 		if err != nil {
-			return fmt.Errorf("loading seed config %s (label %q): %w", entry.File, entry.Label, err)
+			return nil, nil, fmt.Errorf("loading seed config %s (label %q): %w", entry.File, entry.Label, err)
 		}
 		// Replace the seed config's tests with the comparison-config tests for this label.
 		cfg.Tests = cc.TestCasesForLabel(entry.Label)
-		entries[i] = compareEntry{cfg: cfg, label: entry.Label, path: entry.File}
+		entries[i] = compareEntry{cfg: cfg, label: entry.Label, path: entry.File, keep: entry.Keep}
 	}
+	return entries, cc, nil
+}
 
-	return executeComparison(cmd, entries)
+// comparePercentilesOf returns options.percentiles of the first config that
+// sets it.
+func comparePercentilesOf(entries []compareEntry) []float64 {
+	for _, e := range entries {
+		if len(e.cfg.Options.Percentiles) > 0 {
+			return e.cfg.Options.Percentiles
+		}
+	}
+	return nil
+}
+
+// shareSeed gives every config the same data seed so that verified tests
+// compare answers over identical data. Configs without a seed take the first
+// configured one (or defaultVerifySeed); differing explicit seeds are left
+// alone with a warning.
+func shareSeed(entries []compareEntry) {
+	var seed int64
+	for _, e := range entries {
+		s := e.cfg.Options.Seed
+		if s == 0 {
+			continue
+		}
+		if seed == 0 {
+			seed = s
+		} else if s != seed {
+			fmt.Fprintf(os.Stderr, "warning: configs use different seeds (%d, %d); verified tests may report mismatches caused by different data\n", seed, s)
+		}
+	}
+	if seed == 0 {
+		seed = defaultVerifySeed
+	}
+	for _, e := range entries {
+		if e.cfg.Options.Seed == 0 {
+			e.cfg.Options.Seed = seed
+		}
+	}
 }
 
 // executeComparison runs the shared comparison pipeline: connect, seed, test, report.
@@ -122,13 +234,21 @@ func executeComparison(cmd *cobra.Command, entries []compareEntry) error {
 			}
 		}
 	}
-	// Start ephemeral MySQL if requested and no DSN was provided.
+	// Start ephemeral MySQL if requested and no DSN was provided. It is
+	// stopped on the way out unless keepRun records it.
+	var edb *ephemeral.DB
+	recorded := false
 	if compareEphemeral && dsnVal == "" {
-		edb, err := ephemeral.Start(cmd.Context())
+		var err error
+		edb, err = ephemeral.Start(cmd.Context())
 		if err != nil {
 			return err
 		}
-		defer edb.Stop()
+		defer func() {
+			if !recorded {
+				edb.Stop()
+			}
+		}()
 		dsnVal = edb.DSN
 	}
 
@@ -164,30 +284,52 @@ func executeComparison(cmd *cobra.Command, entries []compareEntry) error {
 	}
 	fmt.Printf("Connected to %s\n\n", schema)
 
+	overrides := compareOverrides{
+		Rows:         compareRows,
+		BatchSize:    compareBatchSize,
+		Workers:      compareWorkers,
+		MinChildren:  compareMinChildren,
+		MaxChildren:  compareMaxChildren,
+		MaxRows:      compareMaxRows,
+		FKSampleSize: compareFKSampleSize,
+		LoadData:     compareLoadData,
+		DeferIndexes: compareDeferIndexes,
+		ReuseData:    compareReuseData,
+		Keep:         compareKeep,
+		Explain:      compareReportPath != "",
+	}
+
 	// Run each config sequentially.
 	total := len(entries)
 	results := make([]ConfigResult, total)
+	kept := make(map[string]string) // table -> label of the variant that kept it
 	for i, e := range entries {
 		schemaFile := e.cfg.Options.Schema
 		if schemaFile == "" {
 			return fmt.Errorf("config %s (label %q) does not specify a schema file (options.schema)", e.path, e.label)
 		}
 
-		rows := resolveOverride(compareRows, e.cfg.Options.Rows, 1000)
-		batchSize := resolveOverride(compareBatchSize, e.cfg.Options.BatchSize, 1000)
-		workers := resolveOverride(compareWorkers, e.cfg.Options.Workers, 4)
-		minC := resolveOverride(compareMinChildren, e.cfg.Options.ChildrenPerParent.Min, 10)
-		maxC := resolveOverride(compareMaxChildren, e.cfg.Options.ChildrenPerParent.Max, 100)
-		maxR := resolveOverride(compareMaxRows, e.cfg.Options.MaxRows, 10_000_000)
-
+		opts := overrides.pipelineOptions(e)
+		rows, keep := opts.Rows, opts.Keep
 		fmt.Printf("[%d/%d] Running: %s (%s, %d base rows)...\n", i+1, total, e.label, schemaFile, rows)
 		start := time.Now()
 
-		loadData := e.cfg.Options.LoadData || compareLoadData
-		deferIdx := e.cfg.Options.DeferIndexes || compareDeferIndexes
-		fkSample := resolveOverride(compareFKSampleSize, e.cfg.Options.FKSampleSize, 500_000)
-		testResults, tableCount, err := runTestPipeline(db, schema, e.cfg, schemaFile, rows, batchSize, workers, minC, maxC, maxR, loadData, deferIdx, fkSample, e.cfg.Options.SeedTables)
+		testResults, tables, err := runTestPipeline(db, schema, e.cfg, opts)
 		duration := time.Since(start)
+		tableCount := len(tables)
+
+		// Variants share one database, so a later variant with the same table
+		// names replaces tables an earlier variant kept.
+		for _, t := range tables {
+			if owner, ok := kept[t]; ok && owner != e.label {
+				fmt.Fprintf(os.Stderr, "warning: table %s kept from %q was replaced by %q\n", t, owner, e.label)
+			}
+			if keep {
+				kept[t] = e.label
+			} else {
+				delete(kept, t)
+			}
+		}
 
 		results[i] = ConfigResult{
 			ConfigPath: e.path,
@@ -198,6 +340,7 @@ func executeComparison(cmd *cobra.Command, entries []compareEntry) error {
 			Results:    testResults,
 			Error:      err,
 			Duration:   duration,
+			Config:     effectiveConfig(e.cfg, opts),
 		}
 
 		if err != nil {
@@ -212,6 +355,17 @@ func executeComparison(cmd *cobra.Command, entries []compareEntry) error {
 	report := buildComparisonReport(results)
 	fmt.Print(report)
 
+	if compareReportPath != "" {
+		if err := writeHTMLReport(compareReportPath, "Schema comparison: "+filepath.Base(cmd.Flags().Arg(0)), results); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		fmt.Printf("\nWrote HTML report to %s\n", compareReportPath)
+	}
+
+	if len(kept) > 0 {
+		recorded = keepRun(dsnVal, slices.Sorted(maps.Keys(kept)), edb)
+	}
+
 	// If --ai flag set, pipe to Claude.
 	if compareAI {
 		fmt.Println()
@@ -220,6 +374,9 @@ func executeComparison(cmd *cobra.Command, entries []compareEntry) error {
 		}
 	}
 
+	if mismatched := verifyMismatches(results); len(mismatched) > 0 {
+		return fmt.Errorf("result verification failed for %d test(s)", len(mismatched))
+	}
 	return nil
 }
 
@@ -275,28 +432,38 @@ func buildComparisonReport(configs []ConfigResult) string {
 		fmt.Fprintf(&sb, "\n--- %s ---\n", testName)
 
 		w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
-		fmt.Fprintf(w, "  Config\tAvg\tMin\tMax\tp95\tRows\n")
+		header := append(append([]string{"Config"}, latencyHeader()...), "Rows", "Examined", "Exam/Row")
+		fmt.Fprint(w, "  "+tabRow(header...))
 
 		for ci, c := range configs {
 			r, ok := lookup[resultKey{ci, testName}]
 			if !ok || c.Error != nil {
-				fmt.Fprintf(w, "  %s\t-\t-\t-\t-\t-\n", c.Label)
+				cells := make([]string, len(header))
+				cells[0] = c.Label
+				for i := 1; i < len(cells); i++ {
+					cells[i] = "-"
+				}
+				fmt.Fprint(w, "  "+tabRow(cells...))
 				continue
 			}
 			if r.Error != nil {
-				fmt.Fprintf(w, "  %s\tERROR: %v\t\t\t\t\n", c.Label, r.Error)
+				fmt.Fprintf(w, "  %s\tERROR: %v\t\t\t\t\t\t\n", c.Label, r.Error)
 				continue
 			}
-			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%d\n",
-				c.Label,
-				formatDuration(avg(r.Timings)),
-				formatDuration(min(r.Timings)),
-				formatDuration(max(r.Timings)),
-				formatDuration(percentile(r.Timings, 95)),
-				r.RowCount,
-			)
+			examined := "-"
+			if r.Metrics != nil {
+				examined = formatCount(r.Metrics.RowsExamined)
+			}
+			cells := append([]string{c.Label}, latencyCells(r.Latency)...)
+			fmt.Fprint(w, "  "+tabRow(append(cells, r.rowsLabel(), examined, r.Metrics.examinedPerRow())...))
 		}
 		w.Flush()
+		writeVerifyLine(&sb, configs, testName)
+	}
+
+	if mismatched := verifyMismatches(configs); len(mismatched) > 0 {
+		fmt.Fprintf(&sb, "\nResult verification FAILED for %d test(s): %s\n", len(mismatched), strings.Join(mismatched, ", "))
+		sb.WriteString("A variant that returns different rows is not a faster equivalent.\n")
 	}
 
 	return sb.String()
@@ -378,7 +545,7 @@ func analyzeWithAI(report string, configs []ConfigResult) error {
 	prompt.WriteString("\n\nAnalyze:\n")
 	prompt.WriteString("1. Which schema design performs best for each query pattern and why\n")
 	prompt.WriteString("2. The tradeoffs between each schema approach\n")
-	prompt.WriteString("3. How each schema handles off-index queries\n")
+	prompt.WriteString("3. How each schema handles off-index queries (rows examined per row returned shows scan overhead)\n")
 	prompt.WriteString("4. Specific recommendations based on the timing data\n")
 
 	return runClaude(prompt.String())
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+)
+
+// queryPlan is the tabular EXPLAIN output of a test query.
+type queryPlan struct {
+	Query   string // the statement that was explained
+	Columns []string
+	Rows    [][]string
+}
+
+// explainQuery runs EXPLAIN for query on conn. args are bound to ?
+// placeholders for prepared tests.
+func explainQuery(ctx context.Context, conn *sql.Conn, query string, args ...any) (*queryPlan, error) {
+	rows, err := conn.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	plan := &queryPlan{Query: query, Columns: cols}
+	values := make([]sql.NullString, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(cols))
+		for i, v := range values {
+			row[i] = "NULL"
+			if v.Valid {
+				row[i] = v.String
+			}
+		}
+		plan.Rows = append(plan.Rows, row)
+	}
+	return plan, rows.Err()
+}
@@ -0,0 +1,496 @@
+package cmd
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tomfevang/go-test-my-db/internal/stats"
+	"github.com/tomfevang/go-test-my-db/internal/version"
+)
+
+// chartPalette colours series in the order configs appear.
+var chartPalette = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7"}
+
+const (
+	chartWidth  = 720
+	chartLabelW = 170 // left margin for series labels
+	chartRight  = 90  // right margin for value labels
+	histBins    = 24
+)
+
+// writeHTMLReport writes a self-contained HTML report of one or more runs:
+// latency charts per test, EXPLAIN plans and each run's effective config.
+func writeHTMLReport(path, title string, configs []ConfigResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := renderHTMLReport(f, title, configs); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// renderHTMLReport writes the report to w. The page references no external
+// assets, so it can be attached to a review or opened offline.
+func renderHTMLReport(w io.Writer, title string, configs []ConfigResult) error {
+	return htmlReportTemplate.Execute(w, buildHTMLReport(title, configs, time.Now()))
+}
+
+type htmlReport struct {
+	Title     string
+	Generated string
+	Version   string
+	Runs      []htmlRun
+	Scaling   []htmlChart // latency against row count, one per test
+	Columns   []string    // latency and row columns of the stats tables
+	Tests     []htmlTest
+}
+
+type htmlRun struct {
+	Label    string
+	Schema   string
+	Rows     int
+	Tables   int
+	Duration string
+	Error    string
+	Config   string // effective config as YAML
+}
+
+type htmlChart struct {
+	Name string
+	SVG  template.HTML
+}
+
+type htmlTest struct {
+	Name       string
+	Bars       template.HTML // average per config; only with several configs
+	BoxPlots   template.HTML
+	Histograms template.HTML
+	Stats      []htmlStat
+	Plans      []htmlPlan
+}
+
+type htmlStat struct {
+	Label string
+	Cells []string // matches htmlReport.Columns
+	Error string
+}
+
+type htmlPlan struct {
+	Label string
+	Plan  *queryPlan
+}
+
+func buildHTMLReport(title string, configs []ConfigResult, now time.Time) htmlReport {
+	r := htmlReport{
+		Title:     title,
+		Generated: now.Format("2006-01-02 15:04:05 MST"),
+		Version:   version.Version(),
+		Columns:   append(latencyHeader(), "Rows", "Runs"),
+	}
+	for _, c := range configs {
+		run := htmlRun{
+			Label:    c.Label,
+			Schema:   c.SchemaFile,
+			Rows:     c.Rows,
+			Tables:   c.TableCount,
+			Duration: c.Duration.Round(time.Millisecond).String(),
+		}
+		if c.Error != nil {
+			run.Error = c.Error.Error()
+		}
+		if c.Config != nil {
+			if out, err := yaml.Marshal(c.Config); err == nil {
+				run.Config = string(out)
+			}
+		}
+		r.Runs = append(r.Runs, run)
+	}
+
+	names := collectTestNames(configs)
+	if isScalingRun(configs) {
+		sorted := slices.Clone(configs)
+		slices.SortFunc(sorted, func(a, b ConfigResult) int { return a.Rows - b.Rows })
+		for _, name := range names {
+			r.Scaling = append(r.Scaling, htmlChart{Name: name, SVG: scalingChart(sorted, name)})
+		}
+	}
+
+	for _, name := range names {
+		t := htmlTest{Name: name}
+		var (
+			labels    []string
+			latencies []*stats.Recorder
+		)
+		for _, c := range configs {
+			res := testResultFor(c, name)
+			if res == nil {
+				continue
+			}
+			stat := htmlStat{Label: c.Label}
+			if res.Error != nil {
+				stat.Error = res.Error.Error()
+				t.Stats = append(t.Stats, stat)
+				continue
+			}
+			stat.Cells = res.resultCells()[1:]
+			t.Stats = append(t.Stats, stat)
+			if res.Plan != nil {
+				t.Plans = append(t.Plans, htmlPlan{Label: c.Label, Plan: res.Plan})
+			}
+			if res.Latency.Count() > 0 {
+				labels = append(labels, c.Label)
+				latencies = append(latencies, res.Latency)
+			}
+		}
+		if len(latencies) > 0 {
+			if len(latencies) > 1 {
+				avgs := make([]time.Duration, len(latencies))
+				for i, l := range latencies {
+					avgs[i] = l.Mean()
+				}
+				t.Bars = barChart(labels, avgs)
+			}
+			t.BoxPlots = boxPlots(labels, latencies)
+			t.Histograms = histograms(labels, latencies)
+		}
+		r.Tests = append(r.Tests, t)
+	}
+	return r
+}
+
+// testResultFor returns the named test's result in c, failed or not.
+func testResultFor(c ConfigResult, name string) *TestResult {
+	for i := range c.Results {
+		if c.Results[i].Name == name {
+			return &c.Results[i]
+		}
+	}
+	return nil
+}
+
+// isScalingRun reports whether configs ran the same schema at different row
+// counts, in which case latency is plotted against rows.
+func isScalingRun(configs []ConfigResult) bool {
+	if len(configs) < 2 {
+		return false
+	}
+	seen := make(map[int]bool, len(configs))
+	for _, c := range configs {
+		if c.SchemaFile != configs[0].SchemaFile || seen[c.Rows] || c.Rows <= 0 {
+			return false
+		}
+		seen[c.Rows] = true
+	}
+	return true
+}
+
+// ms converts a duration to fractional milliseconds for plotting.
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// svgText writes an SVG text element with escaped content.
+func svgText(sb *strings.Builder, x, y float64, anchor, text string) {
+	fmt.Fprintf(sb, `<text x="%.1f" y="%.1f" text-anchor="%s">%s</text>`, x, y, anchor, template.HTMLEscapeString(text))
+}
+
+// barChart draws one horizontal bar per label.
+func barChart(labels []string, values []time.Duration) template.HTML {
+	const rowH = 26
+	height := len(labels)*rowH + 8
+	var top time.Duration
+	for _, v := range values {
+		if v > top {
+			top = v
+		}
+	}
+	plotW := float64(chartWidth - chartLabelW - chartRight)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg class="chart" viewBox="0 0 %d %d" width="%d" height="%d">`, chartWidth, height, chartWidth, height)
+	for i, label := range labels {
+		y := float64(i*rowH + 4)
+		w := 0.0
+		if top > 0 {
+			w = plotW * ms(values[i]) / ms(top)
+		}
+		svgText(&sb, chartLabelW-8, y+15, "end", label)
+		fmt.Fprintf(&sb, `<rect x="%d" y="%.1f" width="%.1f" height="%d" fill="%s"/>`, chartLabelW, y, w, rowH-8, chartPalette[i%len(chartPalette)])
+		svgText(&sb, chartLabelW+w+6, y+15, "start", formatDuration(values[i]))
+	}
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+// boxPlots draws a box plot per label on a shared axis: whiskers at min and
+// max, the box from p25 to p75, and ticks at the median and p95.
+func boxPlots(labels []string, latencies []*stats.Recorder) template.HTML {
+	const rowH = 30
+	height := len(labels)*rowH + 24
+	var top time.Duration
+	for _, l := range latencies {
+		top = max(top, l.Max())
+	}
+	plotW := float64(chartWidth - chartLabelW - chartRight)
+	x := func(d time.Duration) float64 {
+		if top == 0 {
+			return chartLabelW
+		}
+		return chartLabelW + plotW*ms(d)/ms(top)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg class="chart" viewBox="0 0 %d %d" width="%d" height="%d">`, chartWidth, height, chartWidth, height)
+	for i, l := range latencies {
+		y := float64(i*rowH + 4)
+		mid := y + (rowH-8)/2
+		color := chartPalette[i%len(chartPalette)]
+		lo, q1, med, q3, p95, hi := l.Min(), l.Percentile(25), l.Percentile(50), l.Percentile(75), l.Percentile(95), l.Max()
+		svgText(&sb, chartLabelW-8, mid+5, "end", labels[i])
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#555"/>`, x(lo), mid, x(hi), mid)
+		fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%d" fill="%s" fill-opacity="0.6" stroke="%s"/>`,
+			x(q1), y, math.Max(x(q3)-x(q1), 1), rowH-8, color, color)
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#000" stroke-width="2"/>`, x(med), y, x(med), y+rowH-8)
+		fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#000" stroke-dasharray="2,2"/>`, x(p95), y, x(p95), y+rowH-8)
+		svgText(&sb, x(hi)+6, mid+5, "start", "p95 "+formatDuration(p95))
+	}
+	axisY := float64(len(labels)*rowH + 6)
+	fmt.Fprintf(&sb, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#999"/>`, chartLabelW, axisY, x(top), axisY)
+	svgText(&sb, chartLabelW, axisY+14, "start", "0")
+	svgText(&sb, x(top), axisY+14, "end", formatDuration(top))
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+// histograms draws a latency histogram per label, all on the same bins.
+func histograms(labels []string, latencies []*stats.Recorder) template.HTML {
+	const rowH = 60
+	lo, hi := latencies[0].Min(), latencies[0].Max()
+	for _, l := range latencies {
+		lo = min(lo, l.Min())
+		hi = max(hi, l.Max())
+	}
+	binW := float64(hi-lo) / histBins
+	bin := func(d time.Duration) int {
+		if binW == 0 {
+			return 0
+		}
+		b := int(float64(d-lo) / binW)
+		if b >= histBins {
+			b = histBins - 1
+		}
+		return b
+	}
+	plotW := float64(chartWidth - chartLabelW - chartRight)
+	barW := plotW / histBins
+	height := len(labels)*rowH + 22
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg class="chart" viewBox="0 0 %d %d" width="%d" height="%d">`, chartWidth, height, chartWidth, height)
+	for i, l := range latencies {
+		var counts [histBins]int
+		peak := 0
+		l.Each(func(d time.Duration, n int) {
+			b := bin(d)
+			counts[b] += n
+			peak = max(peak, counts[b])
+		})
+		base := float64(i*rowH + rowH - 4)
+		svgText(&sb, chartLabelW-8, base-rowH/2+8, "end", labels[i])
+		for b, n := range counts {
+			if n == 0 {
+				continue
+			}
+			h := float64(rowH-10) * float64(n) / float64(peak)
+			fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%d runs</title></rect>`,
+				chartLabelW+float64(b)*barW+1, base-h, barW-2, h, chartPalette[i%len(chartPalette)], n)
+		}
+		fmt.Fprintf(&sb, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ccc"/>`, chartLabelW, base, chartLabelW+plotW, base)
+	}
+	axisY := float64(len(labels)*rowH + 12)
+	svgText(&sb, chartLabelW, axisY, "start", formatDuration(lo))
+	svgText(&sb, chartLabelW+plotW, axisY, "end", formatDuration(hi))
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+// scalingChart plots the average and tail latency (the highest report
+// percentile) of a test against the row count of each config, on a logarithmic row axis. configs must be sorted
+// by rows.
+func scalingChart(configs []ConfigResult, name string) template.HTML {
+	const height, top, bottom = 240, 16, 40
+	type point struct {
+		rows      int
+		avg, tail time.Duration
+	}
+	tailPct := slices.Max(reportPercentiles)
+	var points []point
+	var peak time.Duration
+	for _, c := range configs {
+		res := findResult(c, name)
+		if res == nil || res.Latency.Count() == 0 {
+			continue
+		}
+		p := point{c.Rows, res.Latency.Mean(), res.Latency.Percentile(tailPct)}
+		points = append(points, p)
+		if p.tail > peak {
+			peak = p.tail
+		}
+	}
+	if len(points) < 2 || peak == 0 {
+		return ""
+	}
+
+	left, plotW := 70.0, float64(chartWidth-70-chartRight)
+	plotH := float64(height - top - bottom)
+	lx0, lx1 := math.Log10(float64(points[0].rows)), math.Log10(float64(points[len(points)-1].rows))
+	x := func(rows int) float64 {
+		return left + plotW*(math.Log10(float64(rows))-lx0)/(lx1-lx0)
+	}
+	y := func(d time.Duration) float64 {
+		return top + plotH*(1-ms(d)/ms(peak))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg class="chart" viewBox="0 0 %d %d" width="%d" height="%d">`, chartWidth, height, chartWidth, height)
+	fmt.Fprintf(&sb, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%.1f" stroke="#999"/>`, left, top, left, top+plotH)
+	fmt.Fprintf(&sb, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#999"/>`, left, top+plotH, left+plotW, top+plotH)
+	svgText(&sb, left-6, top+10, "end", formatDuration(peak))
+	svgText(&sb, left-6, top+plotH, "end", "0")
+	for _, p := range points {
+		svgText(&sb, x(p.rows), top+plotH+16, "middle", strconv.Itoa(p.rows))
+	}
+	svgText(&sb, left+plotW/2, height-4, "middle", "rows (log scale)")
+
+	series := []struct {
+		name  string
+		value func(point) time.Duration
+	}{
+		{"avg", func(p point) time.Duration { return p.avg }},
+		{"p95", func(p point) time.Duration { return p.tail }},
+	}
+	for si, s := range series {
+		color := chartPalette[si]
+		var path []string
+		for _, p := range points {
+			path = append(path, fmt.Sprintf("%.1f,%.1f", x(p.rows), y(s.value(p))))
+		}
+		fmt.Fprintf(&sb, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(path, " "), color)
+		for _, p := range points {
+			fmt.Fprintf(&sb, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s: %s at %d rows</title></circle>`,
+				x(p.rows), y(s.value(p)), color, s.name, formatDuration(s.value(p)), p.rows)
+		}
+		last := points[len(points)-1]
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%.1f" fill="%s">%s</text>`, x(last.rows)+8, y(s.value(last))+4, color, s.name)
+	}
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font: 14px/1.45 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; max-width: 1000px; margin: 2em auto; padding: 0 1em; }
+h1 { margin-bottom: 0.2em; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: 0.2em; margin-top: 2em; }
+.meta { color: #666; }
+table { border-collapse: collapse; margin: 0.8em 0; }
+th, td { border: 1px solid #ddd; padding: 3px 8px; text-align: left; vertical-align: top; }
+th { background: #f5f5f5; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.error { color: #b00020; }
+.chart { display: block; margin: 0.5em 0; max-width: 100%; height: auto; }
+.chart text { font-size: 12px; fill: #333; }
+pre { background: #f7f7f7; padding: 0.8em; overflow-x: auto; font-size: 12px; }
+details { margin: 0.5em 0; }
+summary { cursor: pointer; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">Generated {{.Generated}} by go-test-my-db {{.Version}}</p>
+
+<h2>Runs</h2>
+<table>
+<tr><th>Run</th><th>Schema</th><th>Rows</th><th>Tables</th><th>Duration</th></tr>
+{{- range .Runs}}
+<tr><td>{{.Label}}</td><td>{{.Schema}}</td><td class="num">{{.Rows}}</td><td class="num">{{.Tables}}</td><td class="num">{{.Duration}}</td></tr>
+{{- if .Error}}
+<tr><td colspan="5" class="error">ERROR: {{.Error}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+
+{{- if .Scaling}}
+<h2>Scaling</h2>
+{{- range .Scaling}}
+{{- if .SVG}}
+<h3>{{.Name}}</h3>
+{{.SVG}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+<h2>Tests</h2>
+{{- range .Tests}}
+<h3>{{.Name}}</h3>
+<table>
+<tr><th>Run</th>{{range $.Columns}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Stats}}
+{{- if .Error}}
+<tr><td>{{.Label}}</td><td colspan="{{len $.Columns}}" class="error">ERROR: {{.Error}}</td></tr>
+{{- else}}
+<tr><td>{{.Label}}</td>{{range .Cells}}<td class="num">{{.}}</td>{{end}}</tr>
+{{- end}}
+{{- end}}
+</table>
+{{- if .Bars}}
+<h4>Average latency</h4>
+{{.Bars}}
+{{- end}}
+{{- if .BoxPlots}}
+<h4>Latency distribution</h4>
+{{.BoxPlots}}
+{{.Histograms}}
+{{- end}}
+{{- range .Plans}}
+<details>
+<summary>EXPLAIN ({{.Label}})</summary>
+<pre>{{.Plan.Query}}</pre>
+<table>
+<tr>{{range .Plan.Columns}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Plan.Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+</details>
+{{- end}}
+{{- end}}
+
+<h2>Effective config</h2>
+{{- range .Runs}}
+{{- if .Config}}
+<details>
+<summary>{{.Label}}</summary>
+<pre>{{.Config}}</pre>
+</details>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
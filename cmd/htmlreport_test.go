@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/stats"
+)
+
+func msLatency(ms ...int) *stats.Recorder {
+	var r stats.Recorder
+	for _, m := range ms {
+		r.Record(time.Duration(m) * time.Millisecond)
+	}
+	return &r
+}
+
+func TestRenderHTMLReport(t *testing.T) {
+	configs := []ConfigResult{
+		{Label: "baseline", SchemaFile: "a.sql", Rows: 1000, TableCount: 2, Config: &config.Config{Options: config.Options{Rows: 1000}},
+			Results: []TestResult{
+				{Name: "by <status>", Latency: msLatency(1, 2, 3, 4), RowCount: 3,
+					Plan: &queryPlan{Query: "SELECT 1", Columns: []string{"id", "key"}, Rows: [][]string{{"1", "NULL"}}}},
+				{Name: "broken", Error: errors.New("no such table")},
+			}},
+		{Label: "indexed", SchemaFile: "b.sql", Rows: 1000, TableCount: 2,
+			Results: []TestResult{{Name: "by <status>", Latency: msLatency(1, 1, 2)}}},
+	}
+	var buf bytes.Buffer
+	if err := renderHTMLReport(&buf, "Comparison", configs); err != nil {
+		t.Fatalf("renderHTMLReport: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<h3>by &lt;status&gt;</h3>",
+		"ERROR: no such table",
+		"EXPLAIN (baseline)",
+		"<td>NULL</td>",
+		"Average latency", // bars with more than one config
+		"rows: 1000",      // effective config
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+	if strings.Contains(out, "<h2>Scaling</h2>") {
+		t.Error("different schemas must not be plotted as a scaling run")
+	}
+	// Self-contained: nothing is loaded from elsewhere.
+	if m := regexp.MustCompile(`(?i)(src|href)=|@import|url\(`).FindString(out); m != "" {
+		t.Errorf("report references an external asset: %s", m)
+	}
+	if strings.Count(out, "<svg") < 3 {
+		t.Errorf("expected bar, box and histogram charts, got %d svg elements", strings.Count(out, "<svg"))
+	}
+}
+
+func TestRenderHTMLReport_EffectiveConfigSecrets(t *testing.T) {
+	cfg := &config.Config{
+		Include:  []string{"secrets.yaml"},
+		Options:  config.Options{DSN: "root:hunter2@tcp(localhost:3306)/app"},
+		Profiles: map[string]config.Profile{"perf": {Options: config.Options{DSN: "admin:s3cret@tcp(prod:3306)/db"}}},
+	}
+	configs := []ConfigResult{{Label: "perf", Config: effectiveConfig(cfg, pipelineOptions{})}}
+	var buf bytes.Buffer
+	if err := renderHTMLReport(&buf, "Secrets", configs); err != nil {
+		t.Fatalf("renderHTMLReport: %v", err)
+	}
+	out := buf.String()
+	for _, secret := range []string{"hunter2", "s3cret", "prod:3306", "secrets.yaml"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("report leaks %q", secret)
+		}
+	}
+	if cfg.Profiles == nil || cfg.Options.DSN != "root:hunter2@tcp(localhost:3306)/app" {
+		t.Error("effectiveConfig changed the config it copies")
+	}
+}
+
+func TestRenderHTMLReport_Scaling(t *testing.T) {
+	configs := []ConfigResult{
+		{Label: "10000 rows", SchemaFile: "s.sql", Rows: 10000, Results: []TestResult{{Name: "q", Latency: msLatency(5, 6)}}},
+		{Label: "1000 rows", SchemaFile: "s.sql", Rows: 1000, Results: []TestResult{{Name: "q", Latency: msLatency(1, 2)}}},
+	}
+	if !isScalingRun(configs) {
+		t.Fatal("same schema at different row counts should be a scaling run")
+	}
+	var buf bytes.Buffer
+	if err := renderHTMLReport(&buf, "Scaling", configs); err != nil {
+		t.Fatalf("renderHTMLReport: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "<h2>Scaling</h2>") || !strings.Contains(out, "<polyline") {
+		t.Error("report lacks the scaling chart")
+	}
+	if !strings.Contains(out, "at 1000 rows") || !strings.Contains(out, "at 10000 rows") {
+		t.Error("scaling chart lacks a point per row count")
+	}
+
+	configs[1].Rows = 10000
+	if isScalingRun(configs) {
+		t.Error("runs with the same row count are not a scaling run")
+	}
+}
+
+func TestBuildScalingReport(t *testing.T) {
+	runs := []ConfigResult{
+		{Rows: 1000, Results: []TestResult{{Name: "q", Latency: msLatency(2)}, {Name: "w", Error: errors.New("x")}}},
+		{Rows: 10000, Results: []TestResult{{Name: "q", Latency: msLatency(10)}, {Name: "w", Latency: msLatency(1)}}},
+	}
+	report := buildScalingReport(runs)
+	if !strings.Contains(report, "1000 rows") || !strings.Contains(report, "10000 rows") {
+		t.Errorf("report lacks row count headers:\n%s", report)
+	}
+	if !regexp.MustCompile(`q\s+2\.00ms\s+10\.00ms\s+5\.0x`).MatchString(report) {
+		t.Errorf("unexpected scaling line for q:\n%s", report)
+	}
+	if !regexp.MustCompile(`w\s+-\s+1\.00ms\s+1\.0x`).MatchString(report) {
+		t.Errorf("unexpected scaling line for w:\n%s", report)
+	}
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/tomfevang/go-test-my-db/internal/querylog"
+	"github.com/tomfevang/go-test-my-db/internal/sqlscan"
+)
+
+var (
+	importFormat     string
+	importTop        int
+	importRepeat     int
+	importOutput     string
+	importSchemaFile string
+)
+
+// importableTypes are the statement types turned into tests. DML is
+// imported as write tests that roll back every iteration.
+var importableTypes = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "REPLACE"}
+
+var importQueriesCmd = &cobra.Command{
+	Use:   "import-queries <logfile>",
+	Short: "Generate test queries from a slow log, general log or digest export",
+	Long: `The import-queries command reads captured MySQL traffic — a slow query log,
+a general query log, or a CSV/TSV export of
+performance_schema.events_statements_summary_by_digest — groups statements
+by normalised digest, and writes the top digests by total time as a tests:
+section for the config file.
+
+Literals compared against columns are replaced with SampleRow lookups, so
+each iteration runs the real query shape against values from the seeded
+data. Use "-" to read the log from stdin.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportQueries,
+}
+
+func init() {
+	importQueriesCmd.Flags().StringVar(&importFormat, "format", "auto", "Log format: auto, slow, general or digest")
+	importQueriesCmd.Flags().IntVar(&importTop, "top", 20, "Number of digests to import, by total time")
+	importQueriesCmd.Flags().IntVar(&importRepeat, "repeat", 50, "Repeat count for each generated test")
+	importQueriesCmd.Flags().StringVarP(&importOutput, "output", "o", "", "Write tests to this file instead of stdout")
+	importQueriesCmd.Flags().StringVar(&importSchemaFile, "schema", "", "Path to SQL DDL file; digests touching other tables are skipped")
+
+	rootCmd.AddCommand(importQueriesCmd)
+}
+
+func runImportQueries(cmd *cobra.Command, args []string) error {
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var known map[string]bool
+	if importSchemaFile != "" {
+		_, tableNames, err := parseDDLFile(importSchemaFile)
+		if err != nil {
+			return fmt.Errorf("parsing schema file: %w", err)
+		}
+		known = make(map[string]bool, len(tableNames))
+		for _, name := range tableNames {
+			known[name] = true
+		}
+	}
+
+	agg := querylog.NewAggregator()
+	if err := querylog.Parse(in, querylog.Format(importFormat), agg.Add); err != nil {
+		return fmt.Errorf("reading %s: %w", args[0], err)
+	}
+
+	digests := agg.Top(0, importableTypes...)
+	var tests []importedTest
+	names := make(map[string]int)
+	for _, d := range digests {
+		if importTop > 0 && len(tests) == importTop {
+			break
+		}
+		it, err := templateDigest(d, known)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping digest %s (%s): %v\n", d.ID, truncateSQL(d.Text, 60), err)
+			continue
+		}
+		// Test names must be unique within a config.
+		names[it.Name]++
+		if n := names[it.Name]; n > 1 {
+			it.Name = fmt.Sprintf("%s #%d", it.Name, n)
+		}
+		tests = append(tests, it)
+	}
+	if len(tests) == 0 {
+		return fmt.Errorf("no importable statements found in %s", args[0])
+	}
+
+	out := io.Writer(os.Stdout)
+	if importOutput != "" {
+		f, err := os.Create(importOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeImportedTests(out, args[0], tests, importRepeat); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Imported %d of %d digests\n", len(tests), len(digests))
+	return nil
+}
+
+// importedTest is a test case generated from a digest.
+type importedTest struct {
+	Name   string
+	Query  string
+	Write  bool
+	Digest querylog.Digest
+}
+
+// templateDigest turns a digest's sample statement into a query template.
+// Literals bound to a table column become SampleRow lookups; all other
+// literals are kept. It fails if digest placeholders would be left in the
+// query, since those cannot run.
+func templateDigest(d querylog.Digest, known map[string]bool) (importedTest, error) {
+	tokens := sqlscan.Tokenize(d.Sample)
+	tables, _ := sqlscan.Tables(tokens)
+	if len(tables) == 0 && d.Type != "SELECT" {
+		return importedTest{}, fmt.Errorf("no table found")
+	}
+	if known != nil {
+		for _, t := range tables {
+			if !known[t] {
+				return importedTest{}, fmt.Errorf("table %s is not in the schema", t)
+			}
+		}
+	}
+
+	// Group scalar bindings by table so each table is sampled once per
+	// iteration and the values come from the same row.
+	replace := make(map[int]string)
+	var order []string
+	columns := make(map[string][]string)
+	for _, b := range sqlscan.Bind(tokens) {
+		table, col := b.Column.Table, b.Column.Column
+		if table == "" || !identRe.MatchString(table) || !identRe.MatchString(col) {
+			continue
+		}
+		quote := tokens[b.Index].Kind != sqlscan.Number
+		if b.InList {
+			// Each IN element samples its own row so the list holds
+			// distinct values more often than not.
+			replace[b.Index] = quoteValue(fmt.Sprintf("{{(SampleRow %q %q).%s}}", table, col, col), quote)
+			continue
+		}
+		if _, ok := columns[table]; !ok {
+			order = append(order, table)
+		}
+		if !slices.Contains(columns[table], col) {
+			columns[table] = append(columns[table], col)
+		}
+		replace[b.Index] = quoteValue(fmt.Sprintf("{{$%s.%s}}", table, col), quote)
+	}
+
+	var sb strings.Builder
+	for _, table := range order {
+		fmt.Fprintf(&sb, "{{$%s := SampleRow %q", table, table)
+		for _, col := range columns[table] {
+			fmt.Fprintf(&sb, " %q", col)
+		}
+		sb.WriteString("}}")
+	}
+	for i, t := range tokens {
+		if t.Text == ";" && i == len(tokens)-1 {
+			break
+		}
+		if i > 0 && t.SpaceBefore {
+			sb.WriteByte(' ')
+		}
+		if r, ok := replace[i]; ok {
+			sb.WriteString(r)
+			continue
+		}
+		if t.Kind == sqlscan.Placeholder {
+			return importedTest{}, fmt.Errorf("placeholder %s could not be bound to a column", t.Text)
+		}
+		// A literal "{{" would start a template action.
+		sb.WriteString(strings.ReplaceAll(t.Text, "{{", `{{"{{"}}`))
+	}
+
+	var bound []string
+	for _, table := range order {
+		bound = append(bound, columns[table]...)
+	}
+	name := strings.ToLower(d.Type)
+	if len(tables) > 0 {
+		name += " " + strings.Join(tables, ", ")
+	}
+	if len(bound) > 0 {
+		name += " by " + strings.Join(bound, ", ")
+	}
+
+	return importedTest{
+		Name:   name,
+		Query:  sb.String(),
+		Write:  d.Type != "SELECT",
+		Digest: d,
+	}, nil
+}
+
+// quoteValue wraps a template action in single quotes for string literals.
+func quoteValue(action string, quote bool) string {
+	if quote {
+		return "'" + action + "'"
+	}
+	return action
+}
+
+// truncateSQL shortens a statement for log messages.
+func truncateSQL(sql string, n int) string {
+	if len(sql) <= n {
+		return sql
+	}
+	return sql[:n] + "..."
+}
+
+// writeImportedTests writes tests as a tests: section, with a comment per
+// test recording the digest it came from.
+func writeImportedTests(w io.Writer, source string, tests []importedTest, repeat int) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Generated by go-test-my-db import-queries from %s.\n", source)
+	sb.WriteString("# Digests are ordered by total time in the log; literals are sampled from\n")
+	sb.WriteString("# the seeded data with SampleRow. Write tests roll back every iteration.\n")
+	sb.WriteString("tests:\n")
+	for _, t := range tests {
+		d := t.Digest
+		fmt.Fprintf(&sb, "  # digest %s: %d calls, %s total\n", d.ID, d.Count, d.TotalTime.Round(time.Millisecond))
+		fmt.Fprintf(&sb, "  - name: %s\n", yamlScalar(t.Name))
+		fmt.Fprintf(&sb, "    query: %s\n", yamlScalar(t.Query))
+		fmt.Fprintf(&sb, "    repeat: %s\n", strconv.Itoa(repeat))
+		if t.Write {
+			sb.WriteString("    kind: write\n")
+			sb.WriteString("    rollback: true\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// yamlScalar encodes s as a single-line YAML scalar, quoting as needed.
+func yamlScalar(s string) string {
+	node := yaml.Node{Kind: yaml.ScalarNode, Value: s}
+	if strings.ContainsAny(s, "\n\r\t") {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return strconv.Quote(s)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/querylog"
+)
+
+func digestFor(sql string) querylog.Digest {
+	agg := querylog.NewAggregator()
+	agg.Add(querylog.Entry{SQL: sql, Count: 1})
+	return agg.Top(1)[0]
+}
+
+func TestTemplateDigest(t *testing.T) {
+	d := digestFor("SELECT * FROM orders o WHERE o.customer_id = 42 AND o.status = 'open' AND o.region IN (1, 2) LIMIT 10")
+	it, err := templateDigest(d, nil)
+	if err != nil {
+		t.Fatalf("templateDigest: %v", err)
+	}
+	want := `{{$orders := SampleRow "orders" "customer_id" "status"}}` +
+		`SELECT * FROM orders o WHERE o.customer_id = {{$orders.customer_id}} AND o.status = '{{$orders.status}}'` +
+		` AND o.region IN ({{(SampleRow "orders" "region").region}}, {{(SampleRow "orders" "region").region}}) LIMIT 10`
+	if it.Query != want {
+		t.Errorf("Query =\n%s\nwant\n%s", it.Query, want)
+	}
+	if it.Name != "select orders by customer_id, status" || it.Write {
+		t.Errorf("unexpected name/kind: %q write=%v", it.Name, it.Write)
+	}
+
+	// The template renders against SampleRow rows.
+	fm := template.FuncMap{"SampleRow": func(args ...string) (map[string]any, error) {
+		row := make(map[string]any)
+		for _, c := range args[1:] {
+			row[c] = c + "_v"
+		}
+		return row, nil
+	}}
+	tmpl, err := template.New("t").Funcs(fm).Parse(it.Query)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "o.customer_id = customer_id_v AND o.status = 'status_v'") {
+		t.Errorf("rendered = %q", got)
+	}
+}
+
+func TestTemplateDigest_Skips(t *testing.T) {
+	// Digest text whose placeholder is not compared to a column cannot run.
+	if _, err := templateDigest(digestFor("SELECT * FROM t LIMIT ?"), nil); err == nil {
+		t.Error("expected an error for an unbound placeholder")
+	}
+	// Tables outside the schema are rejected.
+	if _, err := templateDigest(digestFor("SELECT * FROM other WHERE id = 1"), map[string]bool{"t": true}); err == nil {
+		t.Error("expected an error for a table not in the schema")
+	}
+	// DML becomes a write test; placeholders compared to columns are bound.
+	it, err := templateDigest(digestFor("DELETE FROM t WHERE id = ?"), map[string]bool{"t": true})
+	if err != nil {
+		t.Fatalf("templateDigest: %v", err)
+	}
+	if !it.Write || !strings.Contains(it.Query, "id = '{{$t.id}}'") {
+		t.Errorf("unexpected test: %+v", it)
+	}
+}
+
+func TestWriteImportedTests(t *testing.T) {
+	tests := []importedTest{
+		{Name: "select t by id", Query: `{{$t := SampleRow "t" "id"}}SELECT 'a: b' FROM t WHERE id = {{$t.id}}`, Digest: digestFor("SELECT 'a: b' FROM t WHERE id = 1")},
+		{Name: "delete t by id", Query: "DELETE FROM t", Write: true, Digest: digestFor("DELETE FROM t")},
+	}
+	var buf bytes.Buffer
+	if err := writeImportedTests(&buf, "slow.log", tests, 25); err != nil {
+		t.Fatalf("writeImportedTests: %v", err)
+	}
+
+	var cfg config.Config
+	if err := yaml.Unmarshal(buf.Bytes(), &cfg); err != nil {
+		t.Fatalf("output is not valid YAML: %v\n%s", err, buf.String())
+	}
+	if len(cfg.Tests) != 2 {
+		t.Fatalf("expected 2 tests, got %d", len(cfg.Tests))
+	}
+	if cfg.Tests[0].Query != tests[0].Query || cfg.Tests[0].Repeat != 25 {
+		t.Errorf("first test round-tripped as %+v", cfg.Tests[0])
+	}
+	if got := cfg.Tests[1]; got.EffectiveKind() != config.TestKindWrite || !got.Rollback {
+		t.Errorf("expected a rolled-back write test, got %+v", got)
+	}
+	for _, tc := range cfg.Tests {
+		if err := tc.Validate(); err != nil {
+			t.Errorf("Validate: %v", err)
+		}
+	}
+}
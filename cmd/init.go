@@ -9,6 +9,7 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/spf13/cobra"
 
+	"github.com/tomfevang/go-test-my-db/internal/config"
 	"github.com/tomfevang/go-test-my-db/internal/generator"
 	"github.com/tomfevang/go-test-my-db/internal/introspect"
 )
@@ -100,6 +101,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 func buildInitYAML(dsnVal string, tables []*introspect.Table) string {
 	var sb strings.Builder
 
+	// Editors using the YAML language server complete and check keys
+	// against the published schema.
+	fmt.Fprintf(&sb, "# yaml-language-server: $schema=%s\n", config.SchemaURL)
 	sb.WriteString("# Generated by: go-test-my-db init\n")
 	sb.WriteString("# Review and uncomment sections as needed.\n\n")
 
@@ -135,7 +139,7 @@ func buildInitYAML(dsnVal string, tables []*introspect.Table) string {
 		// Collect columns with heuristic matches, templates, or enums.
 		var colLines []string
 		for _, col := range t.Columns {
-			if col.IsAutoInc || col.IsGenerated || col.FK != nil {
+			if col.IsAutoInc || col.IsGenerated || col.IsDefaultExpr || col.FK != nil {
 				continue
 			}
 
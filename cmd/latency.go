@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tomfevang/go-test-my-db/internal/stats"
+)
+
+// defaultPercentiles are reported when neither --percentiles nor
+// options.percentiles is set.
+var defaultPercentiles = []float64{50, 95, 99, 99.9}
+
+// reportPercentiles are the latency percentiles shown in reports, resolved
+// by each command before it runs.
+var reportPercentiles = defaultPercentiles
+
+// outlierIQRs is Tukey's k for counting outliers: runs slower than
+// Q3 + k×IQR. 3 flags only extreme outliers, such as runs that hit a stall.
+const outlierIQRs = 3
+
+// resolvePercentiles picks the report percentiles: CLI flag > config >
+// default.
+func resolvePercentiles(cmd *cobra.Command, flagVal, cfgVal []float64) ([]float64, error) {
+	pcts := defaultPercentiles
+	if cmd.Flags().Changed("percentiles") {
+		pcts = flagVal
+	} else if len(cfgVal) > 0 {
+		pcts = cfgVal
+	}
+	if err := checkPercentiles(pcts); err != nil {
+		return nil, err
+	}
+	return pcts, nil
+}
+
+// checkPercentiles rejects percentiles outside (0, 100).
+func checkPercentiles(pcts []float64) error {
+	for _, p := range pcts {
+		if p <= 0 || p >= 100 {
+			return fmt.Errorf("percentile %v is out of range: use values between 0 and 100 (min and max are always shown)", p)
+		}
+	}
+	return nil
+}
+
+// percentileLabel formats a percentile as a column header, e.g. p99.9.
+func percentileLabel(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// latencyHeader returns the header cells of the latency columns.
+func latencyHeader() []string {
+	cells := []string{"Avg", "Min"}
+	for _, p := range reportPercentiles {
+		cells = append(cells, percentileLabel(p))
+	}
+	return append(cells, "Max", "StdDev", "CV", "Outliers")
+}
+
+// latencyCells returns the latency columns for r, matching latencyHeader.
+func latencyCells(r *stats.Recorder) []string {
+	cells := []string{formatDuration(r.Mean()), formatDuration(r.Min())}
+	for _, p := range reportPercentiles {
+		cells = append(cells, formatDuration(r.Percentile(p)))
+	}
+	return append(cells,
+		formatDuration(r.Max()),
+		formatDuration(r.StdDev()),
+		fmt.Sprintf("%.1f%%", r.CV()*100),
+		strconv.Itoa(r.Outliers(outlierIQRs)),
+	)
+}
+
+// tabRow joins cells into a tabwriter row.
+func tabRow(cells ...string) string {
+	return strings.Join(cells, "\t") + "\n"
+}
+
+// dashes returns a separator cell as wide as each header cell.
+func dashes(header []string) []string {
+	out := make([]string, len(header))
+	for i, h := range header {
+		out[i] = strings.Repeat("-", len(h))
+	}
+	return out
+}
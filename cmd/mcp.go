@@ -2,9 +2,18 @@ package cmd
 
 import (
 	"context"
+	"crypto/subtle"
 	"embed"
+	"errors"
 	"fmt"
-
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/auth"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/spf13/cobra"
 
@@ -17,7 +26,7 @@ var SkillsFS embed.FS
 
 var mcpCmd = &cobra.Command{
 	Use:   "mcp",
-	Short: "Start an MCP stdio server for use with Claude Code and other AI tools",
+	Short: "Start an MCP server for use with Claude Code and other AI tools",
 	Long: `The mcp subcommand starts a Model Context Protocol server that communicates
 over stdin/stdout using JSON-RPC. This allows AI tools like Claude Code to
 introspect schemas, preview data, seed databases, and compare performance.
@@ -30,11 +39,27 @@ Configure in .claude/settings.json:
       "args": ["mcp"],
       "env": { "SEED_DSN": "user:pass@tcp(localhost:3306)/mydb" }
     }
-  }`,
+  }
+
+With --http, the server instead listens on the given address using the
+streamable HTTP transport, so one shared instance can serve several clients:
+
+  go-test-my-db mcp --http :8080 --token "$(openssl rand -hex 32)"
+
+Clients then connect to http://host:8080/ and, when a token is set, send it
+as "Authorization: Bearer <token>".`,
 	RunE: runMCP,
 }
 
+var (
+	mcpHTTPAddr string
+	mcpToken    string
+)
+
 func init() {
+	mcpCmd.Flags().StringVar(&mcpHTTPAddr, "http", "", "Serve the streamable HTTP transport on this address (e.g. :8080) instead of stdio")
+	mcpCmd.Flags().StringVar(&mcpToken, "token", "", "Bearer token HTTP clients must send (env: SEED_MCP_TOKEN)")
+
 	rootCmd.AddCommand(mcpCmd)
 }
 
@@ -42,7 +67,9 @@ const mcpInstructions = `go-test-my-db seeds MySQL databases with realistic fake
 
 ## Connection
 
-The MySQL DSN can be pre-configured via the SEED_DSN environment variable. If SEED_DSN is not set and Docker or Podman is available, the tools automatically start an ephemeral MySQL container — no configuration needed.
+The MySQL DSN can be pre-configured via the SEED_DSN environment variable. If SEED_DSN is not set and Docker or Podman is available, test, compare and suggest_indexes automatically start an ephemeral MySQL container for each call — no configuration needed.
+
+To work against one database across calls, call **start_database**: it starts a MySQL container that becomes the default database of every tool until **stop_database** or until the server exits. Create tables in it with **apply_schema**. Every database tool also accepts a dsn argument, which wins over everything else, and a database argument naming a started container (db1, db2, …; the latest one is the default).
 
 ## Workflow
 
@@ -53,8 +80,14 @@ The MySQL DSN can be pre-configured via the SEED_DSN environment variable. If SE
 5. **seed_database** → insert fake data into the database
 6. **test** → benchmark query performance for a single schema config
 7. **compare** → benchmark and compare query performance across multiple schema configs side-by-side
+8. **run_query** / **explain_query** / **benchmark_query** → try a single query against the seeded data: see its rows, its plan, or its latency over N runs
+9. **suggest_indexes** → propose indexes from a config's test queries and measure each one on seeded data
 
-Use **test** when benchmarking one schema. Use **compare** when comparing alternative schemas (e.g., different index strategies). The compare tool requires a comparison YAML that references 2+ seed configs with per-config query variants.
+Without a database of your own: **start_database** → **apply_schema** → **seed_database** → **run_query** / **explain_query** / **benchmark_query** → **stop_database**.
+
+Use **test** when benchmarking one schema. Use **compare** when comparing alternative schemas (e.g., different index strategies). Use **suggest_indexes** before recommending indexes: it only recommends an index the server used and that measurably lowered latency. The compare tool requires a comparison YAML that references 2+ seed configs with per-config query variants.
+
+seed_database, test, compare and suggest_indexes send progress notifications per seeded table and per finished test, can be cancelled, and return structured results (latencies in milliseconds, row counts, EXPLAIN plans) alongside a plain-text report. Prefer the structured results over parsing the text.
 
 Start with list_tables to orient yourself, then use the other tools as needed. Most tools work without any arguments.
 
@@ -66,7 +99,12 @@ This server provides skill resources that guide you through complex workflows:
 
 To use a skill, read the resource and follow its instructions.`
 
-func runMCP(_ *cobra.Command, _ []string) error {
+func runMCP(cmd *cobra.Command, _ []string) error {
+	token := resolveString(cmd, "token", mcpToken, "SEED_MCP_TOKEN", "", "")
+	if token != "" && mcpHTTPAddr == "" {
+		return fmt.Errorf("--token requires --http")
+	}
+
 	server := mcp.NewServer(
 		&mcp.Implementation{
 			Name:    "go-test-my-db",
@@ -77,10 +115,69 @@ func runMCP(_ *cobra.Command, _ []string) error {
 		},
 	)
 
-	mcptools.RegisterAll(server, SkillsFS)
+	stopDatabases := mcptools.RegisterAll(server, SkillsFS, &mcpEngine{})
+	defer stopDatabases()
+
+	// Exit cleanly on SIGINT and SIGTERM too, so session databases are removed.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if mcpHTTPAddr != "" {
+		return serveMCPHTTP(ctx, server, mcpHTTPAddr, token)
+	}
 
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+	// The tools run the seeding and test pipelines in process, and those
+	// print progress to stdout. Keep the real stdout for the protocol and
+	// send everything else to stderr.
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	if err := server.Run(ctx, &mcp.IOTransport{Reader: os.Stdin, Writer: stdout}); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("MCP server error: %w", err)
+	}
+	return nil
+}
+
+// serveMCPHTTP serves server over the streamable HTTP transport until ctx is
+// cancelled. All clients share server, and so its session databases.
+func serveMCPHTTP(ctx context.Context, server *mcp.Server, addr, token string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	srv := &http.Server{Handler: mcpHTTPHandler(server, token)}
+
+	fmt.Fprintf(os.Stderr, "MCP server listening on http://%s/\n", ln.Addr())
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "Warning: no --token set; anyone who can reach this address can run queries against your databases.")
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("MCP server error: %w", err)
 	}
 	return nil
 }
+
+// mcpHTTPHandler returns the streamable HTTP handler for server. When token is
+// set, requests without "Authorization: Bearer <token>" get 401 Unauthorized.
+func mcpHTTPHandler(server *mcp.Server, token string) http.Handler {
+	handler := http.Handler(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	if token == "" {
+		return handler
+	}
+	verify := func(_ context.Context, got string, _ *http.Request) (*auth.TokenInfo, error) {
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return nil, auth.ErrInvalidToken
+		}
+		// The token is static; the expiry only satisfies the middleware.
+		return &auth.TokenInfo{Expiration: time.Now().Add(time.Hour)}, nil
+	}
+	return auth.RequireBearerToken(verify, nil)(handler)
+}
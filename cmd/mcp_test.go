@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/tomfevang/go-test-my-db/internal/mcptools"
+)
+
+// bearerTransport adds an Authorization header to every request.
+type bearerTransport string
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestMCPHTTPHandler(t *testing.T) {
+	t.Setenv("SEED_DSN", "")
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	defer mcptools.RegisterAll(server, fstest.MapFS{}, &mcpEngine{})()
+	ts := httptest.NewServer(mcpHTTPHandler(server, "secret"))
+	defer ts.Close()
+
+	ctx := context.Background()
+	for _, token := range []string{"", "wrong"} {
+		client := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil)
+		transport := &mcp.StreamableClientTransport{Endpoint: ts.URL, MaxRetries: -1}
+		if token != "" {
+			transport.HTTPClient = &http.Client{Transport: bearerTransport(token)}
+		}
+		if cs, err := client.Connect(ctx, transport, nil); err == nil {
+			cs.Close()
+			t.Errorf("token %q: connected, want 401 Unauthorized", token)
+		}
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil)
+	cs, err := client.Connect(ctx, &mcp.StreamableClientTransport{
+		Endpoint:   ts.URL,
+		HTTPClient: &http.Client{Transport: bearerTransport("secret")},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	tools, err := cs.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	if !strings.Contains(strings.Join(names, " "), "run_query") {
+		t.Errorf("tools = %v, want run_query among them", names)
+	}
+
+	res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: "run_query", Arguments: map[string]any{"query": "SELECT 1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; !res.IsError || !strings.HasPrefix(text, "no database") {
+		t.Errorf("run_query = %q, want the no-database error", text)
+	}
+}
@@ -0,0 +1,443 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/ephemeral"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+	"github.com/tomfevang/go-test-my-db/internal/mcptools"
+	"github.com/tomfevang/go-test-my-db/internal/seeder"
+	"github.com/tomfevang/go-test-my-db/internal/sqlscan"
+	"github.com/tomfevang/go-test-my-db/internal/stats"
+)
+
+// mcpEngine runs the seed, test and compare pipelines for the MCP tools.
+// Runs are serialized: the test pipelines create and drop tables by name in
+// a shared database, and the text reports read reportPercentiles.
+type mcpEngine struct {
+	mu sync.Mutex
+}
+
+var _ mcptools.Engine = (*mcpEngine)(nil)
+
+// Seed fills existing tables like the root command, reporting each seeded
+// table.
+func (e *mcpEngine) Seed(ctx context.Context, req mcptools.SeedRequest, progress mcptools.ProgressFunc) (*mcptools.SeedResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	start := time.Now()
+
+	cfg, err := config.LoadOrDefault(req.ConfigPath, req.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	rows := resolveOverride(req.Rows, cfg.Options.Rows, 1000)
+	batchSize := resolveOverride(req.BatchSize, cfg.Options.BatchSize, 1000)
+	workers := resolveOverride(req.Workers, cfg.Options.Workers, 4)
+	minC := resolveOverride(req.MinChildren, cfg.Options.ChildrenPerParent.Min, 10)
+	maxC := resolveOverride(req.MaxChildren, cfg.Options.ChildrenPerParent.Max, 100)
+	maxR := resolveOverride(req.MaxRows, cfg.Options.MaxRows, 10_000_000)
+
+	db, schema, closeDB, err := connectEngine(ctx, req.DSN, cfg.Options.LoadData, workers)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	// Tables to seed: request > config > all tables.
+	tableNames := req.Tables
+	if len(tableNames) == 0 {
+		tableNames = cfg.Options.SeedTables
+	}
+	if len(tableNames) == 0 {
+		if tableNames, err = introspect.ListTables(db, schema); err != nil {
+			return nil, err
+		}
+		if len(tableNames) == 0 {
+			return nil, fmt.Errorf("no tables found in schema %s", schema)
+		}
+	}
+
+	plan, err := planSeed(db, schema, cfg, tableNames, rows, minC, maxC, maxR)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &mcptools.SeedResult{Schema: schema}
+	if err := seeder.SeedAll(seeder.Config{
+		DB:           db,
+		Schema:       schema,
+		Tables:       plan.tables,
+		RowsPerTable: plan.rowCounts,
+		BatchSize:    batchSize,
+		Workers:      workers,
+		Clear:        req.Clear,
+		LoadData:     cfg.Options.LoadData,
+		DeferIndexes: req.DeferIndexes || cfg.Options.DeferIndexes,
+		GenConfig:    cfg,
+		FKSampleSize: resolveOverride(0, cfg.Options.FKSampleSize, 500_000),
+		MaxRows:      maxR,
+		Context:      ctx,
+		OnTable: func(table string, n int) {
+			res.Tables = append(res.Tables, mcptools.SeededTable{Name: table, Rows: n, Parents: plan.relations.Parents[table]})
+			res.TotalRows += n
+			progress(len(res.Tables), len(plan.tables), fmt.Sprintf("seeded %s (%d rows)", table, n))
+		},
+	}); err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+	res.DurationMS = durationMS(elapsed)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Seeded %d tables in %s:\n", len(res.Tables), schema)
+	for _, t := range res.Tables {
+		if len(t.Parents) > 0 {
+			fmt.Fprintf(&sb, "  %-30s %10d rows (child of %s)\n", t.Name, t.Rows, strings.Join(t.Parents, ", "))
+		} else {
+			fmt.Fprintf(&sb, "  %-30s %10d rows (root)\n", t.Name, t.Rows)
+		}
+	}
+	fmt.Fprintf(&sb, "Inserted %d total rows in %s\n", res.TotalRows, elapsed.Round(time.Millisecond))
+	res.Report = sb.String()
+	return res, nil
+}
+
+// ApplySchema creates the tables of a DDL file or inline DDL and keeps
+// them, replacing tables with the same names.
+func (e *mcpEngine) ApplySchema(ctx context.Context, req mcptools.SchemaRequest) (*mcptools.AppliedSchema, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var statements, tableNames []string
+	if req.SchemaPath != "" {
+		var err error
+		if statements, tableNames, err = parseDDLFile(req.SchemaPath); err != nil {
+			return nil, fmt.Errorf("parsing schema file: %w", err)
+		}
+	} else {
+		statements, tableNames = parseDDL(req.DDL)
+	}
+	// Drop comment-only fragments, which the server rejects as empty queries.
+	statements = slices.DeleteFunc(statements, func(s string) bool {
+		return len(sqlscan.Tokenize(s)) == 0
+	})
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("no statements found in the DDL")
+	}
+
+	db, schema, closeDB, err := connectEngine(ctx, req.DSN, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	if err := createTables(db, tableNames, statements); err != nil {
+		return nil, err
+	}
+	return &mcptools.AppliedSchema{Schema: schema, Tables: append([]string{}, tableNames...), Statements: len(statements)}, nil
+}
+
+// Test runs the test pipeline for one config, reporting each seeded table
+// and each finished test.
+func (e *mcpEngine) Test(ctx context.Context, req mcptools.TestRequest, progress mcptools.ProgressFunc) (*mcptools.TestRun, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cfg, err := config.LoadOrDefault(req.ConfigPath, req.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	if cfg.Options.Schema == "" {
+		return nil, fmt.Errorf("config %s does not specify a schema file (options.schema)", req.ConfigPath)
+	}
+	if err := useConfigPercentiles(cfg.Options.Percentiles); err != nil {
+		return nil, err
+	}
+
+	entry := compareEntry{cfg: cfg, path: req.ConfigPath}
+	opts := compareOverrides{Rows: req.Rows, BatchSize: req.BatchSize, Workers: req.Workers, Explain: true}.pipelineOptions(entry)
+	opts.Keep = false // MCP runs always clean up; use the CLI with --keep to investigate
+
+	dsn := req.DSN
+	if dsn == "" {
+		dsn = cfg.Options.DSN
+	}
+	db, schema, closeDB, err := connectEngine(ctx, dsn, opts.LoadData, opts.Workers)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	tables, tests := pipelineSteps(cfg, opts)
+	steps := &stepCounter{progress: progress, total: tables + tests}
+	steps.track(&opts, "", tables, tests)
+	c := runEngineConfig(ctx, db, schema, entry, opts)
+	if c.Error != nil {
+		return nil, c.Error
+	}
+
+	run := testRun(c)
+	if len(c.Results) > 0 {
+		run.Report = buildTestReport(c.Results)
+	} else {
+		run.Report = "No test queries configured.\n"
+	}
+	return &run, nil
+}
+
+// Compare runs the test pipeline for every config of a comparison file. A
+// failing config is reported in its entry rather than failing the call.
+func (e *mcpEngine) Compare(ctx context.Context, req mcptools.CompareRequest, progress mcptools.ProgressFunc) (*mcptools.Comparison, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entries, cc, err := loadCompareEntries(req.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := useConfigPercentiles(comparePercentilesOf(entries)); err != nil {
+		return nil, err
+	}
+	if cc.HasVerify() {
+		shareSeed(entries)
+	}
+
+	overrides := compareOverrides{Rows: req.Rows, BatchSize: req.BatchSize, Workers: req.Workers, Explain: true}
+	dsn, maxWorkers, loadData := req.DSN, 4, false
+	opts := make([]pipelineOptions, len(entries))
+	steps := &stepCounter{progress: progress}
+	tables, tests := make([]int, len(entries)), make([]int, len(entries))
+	for i, en := range entries {
+		if en.cfg.Options.Schema == "" {
+			return nil, fmt.Errorf("config %s (label %q) does not specify a schema file (options.schema)", en.path, en.label)
+		}
+		opts[i] = overrides.pipelineOptions(en)
+		opts[i].Keep = false
+		if dsn == "" {
+			dsn = en.cfg.Options.DSN
+		}
+		maxWorkers = max(maxWorkers, opts[i].Workers)
+		loadData = loadData || opts[i].LoadData
+		tables[i], tests[i] = pipelineSteps(en.cfg, opts[i])
+		steps.total += tables[i] + tests[i]
+	}
+
+	db, schema, closeDB, err := connectEngine(ctx, dsn, loadData, maxWorkers)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	results := make([]ConfigResult, 0, len(entries))
+	for i, en := range entries {
+		steps.track(&opts[i], en.label, tables[i], tests[i])
+		results = append(results, runEngineConfig(ctx, db, schema, en, opts[i]))
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	out := &mcptools.Comparison{
+		VerifyMismatches: verifyMismatches(results),
+		Report:           buildComparisonReport(results),
+	}
+	for _, c := range results {
+		out.Configs = append(out.Configs, testRun(c))
+	}
+	return out, nil
+}
+
+// connectEngine opens a pool on dsn, or on an ephemeral MySQL container when
+// dsn is empty. The returned function closes the pool and stops the
+// container.
+func connectEngine(ctx context.Context, dsn string, loadData bool, workers int) (*sql.DB, string, func(), error) {
+	var edb *ephemeral.DB
+	if dsn == "" {
+		var err error
+		if edb, err = ephemeral.Start(ctx); err != nil {
+			return nil, "", nil, err
+		}
+		dsn = edb.DSN
+	}
+	stop := func() {
+		if edb != nil {
+			edb.Stop()
+		}
+	}
+
+	schema := extractSchema(dsn)
+	if schema == "" {
+		stop()
+		return nil, "", nil, fmt.Errorf("could not extract database name from DSN — ensure it ends with /dbname")
+	}
+	if loadData {
+		dsn = ensureAllowAllFiles(dsn)
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		stop()
+		return nil, "", nil, fmt.Errorf("connecting to MySQL: %w", err)
+	}
+	db.SetMaxOpenConns(workers + 2)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		stop()
+		return nil, "", nil, fmt.Errorf("pinging MySQL: %w", err)
+	}
+	return db, schema, func() {
+		db.Close()
+		stop()
+	}, nil
+}
+
+// useConfigPercentiles sets the report percentiles from a config, falling
+// back to the defaults.
+func useConfigPercentiles(pcts []float64) error {
+	if len(pcts) == 0 {
+		pcts = defaultPercentiles
+	}
+	if err := checkPercentiles(pcts); err != nil {
+		return err
+	}
+	reportPercentiles = pcts
+	return nil
+}
+
+// runEngineConfig runs the pipeline for one config and records the outcome.
+func runEngineConfig(ctx context.Context, db *sql.DB, schema string, e compareEntry, opts pipelineOptions) ConfigResult {
+	opts.Context = ctx
+	start := time.Now()
+	results, tables, err := runTestPipeline(db, schema, e.cfg, opts)
+	return ConfigResult{
+		ConfigPath: e.path,
+		Label:      e.label,
+		SchemaFile: opts.SchemaFile,
+		Rows:       opts.Rows,
+		TableCount: len(tables),
+		Results:    results,
+		Error:      err,
+		Duration:   time.Since(start),
+		Config:     effectiveConfig(e.cfg, opts),
+	}
+}
+
+// pipelineSteps estimates the progress steps of one pipeline run: one per
+// table in the schema file (the most it can seed) and one per test.
+func pipelineSteps(cfg *config.Config, opts pipelineOptions) (tables, tests int) {
+	_, names, _ := parseDDLFile(opts.SchemaFile)
+	return len(names), len(cfg.Tests)
+}
+
+// stepCounter turns pipeline callbacks into monotonic progress over all
+// runs of a call.
+type stepCounter struct {
+	progress mcptools.ProgressFunc
+	total    int
+	done     int
+	end      int // where the current run's steps end
+}
+
+// track wires opts' callbacks to the counter for the next run. A run that
+// seeds fewer tables than estimated skips ahead when its tests start.
+func (s *stepCounter) track(opts *pipelineOptions, label string, tables, tests int) {
+	s.done = s.end
+	testsStart := s.done + tables
+	s.end = testsStart + tests
+
+	prefix := ""
+	if label != "" {
+		prefix = label + ": "
+	}
+	opts.OnTable = func(table string, rows int) {
+		if s.done < testsStart {
+			s.done++
+		}
+		s.progress(s.done, s.total, fmt.Sprintf("%sseeded %s (%d rows)", prefix, table, rows))
+	}
+	ran := 0
+	opts.OnTest = func(r TestResult) {
+		ran++
+		s.done = testsStart + ran
+		msg := fmt.Sprintf("%s%s: avg %s", prefix, r.Name, formatDuration(r.Latency.Mean()))
+		if r.Error != nil {
+			msg = fmt.Sprintf("%s%s: error: %v", prefix, r.Name, r.Error)
+		}
+		s.progress(s.done, s.total, msg)
+	}
+}
+
+// testRun converts a pipeline result to the tools' structured output.
+func testRun(c ConfigResult) mcptools.TestRun {
+	run := mcptools.TestRun{
+		Label:      c.Label,
+		ConfigPath: c.ConfigPath,
+		SchemaFile: c.SchemaFile,
+		Rows:       c.Rows,
+		Tables:     c.TableCount,
+		DurationMS: durationMS(c.Duration),
+		Results:    []mcptools.QueryResult{},
+	}
+	if c.Error != nil {
+		run.Error = c.Error.Error()
+	}
+	for _, r := range c.Results {
+		q := mcptools.QueryResult{
+			Name:         r.Name,
+			Kind:         r.Kind,
+			Query:        r.Query,
+			Runs:         r.Latency.Count(),
+			RowCount:     r.RowCount,
+			RowsAffected: r.RowsAffected,
+			Checksum:     r.Checksum,
+		}
+		if q.Kind == "" {
+			q.Kind = config.TestKindRead
+		}
+		if r.Error != nil {
+			q.Error = r.Error.Error()
+		}
+		if r.Latency.Count() > 0 {
+			q.Latency = latencySummary(r.Latency)
+		}
+		if r.Metrics != nil {
+			examined := r.Metrics.RowsExamined
+			q.RowsExamined = &examined
+		}
+		if r.Plan != nil {
+			q.Plan = &mcptools.QueryPlan{Columns: r.Plan.Columns, Rows: r.Plan.Rows}
+		}
+		run.Results = append(run.Results, q)
+	}
+	return run
+}
+
+// latencySummary converts a recorder to milliseconds at the report
+// percentiles.
+func latencySummary(r *stats.Recorder) *mcptools.Latency {
+	l := &mcptools.Latency{
+		MeanMS:      durationMS(r.Mean()),
+		MinMS:       durationMS(r.Min()),
+		MaxMS:       durationMS(r.Max()),
+		StdDevMS:    durationMS(r.StdDev()),
+		CV:          r.CV(),
+		Percentiles: make(map[string]float64, len(reportPercentiles)),
+		Outliers:    r.Outliers(outlierIQRs),
+	}
+	for _, p := range reportPercentiles {
+		l.Percentiles[percentileLabel(p)] = durationMS(r.Percentile(p))
+	}
+	return l
+}
+
+// durationMS converts d to fractional milliseconds.
+func durationMS(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
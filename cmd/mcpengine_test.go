@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+)
+
+func TestStepCounter(t *testing.T) {
+	type step struct{ done, total int }
+	var got []step
+	s := &stepCounter{total: 7, progress: func(done, total int, _ string) {
+		got = append(got, step{done, total})
+	}}
+
+	// First run: 3 tables estimated but only 1 seeded, then 1 test.
+	var a pipelineOptions
+	s.track(&a, "a", 3, 1)
+	a.OnTable("users", 10)
+	a.OnTest(TestResult{Name: "q", Latency: msLatency(1)})
+	// Second run: 2 tables, 1 test.
+	var b pipelineOptions
+	s.track(&b, "b", 2, 1)
+	b.OnTable("users", 10)
+	b.OnTable("orders", 100)
+	b.OnTest(TestResult{Name: "q", Error: errors.New("x")})
+
+	want := []step{{1, 7}, {4, 7}, {5, 7}, {6, 7}, {7, 7}}
+	if len(got) != len(want) {
+		t.Fatalf("steps = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("steps = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTestRun(t *testing.T) {
+	saved := reportPercentiles
+	defer func() { reportPercentiles = saved }()
+	reportPercentiles = []float64{50, 99}
+
+	run := testRun(ConfigResult{
+		Label: "v1", SchemaFile: "s.sql", Rows: 1000, TableCount: 2,
+		Results: []TestResult{
+			{Name: "read", Latency: msLatency(1, 2, 3, 4), RowCount: 7,
+				Metrics: &ServerMetrics{RowsExamined: 70},
+				Plan:    &queryPlan{Columns: []string{"type"}, Rows: [][]string{{"ref"}}}},
+			{Name: "write", Kind: config.TestKindWrite, Latency: msLatency(1), RowsAffected: 5},
+			{Name: "broken", Error: errors.New("no such table")},
+		},
+	})
+
+	if run.Label != "v1" || run.Tables != 2 || len(run.Results) != 3 {
+		t.Fatalf("unexpected run: %+v", run)
+	}
+	read := run.Results[0]
+	if read.Kind != config.TestKindRead || read.Runs != 4 || read.RowCount != 7 || *read.RowsExamined != 70 || read.Plan.Rows[0][0] != "ref" {
+		t.Errorf("unexpected read result: %+v", read)
+	}
+	if read.Latency.MeanMS != 2.5 || read.Latency.MinMS != 1 || read.Latency.MaxMS != 4 || len(read.Latency.Percentiles) != 2 || read.Latency.Percentiles["p99"] != 4 {
+		t.Errorf("unexpected latency: %+v", read.Latency)
+	}
+	if run.Results[1].RowsAffected != 5 || run.Results[1].Kind != config.TestKindWrite {
+		t.Errorf("unexpected write result: %+v", run.Results[1])
+	}
+	if broken := run.Results[2]; broken.Error != "no such table" || broken.Latency != nil {
+		t.Errorf("unexpected failed result: %+v", broken)
+	}
+}
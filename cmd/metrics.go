@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// handlerReadCounters are the session status counters whose sum approximates
+// the number of rows the storage engine examined.
+var handlerReadCounters = []string{
+	"Handler_read_first",
+	"Handler_read_key",
+	"Handler_read_last",
+	"Handler_read_next",
+	"Handler_read_prev",
+	"Handler_read_rnd",
+	"Handler_read_rnd_next",
+}
+
+// statusCounters lists every counter read from SHOW SESSION STATUS.
+var statusCounters = append([]string{
+	"Created_tmp_tables",
+	"Created_tmp_disk_tables",
+	"Sort_merge_passes",
+	"Innodb_buffer_pool_reads",
+}, handlerReadCounters...)
+
+// ServerMetrics holds per-run averages of server-side counters for a test.
+type ServerMetrics struct {
+	RowsExamined  float64 // sum of Handler_read_* counters
+	RowsSent      float64 // rows returned (read) or affected (write, transaction)
+	TmpTables     float64 // Created_tmp_tables
+	TmpDiskTables float64 // Created_tmp_disk_tables
+	SortMergePass float64 // Sort_merge_passes
+	DiskReads     float64 // Innodb_buffer_pool_reads (server-wide)
+}
+
+// statusSnapshot maps a status counter name to its value.
+type statusSnapshot map[string]int64
+
+// takeStatusSnapshot reads the tracked session counters on conn.
+func takeStatusSnapshot(ctx context.Context, conn *sql.Conn) (statusSnapshot, error) {
+	quoted := make([]string, len(statusCounters))
+	for i, name := range statusCounters {
+		quoted[i] = "'" + name + "'"
+	}
+	rows, err := conn.QueryContext(ctx,
+		"SHOW SESSION STATUS WHERE Variable_name IN ("+strings.Join(quoted, ", ")+")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snap := make(statusSnapshot, len(statusCounters))
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		snap[name] = n
+	}
+	return snap, rows.Err()
+}
+
+// sub returns s - other for every counter.
+func (s statusSnapshot) sub(other statusSnapshot) statusSnapshot {
+	d := make(statusSnapshot, len(s))
+	for k, v := range s {
+		d[k] = v - other[k]
+	}
+	return d
+}
+
+// statusBaseline takes two back-to-back snapshots. The second is the
+// baseline; the difference between them is the cost of taking one snapshot
+// (SHOW STATUS itself touches handler and temp-table counters), which is
+// subtracted from the final delta.
+func statusBaseline(ctx context.Context, conn *sql.Conn) (baseline, overhead statusSnapshot, err error) {
+	first, err := takeStatusSnapshot(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseline, err = takeStatusSnapshot(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return baseline, baseline.sub(first), nil
+}
+
+// serverMetrics converts a counter delta over runs iterations into per-run
+// averages. rowsSent is the total across all runs.
+func serverMetrics(delta, overhead statusSnapshot, runs int, rowsSent int64) *ServerMetrics {
+	if runs <= 0 {
+		return nil
+	}
+	per := func(names ...string) float64 {
+		var total int64
+		for _, n := range names {
+			total += delta[n] - overhead[n]
+		}
+		if total < 0 {
+			total = 0
+		}
+		return float64(total) / float64(runs)
+	}
+	return &ServerMetrics{
+		RowsExamined:  per(handlerReadCounters...),
+		RowsSent:      float64(rowsSent) / float64(runs),
+		TmpTables:     per("Created_tmp_tables"),
+		TmpDiskTables: per("Created_tmp_disk_tables"),
+		SortMergePass: per("Sort_merge_passes"),
+		DiskReads:     per("Innodb_buffer_pool_reads"),
+	}
+}
+
+// examinedPerRow formats rows examined per row sent, or "-" when no rows
+// were sent.
+func (m *ServerMetrics) examinedPerRow() string {
+	if m == nil || m.RowsSent == 0 {
+		return "-"
+	}
+	return formatCount(m.RowsExamined / m.RowsSent)
+}
+
+// formatCount prints a per-run average compactly.
+func formatCount(v float64) string {
+	switch {
+	case v >= 1_000_000:
+		return fmt.Sprintf("%.1fM", v/1_000_000)
+	case v >= 10_000:
+		return fmt.Sprintf("%.1fk", v/1_000)
+	case v == float64(int64(v)):
+		return strconv.FormatInt(int64(v), 10)
+	default:
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+}
+
+// hasMetrics reports whether any result collected server metrics.
+func hasMetrics(results []TestResult) bool {
+	for _, r := range results {
+		if r.Metrics != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMetricsTable writes per-run server metrics for results that have them.
+func writeMetricsTable(out io.Writer, results []TestResult, indent string) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%sTest\tExamined\tSent\tExam/Row\tTmp\tTmp disk\tSort merges\tDisk reads\n", indent)
+	fmt.Fprintf(w, "%s----\t--------\t----\t--------\t---\t--------\t-----------\t----------\n", indent)
+	for _, r := range results {
+		m := r.Metrics
+		if m == nil {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			indent, r.Name,
+			formatCount(m.RowsExamined),
+			formatCount(m.RowsSent),
+			m.examinedPerRow(),
+			formatCount(m.TmpTables),
+			formatCount(m.TmpDiskTables),
+			formatCount(m.SortMergePass),
+			formatCount(m.DiskReads),
+		)
+	}
+	w.Flush()
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import "testing"
+
+func TestServerMetrics(t *testing.T) {
+	delta := statusSnapshot{
+		"Handler_read_key":         110,
+		"Handler_read_next":        1000,
+		"Handler_read_rnd_next":    30,
+		"Created_tmp_tables":       12,
+		"Created_tmp_disk_tables":  2,
+		"Innodb_buffer_pool_reads": 4,
+	}
+	// One snapshot costs 10 rnd_next reads and 2 temp tables.
+	overhead := statusSnapshot{"Handler_read_rnd_next": 10, "Created_tmp_tables": 2}
+
+	m := serverMetrics(delta, overhead, 10, 50)
+	if m.RowsExamined != 113 {
+		t.Errorf("RowsExamined = %v, want 113", m.RowsExamined)
+	}
+	if m.RowsSent != 5 {
+		t.Errorf("RowsSent = %v, want 5", m.RowsSent)
+	}
+	if m.TmpTables != 1 || m.TmpDiskTables != 0.2 || m.DiskReads != 0.4 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+	if got := m.examinedPerRow(); got != "22.6" {
+		t.Errorf("examinedPerRow() = %q, want %q", got, "22.6")
+	}
+
+	// Overhead larger than the delta clamps to zero instead of going negative.
+	m = serverMetrics(statusSnapshot{}, overhead, 1, 0)
+	if m.RowsExamined != 0 || m.TmpTables != 0 {
+		t.Errorf("expected zero metrics, got %+v", m)
+	}
+	if got := m.examinedPerRow(); got != "-" {
+		t.Errorf("examinedPerRow() with no rows = %q, want \"-\"", got)
+	}
+
+	if serverMetrics(delta, overhead, 0, 0) != nil {
+		t.Error("expected nil metrics for zero runs")
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{12, "12"},
+		{1.25, "1.2"},
+		{9999, "9999"},
+		{12_345, "12.3k"},
+		{2_500_000, "2.5M"},
+	}
+	for _, tt := range tests {
+		if got := formatCount(tt.v); got != tt.want {
+			t.Errorf("formatCount(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/tomfevang/go-test-my-db/internal/sqlscan"
+)
+
+// migrationStep is the outcome of one statement of a migration script.
+type migrationStep struct {
+	Statement    string
+	Duration     time.Duration
+	Algorithm    string // INSTANT, INPLACE or COPY for DDL; "" for other statements, "?" if unknown
+	Lock         string // lock level the DDL ran with (NONE, SHARED, EXCLUSIVE); "" if not probed
+	RowsAffected int64
+	MDLWait      time.Duration // time spent waiting for a metadata lock
+	Error        error
+}
+
+// blocksWrites reports whether concurrent writes to the table stall while
+// the statement runs (beyond the brief metadata lock every DDL takes).
+func (s migrationStep) blocksWrites() string {
+	switch {
+	case s.Algorithm == "" || s.Algorithm == "?":
+		return "-"
+	case s.Algorithm == "INSTANT", s.Lock == "NONE":
+		return "no"
+	case s.Lock == "EXCLUSIVE":
+		return "yes, and reads"
+	default:
+		return "yes"
+	}
+}
+
+// algorithmAttempt is one ALGORITHM/LOCK combination tried for a DDL
+// statement, from least to most disruptive.
+type algorithmAttempt struct {
+	Algorithm string
+	Lock      string
+}
+
+var algorithmAttempts = []algorithmAttempt{
+	{"INSTANT", ""},
+	{"INPLACE", "NONE"},
+	{"INPLACE", "SHARED"},
+	{"INPLACE", "EXCLUSIVE"},
+}
+
+// Server errors that mean the requested ALGORITHM or LOCK is not possible
+// for the statement. The server rejects these before doing any work.
+const (
+	errAlterNotSupported       = 1845 // ER_ALTER_OPERATION_NOT_SUPPORTED
+	errAlterNotSupportedReason = 1846 // ER_ALTER_OPERATION_NOT_SUPPORTED_REASON
+	errParse                   = 1064 // ER_PARSE_ERROR: INSTANT is unknown before MySQL 8.0
+)
+
+// ddlKind classifies a migration statement: "alter" for ALTER TABLE, "index"
+// for CREATE/DROP INDEX, and "" for everything else.
+func ddlKind(stmt string) string {
+	var words []string
+	for _, t := range sqlscan.Tokenize(stmt) {
+		if t.Kind != sqlscan.Ident || len(words) == 3 {
+			break
+		}
+		words = append(words, strings.ToUpper(t.Text))
+	}
+	at := func(i int) string {
+		if i < len(words) {
+			return words[i]
+		}
+		return ""
+	}
+	switch {
+	case at(0) == "ALTER" && at(1) == "TABLE":
+		return "alter"
+	case at(0) == "CREATE" && at(1) == "INDEX",
+		at(0) == "CREATE" && (at(1) == "UNIQUE" || at(1) == "FULLTEXT" || at(1) == "SPATIAL") && at(2) == "INDEX",
+		at(0) == "DROP" && at(1) == "INDEX":
+		return "index"
+	}
+	return ""
+}
+
+// explicitOptions returns the ALGORITHM and LOCK values a statement sets
+// itself, upper-cased, or "" for options it leaves to the server.
+func explicitOptions(stmt string) (algorithm, lock string) {
+	tokens := sqlscan.Tokenize(stmt)
+	for i, t := range tokens {
+		if !t.Is("ALGORITHM") && !t.Is("LOCK") {
+			continue
+		}
+		j := i + 1
+		if j < len(tokens) && tokens[j].Text == "=" {
+			j++
+		}
+		if j >= len(tokens) || tokens[j].Kind != sqlscan.Ident {
+			continue
+		}
+		if t.Is("ALGORITHM") {
+			algorithm = strings.ToUpper(tokens[j].Text)
+		} else {
+			lock = strings.ToUpper(tokens[j].Text)
+		}
+	}
+	return algorithm, lock
+}
+
+// withAlgorithm appends ALGORITHM and LOCK clauses to a DDL statement.
+func withAlgorithm(stmt, kind string, a algorithmAttempt) string {
+	sep := ", "
+	if kind == "index" {
+		sep = " "
+	}
+	stmt = strings.TrimRight(strings.TrimSpace(stmt), ";")
+	stmt += sep + "ALGORITHM=" + a.Algorithm
+	if a.Lock != "" {
+		stmt += sep + "LOCK=" + a.Lock
+	}
+	return stmt
+}
+
+// runMigrationStatement executes one statement on conn. DDL without an
+// explicit ALGORITHM is tried with the least disruptive algorithm and lock
+// first, which is what the server itself would pick; the first combination
+// the server accepts is the one that runs and is reported.
+func runMigrationStatement(ctx context.Context, conn *sql.Conn, stmt string) migrationStep {
+	step := migrationStep{Statement: stmt}
+	kind := ddlKind(stmt)
+
+	exec := func(query string) error {
+		start := time.Now()
+		res, err := conn.ExecContext(ctx, query)
+		step.Duration = time.Since(start)
+		if err == nil && kind == "" {
+			step.RowsAffected, _ = res.RowsAffected()
+		}
+		return err
+	}
+
+	if kind == "" {
+		step.Error = exec(stmt)
+		return step
+	}
+	if algorithm, lock := explicitOptions(stmt); algorithm != "" && algorithm != "DEFAULT" {
+		step.Algorithm, step.Lock = algorithm, lock
+		step.Error = exec(stmt)
+		return step
+	}
+
+	for _, a := range algorithmAttempts {
+		if kind == "index" && a.Algorithm == "INSTANT" {
+			continue // CREATE/DROP INDEX only accept INPLACE and COPY
+		}
+		err := exec(withAlgorithm(stmt, kind, a))
+		if err == nil {
+			step.Algorithm, step.Lock = a.Algorithm, a.Lock
+			return step
+		}
+		var myErr *mysql.MySQLError
+		if !errors.As(err, &myErr) {
+			step.Error = err
+			return step
+		}
+		switch {
+		case myErr.Number == errAlterNotSupported, myErr.Number == errAlterNotSupportedReason:
+			continue
+		case myErr.Number == errParse && a.Algorithm == "INSTANT":
+			continue
+		}
+		// Any other error is the statement's own (duplicate column, bad
+		// syntax, ...) or a clause the statement can't take; running it as
+		// written reports the real error or succeeds without a probe.
+		step.Algorithm = "?"
+		step.Error = exec(stmt)
+		return step
+	}
+
+	// Nothing in-place works: the server copies the table.
+	step.Algorithm = "COPY"
+	step.Error = exec(stmt)
+	return step
+}
+
+// mdlMonitor samples the process list for sessions waiting on a metadata
+// lock. Waits are accumulated per connection at the sampling interval's
+// resolution.
+type mdlMonitor struct {
+	mu      sync.Mutex
+	waited  map[int64]time.Duration // total observed wait per connection
+	streak  map[int64]time.Duration // current uninterrupted wait
+	longest map[int64]time.Duration // longest uninterrupted wait
+	err     error                   // first sampling error
+}
+
+// startMDLMonitor samples every interval until the returned stop function
+// is called. Sessions of other users are only visible with the PROCESS
+// privilege.
+func startMDLMonitor(db *sql.DB, interval time.Duration) (*mdlMonitor, func()) {
+	m := &mdlMonitor{
+		waited:  make(map[int64]time.Duration),
+		streak:  make(map[int64]time.Duration),
+		longest: make(map[int64]time.Duration),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ids, err := mdlWaiters(ctx, db)
+				if err != nil {
+					m.mu.Lock()
+					if m.err == nil && ctx.Err() == nil {
+						m.err = err
+					}
+					m.mu.Unlock()
+					continue
+				}
+				m.record(ids, interval)
+			}
+		}
+	}()
+	return m, func() {
+		cancel()
+		<-done
+	}
+}
+
+// mdlWaiters returns the connection IDs currently waiting for a metadata lock.
+func mdlWaiters(ctx context.Context, db *sql.DB) ([]int64, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT ID FROM information_schema.PROCESSLIST WHERE STATE LIKE 'Waiting for %metadata lock'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// record adds one sample: every listed connection waited for interval.
+func (m *mdlMonitor) record(ids []int64, interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	waiting := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		waiting[id] = true
+		m.waited[id] += interval
+		m.streak[id] += interval
+		if m.streak[id] > m.longest[id] {
+			m.longest[id] = m.streak[id]
+		}
+	}
+	for id := range m.streak {
+		if !waiting[id] {
+			delete(m.streak, id)
+		}
+	}
+}
+
+// waitedFor returns the total observed metadata lock wait of a connection.
+func (m *mdlMonitor) waitedFor(id int64) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.waited[id]
+}
+
+// longestStall returns the longest uninterrupted wait among ids.
+func (m *mdlMonitor) longestStall(ids []int64) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var longest time.Duration
+	for _, id := range ids {
+		if m.longest[id] > longest {
+			longest = m.longest[id]
+		}
+	}
+	return longest
+}
+
+// connectionID returns the server thread ID of conn.
+func connectionID(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var id int64
+	err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id)
+	return id, err
+}
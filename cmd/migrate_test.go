@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/stats"
+)
+
+func TestDDLKind(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{"ALTER TABLE orders ADD COLUMN note TEXT", "alter"},
+		{"-- add a column\nalter table `orders` drop column note", "alter"},
+		{"CREATE INDEX idx_status ON orders (status)", "index"},
+		{"CREATE UNIQUE INDEX idx_email ON users (email)", "index"},
+		{"DROP INDEX idx_status ON orders", "index"},
+		{"CREATE TABLE audit (id INT PRIMARY KEY)", ""},
+		{"UPDATE orders SET note = ''", ""},
+		{"ALTER VIEW v AS SELECT 1", ""},
+	}
+	for _, tt := range tests {
+		if got := ddlKind(tt.stmt); got != tt.want {
+			t.Errorf("ddlKind(%q) = %q, want %q", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestExplicitOptions(t *testing.T) {
+	algorithm, lock := explicitOptions("ALTER TABLE t ADD INDEX (a), ALGORITHM = inplace, LOCK=NONE")
+	if algorithm != "INPLACE" || lock != "NONE" {
+		t.Errorf("got %q, %q; want INPLACE, NONE", algorithm, lock)
+	}
+	if algorithm, lock := explicitOptions("ALTER TABLE t ADD COLUMN c INT"); algorithm != "" || lock != "" {
+		t.Errorf("got %q, %q for a statement without options", algorithm, lock)
+	}
+}
+
+func TestWithAlgorithm(t *testing.T) {
+	got := withAlgorithm("ALTER TABLE t ADD COLUMN c INT;", "alter", algorithmAttempt{"INPLACE", "NONE"})
+	if want := "ALTER TABLE t ADD COLUMN c INT, ALGORITHM=INPLACE, LOCK=NONE"; got != want {
+		t.Errorf("alter: got %q, want %q", got, want)
+	}
+	got = withAlgorithm("ALTER TABLE t ADD COLUMN c INT", "alter", algorithmAttempt{"INSTANT", ""})
+	if want := "ALTER TABLE t ADD COLUMN c INT, ALGORITHM=INSTANT"; got != want {
+		t.Errorf("instant: got %q, want %q", got, want)
+	}
+	got = withAlgorithm("CREATE INDEX i ON t (c)", "index", algorithmAttempt{"INPLACE", "SHARED"})
+	if want := "CREATE INDEX i ON t (c) ALGORITHM=INPLACE LOCK=SHARED"; got != want {
+		t.Errorf("index: got %q, want %q", got, want)
+	}
+}
+
+func TestMigrationStep_BlocksWrites(t *testing.T) {
+	tests := []struct {
+		algorithm, lock, want string
+	}{
+		{"INSTANT", "", "no"},
+		{"INPLACE", "NONE", "no"},
+		{"INPLACE", "SHARED", "yes"},
+		{"INPLACE", "EXCLUSIVE", "yes, and reads"},
+		{"COPY", "", "yes"},
+		{"", "", "-"},
+	}
+	for _, tt := range tests {
+		s := migrationStep{Algorithm: tt.algorithm, Lock: tt.lock}
+		if got := s.blocksWrites(); got != tt.want {
+			t.Errorf("%s/%s: got %q, want %q", tt.algorithm, tt.lock, got, tt.want)
+		}
+	}
+}
+
+func TestMDLMonitor_Record(t *testing.T) {
+	m := &mdlMonitor{
+		waited:  make(map[int64]time.Duration),
+		streak:  make(map[int64]time.Duration),
+		longest: make(map[int64]time.Duration),
+	}
+	const tick = 50 * time.Millisecond
+	m.record([]int64{1, 2}, tick)
+	m.record([]int64{1}, tick)
+	m.record(nil, tick)
+	m.record([]int64{2}, tick)
+
+	if got := m.waitedFor(1); got != 2*tick {
+		t.Errorf("waitedFor(1) = %s, want %s", got, 2*tick)
+	}
+	if got := m.waitedFor(2); got != 2*tick {
+		t.Errorf("waitedFor(2) = %s, want %s", got, 2*tick)
+	}
+	// Connection 2 waited twice, but never for two samples in a row.
+	if got := m.longestStall([]int64{2}); got != tick {
+		t.Errorf("longestStall(2) = %s, want %s", got, tick)
+	}
+	if got := m.longestStall([]int64{1, 2}); got != 2*tick {
+		t.Errorf("longestStall(1, 2) = %s, want %s", got, 2*tick)
+	}
+}
+
+func TestPrintMigrationReport(t *testing.T) {
+	r := &migrationResult{
+		Steps: []migrationStep{
+			{Statement: "ALTER TABLE orders ADD COLUMN note TEXT", Duration: 5 * time.Millisecond, Algorithm: "INSTANT"},
+			{Statement: "ALTER TABLE orders\n  MODIFY amount DECIMAL(12,2)", Duration: 2 * time.Second, Algorithm: "COPY"},
+			{Statement: "UPDATE orders SET note = ''", Duration: time.Second, RowsAffected: 1200},
+			{Statement: "CREATE INDEX i ON orders (note(10))", Error: errors.New("boom")},
+		},
+		Total: 3 * time.Second,
+		Workload: []workloadStats{
+			{Test: "by id", Phase: "before", Latency: stats.FromDurations(time.Millisecond)},
+			{Test: "by id", Phase: "during", Latency: stats.FromDurations(time.Second), Errors: 2},
+		},
+		WorkloadErrors: map[string]error{"by id": errors.New("lock wait timeout")},
+		LongestStall:   1500 * time.Millisecond,
+	}
+	var buf bytes.Buffer
+	printMigrationReport(&buf, r)
+	out := buf.String()
+
+	for _, want := range []string{
+		"ALTER TABLE orders MODIFY amount DECIMAL(12,2)",
+		"Total: 3s (writes blocked for 2s)",
+		"1200",
+		"ERROR: boom",
+		"Longest workload stall on a metadata lock: 1.5s",
+		"by id: last error: lock wait timeout",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/ephemeral"
+	"github.com/tomfevang/go-test-my-db/internal/sqlscan"
+)
+
+// mdlSampleInterval is how often the process list is checked for sessions
+// waiting on a metadata lock.
+const mdlSampleInterval = 50 * time.Millisecond
+
+var (
+	migrateDSN            string
+	migrateSchemaFile     string
+	migrateMigrationFile  string
+	migrateConfigPath     string
+	migrateProfile        string
+	migrateRows           int
+	migrateBatchSize      int
+	migrateWorkers        int
+	migrateMinChildren    int
+	migrateMaxChildren    int
+	migrateMaxRows        int
+	migrateLoadData       bool
+	migrateDeferIndexes   bool
+	migrateFKSampleSize   int
+	migrateEphemeral      bool
+	migrateReuseData      bool
+	migrateKeep           bool
+	migrateSeed           int64
+	migrateConcurrency    int
+	migrateWorkloadPeriod time.Duration
+	migratePercentiles    []float64
+)
+
+var migrateBenchCmd = &cobra.Command{
+	Use:   "migrate-bench",
+	Short: "Time a DDL migration against seeded data and measure its impact on a concurrent workload",
+	Long: `The migrate-bench command creates the tables of the old schema, seeds them,
+then runs a migration script (ALTER TABLE, CREATE INDEX, backfill UPDATEs, ...)
+statement by statement and reports for each one how long it took, which
+algorithm the server used (INSTANT, INPLACE or COPY), whether it blocked
+writes, and how long it waited for a metadata lock.
+
+With --concurrency, the config's tests run in a loop on that many connections
+before, during and after the migration, showing the latency and errors the
+application would see while the migration runs.
+
+Tables are dropped afterwards unless --keep is set.`,
+	RunE: runMigrateBench,
+}
+
+func init() {
+	migrateBenchCmd.Flags().StringVar(&migrateDSN, "dsn", "", "MySQL DSN (required), e.g. user:pass@tcp(localhost:3306)/mydb")
+	migrateBenchCmd.Flags().StringVar(&migrateSchemaFile, "schema", "", "Path to SQL DDL file of the schema before the migration (required)")
+	migrateBenchCmd.Flags().StringVar(&migrateMigrationFile, "migration", "", "Path to SQL file with the migration statements (required)")
+	migrateBenchCmd.Flags().StringVar(&migrateConfigPath, "config", "", "Path to config YAML file (default: auto-detect go-test-my-db.yaml)")
+	migrateBenchCmd.Flags().StringVar(&migrateProfile, "profile", "", "Config profile to apply over the file's settings (env SEED_PROFILE)")
+	migrateBenchCmd.Flags().IntVar(&migrateRows, "rows", 1000, "Number of rows per table")
+	migrateBenchCmd.Flags().IntVar(&migrateBatchSize, "batch-size", 1000, "Rows per INSERT statement")
+	migrateBenchCmd.Flags().IntVar(&migrateWorkers, "workers", 4, "Concurrent insert workers")
+	migrateBenchCmd.Flags().IntVar(&migrateMinChildren, "min-children", 10, "Min children per parent row for child tables")
+	migrateBenchCmd.Flags().IntVar(&migrateMaxChildren, "max-children", 100, "Max children per parent row for child tables")
+	migrateBenchCmd.Flags().IntVar(&migrateMaxRows, "max-rows", 10_000_000, "Maximum rows per table (safeguard for deep hierarchies)")
+	migrateBenchCmd.Flags().BoolVar(&migrateLoadData, "load-data", false, "Use LOAD DATA LOCAL INFILE for faster bulk loading (requires server local_infile=ON)")
+	migrateBenchCmd.Flags().BoolVar(&migrateDeferIndexes, "defer-indexes", false, "Drop secondary indexes before seeding and rebuild after (faster for large tables)")
+	migrateBenchCmd.Flags().IntVar(&migrateFKSampleSize, "fk-sample-size", 500_000, "Max FK parent values to cache per column (0 = unlimited)")
+	migrateBenchCmd.Flags().BoolVar(&migrateEphemeral, "ephemeral", false, "Start a temporary MySQL container via Docker or Podman (no DSN needed)")
+	migrateBenchCmd.Flags().BoolVar(&migrateKeep, "keep", false, "Keep the migrated tables (and --ephemeral container) after the run; remove them later with the cleanup command")
+	migrateBenchCmd.Flags().Int64Var(&migrateSeed, "seed", 0, "Seed for reproducible data (0 = random; overrides options.seed)")
+	migrateBenchCmd.Flags().BoolVar(&migrateReuseData, "reuse-data", false, "Restore a cached snapshot of an identical seeded dataset instead of re-seeding (saved on first run)")
+	migrateBenchCmd.Flags().IntVar(&migrateConcurrency, "concurrency", 0, "Connections running the config's tests as a workload during the migration (0 = no workload)")
+	migrateBenchCmd.Flags().DurationVar(&migrateWorkloadPeriod, "baseline", 5*time.Second, "How long the workload runs before and after the migration")
+
+	migrateBenchCmd.Flags().Float64SliceVar(&migratePercentiles, "percentiles", defaultPercentiles, "Workload latency percentiles to report (overrides options.percentiles)")
+
+	rootCmd.AddCommand(migrateBenchCmd)
+}
+
+func runMigrateBench(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd, migrateConfigPath, migrateProfile)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	// Resolve operational parameters: CLI flag > env var > config > default.
+	migrateDSN = resolveString(cmd, "dsn", migrateDSN, "SEED_DSN", cfg.Options.DSN, "")
+	migrateSchemaFile = resolveString(cmd, "schema", migrateSchemaFile, "", cfg.Options.Schema, "")
+	migrateRows = resolveInt(cmd, "rows", migrateRows, cfg.Options.Rows, 1000)
+	migrateBatchSize = resolveInt(cmd, "batch-size", migrateBatchSize, cfg.Options.BatchSize, 1000)
+	migrateWorkers = resolveInt(cmd, "workers", migrateWorkers, cfg.Options.Workers, 4)
+	migrateMinChildren = resolveInt(cmd, "min-children", migrateMinChildren, cfg.Options.ChildrenPerParent.Min, 10)
+	migrateMaxChildren = resolveInt(cmd, "max-children", migrateMaxChildren, cfg.Options.ChildrenPerParent.Max, 100)
+	migrateMaxRows = resolveInt(cmd, "max-rows", migrateMaxRows, cfg.Options.MaxRows, 10_000_000)
+	if !cmd.Flags().Changed("load-data") && cfg.Options.LoadData {
+		migrateLoadData = true
+	}
+	if !cmd.Flags().Changed("defer-indexes") && cfg.Options.DeferIndexes {
+		migrateDeferIndexes = true
+	}
+	migrateFKSampleSize = resolveInt(cmd, "fk-sample-size", migrateFKSampleSize, cfg.Options.FKSampleSize, 500_000)
+	if cmd.Flags().Changed("seed") {
+		cfg.Options.Seed = migrateSeed
+	}
+	if reportPercentiles, err = resolvePercentiles(cmd, migratePercentiles, cfg.Options.Percentiles); err != nil {
+		return err
+	}
+
+	if migrateSchemaFile == "" {
+		return fmt.Errorf("schema file is required — set via --schema flag or options.schema in config file")
+	}
+	if migrateMigrationFile == "" {
+		return fmt.Errorf("migration file is required — set via --migration flag")
+	}
+	if migrateConcurrency > 0 && len(cfg.Tests) == 0 {
+		return fmt.Errorf("--concurrency needs tests in the config file to use as the workload")
+	}
+
+	// Parse both files before touching the database.
+	statements, tableNames, err := parseDDLFile(migrateSchemaFile)
+	if err != nil {
+		return fmt.Errorf("parsing schema file: %w", err)
+	}
+	if len(tableNames) == 0 {
+		return fmt.Errorf("no CREATE TABLE statements found in %s", migrateSchemaFile)
+	}
+	migration, migrationTables, err := parseDDLFile(migrateMigrationFile)
+	if err != nil {
+		return fmt.Errorf("parsing migration file: %w", err)
+	}
+	// Drop comment-only fragments, which the server rejects as empty queries.
+	migration = slices.DeleteFunc(migration, func(s string) bool {
+		return len(sqlscan.Tokenize(s)) == 0
+	})
+	if len(migration) == 0 {
+		return fmt.Errorf("no statements found in %s", migrateMigrationFile)
+	}
+
+	// Start ephemeral MySQL if requested and no DSN was provided. It is
+	// stopped on the way out unless keepRun records it.
+	var edb *ephemeral.DB
+	kept := false
+	if migrateEphemeral && migrateDSN == "" {
+		edb, err = ephemeral.Start(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if !kept {
+				edb.Stop()
+			}
+		}()
+		migrateDSN = edb.DSN
+	}
+	if migrateDSN == "" {
+		return fmt.Errorf("DSN is required — set via --dsn flag, SEED_DSN env var, options.dsn in config file, or use --ephemeral")
+	}
+
+	schema := extractSchema(migrateDSN)
+	if schema == "" {
+		return fmt.Errorf("could not extract database name from DSN — ensure it ends with /dbname")
+	}
+	if migrateLoadData {
+		migrateDSN = ensureAllowAllFiles(migrateDSN)
+	}
+
+	db, err := sql.Open("mysql", migrateDSN)
+	if err != nil {
+		return fmt.Errorf("connecting to MySQL: %w", err)
+	}
+	defer db.Close()
+
+	// Seeding workers, workload connections, the migration connection and
+	// the lock monitor all need their own connection.
+	db.SetMaxOpenConns(migrateWorkers + migrateConcurrency + 3)
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("pinging MySQL: %w", err)
+	}
+	fmt.Printf("Connected to %s\n", schema)
+
+	if err := createTables(db, tableNames, statements); err != nil {
+		return fmt.Errorf("creating tables: %w", err)
+	}
+	fmt.Printf("Created %d tables\n", len(tableNames))
+
+	// Tables the migration creates are cleaned up along with the schema's.
+	allTables := slices.Clone(tableNames)
+	for _, name := range migrationTables {
+		if !slices.Contains(allTables, name) {
+			allTables = append(allTables, name)
+		}
+	}
+	defer func() {
+		if migrateKeep {
+			kept = keepRun(migrateDSN, allTables, edb)
+			return
+		}
+		dropTables(db, allTables)
+		fmt.Println("Cleaned up: dropped test tables")
+	}()
+
+	if err := seedCreatedTables(db, schema, cfg, pipelineOptions{
+		SchemaFile:   migrateSchemaFile,
+		Rows:         migrateRows,
+		BatchSize:    migrateBatchSize,
+		Workers:      migrateWorkers,
+		MinChildren:  migrateMinChildren,
+		MaxChildren:  migrateMaxChildren,
+		MaxRows:      migrateMaxRows,
+		LoadData:     migrateLoadData,
+		DeferIndexes: migrateDeferIndexes,
+		FKSampleSize: migrateFKSampleSize,
+		SeedTables:   cfg.Options.SeedTables,
+		ReuseData:    migrateReuseData,
+	}, tableNames); err != nil {
+		return err
+	}
+
+	result, err := runMigration(db, migration, cfg.Tests)
+	if err != nil {
+		return err
+	}
+	printMigrationReport(os.Stdout, result)
+
+	for i, s := range result.Steps {
+		if s.Error != nil {
+			return fmt.Errorf("migration statement %d failed: %w", i+1, s.Error)
+		}
+	}
+	return nil
+}
+
+// migrationResult is the outcome of a migrate-bench run.
+type migrationResult struct {
+	Steps          []migrationStep
+	Total          time.Duration
+	Workload       []workloadStats
+	WorkloadErrors map[string]error
+	LongestStall   time.Duration // longest metadata lock wait of a workload connection
+	MonitorError   error         // sampling the process list failed
+}
+
+// runMigration executes the migration statements in order on one connection,
+// stopping at the first failure. When tests are given they run as a
+// concurrent workload for migrateWorkloadPeriod before and after the
+// migration, and throughout it.
+func runMigration(db *sql.DB, statements []string, tests []config.TestCase) (*migrationResult, error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer conn.Close()
+	migrationID, err := connectionID(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("reading connection id: %w", err)
+	}
+
+	var wl *workload
+	if migrateConcurrency > 0 {
+		wl, err = startWorkload(db, tests, migrateConcurrency)
+		if err != nil {
+			return nil, err
+		}
+		defer wl.stop()
+		fmt.Printf("\nRunning workload of %d tests on %d connections for %s...\n", len(tests), migrateConcurrency, migrateWorkloadPeriod)
+		time.Sleep(migrateWorkloadPeriod)
+		wl.setPhase(phaseDuring)
+	}
+
+	monitor, stopMonitor := startMDLMonitor(db, mdlSampleInterval)
+
+	result := &migrationResult{}
+	fmt.Printf("\nRunning %d migration statements...\n", len(statements))
+	start := time.Now()
+	for i, stmt := range statements {
+		prefix := fmt.Sprintf("[%d/%d] %s", i+1, len(statements), truncateSQL(oneLine(stmt), 60))
+		fmt.Printf("%s ...", prefix)
+		waited := monitor.waitedFor(migrationID)
+		step := runMigrationStatement(ctx, conn, stmt)
+		step.MDLWait = monitor.waitedFor(migrationID) - waited
+		result.Steps = append(result.Steps, step)
+		if step.Error != nil {
+			fmt.Printf("\r%s ... ERROR: %v\n", prefix, step.Error)
+			break
+		}
+		fmt.Printf("\r%s ... done (%s)\n", prefix, formatDuration(step.Duration))
+	}
+	result.Total = time.Since(start)
+
+	if wl != nil {
+		wl.setPhase(phaseAfter)
+		time.Sleep(migrateWorkloadPeriod)
+	}
+	stopMonitor()
+	result.MonitorError = monitor.err
+
+	if wl != nil {
+		wl.stop()
+		result.Workload = wl.stats()
+		result.WorkloadErrors = wl.errorsByTest()
+		result.LongestStall = monitor.longestStall(wl.connectionIDs())
+	}
+	return result, nil
+}
+
+// oneLine collapses whitespace so a statement fits on a progress line.
+func oneLine(stmt string) string {
+	return strings.Join(strings.Fields(stmt), " ")
+}
+
+// printMigrationReport writes the per-statement table, the total, and the
+// workload latencies per phase.
+func printMigrationReport(out io.Writer, r *migrationResult) {
+	fmt.Fprintln(out, "\n=== Migration Results ===")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Statement\tDuration\tAlgorithm\tLock\tBlocks writes\tMDL wait\tRows\n")
+	fmt.Fprintf(w, "  ---------\t--------\t---------\t----\t-------------\t--------\t----\n")
+	var blocking time.Duration
+	for _, s := range r.Steps {
+		stmt := truncateSQL(oneLine(s.Statement), 50)
+		if s.Error != nil {
+			fmt.Fprintf(w, "  %s\tERROR: %v\t\t\t\t\t\n", stmt, s.Error)
+			continue
+		}
+		algorithm, lock, rows := s.Algorithm, s.Lock, strconv.FormatInt(s.RowsAffected, 10)
+		if algorithm == "" {
+			algorithm = "-"
+		} else {
+			rows = "-"
+		}
+		if lock == "" {
+			lock = "-"
+		}
+		if b := s.blocksWrites(); b != "no" && b != "-" {
+			blocking += s.Duration
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			stmt, formatDuration(s.Duration), algorithm, lock, s.blocksWrites(), formatDuration(s.MDLWait), rows)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nTotal: %s", formatDuration(r.Total))
+	if blocking > 0 {
+		fmt.Fprintf(out, " (writes blocked for %s)", formatDuration(blocking))
+	}
+	fmt.Fprintln(out)
+	if r.MonitorError != nil {
+		fmt.Fprintf(out, "Metadata lock waits could not be sampled: %v\n", r.MonitorError)
+	}
+
+	if len(r.Workload) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "\nWorkload latency:")
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := append(append([]string{"Test", "Phase"}, latencyHeader()...), "Runs", "Errors")
+	fmt.Fprint(w, "  "+tabRow(header...))
+	fmt.Fprint(w, "  "+tabRow(dashes(header)...))
+	for _, s := range r.Workload {
+		cells := append([]string{s.Test, s.Phase}, latencyCells(s.Latency)...)
+		fmt.Fprint(w, "  "+tabRow(append(cells, strconv.Itoa(s.Latency.Count()), strconv.Itoa(s.Errors))...))
+	}
+	w.Flush()
+	if r.LongestStall > 0 {
+		fmt.Fprintf(out, "\nLongest workload stall on a metadata lock: %s\n", formatDuration(r.LongestStall))
+	}
+	for _, name := range slices.Sorted(maps.Keys(r.WorkloadErrors)) {
+		fmt.Fprintf(out, "  %s: last error: %v\n", name, r.WorkloadErrors[name])
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"text/tabwriter"
 
@@ -20,6 +21,7 @@ var (
 	previewDSN         string
 	previewSchemaFile  string
 	previewConfigPath  string
+	previewProfile     string
 	previewTables      []string
 	previewSampleRows  int
 	previewRows        int
@@ -56,6 +58,7 @@ func init() {
 	previewCmd.Flags().StringVar(&previewDSN, "dsn", "", "MySQL DSN (required), e.g. user:pass@tcp(localhost:3306)/mydb")
 	previewCmd.Flags().StringVar(&previewSchemaFile, "schema", "", "Path to SQL DDL file (creates temporary tables)")
 	previewCmd.Flags().StringVar(&previewConfigPath, "config", "", "Path to config YAML file (default: auto-detect go-test-my-db.yaml)")
+	previewCmd.Flags().StringVar(&previewProfile, "profile", "", "Config profile to apply over the file's settings (env SEED_PROFILE)")
 	previewCmd.Flags().StringSliceVar(&previewTables, "table", nil, "Table(s) to preview (repeatable). If omitted, previews all tables")
 	previewCmd.Flags().IntVar(&previewSampleRows, "sample-rows", 5, "Number of sample rows/groups to display per table")
 	previewCmd.Flags().IntVar(&previewRows, "rows", 1000, "Base row count for root tables")
@@ -73,7 +76,7 @@ func init() {
 
 func runPreview(cmd *cobra.Command, args []string) error {
 	// Load config.
-	cfg, err := config.LoadOrDefault(previewConfigPath)
+	cfg, err := loadConfig(cmd, previewConfigPath, previewProfile)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
@@ -216,6 +219,7 @@ func runPreviewWithSchema(db *sql.DB, schema string, cfg *config.Config) error {
 		DeferIndexes: previewDeferIndexes,
 		GenConfig:    cfg,
 		FKSampleSize: previewFKSampleSize,
+		MaxRows:      previewMaxRows,
 	}); err != nil {
 		return fmt.Errorf("seeding tables: %w", err)
 	}
@@ -428,6 +432,7 @@ func runPreviewInMemory(db *sql.DB, schema string, cfg *config.Config) error {
 
 	// Generate and display sample rows for each table.
 	fkCache := make(map[string][]any)
+	generated := make(map[string]generatedRows) // for templates reading .parent
 
 	n := previewSampleRows
 
@@ -456,9 +461,17 @@ func runPreviewInMemory(db *sql.DB, schema string, cfg *config.Config) error {
 			}
 		}
 
-		// Find the FK column to group by (first FK to a parent in the seed set).
+		// Find the FK column to group by: the one with children_per_parent,
+		// else the first FK to a parent in the seed set.
 		var groupByFK *introspect.Column
-		if len(parents) > 0 {
+		if name, _ := cfg.GetChildren(tableName); name != "" {
+			for i, col := range table.Columns {
+				if col.Name == name && col.FK != nil {
+					groupByFK = &table.Columns[i]
+				}
+			}
+		}
+		if groupByFK == nil && len(parents) > 0 {
 			for i, col := range table.Columns {
 				if col.FK == nil {
 					continue
@@ -481,20 +494,37 @@ func runPreviewInMemory(db *sql.DB, schema string, cfg *config.Config) error {
 			genCount = n * n * 4 // e.g., 100 rows to group across 5 parents
 		}
 
-		gen, err := generator.NewRowGenerator(table, tableFKValues, nil, cfg, pkStartValues, nil, nil)
+		// Parent columns read by templates, from the generated parent rows.
+		needed, err := generator.ParentColumns(table, cfg)
+		if err != nil {
+			return err
+		}
+		parentRows := make(map[string]generator.ParentRows, len(needed))
+		for _, col := range table.Columns {
+			if cols := needed[col.Name]; len(cols) > 0 {
+				parentRows[col.Name] = generated[col.FK.ReferencedTable].parentRows(col.FK.ReferencedColumn, cols)
+			}
+		}
+
+		gen, err := generator.NewRowGenerator(table, tableFKValues, nil, parentRows, cfg, pkStartValues, nil, nil)
 		if err != nil {
 			return err
 		}
+		if rows, ok := gen.ChildRows(); ok {
+			genCount = min(genCount, rows)
+		}
+		gen.PlanRows(genCount)
 		genCols := gen.Columns()
 		sampleRows := make([][]any, genCount)
 		for i := range sampleRows {
 			sampleRows[i] = gen.GenerateRow()
 		}
+		generated[tableName] = generatedRows{table: table, cols: genCols, rows: sampleRows}
 
 		// Build column names (all except generated).
 		var colNames []string
 		for _, col := range table.Columns {
-			if col.IsGenerated {
+			if col.IsGenerated || generator.LeavesDefault(cfg, tableName, col) {
 				continue
 			}
 			colNames = append(colNames, col.Name)
@@ -506,7 +536,7 @@ func runPreviewInMemory(db *sql.DB, schema string, cfg *config.Config) error {
 			colPos := 0
 			genIdx := 0
 			for _, col := range table.Columns {
-				if col.IsGenerated {
+				if col.IsGenerated || generator.LeavesDefault(cfg, tableName, col) {
 					continue
 				}
 				if col.IsAutoInc {
@@ -565,7 +595,7 @@ func runPreviewInMemory(db *sql.DB, schema string, cfg *config.Config) error {
 
 			if fkGenIdx < 0 {
 				// FK column not in generated columns; fall back to flat display.
-				printInMemoryFlat(colNames, sampleRows[:n], toDisplayRow)
+				printInMemoryFlat(colNames, sampleRows[:min(n, len(sampleRows))], toDisplayRow)
 			} else {
 				// Group rows by FK value.
 				type group struct {
@@ -617,7 +647,7 @@ func runPreviewInMemory(db *sql.DB, schema string, cfg *config.Config) error {
 				}
 			}
 		} else {
-			printInMemoryFlat(colNames, sampleRows[:n], toDisplayRow)
+			printInMemoryFlat(colNames, sampleRows[:min(n, len(sampleRows))], toDisplayRow)
 		}
 	}
 
@@ -626,6 +656,42 @@ func runPreviewInMemory(db *sql.DB, schema string, cfg *config.Config) error {
 }
 
 // printInMemoryFlat prints a flat table from in-memory generated rows.
+// generatedRows are the rows generated for a table in memory.
+type generatedRows struct {
+	table *introspect.Table
+	cols  []string // generated columns, excluding auto-increment ones
+	rows  [][]any
+}
+
+// parentRows returns the columns cols of the rows by their key column, as
+// read by child templates through .parent. Auto-increment columns number
+// the rows from 1.
+func (g generatedRows) parentRows(key string, cols []string) generator.ParentRows {
+	if g.table == nil {
+		return nil
+	}
+	value := func(i int, name string) any {
+		if j := slices.Index(g.cols, name); j >= 0 {
+			return g.rows[i][j]
+		}
+		for _, col := range g.table.Columns {
+			if col.Name == name && col.IsAutoInc {
+				return int64(i + 1)
+			}
+		}
+		return nil
+	}
+	parents := make(generator.ParentRows, len(g.rows))
+	for i := range g.rows {
+		row := make(map[string]any, len(cols))
+		for _, col := range cols {
+			row[col] = value(i, col)
+		}
+		parents[generator.ParentKey(value(i, key))] = row
+	}
+	return parents
+}
+
 func printInMemoryFlat(colNames []string, rows [][]any, toDisplay func([]any, int) []string) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintf(w, "  %s\n", strings.Join(colNames, "\t"))
@@ -4,8 +4,16 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
 )
 
+// loadConfig loads the config file at path, auto-detected when empty, with
+// the profile from the flag or SEED_PROFILE applied.
+func loadConfig(cmd *cobra.Command, path, profile string) (*config.Config, error) {
+	return config.LoadOrDefault(path, resolveString(cmd, "profile", profile, "SEED_PROFILE", "", ""))
+}
+
 // resolveString returns the first non-empty value in priority order:
 // CLI flag (if explicitly set) > env var > config file value > default.
 func resolveString(cmd *cobra.Command, flagName, flagVal, envVar, cfgVal, defaultVal string) string {
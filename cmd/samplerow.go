@@ -12,15 +12,21 @@ var identRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 
 // makeSampleRowFunc creates a template function that picks a random row from the
 // database. It lazily fetches and caches up to 1000 rows per table+columns
-// combination, returning a map[string]any keyed by column name.
+// combination, returning a map[string]any keyed by column name. With a
+// non-nil rng the rows are fetched in column order and picked from rng, so
+// identical data yields an identical sequence of rows.
 //
 // Usage in query templates:
 //
 //	{{with SampleRow "tableName" "col1" "col2"}}
 //	SELECT * FROM tableName WHERE col1 = {{.col1}} AND col2 = {{.col2}}
 //	{{end}}
-func makeSampleRowFunc(db *sql.DB) func(args ...string) (map[string]any, error) {
+func makeSampleRowFunc(db *sql.DB, rng *rand.Rand) func(args ...string) (map[string]any, error) {
 	cache := make(map[string][]map[string]any)
+	pick := rand.IntN
+	if rng != nil {
+		pick = rng.IntN
+	}
 
 	return func(args ...string) (map[string]any, error) {
 		if len(args) < 2 {
@@ -42,7 +48,7 @@ func makeSampleRowFunc(db *sql.DB) func(args ...string) (map[string]any, error)
 		key := table + ":" + strings.Join(cols, ",")
 
 		if rows, ok := cache[key]; ok && len(rows) > 0 {
-			return rows[rand.IntN(len(rows))], nil
+			return rows[pick(len(rows))], nil
 		}
 
 		// Fetch sample rows. No ORDER BY RAND() needed — seeded data is
@@ -51,8 +57,11 @@ func makeSampleRowFunc(db *sql.DB) func(args ...string) (map[string]any, error)
 		for i, c := range cols {
 			quotedCols[i] = "`" + c + "`"
 		}
-		query := fmt.Sprintf("SELECT %s FROM `%s` LIMIT 1000",
-			strings.Join(quotedCols, ", "), table)
+		query := fmt.Sprintf("SELECT %s FROM `%s`", strings.Join(quotedCols, ", "), table)
+		if rng != nil {
+			query += " ORDER BY " + strings.Join(quotedCols, ", ")
+		}
+		query += " LIMIT 1000"
 
 		dbRows, err := db.Query(query)
 		if err != nil {
@@ -88,6 +97,6 @@ func makeSampleRowFunc(db *sql.DB) func(args ...string) (map[string]any, error)
 		}
 
 		cache[key] = rows
-		return rows[rand.IntN(len(rows))], nil
+		return rows[pick(len(rows))], nil
 	}
 }
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/spf13/cobra"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+)
+
+var schemaCompare bool
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the config file for editor completion and validation",
+	Long: `The schema command prints the JSON Schema of go-test-my-db.yaml, or with
+--compare of comparison configs. Editors using the YAML language server (VS Code,
+Neovim, JetBrains) complete keys and flag mistakes when a config starts with
+
+  # yaml-language-server: $schema=` + config.SchemaURL + `
+
+To pin the schema to the installed version, write it next to the config and
+point the comment at the file instead:
+
+  go-test-my-db schema > go-test-my-db.schema.json
+  # yaml-language-server: $schema=./go-test-my-db.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: runSchema,
+}
+
+func init() {
+	schemaCmd.Flags().BoolVar(&schemaCompare, "compare", false, "Print the schema of comparison configs instead")
+
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	s := config.JSONSchema()
+	if schemaCompare {
+		s = config.CompareJSONSchema()
+	}
+	data, err := marshalSchema(s)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(data)
+	return err
+}
+
+// marshalSchema formats a schema as indented JSON with a trailing newline.
+func marshalSchema(s *jsonschema.Schema) ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+)
+
+// TestPublishedSchemas keeps the schema files that configs link to in sync
+// with the config types. Run with -update after changing them.
+func TestPublishedSchemas(t *testing.T) {
+	for file, s := range map[string]*jsonschema.Schema{
+		"config.schema.json":  config.JSONSchema(),
+		"compare.schema.json": config.CompareJSONSchema(),
+	} {
+		got, err := marshalSchema(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join("..", "schema", file)
+		if *update {
+			if err := os.WriteFile(path, got, 0644); err != nil {
+				t.Fatalf("failed to update %s: %v", path, err)
+			}
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read %s (run with -update to create): %v", path, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s is out of date; run go test ./cmd -run TestPublishedSchemas -update", path)
+		}
+	}
+}
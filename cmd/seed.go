@@ -3,7 +3,6 @@ package cmd
 import (
 	"database/sql"
 	"fmt"
-	"math/rand/v2"
 	"strings"
 	"time"
 
@@ -26,12 +25,14 @@ var (
 	clear       bool
 	loadData    bool
 	configPath  string
+	profile     string
 	minChildren int
 	maxChildren int
 	maxRows      int
 	dryRun       bool
 	deferIndexes bool
 	fkSampleSize int
+	dataSeed     int64
 )
 
 var rootCmd = &cobra.Command{
@@ -51,12 +52,14 @@ func init() {
 	rootCmd.Flags().IntVar(&workers, "workers", 4, "Concurrent insert workers")
 	rootCmd.Flags().BoolVar(&clear, "clear", false, "Truncate target tables before seeding")
 	rootCmd.Flags().StringVar(&configPath, "config", "", "Path to config YAML file (default: auto-detect go-test-my-db.yaml)")
+	rootCmd.Flags().StringVar(&profile, "profile", "", "Config profile to apply over the file's settings (env SEED_PROFILE)")
 	rootCmd.Flags().BoolVar(&loadData, "load-data", false, "Use LOAD DATA LOCAL INFILE for faster bulk loading (requires server local_infile=ON)")
 	rootCmd.Flags().IntVar(&minChildren, "min-children", 10, "Min children per parent row for child tables")
 	rootCmd.Flags().IntVar(&maxChildren, "max-children", 100, "Max children per parent row for child tables")
 	rootCmd.Flags().IntVar(&maxRows, "max-rows", 10_000_000, "Maximum rows per table (safeguard for deep hierarchies)")
 	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be seeded without inserting any data")
 	rootCmd.Flags().BoolVar(&deferIndexes, "defer-indexes", false, "Drop secondary indexes before seeding and rebuild after (faster for large tables)")
+	rootCmd.Flags().Int64Var(&dataSeed, "seed", 0, "Seed for reproducible data (0 = random; overrides options.seed)")
 	rootCmd.Flags().IntVar(&fkSampleSize, "fk-sample-size", 500_000, "Max FK parent values to cache per column (0 = unlimited)")
 }
 
@@ -68,7 +71,7 @@ func runSeed(cmd *cobra.Command, args []string) error {
 	start := time.Now()
 
 	// Load config file.
-	cfg, err := config.LoadOrDefault(configPath)
+	cfg, err := loadConfig(cmd, configPath, profile)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
@@ -88,6 +91,9 @@ func runSeed(cmd *cobra.Command, args []string) error {
 	if !cmd.Flags().Changed("defer-indexes") && cfg.Options.DeferIndexes {
 		deferIndexes = true
 	}
+	if cmd.Flags().Changed("seed") {
+		cfg.Options.Seed = dataSeed
+	}
 
 	if dsn == "" {
 		return fmt.Errorf("DSN is required — set via --dsn flag, SEED_DSN env var, or options.dsn in config file")
@@ -134,53 +140,14 @@ func runSeed(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Found %d tables\n", len(tableNames))
 	}
 
-	// Introspect all tables in the database (needed for FK resolution).
-	allTableNames, err := introspect.ListTables(db, schema)
+	plan, err := planSeed(db, schema, cfg, tableNames, rows, minChildren, maxChildren, maxRows)
 	if err != nil {
 		return err
 	}
-	allTables := make(map[string]*introspect.Table, len(allTableNames))
-	for _, name := range allTableNames {
-		t, err := introspect.IntrospectTable(db, schema, name)
-		if err != nil {
-			return err
-		}
-		allTables[name] = t
-	}
-
-	// Apply config-declared references (logical FKs without actual constraints).
-	if refs := cfg.GetReferences(); refs != nil {
-		introspect.ApplyReferences(allTables, refs)
-	}
-
-	// Build the requested table set.
-	requestedTables := make(map[string]*introspect.Table, len(tableNames))
-	for _, name := range tableNames {
-		t, ok := allTables[name]
-		if !ok {
-			return fmt.Errorf("table %q not found in schema %s", name, schema)
-		}
-		requestedTables[name] = t
+	if len(plan.autoIncluded) > 0 {
+		fmt.Printf("Auto-included parent tables: %s\n", strings.Join(plan.autoIncluded, ", "))
 	}
-
-	// Resolve FK dependencies (topological order, auto-include parents).
-	order, autoIncluded, relations, err := depgraph.Resolve(requestedTables, allTables)
-	if err != nil {
-		return err
-	}
-
-	if len(autoIncluded) > 0 {
-		fmt.Printf("Auto-included parent tables: %s\n", strings.Join(autoIncluded, ", "))
-	}
-
-	// Build ordered table slice.
-	orderedTables := make([]*introspect.Table, len(order))
-	for i, name := range order {
-		orderedTables[i] = requestedTables[name]
-	}
-
-	// Compute per-table row counts.
-	rowCounts := computeRowCounts(order, relations, cfg, rows, minChildren, maxChildren, maxRows)
+	orderedTables, rowCounts, relations := plan.tables, plan.rowCounts, plan.relations
 
 	totalRowCount := 0
 	fmt.Printf("Seeding %d tables (%d workers, batch size %d):\n", len(orderedTables), workers, batchSize)
@@ -213,6 +180,7 @@ func runSeed(cmd *cobra.Command, args []string) error {
 		DeferIndexes: deferIndexes,
 		GenConfig:    cfg,
 		FKSampleSize: fkSampleSize,
+		MaxRows:      maxRows,
 	}); err != nil {
 		return err
 	}
@@ -224,10 +192,75 @@ func runSeed(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// seedPlan is the FK-ordered set of tables to seed, with their row counts.
+type seedPlan struct {
+	tables       []*introspect.Table
+	rowCounts    map[string]int
+	relations    *depgraph.TableRelations
+	autoIncluded []string // parents seeded because a requested table needs them
+}
+
+// planSeed introspects the schema and orders the requested tables, plus the
+// parent tables they reference, for seeding.
+func planSeed(db *sql.DB, schema string, cfg *config.Config, tableNames []string, baseRows, minC, maxC, maxRowsCap int) (*seedPlan, error) {
+	// Introspect all tables in the database (needed for FK resolution).
+	allTableNames, err := introspect.ListTables(db, schema)
+	if err != nil {
+		return nil, err
+	}
+	allTables := make(map[string]*introspect.Table, len(allTableNames))
+	for _, name := range allTableNames {
+		t, err := introspect.IntrospectTable(db, schema, name)
+		if err != nil {
+			return nil, err
+		}
+		allTables[name] = t
+	}
+
+	if err := checkConfig(cfg, allTables); err != nil {
+		return nil, err
+	}
+
+	// Apply config-declared references (logical FKs without actual constraints).
+	if refs := cfg.GetReferences(); refs != nil {
+		introspect.ApplyReferences(allTables, refs)
+	}
+
+	// Build the requested table set.
+	requestedTables := make(map[string]*introspect.Table, len(tableNames))
+	for _, name := range tableNames {
+		t, ok := allTables[name]
+		if !ok {
+			return nil, fmt.Errorf("table %q not found in schema %s", name, schema)
+		}
+		requestedTables[name] = t
+	}
+
+	// Resolve FK dependencies (topological order, auto-include parents).
+	order, autoIncluded, relations, err := depgraph.Resolve(requestedTables, allTables)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build ordered table slice.
+	orderedTables := make([]*introspect.Table, len(order))
+	for i, name := range order {
+		orderedTables[i] = requestedTables[name]
+	}
+
+	return &seedPlan{
+		tables:       orderedTables,
+		rowCounts:    computeRowCounts(order, relations, cfg, baseRows, minC, maxC, maxRowsCap),
+		relations:    relations,
+		autoIncluded: autoIncluded,
+	}, nil
+}
+
 // computeRowCounts determines how many rows to generate for each table.
 // Root tables (no FK parents in the seed set) get the base row count.
 // Child tables get parent_rows * random_multiplier from [minC, maxC].
-// Per-table config overrides take highest priority.
+// Per-table config overrides take priority over both, and children_per_parent
+// over all: it plans the children of every parent row, up to maxRowsCap.
 func computeRowCounts(
 	order []string,
 	relations *depgraph.TableRelations,
@@ -237,13 +270,21 @@ func computeRowCounts(
 	rowCounts := make(map[string]int, len(order))
 
 	for _, tableName := range order {
-		// Priority 1: Per-table config override.
+		// Priority 1: Children per parent row.
+		if col, card := cfg.GetChildren(tableName); card != nil {
+			if parent, ok := relations.ParentOf[tableName][col]; ok {
+				rowCounts[tableName] = min(generator.PlanChildren(cfg, tableName, col, rowCounts[parent]), maxRowsCap)
+				continue
+			}
+		}
+
+		// Priority 2: Per-table config override.
 		if tc, ok := cfg.Tables[tableName]; ok && tc.Rows > 0 {
 			rowCounts[tableName] = tc.Rows
 			continue
 		}
 
-		// Priority 2: Compute based on parentage.
+		// Priority 3: Compute based on parentage.
 		parents := relations.Parents[tableName]
 		if len(parents) == 0 {
 			rowCounts[tableName] = baseRows
@@ -260,7 +301,7 @@ func computeRowCounts(
 
 		multiplier := minC
 		if maxC > minC {
-			multiplier = minC + rand.IntN(maxC-minC+1)
+			multiplier = minC + generator.NewRand(cfg.Options.Seed, tableName).IntN(maxC-minC+1)
 		}
 
 		computed := maxParentRows * multiplier
@@ -298,16 +339,34 @@ func printDryRunDetails(tables []*introspect.Table, rowCounts map[string]int, re
 		fmt.Println(header)
 		for _, col := range t.Columns {
 			strategy := generator.DescribeGenerator(col, t.Name, cfg)
-			nullable := ""
-			if col.IsNullable {
-				nullable = ", nullable"
-			}
-			fmt.Printf("  %-30s %-15s %s%s\n", col.Name, col.DataType, strategy, nullable)
+			fmt.Printf("  %-30s %-15s %s%s\n", col.Name, col.DataType, strategy, describeRates(col, t.Name, cfg))
 		}
 		fmt.Println()
 	}
 }
 
+// describeRates describes the NULL and DEFAULT rates of a column for the
+// dry-run plan, e.g. ", nullable 95% NULL, 50% DEFAULT 'active'".
+func describeRates(col introspect.Column, table string, cfg *config.Config) string {
+	if col.IsAutoInc || col.IsGenerated || col.FK != nil || generator.LeavesDefault(cfg, table, col) {
+		if col.IsNullable {
+			return ", nullable"
+		}
+		return ""
+	}
+	var out string
+	if col.IsNullable {
+		out = ", nullable"
+		if !col.IsPrimaryKey {
+			out += fmt.Sprintf(" %.3g%% NULL", cfg.NullRate(table, col.Name)*100)
+		}
+	}
+	if rate := cfg.DefaultRate(table, col.Name); rate > 0 && generator.DefaultValue(col) != nil {
+		out += fmt.Sprintf(", %.3g%% DEFAULT %s", rate*100, *col.Default)
+	}
+	return out
+}
+
 func extractSchema(dsn string) string {
 	// DSN format: user:pass@tcp(host:port)/dbname?params
 	idx := strings.LastIndex(dsn, "/")
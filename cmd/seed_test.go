@@ -76,6 +76,27 @@ func TestComputeRowCounts(t *testing.T) {
 			maxCap:   10_000_000,
 			expected: map[string]int{"users": 100, "orders": 500},
 		},
+		{
+			name:  "children_per_parent",
+			order: []string{"users", "orders"},
+			relations: &depgraph.TableRelations{
+				Parents:  map[string][]string{"orders": {"users"}},
+				ParentOf: map[string]map[string]string{"orders": {"user_id": "users"}},
+			},
+			cfg: &config.Config{
+				Tables: map[string]config.TableConfig{
+					"orders": {Rows: 500, Children: map[string]config.CardinalityConfig{
+						"user_id": {Min: 3, Max: 3, None: 0.5},
+					}},
+				},
+			},
+			baseRows: 100,
+			minC:     10,
+			maxC:     10,
+			maxCap:   10_000_000,
+			// Half of the users have 3 orders, the rest none.
+			expected: map[string]int{"users": 100, "orders": 150},
+		},
 		{
 			name:  "max_rows_cap",
 			order: []string{"users", "orders"},
@@ -89,6 +110,26 @@ func TestComputeRowCounts(t *testing.T) {
 			maxCap:   100000,
 			expected: map[string]int{"users": 10000, "orders": 100000},
 		},
+		{
+			name:  "children_per_parent_cap",
+			order: []string{"users", "orders"},
+			relations: &depgraph.TableRelations{
+				Parents:  map[string][]string{"orders": {"users"}},
+				ParentOf: map[string]map[string]string{"orders": {"user_id": "users"}},
+			},
+			cfg: &config.Config{
+				Tables: map[string]config.TableConfig{
+					"orders": {Children: map[string]config.CardinalityConfig{
+						"user_id": {Min: 3, Max: 3},
+					}},
+				},
+			},
+			baseRows: 100,
+			minC:     10,
+			maxC:     10,
+			maxCap:   250,
+			expected: map[string]int{"users": 100, "orders": 250},
+		},
 		{
 			name:  "multiple_parents_picks_max",
 			order: []string{"users", "products", "reviews"},
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+)
+
+// snapshotDir returns the cache directory for the dataset produced by the
+// given schema file, config and pipeline options. The directory may not exist
+// yet; seeder.SnapshotExists reports whether a usable snapshot is stored there.
+func snapshotDir(schemaFile string, cfg *config.Config, opts pipelineOptions) (string, error) {
+	ddl, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return "", err
+	}
+	key, err := snapshotKey(ddl, cfg, opts)
+	if err != nil {
+		return "", err
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "go-test-my-db", "snapshots", key), nil
+}
+
+// snapshotKey hashes everything that influences the generated data: the DDL,
+// the table generation config, the row-count parameters and the generation
+// options (seed, null and default rates). Test queries and insert tuning
+// (batch size, workers, load mode) are deliberately excluded so that
+// iterating on queries keeps hitting the same snapshot.
+func snapshotKey(ddl []byte, cfg *config.Config, opts pipelineOptions) (string, error) {
+	inputs, err := yaml.Marshal(struct {
+		Tables       map[string]config.TableConfig `yaml:"tables"`
+		Rows         int                           `yaml:"rows"`
+		MinChildren  int                           `yaml:"min_children"`
+		MaxChildren  int                           `yaml:"max_children"`
+		MaxRows      int                           `yaml:"max_rows"`
+		FKSampleSize int                           `yaml:"fk_sample_size"`
+		SeedTables   []string                      `yaml:"seed_tables"`
+		Seed         int64                         `yaml:"seed"`
+		NullRate     *float64                      `yaml:"null_rate"`
+		DefaultRate  *float64                      `yaml:"default_rate"`
+	}{
+		cfg.Tables, opts.Rows, opts.MinChildren, opts.MaxChildren, opts.MaxRows, opts.FKSampleSize,
+		opts.SeedTables, cfg.Options.Seed, cfg.Options.NullRate, cfg.Options.DefaultRate,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding generation config: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write(ddl)
+	h.Write([]byte{0})
+	h.Write(inputs)
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+)
+
+func TestSnapshotKey(t *testing.T) {
+	ddl := []byte("CREATE TABLE users (id INT PRIMARY KEY);")
+	cfg := &config.Config{
+		Tables: map[string]config.TableConfig{
+			"users": {Columns: map[string]string{"name": "{{Name}}"}},
+		},
+	}
+	opts := pipelineOptions{Rows: 1000, MinChildren: 10, MaxChildren: 100, MaxRows: 1000000}
+
+	base, err := snapshotKey(ddl, cfg, opts)
+	if err != nil {
+		t.Fatalf("snapshotKey: %v", err)
+	}
+
+	// Tuning and test changes must not invalidate the snapshot.
+	tuned := opts
+	tuned.Workers, tuned.BatchSize, tuned.LoadData = 8, 5000, true
+	withTests := *cfg
+	withTests.Tests = []config.TestCase{{Name: "q", Query: "SELECT 1"}}
+	if got, _ := snapshotKey(ddl, &withTests, tuned); got != base {
+		t.Errorf("key changed for tests/tuning: %s != %s", got, base)
+	}
+
+	// Anything that changes the data must.
+	more := opts
+	more.Rows = 2000
+	if got, _ := snapshotKey(ddl, cfg, more); got == base {
+		t.Error("key unchanged after changing rows")
+	}
+	if got, _ := snapshotKey([]byte("CREATE TABLE users (id BIGINT PRIMARY KEY);"), cfg, opts); got == base {
+		t.Error("key unchanged after changing DDL")
+	}
+	other := &config.Config{
+		Tables: map[string]config.TableConfig{
+			"users": {Columns: map[string]string{"name": "{{FirstName}}"}},
+		},
+	}
+	if got, _ := snapshotKey(ddl, other, opts); got == base {
+		t.Error("key unchanged after changing table config")
+	}
+	seeded := *cfg
+	seeded.Options.Seed = 42
+	if got, _ := snapshotKey(ddl, &seeded, opts); got == base {
+		t.Error("key unchanged after changing seed")
+	}
+	rate := 0.5
+	nulls := *cfg
+	nulls.Options.NullRate = &rate
+	if got, _ := snapshotKey(ddl, &nulls, opts); got == base {
+		t.Error("key unchanged after changing options.null_rate")
+	}
+	defaults := *cfg
+	defaults.Options.DefaultRate = &rate
+	if got, _ := snapshotKey(ddl, &defaults, opts); got == base {
+		t.Error("key unchanged after changing options.default_rate")
+	}
+}
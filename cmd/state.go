@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/ephemeral"
+)
+
+// keptRun records the tables (and, for ephemeral runs, the container) left
+// behind by a run with --keep so that cleanup can remove exactly those.
+type keptRun struct {
+	CreatedAt   time.Time `json:"created_at"`
+	DSN         string    `json:"dsn"`
+	Tables      []string  `json:"tables"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Runtime     string    `json:"runtime,omitempty"`
+}
+
+// runState is the on-disk list of kept runs.
+type runState struct {
+	Runs []keptRun `json:"runs"`
+}
+
+// statePath returns the location of the state file in the user cache directory.
+func statePath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "go-test-my-db", "state.json"), nil
+}
+
+// loadState reads the state file. A missing file yields an empty state.
+func loadState(path string) (*runState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &runState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s runState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// save writes the state file, removing it once no runs remain. The file holds
+// DSNs, so it is only readable by the current user.
+func (s *runState) save(path string) error {
+	if len(s.Runs) == 0 {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// add records a kept run. Re-running against the same database (or container)
+// merges the table lists instead of adding a duplicate entry.
+func (s *runState) add(run keptRun) {
+	for i := range s.Runs {
+		r := &s.Runs[i]
+		if r.DSN != run.DSN || r.ContainerID != run.ContainerID {
+			continue
+		}
+		for _, t := range run.Tables {
+			if !slices.Contains(r.Tables, t) {
+				r.Tables = append(r.Tables, t)
+			}
+		}
+		r.CreatedAt = run.CreatedAt
+		return
+	}
+	s.Runs = append(s.Runs, run)
+}
+
+// keepRun records kept tables in the state file and prints how to reach them.
+// edb is nil unless the run used an ephemeral container, which is kept too.
+// It reports whether the run was recorded; callers stop a container whose
+// run was not, since cleanup could never find it.
+func keepRun(dsn string, tables []string, edb *ephemeral.DB) bool {
+	run := keptRun{CreatedAt: time.Now().UTC(), DSN: dsn, Tables: tables}
+	if edb != nil {
+		run.ContainerID = edb.ContainerID
+		run.Runtime = edb.Runtime()
+	}
+
+	path, err := statePath()
+	if err == nil {
+		var s *runState
+		if s, err = loadState(path); err == nil {
+			s.add(run)
+			err = s.save(path)
+		}
+	}
+	if err != nil && edb != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record kept tables, so the container is not kept: %v\n", err)
+		return false
+	}
+
+	fmt.Printf("\nKept %d tables for investigation: %s\n", len(tables), strings.Join(tables, ", "))
+	fmt.Printf("  DSN: %s\n", redactDSNPassword(dsn))
+	if edb != nil {
+		fmt.Printf("  Connect: %s\n", edb.ClientCommand())
+		fmt.Printf("  Container: %s (%s)\n", edb.ContainerID, edb.Runtime())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not record kept tables: %v\n", err)
+		return false
+	}
+	fmt.Println("Run 'go-test-my-db cleanup' to remove them.")
+	return true
+}
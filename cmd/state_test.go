@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tomfevang/go-test-my-db/internal/ephemeral"
+)
+
+func TestRunState_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "state.json")
+
+	s, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState on missing file: %v", err)
+	}
+	if len(s.Runs) != 0 {
+		t.Fatalf("expected empty state, got %d runs", len(s.Runs))
+	}
+
+	s.add(keptRun{DSN: "u:p@tcp(h:3306)/db", Tables: []string{"a", "b"}})
+	if err := s.save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected mode 0600, got %o", perm)
+	}
+
+	loaded, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	if len(loaded.Runs) != 1 || !slices.Equal(loaded.Runs[0].Tables, []string{"a", "b"}) {
+		t.Fatalf("unexpected state after round-trip: %+v", loaded.Runs)
+	}
+
+	// Saving an empty state removes the file.
+	loaded.Runs = nil
+	if err := loaded.save(path); err != nil {
+		t.Fatalf("save empty: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected state file to be removed, stat err = %v", err)
+	}
+}
+
+func TestRunState_AddMerges(t *testing.T) {
+	var s runState
+	s.add(keptRun{DSN: "dsn1", Tables: []string{"a", "b"}})
+	s.add(keptRun{DSN: "dsn1", Tables: []string{"b", "c"}})
+	s.add(keptRun{DSN: "dsn2", Tables: []string{"a"}})
+	s.add(keptRun{DSN: "dsn1", ContainerID: "abc", Tables: []string{"a"}})
+
+	if len(s.Runs) != 3 {
+		t.Fatalf("expected 3 runs, got %d: %+v", len(s.Runs), s.Runs)
+	}
+	if !slices.Equal(s.Runs[0].Tables, []string{"a", "b", "c"}) {
+		t.Errorf("expected merged tables [a b c], got %v", s.Runs[0].Tables)
+	}
+}
+
+func TestKeepRun(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+
+	edb := &ephemeral.DB{ContainerID: "abc", DSN: "root:pw@tcp(127.0.0.1:3306)/test"}
+	if !keepRun(edb.DSN, []string{"users"}, edb) {
+		t.Fatal("keepRun did not record the run")
+	}
+	path, err := statePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Runs) != 1 || s.Runs[0].ContainerID != "abc" {
+		t.Fatalf("unexpected state: %+v", s.Runs)
+	}
+
+	// A run that can't be recorded must not keep its container, which
+	// cleanup would never find.
+	if err := os.WriteFile(filepath.Join(cache, "blocked"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(cache, "blocked"))
+	t.Setenv("HOME", filepath.Join(cache, "blocked"))
+	if keepRun(edb.DSN, []string{"users"}, edb) {
+		t.Error("keepRun reported an unrecorded run as recorded")
+	}
+}
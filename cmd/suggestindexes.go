@@ -0,0 +1,902 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/ephemeral"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+	"github.com/tomfevang/go-test-my-db/internal/mcptools"
+	"github.com/tomfevang/go-test-my-db/internal/sqlscan"
+)
+
+// maxIndexColumns caps the width of a candidate index; columns beyond it
+// rarely narrow a lookup further.
+const maxIndexColumns = 5
+
+var (
+	suggestDSN            string
+	suggestSchemaFile     string
+	suggestConfigPath     string
+	suggestProfile        string
+	suggestRows           int
+	suggestBatchSize      int
+	suggestWorkers        int
+	suggestMinChildren    int
+	suggestMaxChildren    int
+	suggestMaxRows        int
+	suggestLoadData       bool
+	suggestDeferIndexes   bool
+	suggestFKSampleSize   int
+	suggestEphemeral      bool
+	suggestReuseData      bool
+	suggestKeep           bool
+	suggestSeed           int64
+	suggestMaxCandidates  int
+	suggestMinImprovement float64
+)
+
+var suggestIndexesCmd = &cobra.Command{
+	Use:   "suggest-indexes",
+	Short: "Propose indexes for the config's tests and measure each one on seeded data",
+	Long: `The suggest-indexes command creates the tables from a DDL file, seeds them,
+and runs the config's tests once as a baseline. It then parses the test
+queries, collects the columns they filter on (WHERE), join on (ON), and
+group or sort by (GROUP BY, ORDER BY), and proposes composite indexes:
+equality columns first, then the sort columns or the first range column.
+Candidates already covered by an existing index are skipped.
+
+Each candidate is then tried on its own: every test that touches its table
+is rerun without the index, the index is created, the tests are rerun with
+it, and the index is dropped again. Write and transaction tests are rolled
+back so the data stays the same across runs. The report shows the measured
+change in average latency, whether the server actually used the index, and
+the CREATE INDEX statements worth keeping.
+
+Tables are dropped afterwards unless --keep is set.`,
+	RunE: runSuggestIndexes,
+}
+
+func init() {
+	suggestIndexesCmd.Flags().StringVar(&suggestDSN, "dsn", "", "MySQL DSN (required), e.g. user:pass@tcp(localhost:3306)/mydb")
+	suggestIndexesCmd.Flags().StringVar(&suggestSchemaFile, "schema", "", "Path to SQL DDL file (required)")
+	suggestIndexesCmd.Flags().StringVar(&suggestConfigPath, "config", "", "Path to config YAML file (default: auto-detect go-test-my-db.yaml)")
+	suggestIndexesCmd.Flags().StringVar(&suggestProfile, "profile", "", "Config profile to apply over the file's settings (env SEED_PROFILE)")
+	suggestIndexesCmd.Flags().IntVar(&suggestRows, "rows", 1000, "Number of rows per table")
+	suggestIndexesCmd.Flags().IntVar(&suggestBatchSize, "batch-size", 1000, "Rows per INSERT statement")
+	suggestIndexesCmd.Flags().IntVar(&suggestWorkers, "workers", 4, "Concurrent insert workers")
+	suggestIndexesCmd.Flags().IntVar(&suggestMinChildren, "min-children", 10, "Min children per parent row for child tables")
+	suggestIndexesCmd.Flags().IntVar(&suggestMaxChildren, "max-children", 100, "Max children per parent row for child tables")
+	suggestIndexesCmd.Flags().IntVar(&suggestMaxRows, "max-rows", 10_000_000, "Maximum rows per table (safeguard for deep hierarchies)")
+	suggestIndexesCmd.Flags().BoolVar(&suggestLoadData, "load-data", false, "Use LOAD DATA LOCAL INFILE for faster bulk loading (requires server local_infile=ON)")
+	suggestIndexesCmd.Flags().BoolVar(&suggestDeferIndexes, "defer-indexes", false, "Drop secondary indexes before seeding and rebuild after (faster for large tables)")
+	suggestIndexesCmd.Flags().IntVar(&suggestFKSampleSize, "fk-sample-size", 500_000, "Max FK parent values to cache per column (0 = unlimited)")
+	suggestIndexesCmd.Flags().BoolVar(&suggestEphemeral, "ephemeral", false, "Start a temporary MySQL container via Docker or Podman (no DSN needed)")
+	suggestIndexesCmd.Flags().BoolVar(&suggestKeep, "keep", false, "Keep the tables, without the candidate indexes, (and --ephemeral container) after the run; remove them later with the cleanup command")
+	suggestIndexesCmd.Flags().Int64Var(&suggestSeed, "seed", 0, "Seed for reproducible data (0 = random; overrides options.seed)")
+	suggestIndexesCmd.Flags().BoolVar(&suggestReuseData, "reuse-data", false, "Restore a cached snapshot of an identical seeded dataset instead of re-seeding (saved on first run)")
+	suggestIndexesCmd.Flags().IntVar(&suggestMaxCandidates, "max-candidates", 10, "Maximum number of candidate indexes to try")
+	suggestIndexesCmd.Flags().Float64Var(&suggestMinImprovement, "min-improvement", 10, "Percentage by which an index must lower the affected tests' average latency to be recommended")
+
+	rootCmd.AddCommand(suggestIndexesCmd)
+}
+
+func runSuggestIndexes(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd, suggestConfigPath, suggestProfile)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	// Resolve operational parameters: CLI flag > env var > config > default.
+	suggestDSN = resolveString(cmd, "dsn", suggestDSN, "SEED_DSN", cfg.Options.DSN, "")
+	suggestSchemaFile = resolveString(cmd, "schema", suggestSchemaFile, "", cfg.Options.Schema, "")
+	suggestRows = resolveInt(cmd, "rows", suggestRows, cfg.Options.Rows, 1000)
+	suggestBatchSize = resolveInt(cmd, "batch-size", suggestBatchSize, cfg.Options.BatchSize, 1000)
+	suggestWorkers = resolveInt(cmd, "workers", suggestWorkers, cfg.Options.Workers, 4)
+	suggestMinChildren = resolveInt(cmd, "min-children", suggestMinChildren, cfg.Options.ChildrenPerParent.Min, 10)
+	suggestMaxChildren = resolveInt(cmd, "max-children", suggestMaxChildren, cfg.Options.ChildrenPerParent.Max, 100)
+	suggestMaxRows = resolveInt(cmd, "max-rows", suggestMaxRows, cfg.Options.MaxRows, 10_000_000)
+	if !cmd.Flags().Changed("load-data") && cfg.Options.LoadData {
+		suggestLoadData = true
+	}
+	if !cmd.Flags().Changed("defer-indexes") && cfg.Options.DeferIndexes {
+		suggestDeferIndexes = true
+	}
+	suggestFKSampleSize = resolveInt(cmd, "fk-sample-size", suggestFKSampleSize, cfg.Options.FKSampleSize, 500_000)
+	if cmd.Flags().Changed("seed") {
+		cfg.Options.Seed = suggestSeed
+	}
+
+	if suggestSchemaFile == "" {
+		return fmt.Errorf("schema file is required — set via --schema flag or options.schema in config file")
+	}
+	if len(cfg.Tests) == 0 {
+		return fmt.Errorf("no test queries configured — suggest-indexes derives its candidates from the config's tests")
+	}
+	if suggestMaxCandidates < 1 {
+		return fmt.Errorf("--max-candidates must be at least 1")
+	}
+
+	// Start ephemeral MySQL if requested and no DSN was provided.
+	var edb *ephemeral.DB
+	if suggestEphemeral && suggestDSN == "" {
+		edb, err = ephemeral.Start(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if !suggestKeep {
+			defer edb.Stop()
+		}
+		suggestDSN = edb.DSN
+	}
+	if suggestDSN == "" {
+		return fmt.Errorf("DSN is required — set via --dsn flag, SEED_DSN env var, options.dsn in config file, or use --ephemeral")
+	}
+
+	schema := extractSchema(suggestDSN)
+	if schema == "" {
+		return fmt.Errorf("could not extract database name from DSN — ensure it ends with /dbname")
+	}
+	if suggestLoadData {
+		suggestDSN = ensureAllowAllFiles(suggestDSN)
+	}
+
+	db, err := sql.Open("mysql", suggestDSN)
+	if err != nil {
+		return fmt.Errorf("connecting to MySQL: %w", err)
+	}
+	defer db.Close()
+
+	db.SetMaxOpenConns(suggestWorkers + 2)
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("pinging MySQL: %w", err)
+	}
+	fmt.Printf("Connected to %s\n", schema)
+
+	report, tables, err := runIndexPipeline(db, schema, cfg, pipelineOptions{
+		SchemaFile:   suggestSchemaFile,
+		Rows:         suggestRows,
+		BatchSize:    suggestBatchSize,
+		Workers:      suggestWorkers,
+		MinChildren:  suggestMinChildren,
+		MaxChildren:  suggestMaxChildren,
+		MaxRows:      suggestMaxRows,
+		LoadData:     suggestLoadData,
+		DeferIndexes: suggestDeferIndexes,
+		FKSampleSize: suggestFKSampleSize,
+		SeedTables:   cfg.Options.SeedTables,
+		ReuseData:    suggestReuseData,
+		Keep:         suggestKeep,
+		Context:      cmd.Context(),
+	}, suggestMaxCandidates, nil)
+	if suggestKeep && len(tables) > 0 {
+		keepRun(suggestDSN, tables, edb)
+	}
+	if err != nil {
+		return err
+	}
+
+	printIndexReport(os.Stdout, report, suggestMinImprovement)
+	return nil
+}
+
+// indexCandidate is a composite index proposed from the test queries.
+type indexCandidate struct {
+	Table       string
+	Columns     []string
+	SuggestedBy []string // tests whose predicates produced the candidate
+	Tests       []string // tests that touch the table, rerun to measure the index
+}
+
+// String describes the candidate as "table (col, col)".
+func (c indexCandidate) String() string {
+	return fmt.Sprintf("%s (%s)", c.Table, strings.Join(c.Columns, ", "))
+}
+
+// name returns the index name, shortened with a hash to MySQL's 64
+// character limit.
+func (c indexCandidate) name() string {
+	name := "idx_" + c.Table + "_" + strings.Join(c.Columns, "_")
+	if len(name) <= 64 {
+		return name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return fmt.Sprintf("%s_%08x", name[:55], h.Sum32())
+}
+
+// createStatement returns the CREATE INDEX statement for the candidate.
+func (c indexCandidate) createStatement() string {
+	cols := make([]string, len(c.Columns))
+	for i, col := range c.Columns {
+		cols[i] = "`" + col + "`"
+	}
+	return fmt.Sprintf("CREATE INDEX `%s` ON `%s` (%s)", c.name(), c.Table, strings.Join(cols, ", "))
+}
+
+// templateAction matches a {{...}} action in a test query. Actions are
+// replaced by a placeholder before the query is analysed.
+var templateAction = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+
+// unindexableTypes need a prefix length to be indexed, so candidates using
+// them are dropped.
+var unindexableTypes = map[string]bool{
+	"tinytext": true, "text": true, "mediumtext": true, "longtext": true,
+	"tinyblob": true, "blob": true, "mediumblob": true, "longblob": true,
+	"json": true, "geometry": true, "point": true, "linestring": true, "polygon": true,
+}
+
+// testStatements returns the SQL statements a test runs.
+func testStatements(tc config.TestCase) []string {
+	if tc.EffectiveKind() == config.TestKindTransaction {
+		return tc.Statements
+	}
+	return []string{tc.Query}
+}
+
+// proposeIndexes derives candidate indexes from the tests' queries, in the
+// order the tests first suggest them. Per statement and table, equality and
+// join columns lead, followed by the GROUP BY or ORDER BY columns or by the
+// first range column. Candidates that are a left prefix of an existing
+// index are dropped. existing maps a table to the column lists of its
+// indexes, including the primary key.
+func proposeIndexes(tests []config.TestCase, tables map[string]*introspect.Table, existing map[string][][]string) []indexCandidate {
+	byName := make(map[string]*introspect.Table, len(tables))
+	for _, t := range tables {
+		byName[strings.ToLower(t.Name)] = t
+	}
+
+	var candidates []indexCandidate
+	seen := make(map[string]int) // candidate key → index in candidates
+	touches := make(map[string][]string)
+	for _, tc := range tests {
+		for _, stmt := range testStatements(tc) {
+			tokens := sqlscan.Tokenize(templateAction.ReplaceAllString(stmt, "?"))
+			names, _ := sqlscan.Tables(tokens)
+			var stmtTables []*introspect.Table
+			for _, n := range names {
+				t := byName[strings.ToLower(n)]
+				if t == nil || slices.Contains(stmtTables, t) {
+					continue
+				}
+				stmtTables = append(stmtTables, t)
+				if !slices.Contains(touches[t.Name], tc.Name) {
+					touches[t.Name] = append(touches[t.Name], tc.Name)
+				}
+			}
+
+			for _, c := range statementCandidates(sqlscan.ColumnUses(tokens), stmtTables) {
+				if coveredByIndex(c.Columns, existing[c.Table]) {
+					continue
+				}
+				key := strings.ToLower(c.String())
+				i, ok := seen[key]
+				if !ok {
+					i = len(candidates)
+					seen[key] = i
+					candidates = append(candidates, c)
+				}
+				if !slices.Contains(candidates[i].SuggestedBy, tc.Name) {
+					candidates[i].SuggestedBy = append(candidates[i].SuggestedBy, tc.Name)
+				}
+			}
+		}
+	}
+	for i := range candidates {
+		candidates[i].Tests = touches[candidates[i].Table]
+	}
+	return candidates
+}
+
+// tableUses collects the resolved column uses of one table in a statement.
+type tableUses struct {
+	equality []string
+	ranges   []string
+}
+
+// statementCandidates proposes the indexes for one statement.
+func statementCandidates(uses []sqlscan.ColumnUse, tables []*introspect.Table) []indexCandidate {
+	perTable := make(map[*introspect.Table]*tableUses)
+	sorts := map[sqlscan.Use][]string{}
+	sortTable := map[sqlscan.Use]*introspect.Table{}
+	sortable := map[sqlscan.Use]bool{sqlscan.UseGroup: true, sqlscan.UseOrder: true}
+	sortDesc := map[sqlscan.Use]bool{}
+
+	for _, u := range uses {
+		t, col, ok := resolveColumn(u.Column, tables)
+		if u.Use == sqlscan.UseGroup || u.Use == sqlscan.UseOrder {
+			// An index serves a sort only if every sort column is in it,
+			// all from one table and in one direction.
+			switch {
+			case !ok, sortTable[u.Use] != nil && sortTable[u.Use] != t,
+				len(sorts[u.Use]) > 0 && sortDesc[u.Use] != u.Desc:
+				sortable[u.Use] = false
+			default:
+				sortTable[u.Use] = t
+				sortDesc[u.Use] = u.Desc
+				sorts[u.Use] = appendNew(sorts[u.Use], col)
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+		tu := perTable[t]
+		if tu == nil {
+			tu = &tableUses{}
+			perTable[t] = tu
+		}
+		if u.Use == sqlscan.UseRange {
+			tu.ranges = appendNew(tu.ranges, col)
+		} else {
+			tu.equality = appendNew(tu.equality, col)
+		}
+	}
+
+	// GROUP BY runs before ORDER BY, so it is the sort an index can serve.
+	sortUse := sqlscan.UseGroup
+	if len(sorts[sqlscan.UseGroup]) == 0 {
+		sortUse = sqlscan.UseOrder
+	}
+	if !sortable[sortUse] {
+		delete(sorts, sortUse)
+	}
+
+	var out []indexCandidate
+	add := func(t *introspect.Table, cols []string) {
+		if len(cols) > maxIndexColumns {
+			cols = cols[:maxIndexColumns]
+		}
+		out = append(out, indexCandidate{Table: t.Name, Columns: cols})
+	}
+	for _, t := range tables {
+		tu := perTable[t]
+		if tu == nil {
+			tu = &tableUses{}
+		}
+		eq := slices.Clip(tu.equality)
+		n := len(out)
+		if sortTable[sortUse] == t && len(sorts[sortUse]) > 0 {
+			add(t, appendNew(eq, sorts[sortUse]...))
+		}
+		if len(tu.ranges) > 0 {
+			add(t, appendNew(eq, tu.ranges[0]))
+		}
+		if len(out) == n && len(eq) > 0 {
+			add(t, eq)
+		}
+	}
+	return out
+}
+
+// resolveColumn finds the table and canonical name of a column reference
+// among the statement's tables. Unqualified columns must belong to exactly
+// one of them. Columns that cannot be indexed without a prefix length are
+// not resolved.
+func resolveColumn(ref sqlscan.ColumnRef, tables []*introspect.Table) (*introspect.Table, string, bool) {
+	var found *introspect.Table
+	var name string
+	for _, t := range tables {
+		if ref.Table != "" && !strings.EqualFold(ref.Table, t.Name) {
+			continue
+		}
+		for _, c := range t.Columns {
+			if !strings.EqualFold(c.Name, ref.Column) {
+				continue
+			}
+			if found != nil || unindexableTypes[strings.ToLower(c.DataType)] {
+				return nil, "", false
+			}
+			found, name = t, c.Name
+		}
+	}
+	return found, name, found != nil
+}
+
+// appendNew returns a copy of list with the columns not already in it
+// appended.
+func appendNew(list []string, cols ...string) []string {
+	out := slices.Clone(list)
+	for _, c := range cols {
+		if !slices.ContainsFunc(out, func(s string) bool { return strings.EqualFold(s, c) }) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// coveredByIndex reports whether cols is a left prefix of one of indexes.
+func coveredByIndex(cols []string, indexes [][]string) bool {
+	for _, idx := range indexes {
+		if len(idx) >= len(cols) && slices.EqualFunc(cols, idx[:len(cols)], strings.EqualFold) {
+			return true
+		}
+	}
+	return false
+}
+
+// existingIndexes returns the column lists of each table's indexes,
+// including the primary key. Functional index parts have an empty name.
+func existingIndexes(db *sql.DB, schema string, tables []string) (map[string][][]string, error) {
+	out := make(map[string][][]string, len(tables))
+	for _, table := range tables {
+		rows, err := db.Query(`
+			SELECT INDEX_NAME, COLUMN_NAME
+			FROM information_schema.STATISTICS
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+			ORDER BY INDEX_NAME, SEQ_IN_INDEX`,
+			schema, table,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("querying indexes for %s: %w", table, err)
+		}
+		var order []string
+		cols := make(map[string][]string)
+		for rows.Next() {
+			var index string
+			var col sql.NullString
+			if err := rows.Scan(&index, &col); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if _, ok := cols[index]; !ok {
+				order = append(order, index)
+			}
+			cols[index] = append(cols[index], col.String)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		for _, index := range order {
+			out[table] = append(out[table], cols[index])
+		}
+	}
+	return out, nil
+}
+
+// indexReport is the outcome of a suggest-indexes run.
+type indexReport struct {
+	Baseline []TestResult
+	Trials   []indexTrial
+	Skipped  int // candidates beyond the maximum, not tried
+}
+
+// indexTrial is the measured effect of one candidate index.
+type indexTrial struct {
+	Candidate indexCandidate
+	Before    []TestResult // the affected tests, rerun before the index is created
+	After     []TestResult // the same tests with the index in place
+	Error     error        // creating the index failed
+}
+
+// testImpact compares one test without and with a candidate index.
+type testImpact struct {
+	Name          string
+	Before, After time.Duration // average latency
+	UsedIndex     bool          // the index is the key of the test's EXPLAIN plan
+	Error         error
+}
+
+// impacts pairs the before and after results of each affected test.
+func (t indexTrial) impacts() []testImpact {
+	name := t.Candidate.name()
+	out := make([]testImpact, 0, len(t.Before))
+	for i, b := range t.Before {
+		im := testImpact{Name: b.Name, Error: b.Error}
+		if i >= len(t.After) {
+			out = append(out, im)
+			continue
+		}
+		a := t.After[i]
+		if im.Error == nil {
+			im.Error = a.Error
+		}
+		if im.Error == nil {
+			im.Before, im.After = b.Latency.Mean(), a.Latency.Mean()
+			im.UsedIndex = planUsesKey(a.Plan, name)
+		}
+		out = append(out, im)
+	}
+	return out
+}
+
+// totals sums the average latencies of the tests that succeeded both times.
+func (t indexTrial) totals() (before, after time.Duration) {
+	for _, im := range t.impacts() {
+		if im.Error == nil {
+			before += im.Before
+			after += im.After
+		}
+	}
+	return before, after
+}
+
+// change returns the relative change in total average latency, in percent.
+func (t indexTrial) change() float64 {
+	before, after := t.totals()
+	if before == 0 {
+		return 0
+	}
+	return float64(after-before) / float64(before) * 100
+}
+
+// used counts the affected tests whose plan picked the index.
+func (t indexTrial) used() int {
+	n := 0
+	for _, im := range t.impacts() {
+		if im.UsedIndex {
+			n++
+		}
+	}
+	return n
+}
+
+// verdict classifies the trial. An index is only recommended when the
+// server used it and it lowered latency by at least minImprovement percent.
+func (t indexTrial) verdict(minImprovement float64) string {
+	switch {
+	case t.Error != nil:
+		return "failed"
+	case t.used() == 0:
+		return "not used"
+	case t.change() <= -minImprovement:
+		return "recommended"
+	case t.change() >= minImprovement:
+		return "slower"
+	default:
+		return "no gain"
+	}
+}
+
+// planUsesKey reports whether an EXPLAIN plan accesses a table through the
+// named index.
+func planUsesKey(plan *queryPlan, key string) bool {
+	if plan == nil {
+		return false
+	}
+	col := slices.Index(plan.Columns, "key")
+	if col < 0 {
+		return false
+	}
+	for _, row := range plan.Rows {
+		if col < len(row) && strings.EqualFold(row[col], key) {
+			return true
+		}
+	}
+	return false
+}
+
+// runIndexPipeline creates and seeds the schema's tables, runs the tests as
+// a baseline, then tries up to maxCandidates proposed indexes one at a time.
+// Tables are dropped afterwards unless opts.Keep is set. onTrial, if set, is
+// called after each trial with its position and the number of trials.
+func runIndexPipeline(db *sql.DB, schema string, cfg *config.Config, opts pipelineOptions, maxCandidates int, onTrial func(i, n int, t indexTrial)) (*indexReport, []string, error) {
+	statements, tableNames, err := parseDDLFile(opts.SchemaFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing schema file: %w", err)
+	}
+	if len(tableNames) == 0 {
+		return nil, nil, fmt.Errorf("no CREATE TABLE statements found in %s", opts.SchemaFile)
+	}
+
+	if err := createTables(db, tableNames, statements); err != nil {
+		return nil, nil, fmt.Errorf("creating tables: %w", err)
+	}
+	fmt.Printf("Created %d tables\n", len(tableNames))
+
+	defer func() {
+		if opts.Keep {
+			fmt.Printf("Kept %d tables: %s\n", len(tableNames), strings.Join(tableNames, ", "))
+			return
+		}
+		dropTables(db, tableNames)
+		fmt.Println("Cleaned up: dropped test tables")
+	}()
+
+	if err := seedCreatedTables(db, schema, cfg, opts, tableNames); err != nil {
+		return nil, tableNames, err
+	}
+
+	tables := make(map[string]*introspect.Table, len(tableNames))
+	for _, name := range tableNames {
+		t, err := introspect.IntrospectTable(db, schema, name)
+		if err != nil {
+			return nil, tableNames, fmt.Errorf("introspecting %s: %w", name, err)
+		}
+		tables[name] = t
+	}
+	existing, err := existingIndexes(db, schema, tableNames)
+	if err != nil {
+		return nil, tableNames, err
+	}
+	candidates := proposeIndexes(cfg.Tests, tables, existing)
+	report := &indexReport{}
+	if len(candidates) > maxCandidates {
+		report.Skipped = len(candidates) - maxCandidates
+		candidates = candidates[:maxCandidates]
+	}
+
+	ctx := opts.ctx()
+	tests := rolledBackTests(cfg.Tests)
+	fmt.Printf("\nRunning %d test queries for the baseline...\n", len(tests))
+	report.Baseline = runTests(ctx, db, tests, true, opts.OnTest)
+	if err := ctx.Err(); err != nil {
+		return report, tableNames, err
+	}
+
+	if len(candidates) > 0 {
+		fmt.Printf("\nTrying %d candidate indexes...\n", len(candidates))
+	}
+	for i, c := range candidates {
+		prefix := fmt.Sprintf("[%d/%d] %s", i+1, len(candidates), c)
+		fmt.Printf("%s ...", prefix)
+		trial, err := tryIndex(ctx, db, c, tests)
+		if err != nil {
+			fmt.Println()
+			return report, tableNames, err
+		}
+		if trial.Error != nil {
+			fmt.Printf("\r%s ... ERROR: %v\n", prefix, trial.Error)
+		} else {
+			fmt.Printf("\r%s ... %+.1f%%\n", prefix, trial.change())
+		}
+		report.Trials = append(report.Trials, trial)
+		if onTrial != nil {
+			onTrial(i, len(candidates), trial)
+		}
+		if err := ctx.Err(); err != nil {
+			return report, tableNames, err
+		}
+	}
+	return report, tableNames, nil
+}
+
+// rolledBackTests returns tests with every write and transaction test rolled
+// back, so no run changes the data the runs after it measure.
+func rolledBackTests(tests []config.TestCase) []config.TestCase {
+	out := slices.Clone(tests)
+	for i := range out {
+		if out[i].EffectiveKind() != config.TestKindRead {
+			out[i].Rollback = true
+		}
+	}
+	return out
+}
+
+// tryIndex reruns the tests that touch the candidate's table without the
+// index, creates it, reruns them with it and drops it again. Measuring both
+// sides back to back keeps drift over a long run, such as a warming buffer
+// pool, out of the comparison. Failing to create the index is recorded in
+// the trial; failing to drop it is returned, since later trials would
+// measure it too.
+func tryIndex(ctx context.Context, db *sql.DB, c indexCandidate, tests []config.TestCase) (indexTrial, error) {
+	trial := indexTrial{Candidate: c}
+	var affected []config.TestCase
+	for _, tc := range tests {
+		if slices.Contains(c.Tests, tc.Name) {
+			affected = append(affected, tc)
+		}
+	}
+
+	trial.Before = runTests(ctx, db, affected, true, nil)
+	if _, err := db.ExecContext(ctx, c.createStatement()); err != nil {
+		trial.Error = err
+		return trial, nil
+	}
+	trial.After = runTests(ctx, db, affected, true, nil)
+	drop := fmt.Sprintf("DROP INDEX `%s` ON `%s`", c.name(), c.Table)
+	if _, err := db.ExecContext(context.WithoutCancel(ctx), drop); err != nil {
+		return trial, fmt.Errorf("dropping candidate index %s: %w", c.name(), err)
+	}
+	return trial, nil
+}
+
+// rankedTrials returns the trials by measured change, largest improvement
+// first and failed trials last.
+func rankedTrials(trials []indexTrial) []indexTrial {
+	out := slices.Clone(trials)
+	slices.SortStableFunc(out, func(a, b indexTrial) int {
+		if (a.Error != nil) != (b.Error != nil) {
+			if a.Error != nil {
+				return 1
+			}
+			return -1
+		}
+		switch ca, cb := a.change(), b.change(); {
+		case ca < cb:
+			return -1
+		case ca > cb:
+			return 1
+		}
+		return 0
+	})
+	return out
+}
+
+// printIndexReport writes the ranked candidates, each trial's per-test
+// impact and the recommended CREATE INDEX statements.
+func printIndexReport(out io.Writer, r *indexReport, minImprovement float64) {
+	fmt.Fprintln(out, "\n=== Index Suggestions ===")
+	if len(r.Trials) == 0 {
+		fmt.Fprintln(out, "No candidate indexes: the tests' WHERE, JOIN, GROUP BY and ORDER BY columns are already covered by existing indexes.")
+		return
+	}
+
+	ranked := rankedTrials(r.Trials)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := []string{"Index", "Tests", "Avg before", "Avg after", "Change", "Used by", "Verdict"}
+	fmt.Fprint(w, "  "+tabRow(header...))
+	fmt.Fprint(w, "  "+tabRow(dashes(header)...))
+	for _, t := range ranked {
+		if t.Error != nil {
+			fmt.Fprintf(w, "  %s\tERROR: %v\t\t\t\t\t\n", t.Candidate, t.Error)
+			continue
+		}
+		before, after := t.totals()
+		fmt.Fprint(w, "  "+tabRow(
+			t.Candidate.String(),
+			fmt.Sprint(len(t.Before)),
+			formatDuration(before),
+			formatDuration(after),
+			fmt.Sprintf("%+.1f%%", t.change()),
+			fmt.Sprintf("%d/%d", t.used(), len(t.Before)),
+			t.verdict(minImprovement),
+		))
+	}
+	w.Flush()
+	if r.Skipped > 0 {
+		fmt.Fprintf(out, "  (%d more candidates not tried; raise --max-candidates to try them)\n", r.Skipped)
+	}
+
+	for _, t := range ranked {
+		if t.Error != nil {
+			continue
+		}
+		fmt.Fprintf(out, "\n%s, suggested by: %s\n", t.Candidate, strings.Join(t.Candidate.SuggestedBy, ", "))
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		header := []string{"Test", "Avg before", "Avg after", "Change", "Index used"}
+		fmt.Fprint(w, "  "+tabRow(header...))
+		fmt.Fprint(w, "  "+tabRow(dashes(header)...))
+		for _, im := range t.impacts() {
+			if im.Error != nil {
+				fmt.Fprintf(w, "  %s\tERROR: %v\t\t\t\n", im.Name, im.Error)
+				continue
+			}
+			change := "-"
+			if im.Before > 0 {
+				change = fmt.Sprintf("%+.1f%%", float64(im.After-im.Before)/float64(im.Before)*100)
+			}
+			used := "no"
+			if im.UsedIndex {
+				used = "yes"
+			}
+			fmt.Fprint(w, "  "+tabRow(im.Name, formatDuration(im.Before), formatDuration(im.After), change, used))
+		}
+		w.Flush()
+	}
+
+	var recommended []string
+	for _, t := range ranked {
+		if t.verdict(minImprovement) == "recommended" {
+			recommended = append(recommended, t.Candidate.createStatement()+";")
+		}
+	}
+	if len(recommended) == 0 {
+		fmt.Fprintf(out, "\nNo candidate lowered the affected tests' average latency by %.0f%% or more.\n", minImprovement)
+		return
+	}
+	fmt.Fprintln(out, "\nRecommended (each measured on its own; retest them together before adopting):")
+	for _, stmt := range recommended {
+		fmt.Fprintf(out, "  %s\n", stmt)
+	}
+}
+
+// SuggestIndexes runs the suggest-indexes pipeline for one config, reporting
+// each seeded table, baseline test and tried candidate.
+func (e *mcpEngine) SuggestIndexes(ctx context.Context, req mcptools.IndexRequest, progress mcptools.ProgressFunc) (*mcptools.IndexSuggestions, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cfg, err := config.LoadOrDefault(req.ConfigPath, req.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	if cfg.Options.Schema == "" {
+		return nil, fmt.Errorf("config %s does not specify a schema file (options.schema)", req.ConfigPath)
+	}
+	if len(cfg.Tests) == 0 {
+		return nil, fmt.Errorf("config %s has no tests to derive index candidates from", req.ConfigPath)
+	}
+	if err := useConfigPercentiles(cfg.Options.Percentiles); err != nil {
+		return nil, err
+	}
+
+	entry := compareEntry{cfg: cfg, path: req.ConfigPath}
+	opts := compareOverrides{Rows: req.Rows, BatchSize: req.BatchSize, Workers: req.Workers}.pipelineOptions(entry)
+	opts.Keep = false
+	opts.Context = ctx
+
+	dsn := req.DSN
+	if dsn == "" {
+		dsn = cfg.Options.DSN
+	}
+	db, schema, closeDB, err := connectEngine(ctx, dsn, opts.LoadData, opts.Workers)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+
+	tables, tests := pipelineSteps(cfg, opts)
+	steps := &stepCounter{progress: progress, total: tables + tests}
+	steps.track(&opts, "", tables, tests)
+	start := time.Now()
+	report, tableNames, err := runIndexPipeline(db, schema, cfg, opts, req.MaxCandidates, func(i, n int, t indexTrial) {
+		msg := fmt.Sprintf("%s: %+.1f%%", t.Candidate, t.change())
+		if t.Error != nil {
+			msg = fmt.Sprintf("%s: error: %v", t.Candidate, t.Error)
+		}
+		progress(steps.end+i+1, steps.total+n, msg)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &mcptools.IndexSuggestions{
+		Baseline: testRun(ConfigResult{
+			ConfigPath: req.ConfigPath,
+			SchemaFile: opts.SchemaFile,
+			Rows:       opts.Rows,
+			TableCount: len(tableNames),
+			Results:    report.Baseline,
+			Duration:   time.Since(start),
+		}),
+		Candidates: []mcptools.IndexCandidate{},
+		Skipped:    report.Skipped,
+	}
+	for _, t := range rankedTrials(report.Trials) {
+		out.Candidates = append(out.Candidates, indexCandidateResult(t, req.MinImprovement))
+	}
+	var sb strings.Builder
+	printIndexReport(&sb, report, req.MinImprovement)
+	out.Report = strings.TrimPrefix(sb.String(), "\n")
+	return out, nil
+}
+
+// indexCandidateResult converts a trial to the tool's structured output.
+func indexCandidateResult(t indexTrial, minImprovement float64) mcptools.IndexCandidate {
+	c := mcptools.IndexCandidate{
+		Table:       t.Candidate.Table,
+		Columns:     t.Candidate.Columns,
+		Statement:   t.Candidate.createStatement(),
+		SuggestedBy: t.Candidate.SuggestedBy,
+		Verdict:     t.verdict(minImprovement),
+		Tests:       []mcptools.IndexImpact{},
+	}
+	if t.Error != nil {
+		c.Error = t.Error.Error()
+		return c
+	}
+	before, after := t.totals()
+	c.BeforeMS, c.AfterMS, c.ChangePct = durationMS(before), durationMS(after), t.change()
+	for _, im := range t.impacts() {
+		impact := mcptools.IndexImpact{Name: im.Name, BeforeMS: durationMS(im.Before), AfterMS: durationMS(im.After), UsedIndex: im.UsedIndex}
+		if im.Error != nil {
+			impact.Error = im.Error.Error()
+		}
+		c.Tests = append(c.Tests, impact)
+	}
+	return c
+}
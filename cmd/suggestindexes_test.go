@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+func TestProposeIndexes(t *testing.T) {
+	tables := map[string]*introspect.Table{
+		"users": {Name: "users", Columns: []introspect.Column{
+			{Name: "id", DataType: "int"}, {Name: "email", DataType: "varchar"}, {Name: "bio", DataType: "text"},
+		}},
+		"orders": {Name: "orders", Columns: []introspect.Column{
+			{Name: "id", DataType: "int"}, {Name: "user_id", DataType: "int"}, {Name: "status", DataType: "varchar"},
+			{Name: "total", DataType: "decimal"}, {Name: "created_at", DataType: "datetime"},
+		}},
+	}
+	existing := map[string][][]string{
+		"users":  {{"id"}, {"email"}},
+		"orders": {{"id"}},
+	}
+	tests := []config.TestCase{
+		{Name: "recent orders", Query: "SELECT * FROM orders WHERE user_id = {{(SampleRow \"users\" \"id\").id}} AND status = 'open' ORDER BY created_at DESC LIMIT 10"},
+		{Name: "big orders", Query: "SELECT * FROM orders o JOIN users u ON u.id = o.user_id WHERE o.status = ? AND total > 100"},
+		{Name: "by email", Query: "SELECT * FROM users WHERE email = ? AND bio = ?"},
+		{Name: "close", Kind: config.TestKindTransaction, Statements: []string{"UPDATE orders SET status = 'closed' WHERE user_id = ? AND status = 'open'"}},
+	}
+
+	got := proposeIndexes(tests, tables, existing)
+	want := []struct{ index, suggestedBy, tests string }{
+		{"orders (user_id, status, created_at)", "recent orders", "recent orders, big orders, close"},
+		{"orders (user_id, status, total)", "big orders", "recent orders, big orders, close"},
+		{"orders (user_id, status)", "close", "recent orders, big orders, close"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d candidates, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		c := got[i]
+		if c.String() != w.index || strings.Join(c.SuggestedBy, ", ") != w.suggestedBy || strings.Join(c.Tests, ", ") != w.tests {
+			t.Errorf("candidate %d = %s by %v for %v, want %s by %s for %s", i, c, c.SuggestedBy, c.Tests, w.index, w.suggestedBy, w.tests)
+		}
+	}
+	if stmt := got[0].createStatement(); stmt != "CREATE INDEX `idx_orders_user_id_status_created_at` ON `orders` (`user_id`, `status`, `created_at`)" {
+		t.Errorf("createStatement() = %s", stmt)
+	}
+}
+
+func TestIndexCandidateName_Long(t *testing.T) {
+	c := indexCandidate{Table: strings.Repeat("t", 40), Columns: []string{strings.Repeat("a", 20), strings.Repeat("b", 20)}}
+	name := c.name()
+	if len(name) != 64 || name == (indexCandidate{Table: c.Table, Columns: []string{c.Columns[0], strings.Repeat("c", 20)}}).name() {
+		t.Errorf("name() = %q (%d chars), want 64 distinct chars", name, len(name))
+	}
+}
+
+func TestIndexTrialVerdict(t *testing.T) {
+	c := indexCandidate{Table: "orders", Columns: []string{"user_id"}}
+	used := &queryPlan{Columns: []string{"table", "key"}, Rows: [][]string{{"orders", c.name()}}}
+	unused := &queryPlan{Columns: []string{"table", "key"}, Rows: [][]string{{"orders", "NULL"}}}
+	before := []TestResult{{Name: "a", Latency: msLatency(10)}, {Name: "b", Latency: msLatency(10)}}
+
+	tests := []struct {
+		name  string
+		trial indexTrial
+		want  string
+	}{
+		{"faster", indexTrial{Candidate: c, Before: before, After: []TestResult{
+			{Name: "a", Latency: msLatency(1), Plan: used}, {Name: "b", Latency: msLatency(10), Plan: unused}}}, "recommended"},
+		{"marginal", indexTrial{Candidate: c, Before: before, After: []TestResult{
+			{Name: "a", Latency: msLatency(9), Plan: used}, {Name: "b", Latency: msLatency(10), Plan: unused}}}, "no gain"},
+		{"write cost", indexTrial{Candidate: c, Before: before, After: []TestResult{
+			{Name: "a", Latency: msLatency(10), Plan: used}, {Name: "b", Latency: msLatency(14)}}}, "slower"},
+		{"unused", indexTrial{Candidate: c, Before: before, After: []TestResult{
+			{Name: "a", Latency: msLatency(1), Plan: unused}, {Name: "b", Latency: msLatency(1)}}}, "not used"},
+		{"failed", indexTrial{Candidate: c, Before: before, Error: errors.New("duplicate key name")}, "failed"},
+	}
+	for _, tt := range tests {
+		if got := tt.trial.verdict(10); got != tt.want {
+			t.Errorf("%s: verdict = %q (change %+.1f%%, used %d), want %q", tt.name, got, tt.trial.change(), tt.trial.used(), tt.want)
+		}
+	}
+
+	// A test failing on either side is left out of the totals.
+	trial := indexTrial{Candidate: c, Before: before, After: []TestResult{
+		{Name: "a", Latency: msLatency(5), Plan: used}, {Name: "b", Error: errors.New("lock wait timeout")}}}
+	if got := trial.change(); got != -50 {
+		t.Errorf("change = %v, want -50", got)
+	}
+
+	ranked := rankedTrials([]indexTrial{tests[4].trial, tests[2].trial, tests[0].trial})
+	if ranked[0].verdict(10) != "recommended" || ranked[2].Error == nil {
+		t.Errorf("unexpected ranking: %+v", ranked)
+	}
+}
+
+func TestRolledBackTests(t *testing.T) {
+	tests := []config.TestCase{
+		{Name: "read", Query: "SELECT 1"},
+		{Name: "write", Kind: config.TestKindWrite, Query: "DELETE FROM t"},
+		{Name: "tx", Kind: config.TestKindTransaction, Statements: []string{"UPDATE t SET a = 1"}},
+	}
+	got := rolledBackTests(tests)
+	for i, want := range []bool{false, true, true} {
+		if got[i].Rollback != want {
+			t.Errorf("%s: Rollback = %v, want %v", got[i].Name, got[i].Rollback, want)
+		}
+		if err := got[i].Validate(); err != nil {
+			t.Errorf("%s: %v", got[i].Name, err)
+		}
+	}
+	if tests[1].Rollback {
+		t.Error("rolledBackTests changed its argument")
+	}
+}
@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"text/template"
 	"text/tabwriter"
@@ -20,6 +23,7 @@ import (
 	"github.com/tomfevang/go-test-my-db/internal/config"
 	"github.com/tomfevang/go-test-my-db/internal/ephemeral"
 	"github.com/tomfevang/go-test-my-db/internal/generator"
+	"github.com/tomfevang/go-test-my-db/internal/stats"
 )
 
 const (
@@ -33,6 +37,7 @@ var (
 	testDSN         string
 	testSchemaFile  string
 	testConfigPath  string
+	testProfile     string
 	testRows        int
 	testBatchSize   int
 	testWorkers     int
@@ -45,6 +50,12 @@ var (
 	testDeferIndexes bool
 	testFKSampleSize int
 	testEphemeral    bool
+	testReuseData    bool
+	testKeep         bool
+	testSeed         int64
+	testReportPath   string
+	testScale        []int
+	testPercentiles  []float64
 )
 
 var testCmd = &cobra.Command{
@@ -60,6 +71,7 @@ func init() {
 	testCmd.Flags().StringVar(&testDSN, "dsn", "", "MySQL DSN (required), e.g. user:pass@tcp(localhost:3306)/mydb")
 	testCmd.Flags().StringVar(&testSchemaFile, "schema", "", "Path to SQL DDL file (required)")
 	testCmd.Flags().StringVar(&testConfigPath, "config", "", "Path to config YAML file (default: auto-detect go-test-my-db.yaml)")
+	testCmd.Flags().StringVar(&testProfile, "profile", "", "Config profile to apply over the file's settings (env SEED_PROFILE)")
 	testCmd.Flags().IntVar(&testRows, "rows", 1000, "Number of rows per table")
 	testCmd.Flags().IntVar(&testBatchSize, "batch-size", 1000, "Rows per INSERT statement")
 	testCmd.Flags().IntVar(&testWorkers, "workers", 4, "Concurrent insert workers")
@@ -72,22 +84,57 @@ func init() {
 	testCmd.Flags().BoolVar(&testDeferIndexes, "defer-indexes", false, "Drop secondary indexes before seeding and rebuild after (faster for large tables)")
 	testCmd.Flags().IntVar(&testFKSampleSize, "fk-sample-size", 500_000, "Max FK parent values to cache per column (0 = unlimited)")
 	testCmd.Flags().BoolVar(&testEphemeral, "ephemeral", false, "Start a temporary MySQL container via Docker or Podman (no DSN needed)")
+	testCmd.Flags().BoolVar(&testKeep, "keep", false, "Keep the tables (and --ephemeral container) after the run; remove them later with the cleanup command")
+	testCmd.Flags().Int64Var(&testSeed, "seed", 0, "Seed for reproducible data (0 = random; overrides options.seed)")
+	testCmd.Flags().Float64SliceVar(&testPercentiles, "percentiles", defaultPercentiles, "Latency percentiles to report (overrides options.percentiles)")
+	testCmd.Flags().StringVar(&testReportPath, "report", "", "Write a self-contained HTML report with charts and EXPLAIN plans to this file")
+	testCmd.Flags().IntSliceVar(&testScale, "scale", nil, "Run the whole test at each of these row counts (e.g. 1000,10000,100000) and report how latency scales")
+	testCmd.Flags().BoolVar(&testReuseData, "reuse-data", false, "Restore a cached snapshot of an identical seeded dataset instead of re-seeding (saved on first run)")
 
 	rootCmd.AddCommand(testCmd)
 }
 
 type TestResult struct {
-	Name     string
-	Query    string
-	Repeat   int
-	Timings  []time.Duration
-	RowCount int
-	Error    error
+	Name         string
+	Kind         string // config.TestKind*; empty means read
+	Query        string
+	Repeat       int
+	Latency      *stats.Recorder // timed runs; nil until the test starts its timed loop
+	RowCount     int             // rows returned by the first run (read tests)
+	RowsAffected int64           // total rows affected across runs (write and transaction tests)
+	Metrics      *ServerMetrics  // per-run server counters; nil if they could not be read
+	Verified     bool            // VerifyRows and Checksum are set (compare verify: true)
+	VerifyRows   int64           // rows returned across the verification runs
+	Checksum     string          // order-independent checksum of those rows
+	Plan         *queryPlan      // EXPLAIN of the last run; only collected for HTML reports
+	Error        error
+}
+
+// rowsLabel formats the Rows report column: rows returned for reads, average
+// rows affected per run for writes and transactions.
+func (r TestResult) rowsLabel() string {
+	if r.Kind == "" || r.Kind == config.TestKindRead {
+		return strconv.Itoa(r.RowCount)
+	}
+	if r.Latency.Count() == 0 {
+		return "0 affected"
+	}
+	return strconv.FormatFloat(float64(r.RowsAffected)/float64(r.Latency.Count()), 'f', -1, 64) + " affected"
+}
+
+// resultHeader returns the header of the results table.
+func resultHeader() []string {
+	return append(append([]string{"Test"}, latencyHeader()...), "Rows", "Runs")
+}
+
+// resultCells returns a successful result's row of the results table.
+func (r TestResult) resultCells() []string {
+	return append(append([]string{r.Name}, latencyCells(r.Latency)...), r.rowsLabel(), strconv.Itoa(r.Latency.Count()))
 }
 
 func runTest(cmd *cobra.Command, args []string) error {
 	// 1. Load config.
-	cfg, err := config.LoadOrDefault(testConfigPath)
+	cfg, err := loadConfig(cmd, testConfigPath, testProfile)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
@@ -108,14 +155,31 @@ func runTest(cmd *cobra.Command, args []string) error {
 		testDeferIndexes = true
 	}
 	testFKSampleSize = resolveInt(cmd, "fk-sample-size", testFKSampleSize, cfg.Options.FKSampleSize, 500_000)
+	if cmd.Flags().Changed("seed") {
+		cfg.Options.Seed = testSeed
+	}
+	if reportPercentiles, err = resolvePercentiles(cmd, testPercentiles, cfg.Options.Percentiles); err != nil {
+		return err
+	}
 
-	// Start ephemeral MySQL if requested and no DSN was provided.
+	if testKeep && len(testScale) > 0 {
+		return fmt.Errorf("--keep cannot be combined with --scale")
+	}
+
+	// Start ephemeral MySQL if requested and no DSN was provided. It is
+	// stopped on the way out unless keepRun records it.
+	var edb *ephemeral.DB
+	kept := false
 	if testEphemeral && testDSN == "" {
-		edb, err := ephemeral.Start(cmd.Context())
+		edb, err = ephemeral.Start(cmd.Context())
 		if err != nil {
 			return err
 		}
-		defer edb.Stop()
+		defer func() {
+			if !kept {
+				edb.Stop()
+			}
+		}()
 		testDSN = edb.DSN
 	}
 
@@ -155,20 +219,77 @@ func runTest(cmd *cobra.Command, args []string) error {
 		seedTables = cfg.Options.SeedTables
 	}
 
-	// Run the pipeline: create → seed → test → drop.
-	results, _, err := runTestPipeline(db, schema, cfg, testSchemaFile, testRows, testBatchSize, testWorkers, testMinChildren, testMaxChildren, testMaxRows, testLoadData, testDeferIndexes, testFKSampleSize, seedTables)
-	if err != nil {
-		return err
+	// With --scale the whole pipeline runs once per row count.
+	rowCounts := []int{testRows}
+	if len(testScale) > 0 {
+		rowCounts = testScale
 	}
 
-	if len(results) > 0 {
-		printReport(results)
+	var runs []ConfigResult
+	for i, rows := range rowCounts {
+		if len(rowCounts) > 1 {
+			fmt.Printf("\n=== [%d/%d] %d rows ===\n", i+1, len(rowCounts), rows)
+		}
+
+		// Run the pipeline: create → seed → test → drop.
+		opts := pipelineOptions{
+			SchemaFile:   testSchemaFile,
+			Rows:         rows,
+			BatchSize:    testBatchSize,
+			Workers:      testWorkers,
+			MinChildren:  testMinChildren,
+			MaxChildren:  testMaxChildren,
+			MaxRows:      testMaxRows,
+			LoadData:     testLoadData,
+			DeferIndexes: testDeferIndexes,
+			FKSampleSize: testFKSampleSize,
+			SeedTables:   seedTables,
+			ReuseData:    testReuseData,
+			Keep:         testKeep,
+			Explain:      testReportPath != "",
+		}
+		start := time.Now()
+		results, tables, err := runTestPipeline(db, schema, cfg, opts)
+		if testKeep && len(tables) > 0 {
+			kept = keepRun(testDSN, tables, edb)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(results) > 0 {
+			printReport(results)
+		}
+		runs = append(runs, ConfigResult{
+			ConfigPath: testConfigPath,
+			Label:      fmt.Sprintf("%d rows", rows),
+			SchemaFile: testSchemaFile,
+			Rows:       rows,
+			TableCount: len(tables),
+			Results:    results,
+			Duration:   time.Since(start),
+			Config:     effectiveConfig(cfg, opts),
+		})
+	}
+
+	report := ""
+	if len(runs) > 1 {
+		report = buildScalingReport(runs)
+		fmt.Print("\n=== Scaling (avg per run) ===\n" + report)
+	} else if len(runs[0].Results) > 0 {
+		report = buildTestReport(runs[0].Results)
+	}
+
+	if testReportPath != "" {
+		if err := writeHTMLReport(testReportPath, "Performance test: "+filepath.Base(testSchemaFile), runs); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		fmt.Printf("\nWrote HTML report to %s\n", testReportPath)
 	}
 
-	if testAI && len(results) > 0 {
+	if testAI && report != "" {
 		fmt.Println()
-		report := buildTestReport(results)
-		if err := analyzeTestWithAI(report, testSchemaFile, testRows); err != nil {
+		if err := analyzeTestWithAI(report, testSchemaFile, rowCounts[len(rowCounts)-1]); err != nil {
 			fmt.Fprintf(os.Stderr, "AI analysis failed: %v\n", err)
 		}
 	}
@@ -183,10 +304,16 @@ func parseDDLFile(path string) (statements []string, tableNames []string, err er
 	if err != nil {
 		return nil, nil, err
 	}
+	statements, tableNames = parseDDL(string(data))
+	return statements, tableNames, nil
+}
 
+// parseDDL splits DDL on semicolons and extracts table names from CREATE
+// TABLE statements.
+func parseDDL(ddl string) (statements []string, tableNames []string) {
 	tableNameRe := regexp.MustCompile(`(?i)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + "`?" + `(\w+)` + "`?")
 
-	raw := strings.Split(string(data), ";")
+	raw := strings.Split(ddl, ";")
 	for _, s := range raw {
 		s = strings.TrimSpace(s)
 		if s == "" {
@@ -198,7 +325,7 @@ func parseDDLFile(path string) (statements []string, tableNames []string, err er
 			tableNames = append(tableNames, m[1])
 		}
 	}
-	return statements, tableNames, nil
+	return statements, tableNames
 }
 
 // createTables drops any existing tables with the given names, then executes
@@ -242,101 +369,373 @@ func dropTables(db *sql.DB, tableNames []string) {
 // runTests executes each test query N times, collecting timings.
 // Queries containing {{...}} are treated as Go templates and rendered
 // before each execution, giving each run fresh random parameter values.
-func runTests(db *sql.DB, tests []config.TestCase) []TestResult {
+// Read tests drain and count result rows; write and transaction tests count
+// affected rows, optionally rolling back after each iteration.
+// With explain set, the last statement of each read and write test is
+// explained for the HTML report. onTest, if set, is called after each test.
+// Cancelling ctx stops the run after the current iteration.
+func runTests(ctx context.Context, db *sql.DB, tests []config.TestCase, explain bool, onTest func(TestResult)) []TestResult {
 	// Set up template rendering once for all tests.
 	fm := generator.FuncMap(gofakeit.New(0))
-	fm["SampleRow"] = makeSampleRowFunc(db)
-	var buf bytes.Buffer
+	fm["SampleRow"] = makeSampleRowFunc(db, nil)
 
 	results := make([]TestResult, 0, len(tests))
 	for ti, tc := range tests {
-		repeat := tc.Repeat
-		if repeat <= 0 {
-			repeat = 1
+		if ctx.Err() != nil {
+			break
+		}
+		prefix := fmt.Sprintf("[%d/%d] %s", ti+1, len(tests), tc.Name)
+		result := runTestCase(ctx, db, tc, fm, prefix, explain)
+		if result.Error != nil {
+			fmt.Printf("\r%s ... ERROR: %v\n", prefix, result.Error)
+		} else {
+			fmt.Printf("\r%s ... done (avg %s)\n", prefix, formatDuration(result.Latency.Mean()))
 		}
+		results = append(results, result)
+		if onTest != nil {
+			onTest(result)
+		}
+	}
+	return results
+}
 
-		// Pre-parse template if the query contains template syntax.
-		var tmpl *template.Template
-		if strings.Contains(tc.Query, "{{") {
-			var err error
-			tmpl, err = template.New(tc.Name).Funcs(fm).Parse(tc.Query)
-			if err != nil {
-				results = append(results, TestResult{
-					Name:  tc.Name,
-					Query: tc.Query,
-					Error: fmt.Errorf("invalid query template: %w", err),
-				})
-				fmt.Printf("[%d/%d] %s ... ERROR: invalid template\n", ti+1, len(tests), tc.Name)
-				continue
-			}
-			// Warm up: execute the template once to populate any SampleRow
-			// caches before the timed loop so that lazy DB fetches don't
-			// skew the first iteration.
-			buf.Reset()
-			if err := tmpl.Execute(&buf, nil); err != nil {
-				results = append(results, TestResult{
-					Name:  tc.Name,
-					Query: tc.Query,
-					Error: fmt.Errorf("template warmup failed: %w", err),
-				})
-				fmt.Printf("[%d/%d] %s ... ERROR: template warmup failed\n", ti+1, len(tests), tc.Name)
-				continue
-			}
+// runTestCase runs a single test on its own pinned connection so that session
+// state from setup statements (SET SESSION ..., temporary tables) applies to
+// every iteration. Teardown always runs, even when the test fails.
+func runTestCase(ctx context.Context, db *sql.DB, tc config.TestCase, fm template.FuncMap, prefix string, explain bool) TestResult {
+	repeat := tc.Repeat
+	if repeat <= 0 {
+		repeat = 1
+	}
+	kind := tc.EffectiveKind()
+
+	sources := []string{tc.Query}
+	if kind == config.TestKindTransaction {
+		sources = tc.Statements
+	}
+	result := TestResult{
+		Name:  tc.Name,
+		Kind:  kind,
+		Query: strings.Join(sources, ";\n"),
+	}
+	// Prepared tests keep the query text fixed and render only the parameters.
+	prepared := len(tc.Params) > 0
+	if prepared {
+		sources = config.ParamValues(tc.Params)
+	}
+
+	// Pre-parse templates for statements that contain template syntax.
+	var buf bytes.Buffer
+	templates := make([]*template.Template, len(sources))
+	for si, src := range sources {
+		if !strings.Contains(src, "{{") {
+			continue
+		}
+		tmpl, err := template.New(tc.Name).Funcs(fm).Parse(src)
+		if err != nil {
+			result.Error = fmt.Errorf("invalid query template: %w", err)
+			return result
 		}
+		// Warm up: execute the template once to populate any SampleRow
+		// caches before the timed loop so that lazy DB fetches don't
+		// skew the first iteration.
+		buf.Reset()
+		if err := tmpl.Execute(&buf, nil); err != nil {
+			result.Error = fmt.Errorf("template warmup failed: %w", err)
+			return result
+		}
+		templates[si] = tmpl
+	}
 
-		result := TestResult{
-			Name:    tc.Name,
-			Query:   tc.Query,
-			Repeat:  repeat,
-			Timings: make([]time.Duration, 0, repeat),
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		result.Error = fmt.Errorf("acquiring connection: %w", err)
+		return result
+	}
+	defer conn.Close()
+
+	// Teardown runs even when the run is cancelled.
+	teardownCtx := context.WithoutCancel(ctx)
+	if err := execAll(ctx, conn, tc.Setup); err != nil {
+		result.Error = fmt.Errorf("setup: %w", err)
+		// Undo whatever part of the setup succeeded.
+		if err := execAll(teardownCtx, conn, tc.Teardown); err != nil {
+			fmt.Fprintf(os.Stderr, "\nwarning: teardown for %q failed: %v\n", tc.Name, err)
+		}
+		return result
+	}
+	defer func() {
+		if err := execAll(teardownCtx, conn, tc.Teardown); err != nil {
+			fmt.Fprintf(os.Stderr, "\nwarning: teardown for %q failed: %v\n", tc.Name, err)
 		}
+	}()
 
-		fmt.Printf("\r[%d/%d] %s ... 0/%d runs", ti+1, len(tests), tc.Name, repeat)
+	// Prepare once, after setup, so session settings apply to the plan.
+	var stmt *sql.Stmt
+	if prepared {
+		stmt, err = conn.PrepareContext(ctx, tc.Query)
+		if err != nil {
+			result.Error = fmt.Errorf("preparing statement: %w", err)
+			return result
+		}
+		defer stmt.Close()
+	}
+
+	result.Repeat = repeat
+	result.Latency = &stats.Recorder{}
+
+	total := tc.Warmup + repeat
+	statements := make([]string, len(sources))
+	var baseline, overhead statusSnapshot
+	var rowsReturned int64
+	for i := 0; i < total; i++ {
+		warm := i < tc.Warmup
+		if i == tc.Warmup {
+			// Server counters cover the timed runs only.
+			if baseline, overhead, err = statusBaseline(ctx, conn); err != nil {
+				fmt.Fprintf(os.Stderr, "\nwarning: could not read session status for %q: %v\n", tc.Name, err)
+			}
+		}
+		if warm {
+			fmt.Printf("\r%s ... warm-up %d/%d", prefix, i+1, tc.Warmup)
+		} else if i == tc.Warmup {
+			fmt.Printf("\r%s ... 0/%d runs      ", prefix, repeat)
+		}
 
-		for i := 0; i < repeat; i++ {
-			// Render query template if present, otherwise use static query.
-			query := tc.Query
-			if tmpl != nil {
+		// Render query templates if present, otherwise use static queries.
+		for si, src := range sources {
+			statements[si] = src
+			if templates[si] != nil {
 				buf.Reset()
-				if err := tmpl.Execute(&buf, nil); err != nil {
+				if err := templates[si].Execute(&buf, nil); err != nil {
 					result.Error = fmt.Errorf("template exec failed on run %d: %w", i+1, err)
-					break
+					return result
 				}
-				query = buf.String()
+				statements[si] = buf.String()
 			}
+		}
 
-			start := time.Now()
-			rows, err := db.Query(query)
-			if err != nil {
-				result.Error = err
-				break
-			}
-			// Drain all rows to measure full execution.
-			n := 0
-			for rows.Next() {
-				n++
+		var (
+			elapsed time.Duration
+			n       int64
+		)
+		if prepared {
+			var args []any
+			if args, err = bindArgs(tc.Params, statements); err == nil {
+				elapsed, n, err = runPreparedIteration(ctx, conn, stmt, kind, tc.Rollback, args)
 			}
-			rows.Close()
-			result.Timings = append(result.Timings, time.Since(start))
-			if i == 0 {
-				result.RowCount = n
+		} else {
+			elapsed, n, err = runIteration(ctx, conn, kind, tc.Rollback, statements)
+		}
+		if err != nil {
+			result.Error = err
+			return result
+		}
+		if warm {
+			continue
+		}
+
+		run := i - tc.Warmup
+		result.Latency.Record(elapsed)
+		if kind == config.TestKindRead {
+			if run == 0 {
+				result.RowCount = int(n)
 			}
+			rowsReturned += n
+		} else {
+			result.RowsAffected += n
+		}
+
+		// Update progress every 10 runs or on the last run.
+		if (run+1)%10 == 0 || run+1 == repeat {
+			fmt.Printf("\r%s ... %d/%d runs", prefix, run+1, repeat)
+		}
+	}
 
-			// Update progress every 10 runs or on the last run.
-			if (i+1)%10 == 0 || i+1 == repeat {
-				fmt.Printf("\r[%d/%d] %s ... %d/%d runs", ti+1, len(tests), tc.Name, i+1, repeat)
+	if baseline != nil {
+		if final, err := takeStatusSnapshot(ctx, conn); err == nil {
+			sent := rowsReturned
+			if kind != config.TestKindRead {
+				sent = result.RowsAffected
 			}
+			result.Metrics = serverMetrics(final.sub(baseline), overhead, repeat, sent)
 		}
+	}
 
-		if result.Error != nil {
-			fmt.Printf("\r[%d/%d] %s ... ERROR: %v\n", ti+1, len(tests), tc.Name, result.Error)
+	if explain && kind != config.TestKindTransaction {
+		var plan *queryPlan
+		if prepared {
+			var args []any
+			if args, err = bindArgs(tc.Params, statements); err == nil {
+				plan, err = explainQuery(ctx, conn, tc.Query, args...)
+			}
 		} else {
-			fmt.Printf("\r[%d/%d] %s ... done (avg %s)\n", ti+1, len(tests), tc.Name, formatDuration(avg(result.Timings)))
+			plan, err = explainQuery(ctx, conn, statements[0])
 		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\nwarning: could not explain %q: %v\n", tc.Name, err)
+		}
+		result.Plan = plan
+	}
 
-		results = append(results, result)
+	// Verification runs after the timed loop so checksumming doesn't skew
+	// the timings or server counters.
+	if tc.Verify {
+		fmt.Printf("\r%s ... verifying         ", prefix)
+		n, sum, err := verifyTestCase(ctx, conn, db, tc, stmt)
+		if err != nil {
+			result.Error = fmt.Errorf("verify: %w", err)
+			return result
+		}
+		result.Verified, result.VerifyRows, result.Checksum = true, n, sum
 	}
-	return results
+	return result
+}
+
+// runPreparedIteration is runIteration for prepared read and write tests: the
+// statement is executed through the binary protocol with bound arguments.
+func runPreparedIteration(ctx context.Context, conn *sql.Conn, stmt *sql.Stmt, kind string, rollback bool, args []any) (time.Duration, int64, error) {
+	if kind == config.TestKindRead {
+		start := time.Now()
+		rows, err := stmt.QueryContext(ctx, args...)
+		if err != nil {
+			return 0, 0, err
+		}
+		// Drain all rows to measure full execution.
+		var n int64
+		for rows.Next() {
+			n++
+		}
+		err = rows.Err()
+		rows.Close()
+		return time.Since(start), n, err
+	}
+
+	if !rollback {
+		start := time.Now()
+		res, err := stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return 0, 0, err
+		}
+		elapsed := time.Since(start)
+		n, _ := res.RowsAffected()
+		return elapsed, n, nil
+	}
+
+	start := time.Now()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	res, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, args...)
+	if err != nil {
+		tx.Rollback()
+		return 0, 0, err
+	}
+	elapsed := time.Since(start)
+	n, _ := res.RowsAffected()
+	if err := tx.Rollback(); err != nil {
+		return 0, 0, fmt.Errorf("rolling back: %w", err)
+	}
+	return elapsed, n, nil
+}
+
+// bindArgs converts rendered parameter templates into the arguments bound to
+// a prepared statement. Params bind as strings unless they declare a type:
+// int binds as int64 and float as float64, and a rendered value that doesn't
+// parse as its type is an error.
+func bindArgs(params []config.Param, rendered []string) ([]any, error) {
+	args := make([]any, len(rendered))
+	for i, s := range rendered {
+		switch params[i].Type {
+		case config.ParamInt:
+			v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("param %d: %q is not an int", i+1, s)
+			}
+			args[i] = v
+		case config.ParamFloat:
+			v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+				return nil, fmt.Errorf("param %d: %q is not a float", i+1, s)
+			}
+			args[i] = v
+		default:
+			args[i] = s
+		}
+	}
+	return args, nil
+}
+
+// execAll runs statements in order on conn, stopping at the first error.
+func execAll(ctx context.Context, conn *sql.Conn, statements []string) error {
+	for _, stmt := range statements {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%w\nStatement: %s", err, stmt)
+		}
+	}
+	return nil
+}
+
+// runIteration executes one timed iteration of a test and returns the elapsed
+// time plus the rows returned (read) or affected (write, transaction).
+// With rollback, the timing covers the statements but not the ROLLBACK;
+// without it, write timings include the commit.
+func runIteration(ctx context.Context, conn *sql.Conn, kind string, rollback bool, statements []string) (time.Duration, int64, error) {
+	if kind == config.TestKindRead {
+		start := time.Now()
+		rows, err := conn.QueryContext(ctx, statements[0])
+		if err != nil {
+			return 0, 0, err
+		}
+		// Drain all rows to measure full execution.
+		var n int64
+		for rows.Next() {
+			n++
+		}
+		err = rows.Err()
+		rows.Close()
+		return time.Since(start), n, err
+	}
+
+	// Autocommitted single write: no explicit transaction needed.
+	if kind == config.TestKindWrite && !rollback {
+		start := time.Now()
+		res, err := conn.ExecContext(ctx, statements[0])
+		if err != nil {
+			return 0, 0, err
+		}
+		elapsed := time.Since(start)
+		n, _ := res.RowsAffected()
+		return elapsed, n, nil
+	}
+
+	start := time.Now()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	var affected int64
+	for _, stmt := range statements {
+		res, err := tx.ExecContext(ctx, stmt)
+		if err != nil {
+			tx.Rollback()
+			return 0, 0, err
+		}
+		n, _ := res.RowsAffected()
+		affected += n
+	}
+	if rollback {
+		elapsed := time.Since(start)
+		if err := tx.Rollback(); err != nil {
+			return 0, 0, fmt.Errorf("rolling back: %w", err)
+		}
+		return elapsed, affected, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("committing: %w", err)
+	}
+	return time.Since(start), affected, nil
 }
 
 // printReport outputs a formatted performance summary table with color coding.
@@ -354,8 +753,9 @@ func printReport(results []TestResult) {
 	// Write plain text to a buffer so ANSI codes don't break tabwriter alignment.
 	var buf bytes.Buffer
 	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(w, "  Test\tAvg\tMin\tMax\tp95\tRows\tRuns\n")
-	fmt.Fprintf(w, "  ----\t---\t---\t---\t---\t----\t----\n")
+	header := resultHeader()
+	fmt.Fprint(w, "  "+tabRow(header...))
+	fmt.Fprint(w, "  "+tabRow(dashes(header)...))
 
 	// Track per-result-line colors (indices match result lines, not header/separator).
 	lineColors := make([]string, 0, len(results))
@@ -371,18 +771,10 @@ func printReport(results []TestResult) {
 			continue
 		}
 
-		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%d\t%d\n",
-			r.Name,
-			formatDuration(avg(r.Timings)),
-			formatDuration(min(r.Timings)),
-			formatDuration(max(r.Timings)),
-			formatDuration(percentile(r.Timings, 95)),
-			r.RowCount,
-			len(r.Timings),
-		)
+		fmt.Fprint(w, "  "+tabRow(r.resultCells()...))
 
 		if useColor {
-			a := avg(r.Timings)
+			a := r.Latency.Mean()
 			switch {
 			case a <= thresholds[0]:
 				lineColors = append(lineColors, colorGreen)
@@ -411,6 +803,11 @@ func printReport(results []TestResult) {
 			fmt.Println(line)
 		}
 	}
+
+	if hasMetrics(results) {
+		fmt.Println("\nServer metrics (per run):")
+		writeMetricsTable(os.Stdout, results, "  ")
+	}
 }
 
 // avgTerciles computes the 33rd and 67th percentile of avg times
@@ -418,8 +815,8 @@ func printReport(results []TestResult) {
 func avgTerciles(results []TestResult) [2]time.Duration {
 	var avgs []time.Duration
 	for _, r := range results {
-		if r.Error == nil && len(r.Timings) > 0 {
-			avgs = append(avgs, avg(r.Timings))
+		if r.Error == nil && r.Latency.Count() > 0 {
+			avgs = append(avgs, r.Latency.Mean())
 		}
 	}
 	if len(avgs) < 2 {
@@ -432,61 +829,6 @@ func avgTerciles(results []TestResult) [2]time.Duration {
 	}
 }
 
-func avg(timings []time.Duration) time.Duration {
-	if len(timings) == 0 {
-		return 0
-	}
-	var sum time.Duration
-	for _, t := range timings {
-		sum += t
-	}
-	return sum / time.Duration(len(timings))
-}
-
-func min(timings []time.Duration) time.Duration {
-	if len(timings) == 0 {
-		return 0
-	}
-	m := timings[0]
-	for _, t := range timings[1:] {
-		if t < m {
-			m = t
-		}
-	}
-	return m
-}
-
-func max(timings []time.Duration) time.Duration {
-	if len(timings) == 0 {
-		return 0
-	}
-	m := timings[0]
-	for _, t := range timings[1:] {
-		if t > m {
-			m = t
-		}
-	}
-	return m
-}
-
-func percentile(timings []time.Duration, pct float64) time.Duration {
-	if len(timings) == 0 {
-		return 0
-	}
-	sorted := make([]time.Duration, len(timings))
-	copy(sorted, timings)
-	slices.Sort(sorted)
-
-	idx := int(math.Ceil(pct/100*float64(len(sorted)))) - 1
-	if idx < 0 {
-		idx = 0
-	}
-	if idx >= len(sorted) {
-		idx = len(sorted) - 1
-	}
-	return sorted[idx]
-}
-
 // formatDuration prints a duration in a human-friendly way.
 func formatDuration(d time.Duration) string {
 	switch {
@@ -503,21 +845,53 @@ func formatDuration(d time.Duration) string {
 func buildTestReport(results []TestResult) string {
 	var sb strings.Builder
 	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(w, "Test\tAvg\tMin\tMax\tp95\tRows\tRuns\n")
+	fmt.Fprint(w, tabRow(resultHeader()...))
 	for _, r := range results {
 		if r.Error != nil {
 			fmt.Fprintf(w, "%s\tERROR: %v\t\t\t\t\t\n", r.Name, r.Error)
 			continue
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\n",
-			r.Name,
-			formatDuration(avg(r.Timings)),
-			formatDuration(min(r.Timings)),
-			formatDuration(max(r.Timings)),
-			formatDuration(percentile(r.Timings, 95)),
-			r.RowCount,
-			len(r.Timings),
-		)
+		fmt.Fprint(w, tabRow(r.resultCells()...))
+	}
+	w.Flush()
+	if hasMetrics(results) {
+		sb.WriteString("\nServer metrics (per run):\n")
+		writeMetricsTable(&sb, results, "")
+	}
+	return sb.String()
+}
+
+// buildScalingReport returns the average latency of each test per row
+// count, with the growth from the smallest to the largest run.
+func buildScalingReport(runs []ConfigResult) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Test")
+	for _, r := range runs {
+		fmt.Fprintf(w, "\t%d rows", r.Rows)
+	}
+	fmt.Fprintf(w, "\tGrowth\n")
+	for _, name := range collectTestNames(runs) {
+		fmt.Fprintf(w, "  %s", name)
+		var first, last time.Duration
+		for _, r := range runs {
+			res := findResult(r, name)
+			if res == nil || res.Latency.Count() == 0 {
+				fmt.Fprintf(w, "\t-")
+				continue
+			}
+			a := res.Latency.Mean()
+			if first == 0 {
+				first = a
+			}
+			last = a
+			fmt.Fprintf(w, "\t%s", formatDuration(a))
+		}
+		if first > 0 {
+			fmt.Fprintf(w, "\t%.1fx\n", float64(last)/float64(first))
+		} else {
+			fmt.Fprintf(w, "\t-\n")
+		}
 	}
 	w.Flush()
 	return sb.String()
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/stats"
+)
+
+func TestBindArgs(t *testing.T) {
+	params := []config.Param{
+		{}, {Type: config.ParamString}, {Type: config.ParamInt}, {Type: config.ParamInt}, {Type: config.ParamFloat}, {},
+	}
+	got, err := bindArgs(params, []string{"42", "00123", "-7", "0", "3.5", "2024-01-02"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{"42", "00123", int64(-7), int64(0), 3.5, "2024-01-02"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bindArgs() = %#v, want %#v", got, want)
+	}
+
+	for _, tc := range []struct {
+		typ, value string
+	}{
+		{config.ParamInt, "3.5"},
+		{config.ParamInt, "abc"},
+		{config.ParamFloat, "NaN"},
+		{config.ParamFloat, ""},
+	} {
+		if _, err := bindArgs([]config.Param{{Type: tc.typ}}, []string{tc.value}); err == nil {
+			t.Errorf("bindArgs(%s, %q) succeeded, want an error", tc.typ, tc.value)
+		}
+	}
+}
+
+func TestRowsLabel(t *testing.T) {
+	read := TestResult{RowCount: 12}
+	if got := read.rowsLabel(); got != "12" {
+		t.Errorf("read rowsLabel() = %q, want %q", got, "12")
+	}
+	write := TestResult{
+		Kind:         config.TestKindWrite,
+		Latency:      stats.FromDurations(time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond),
+		RowsAffected: 6,
+	}
+	if got := write.rowsLabel(); got != "1.5 affected" {
+		t.Errorf("write rowsLabel() = %q, want %q", got, "1.5 affected")
+	}
+}
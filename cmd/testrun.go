@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -22,43 +24,109 @@ type ConfigResult struct {
 	Results    []TestResult
 	Error      error
 	Duration   time.Duration
+	Config     *config.Config // effective config of the run, for HTML reports
+}
+
+// pipelineOptions holds the operational parameters for a single
+// create→seed→test→drop run.
+type pipelineOptions struct {
+	SchemaFile   string
+	Rows         int
+	BatchSize    int
+	Workers      int
+	MinChildren  int
+	MaxChildren  int
+	MaxRows      int
+	LoadData     bool
+	DeferIndexes bool
+	FKSampleSize int
+	SeedTables   []string
+	ReuseData    bool // restore a cached snapshot instead of seeding when available
+	Keep         bool // leave the tables in place after the run
+	Explain      bool // collect EXPLAIN plans for the HTML report
+
+	Context context.Context              // cancels the run; nil never cancels
+	OnTable func(table string, rows int) // called after each table is seeded or restored
+	OnTest  func(result TestResult)      // called after each test
+}
+
+// ctx returns opts.Context, or context.Background().
+func (opts pipelineOptions) ctx() context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
 }
 
 // runTestPipeline runs the full create→seed→test→drop pipeline for a single
 // config against the given database connection. Tables are always dropped,
-// even on error.
-func runTestPipeline(db *sql.DB, schema string, cfg *config.Config, schemaFile string, rows, batchSize, workers, minChildren, maxChildren, maxRowsCap int, loadData, deferIndexes bool, fkSampleSize int, seedTables []string) ([]TestResult, int, error) {
+// even on error, unless opts.Keep is set. It returns the names of the tables
+// it created so callers can track kept tables.
+func runTestPipeline(db *sql.DB, schema string, cfg *config.Config, opts pipelineOptions) ([]TestResult, []string, error) {
+	schemaFile := opts.SchemaFile
+
 	// Parse DDL file.
 	statements, tableNames, err := parseDDLFile(schemaFile)
 	if err != nil {
-		return nil, 0, fmt.Errorf("parsing schema file: %w", err)
+		return nil, nil, fmt.Errorf("parsing schema file: %w", err)
 	}
 	if len(tableNames) == 0 {
-		return nil, 0, fmt.Errorf("no CREATE TABLE statements found in %s", schemaFile)
+		return nil, nil, fmt.Errorf("no CREATE TABLE statements found in %s", schemaFile)
 	}
 
 	// Create tables.
 	if err := createTables(db, tableNames, statements); err != nil {
-		return nil, 0, fmt.Errorf("creating tables: %w", err)
+		return nil, nil, fmt.Errorf("creating tables: %w", err)
 	}
 	fmt.Printf("Created %d tables\n", len(tableNames))
 
 	// Defer cleanup — guarantees table drop even on failure.
 	defer func() {
+		if opts.Keep {
+			fmt.Printf("Kept %d tables: %s\n", len(tableNames), strings.Join(tableNames, ", "))
+			return
+		}
 		dropTables(db, tableNames)
 		fmt.Println("Cleaned up: dropped test tables")
 	}()
 
+	if err := seedCreatedTables(db, schema, cfg, opts, tableNames); err != nil {
+		return nil, tableNames, err
+	}
+
+	// Run test queries.
+	if len(cfg.Tests) == 0 {
+		fmt.Println("\nNo test queries configured.")
+		return nil, tableNames, nil
+	}
+
+	fmt.Printf("\nRunning %d test queries...\n", len(cfg.Tests))
+	results := runTests(opts.ctx(), db, cfg.Tests, opts.Explain, opts.OnTest)
+	if err := opts.ctx().Err(); err != nil {
+		return results, tableNames, err
+	}
+
+	return results, tableNames, nil
+}
+
+// seedCreatedTables introspects freshly created tables and fills them with
+// generated data, or restores a cached snapshot of identical data when
+// opts.ReuseData is set.
+func seedCreatedTables(db *sql.DB, schema string, cfg *config.Config, opts pipelineOptions, tableNames []string) error {
 	// Introspect newly created tables.
 	allTables := make(map[string]*introspect.Table, len(tableNames))
 	for _, name := range tableNames {
 		t, err := introspect.IntrospectTable(db, schema, name)
 		if err != nil {
-			return nil, len(tableNames), fmt.Errorf("introspecting %s: %w", name, err)
+			return fmt.Errorf("introspecting %s: %w", name, err)
 		}
 		allTables[name] = t
 	}
 
+	if err := checkConfig(cfg, allTables); err != nil {
+		return err
+	}
+
 	// Apply config-declared references (logical FKs without actual constraints).
 	if refs := cfg.GetReferences(); refs != nil {
 		introspect.ApplyReferences(allTables, refs)
@@ -66,13 +134,13 @@ func runTestPipeline(db *sql.DB, schema string, cfg *config.Config, schemaFile s
 
 	// Determine which tables to seed.
 	seedTableNames := tableNames
-	if len(seedTables) > 0 {
-		for _, n := range seedTables {
+	if len(opts.SeedTables) > 0 {
+		for _, n := range opts.SeedTables {
 			if _, ok := allTables[n]; !ok {
-				return nil, len(tableNames), fmt.Errorf("table %q not found in schema file", n)
+				return fmt.Errorf("table %q not found in schema file", n)
 			}
 		}
-		seedTableNames = seedTables
+		seedTableNames = opts.SeedTables
 	}
 
 	// Resolve FK ordering.
@@ -83,7 +151,7 @@ func runTestPipeline(db *sql.DB, schema string, cfg *config.Config, schemaFile s
 
 	order, autoIncluded, relations, err := depgraph.Resolve(requestedTables, allTables)
 	if err != nil {
-		return nil, len(tableNames), err
+		return err
 	}
 	if len(autoIncluded) > 0 {
 		fmt.Printf("Auto-included parent tables: %s\n", strings.Join(autoIncluded, ", "))
@@ -95,35 +163,78 @@ func runTestPipeline(db *sql.DB, schema string, cfg *config.Config, schemaFile s
 	}
 
 	// Compute per-table row counts.
-	rowCounts := computeRowCounts(order, relations, cfg, rows, minChildren, maxChildren, maxRowsCap)
-
-	// Seed tables.
-	fmt.Printf("Seeding %d tables...\n", len(orderedTables))
-	if err := seeder.SeedAll(seeder.Config{
-		DB:           db,
-		Schema:       schema,
-		Tables:       orderedTables,
-		RowsPerTable: rowCounts,
-		BatchSize:    batchSize,
-		Workers:      workers,
-		Clear:        false,
-		LoadData:     loadData,
-		DeferIndexes: deferIndexes,
-		GenConfig:    cfg,
-		FKSampleSize: fkSampleSize,
-	}); err != nil {
-		return nil, len(tableNames), fmt.Errorf("seeding tables: %w", err)
-	}
+	rowCounts := computeRowCounts(order, relations, cfg, opts.Rows, opts.MinChildren, opts.MaxChildren, opts.MaxRows)
 
-	// Run test queries.
-	if len(cfg.Tests) == 0 {
-		fmt.Println("\nNo test queries configured.")
-		return nil, len(tableNames), nil
+	// Restore a cached snapshot of an identical dataset if one exists.
+	var snapDir string
+	if opts.ReuseData {
+		snapDir, err = snapshotDir(opts.SchemaFile, cfg, opts)
+		if err != nil {
+			return fmt.Errorf("resolving snapshot: %w", err)
+		}
 	}
+	if snapDir != "" && seeder.SnapshotExists(snapDir) {
+		fmt.Printf("Restoring %d tables from snapshot %s...\n", len(orderedTables), snapDir)
+		start := time.Now()
+		if err := seeder.RestoreSnapshot(db, snapDir, opts.OnTable); err != nil {
+			return fmt.Errorf("restoring snapshot %s: %w (delete it to force a re-seed)", snapDir, err)
+		}
+		fmt.Printf("Restored snapshot in %s\n", time.Since(start).Round(time.Millisecond))
+	} else {
+		// Seed tables.
+		fmt.Printf("Seeding %d tables...\n", len(orderedTables))
+		if err := seeder.SeedAll(seeder.Config{
+			DB:           db,
+			Schema:       schema,
+			Tables:       orderedTables,
+			RowsPerTable: rowCounts,
+			BatchSize:    opts.BatchSize,
+			Workers:      opts.Workers,
+			Clear:        false,
+			LoadData:     opts.LoadData,
+			DeferIndexes: opts.DeferIndexes,
+			GenConfig:    cfg,
+			FKSampleSize: opts.FKSampleSize,
+			MaxRows:      opts.MaxRows,
+			Context:      opts.Context,
+			OnTable:      opts.OnTable,
+		}); err != nil {
+			return fmt.Errorf("seeding tables: %w", err)
+		}
 
-	fmt.Printf("\nRunning %d test queries...\n", len(cfg.Tests))
-	results := runTests(db, cfg.Tests)
+		if snapDir != "" {
+			if err := seeder.SaveSnapshot(db, snapDir, orderedTables, cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: could not save snapshot: %v\n", err)
+			} else {
+				fmt.Printf("Saved snapshot to %s\n", snapDir)
+			}
+		}
+	}
 
-	return results, len(tableNames), nil
+	return nil
 }
 
+
+// effectiveConfig returns a copy of cfg with the options a run actually used,
+// for reports. The DSN password is redacted, and profiles, extends and
+// include are dropped: the applied profile is already merged in, and the
+// others may hold interpolated secrets.
+func effectiveConfig(cfg *config.Config, opts pipelineOptions) *config.Config {
+	c := *cfg
+	c.Extends, c.Include, c.Profiles = "", nil, nil
+	if c.Options.DSN != "" {
+		c.Options.DSN = redactDSNPassword(c.Options.DSN)
+	}
+	c.Options.Schema = opts.SchemaFile
+	c.Options.SeedTables = opts.SeedTables
+	c.Options.Rows = opts.Rows
+	c.Options.BatchSize = opts.BatchSize
+	c.Options.Workers = opts.Workers
+	c.Options.ChildrenPerParent = config.ChildrenPerParent{Min: opts.MinChildren, Max: opts.MaxChildren}
+	c.Options.MaxRows = opts.MaxRows
+	c.Options.LoadData = opts.LoadData
+	c.Options.DeferIndexes = opts.DeferIndexes
+	c.Options.FKSampleSize = opts.FKSampleSize
+	c.Options.Percentiles = reportPercentiles
+	return &c
+}
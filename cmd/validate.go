@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+	"github.com/tomfevang/go-test-my-db/internal/validate"
+)
+
+var (
+	validateConfigPath string
+	validateProfile    string
+	validateDSN        string
+	validateSchemaFile string
+	validateEphemeral  bool
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a config file for mistakes, with line numbers",
+	Long: `The validate subcommand checks a go-test-my-db.yaml config and reports every
+problem as file:line:column: unknown keys, values of the wrong type, unknown
+distribution types and correlation sources, impossible weights, invalid
+templates, and settings the generator would silently ignore. Files the config
+extends or includes are checked too, with the profile given by --profile
+applied.
+
+With a database it also checks the config against the schema: unknown tables
+and columns, reference targets, enum weights that match no member, and
+correlations over auto-increment or generated columns. With --dsn the tables
+already in that database are read; nothing is written. With --ephemeral the
+tables are created from the schema file in a temporary MySQL container.
+
+seed and test run the same checks after introspecting the schema, and stop on
+errors.`,
+	Args: cobra.NoArgs,
+	RunE: runValidate,
+}
+
+func init() {
+	validateCmd.Flags().StringVar(&validateConfigPath, "config", "", "Path to config YAML file (default: auto-detect go-test-my-db.yaml)")
+	validateCmd.Flags().StringVar(&validateProfile, "profile", "", "Config profile to apply over the file's settings (env SEED_PROFILE)")
+	validateCmd.Flags().StringVar(&validateDSN, "dsn", "", "MySQL DSN whose existing tables the config is checked against")
+	validateCmd.Flags().StringVar(&validateSchemaFile, "schema", "", "Path to SQL DDL file to check against (with --ephemeral)")
+	validateCmd.Flags().BoolVar(&validateEphemeral, "ephemeral", false, "Create the schema file's tables in a temporary MySQL container via Docker or Podman and check against them")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	path := validateConfigPath
+	if path == "" {
+		if _, err := os.Stat(config.DefaultFile); err != nil {
+			return fmt.Errorf("no config file: pass --config or create %s", config.DefaultFile)
+		}
+		path = config.DefaultFile
+	}
+	doc, err := validate.Load(path, resolveString(cmd, "profile", validateProfile, "SEED_PROFILE", "", ""))
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	tables, err := validationSchema(cmd, doc.Config)
+	if err != nil {
+		return err
+	}
+	if tables == nil {
+		fmt.Println("No database: skipped the checks against the schema (use --dsn or --ephemeral to run them).")
+	}
+
+	problems := doc.Check(tables)
+	printProblems(os.Stdout, path, problems)
+	errs := validate.Errors(problems)
+	if errs > 0 {
+		return fmt.Errorf("%s: %s, %s", path, plural(errs, "error"), plural(len(problems)-errs, "warning"))
+	}
+	if len(problems) > 0 {
+		fmt.Printf("%s: %s\n", path, plural(len(problems), "warning"))
+		return nil
+	}
+	fmt.Printf("%s: no problems found\n", path)
+	return nil
+}
+
+// validationSchema returns the tables to validate cfg against: those created
+// from the schema file in an ephemeral container, those already in the DSN's
+// database, or nil without a database.
+func validationSchema(cmd *cobra.Command, cfg *config.Config) (map[string]*introspect.Table, error) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if validateEphemeral {
+		schemaFile := resolveString(cmd, "schema", validateSchemaFile, "", cfg.Options.Schema, "")
+		if schemaFile == "" {
+			return nil, fmt.Errorf("schema file is required with --ephemeral — set via --schema flag or options.schema in config file")
+		}
+		statements, tableNames, err := parseDDLFile(schemaFile)
+		if err != nil {
+			return nil, fmt.Errorf("parsing schema file: %w", err)
+		}
+		db, schema, closeDB, err := connectEngine(ctx, "", false, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer closeDB()
+		if err := createTables(db, tableNames, statements); err != nil {
+			return nil, fmt.Errorf("creating tables: %w", err)
+		}
+		return introspectTables(db, schema, tableNames)
+	}
+
+	dsn := resolveString(cmd, "dsn", validateDSN, "SEED_DSN", cfg.Options.DSN, "")
+	if dsn == "" {
+		return nil, nil
+	}
+	db, schema, closeDB, err := connectEngine(ctx, dsn, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDB()
+	tableNames, err := introspect.ListTables(db, schema)
+	if err != nil {
+		return nil, err
+	}
+	return introspectTables(db, schema, tableNames)
+}
+
+func introspectTables(db *sql.DB, schema string, tableNames []string) (map[string]*introspect.Table, error) {
+	tables := make(map[string]*introspect.Table, len(tableNames))
+	for _, name := range tableNames {
+		t, err := introspect.IntrospectTable(db, schema, name)
+		if err != nil {
+			return nil, fmt.Errorf("introspecting %s: %w", name, err)
+		}
+		tables[name] = t
+	}
+	return tables, nil
+}
+
+// checkConfig validates the file cfg was loaded from against the introspected
+// tables before they are seeded. Warnings are printed; errors fail the run.
+// Configs not loaded from a file are not checked.
+func checkConfig(cfg *config.Config, tables map[string]*introspect.Table) error {
+	if cfg == nil || cfg.Path == "" {
+		return nil
+	}
+	problems, err := validate.File(cfg.Path, cfg.Profile, tables)
+	if err != nil {
+		return fmt.Errorf("validating config: %w", err)
+	}
+	var errs []string
+	for _, p := range problems {
+		if p.Severity == validate.Warning {
+			fmt.Println(formatProblem(cfg.Path, p))
+		} else {
+			errs = append(errs, formatProblem(cfg.Path, p))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func printProblems(w io.Writer, path string, problems []validate.Problem) {
+	for _, p := range problems {
+		fmt.Fprintln(w, formatProblem(path, p))
+	}
+}
+
+// formatProblem formats p as "file:line:col: severity: message". Problems in
+// files the config extends or includes name their own file.
+func formatProblem(path string, p validate.Problem) string {
+	if p.File != "" {
+		path = p.File
+	}
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", path, p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s:%s", path, p)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
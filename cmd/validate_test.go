@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+func TestCheckConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.yaml")
+	data := "tables:\n  users:\n    colums:\n      email: \"{{Email}}\"\n  orders:\n    rows: 10\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := map[string]*introspect.Table{"users": {Name: "users", Columns: []introspect.Column{{Name: "email"}}}}
+
+	err = checkConfig(cfg, tables)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		path + `:3:5: error: unknown key "colums" in tables.users (did you mean "columns"?)`,
+		path + `:5:3: error: unknown table "orders"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+
+	// Configs not loaded from a file are not checked.
+	if err := checkConfig(&config.Config{}, tables); err != nil {
+		t.Errorf("checkConfig(defaults) = %v", err)
+	}
+}
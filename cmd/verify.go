@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/brianvoe/gofakeit/v7"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/generator"
+)
+
+// verifyRuns is the number of parameter sets a verified test is checked with.
+const verifyRuns = 10
+
+// defaultVerifySeed is the data seed compare uses for verified tests when no
+// config sets options.seed.
+const defaultVerifySeed = 1
+
+// verifySeed seeds the parameter stream of verification passes. It is fixed
+// so that every compare variant renders the same values.
+const verifySeed = 1
+
+// verifyFuncMap returns template functions whose random values depend only
+// on the test name, so variants that call the same functions in the same
+// order render identical parameters.
+func verifyFuncMap(db *sql.DB, name string) template.FuncMap {
+	rng := generator.NewRand(verifySeed, "verify:"+name)
+	fm := generator.FuncMap(gofakeit.NewFaker(rng, false))
+	fm["SampleRow"] = makeSampleRowFunc(db, rng)
+	return fm
+}
+
+// verifyTestCase runs a read test verifyRuns times, untimed, with parameters
+// from verifyFuncMap and returns the total row count and a checksum of the
+// rows. The checksum ignores row order, since variants with different
+// indexes may return rows in different orders when there is no ORDER BY.
+func verifyTestCase(ctx context.Context, conn *sql.Conn, db *sql.DB, tc config.TestCase, stmt *sql.Stmt) (int64, string, error) {
+	fm := verifyFuncMap(db, tc.Name)
+	sources := []string{tc.Query}
+	if stmt != nil {
+		sources = config.ParamValues(tc.Params)
+	}
+	templates := make([]*template.Template, len(sources))
+	for si, src := range sources {
+		if !strings.Contains(src, "{{") {
+			continue
+		}
+		tmpl, err := template.New(tc.Name).Funcs(fm).Parse(src)
+		if err != nil {
+			return 0, "", err
+		}
+		templates[si] = tmpl
+	}
+
+	runs := verifyRuns
+	if tc.Repeat > 0 && tc.Repeat < runs {
+		runs = tc.Repeat
+	}
+	var (
+		buf      bytes.Buffer
+		rowCount int64
+		sum      uint64
+	)
+	rendered := make([]string, len(sources))
+	for i := 0; i < runs; i++ {
+		for si, src := range sources {
+			rendered[si] = src
+			if templates[si] != nil {
+				buf.Reset()
+				if err := templates[si].Execute(&buf, nil); err != nil {
+					return 0, "", fmt.Errorf("template exec failed on verify run %d: %w", i+1, err)
+				}
+				rendered[si] = buf.String()
+			}
+		}
+
+		var (
+			rows *sql.Rows
+			err  error
+		)
+		if stmt != nil {
+			var args []any
+			if args, err = bindArgs(tc.Params, rendered); err != nil {
+				return 0, "", err
+			}
+			rows, err = stmt.QueryContext(ctx, args...)
+		} else {
+			rows, err = conn.QueryContext(ctx, rendered[0])
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		n, s, err := checksumRows(rows)
+		rows.Close()
+		if err != nil {
+			return 0, "", err
+		}
+		rowCount += n
+		// Mix in the run index so moving rows between runs is detected.
+		sum += s * uint64(2*i+1)
+	}
+	return rowCount, fmt.Sprintf("%016x", sum), nil
+}
+
+// checksumRows drains rows and returns their count and an order-independent
+// checksum: the sum of a hash of each row's values as returned by the server.
+func checksumRows(rows *sql.Rows) (int64, uint64, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, 0, err
+	}
+	values := make([]sql.RawBytes, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	var n int64
+	var sum uint64
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return 0, 0, err
+		}
+		sum += hashRow(values)
+		n++
+	}
+	return n, sum, rows.Err()
+}
+
+// hashRow hashes a row's values, distinguishing NULL from the empty string
+// and keeping column boundaries.
+func hashRow(values []sql.RawBytes) uint64 {
+	h := fnv.New64a()
+	var size [binary.MaxVarintLen64]byte
+	for _, v := range values {
+		if v == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		h.Write([]byte{1})
+		h.Write(size[:binary.PutUvarint(size[:], uint64(len(v)))])
+		h.Write(v)
+	}
+	return h.Sum64()
+}
+
+// verifiedResults returns the verified results of a test per config label,
+// in config order, and whether they all agree. Configs that failed or did
+// not run the test are skipped.
+func verifiedResults(configs []ConfigResult, name string) (labels []string, results []*TestResult, match bool) {
+	match = true
+	for _, c := range configs {
+		r := findResult(c, name)
+		if r == nil || !r.Verified {
+			continue
+		}
+		if len(results) > 0 && (r.VerifyRows != results[0].VerifyRows || r.Checksum != results[0].Checksum) {
+			match = false
+		}
+		labels = append(labels, c.Label)
+		results = append(results, r)
+	}
+	return labels, results, match
+}
+
+// verifyMismatches returns the verified tests whose row count or checksum
+// differs between configs, in report order.
+func verifyMismatches(configs []ConfigResult) []string {
+	var mismatched []string
+	for _, name := range collectTestNames(configs) {
+		if _, _, match := verifiedResults(configs, name); !match {
+			mismatched = append(mismatched, name)
+		}
+	}
+	return mismatched
+}
+
+// writeVerifyLine reports whether the verified results of a test agree
+// across configs. Tests without verify: true print nothing.
+func writeVerifyLine(sb *strings.Builder, configs []ConfigResult, name string) {
+	labels, results, match := verifiedResults(configs, name)
+	switch {
+	case len(results) == 0:
+	case match:
+		fmt.Fprintf(sb, "  Results: match (%s)\n", results[0].verifyLabel())
+	default:
+		sb.WriteString("  Results: MISMATCH\n")
+		for i, r := range results {
+			fmt.Fprintf(sb, "    %s: %s\n", labels[i], r.verifyLabel())
+		}
+	}
+}
+
+// findResult returns the successful result for the named test, or nil.
+func findResult(c ConfigResult, name string) *TestResult {
+	if c.Error != nil {
+		return nil
+	}
+	for i := range c.Results {
+		if c.Results[i].Name == name && c.Results[i].Error == nil {
+			return &c.Results[i]
+		}
+	}
+	return nil
+}
+
+// verifyLabel describes a verified result for the comparison report.
+func (r *TestResult) verifyLabel() string {
+	return strconv.FormatInt(r.VerifyRows, 10) + " rows, checksum " + r.Checksum
+}
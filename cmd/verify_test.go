@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+)
+
+func TestHashRow(t *testing.T) {
+	base := hashRow([]sql.RawBytes{sql.RawBytes("a"), sql.RawBytes("bc")})
+	if got := hashRow([]sql.RawBytes{sql.RawBytes("a"), sql.RawBytes("bc")}); got != base {
+		t.Error("identical rows hashed differently")
+	}
+	if got := hashRow([]sql.RawBytes{sql.RawBytes("ab"), sql.RawBytes("c")}); got == base {
+		t.Error("column boundaries are not part of the hash")
+	}
+	if hashRow([]sql.RawBytes{nil}) == hashRow([]sql.RawBytes{sql.RawBytes{}}) {
+		t.Error("NULL and empty string hash the same")
+	}
+}
+
+func TestVerifyFuncMap_Deterministic(t *testing.T) {
+	render := func(name string) string {
+		tmpl := template.Must(template.New("q").Funcs(verifyFuncMap(nil, name)).Parse(
+			"{{Number 1 1000}} {{RandomString (SliceString \"a\" \"b\" \"c\")}} {{Number 1 1000}}"))
+		var buf bytes.Buffer
+		for range 5 {
+			if err := tmpl.Execute(&buf, nil); err != nil {
+				t.Fatal(err)
+			}
+			buf.WriteByte('\n')
+		}
+		return buf.String()
+	}
+	if a, b := render("t1"), render("t1"); a != b {
+		t.Errorf("same test rendered different parameters:\n%s\n%s", a, b)
+	}
+	if a, b := render("t1"), render("t2"); a == b {
+		t.Error("different tests rendered identical parameters")
+	}
+}
+
+func TestVerifyMismatches(t *testing.T) {
+	configs := []ConfigResult{
+		{Label: "a", Results: []TestResult{
+			{Name: "same", Verified: true, VerifyRows: 10, Checksum: "01"},
+			{Name: "differs", Verified: true, VerifyRows: 10, Checksum: "02"},
+			{Name: "unverified", RowCount: 1},
+		}},
+		{Label: "b", Results: []TestResult{
+			{Name: "same", Verified: true, VerifyRows: 10, Checksum: "01"},
+			{Name: "differs", Verified: true, VerifyRows: 9, Checksum: "03"},
+			{Name: "unverified", RowCount: 2},
+		}},
+	}
+	if got := verifyMismatches(configs); !slices.Equal(got, []string{"differs"}) {
+		t.Errorf("verifyMismatches() = %v, want [differs]", got)
+	}
+
+	report := buildComparisonReport(configs)
+	if !strings.Contains(report, "Results: match (10 rows, checksum 01)") {
+		t.Errorf("report lacks match line:\n%s", report)
+	}
+	if !strings.Contains(report, "Results: MISMATCH") || !strings.Contains(report, "b: 9 rows, checksum 03") {
+		t.Errorf("report lacks mismatch details:\n%s", report)
+	}
+	if !strings.Contains(report, "Result verification FAILED for 1 test(s): differs") {
+		t.Errorf("report lacks failure summary:\n%s", report)
+	}
+}
+
+func TestShareSeed(t *testing.T) {
+	entries := func(seeds ...int64) []compareEntry {
+		var es []compareEntry
+		for _, s := range seeds {
+			es = append(es, compareEntry{cfg: &config.Config{Options: config.Options{Seed: s}}})
+		}
+		return es
+	}
+	seeds := func(es []compareEntry) []int64 {
+		var out []int64
+		for _, e := range es {
+			out = append(out, e.cfg.Options.Seed)
+		}
+		return out
+	}
+
+	es := entries(0, 0)
+	shareSeed(es)
+	if got := seeds(es); !slices.Equal(got, []int64{defaultVerifySeed, defaultVerifySeed}) {
+		t.Errorf("unseeded configs got %v", got)
+	}
+	es = entries(0, 42, 0)
+	shareSeed(es)
+	if got := seeds(es); !slices.Equal(got, []int64{42, 42, 42}) {
+		t.Errorf("expected the configured seed to be shared, got %v", got)
+	}
+}
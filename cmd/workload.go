@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/generator"
+	"github.com/tomfevang/go-test-my-db/internal/stats"
+)
+
+// Workload phases, in the order they run.
+const (
+	phaseBefore = iota
+	phaseDuring
+	phaseAfter
+	numPhases
+)
+
+var phaseNames = [numPhases]string{"before", "during", "after"}
+
+// workload runs the configured tests in a loop on several connections and
+// records their latencies per phase, showing how concurrent traffic behaves
+// while something else (a migration) runs. Setup, teardown and warm-up do
+// not apply; every test runs as a plain iteration.
+type workload struct {
+	db    *sql.DB
+	tests []config.TestCase
+	phase atomic.Int32
+
+	mu      sync.Mutex
+	latency map[string]*[numPhases]stats.Recorder
+	errors  map[string]*[numPhases]int
+	lastErr map[string]error
+	connIDs []int64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// workloadStats summarises one test in one phase.
+type workloadStats struct {
+	Test    string
+	Phase   string
+	Latency *stats.Recorder
+	Errors  int
+}
+
+// startWorkload starts concurrency workers, each on its own connection and
+// with its own template state, cycling through tests from a different
+// offset. It returns once every worker is connected.
+func startWorkload(db *sql.DB, tests []config.TestCase, concurrency int) (*workload, error) {
+	w := &workload{
+		db:      db,
+		tests:   tests,
+		latency: make(map[string]*[numPhases]stats.Recorder),
+		errors:  make(map[string]*[numPhases]int),
+		lastErr: make(map[string]error),
+	}
+	for _, tc := range tests {
+		w.latency[tc.Name] = new([numPhases]stats.Recorder)
+		w.errors[tc.Name] = new([numPhases]int)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	for i := 0; i < concurrency; i++ {
+		worker, err := w.newWorker(ctx)
+		if err != nil {
+			w.stop()
+			return nil, fmt.Errorf("workload connection %d: %w", i+1, err)
+		}
+		w.wg.Add(1)
+		go func(offset int) {
+			defer w.wg.Done()
+			defer worker.close()
+			worker.run(ctx, offset)
+		}(i)
+	}
+	return w, nil
+}
+
+// setPhase attributes subsequent iterations to phase.
+func (w *workload) setPhase(phase int) {
+	w.phase.Store(int32(phase))
+}
+
+// stop cancels the workers and waits for them to finish.
+func (w *workload) stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// record adds the outcome of one iteration.
+func (w *workload) record(name string, phase int, elapsed time.Duration, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.errors[name][phase]++
+		w.lastErr[name] = err
+		return
+	}
+	w.latency[name][phase].Record(elapsed)
+}
+
+// stats returns per-test, per-phase results in test order. Phases without
+// any iterations are omitted.
+func (w *workload) stats() []workloadStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var out []workloadStats
+	for _, tc := range w.tests {
+		for p := 0; p < numPhases; p++ {
+			latency, errs := &stats.Recorder{}, w.errors[tc.Name][p]
+			latency.Merge(&w.latency[tc.Name][p])
+			if latency.Count() == 0 && errs == 0 {
+				continue
+			}
+			out = append(out, workloadStats{Test: tc.Name, Phase: phaseNames[p], Latency: latency, Errors: errs})
+		}
+	}
+	return out
+}
+
+// errorsByTest returns the last error of each test that failed at least once.
+func (w *workload) errorsByTest() map[string]error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make(map[string]error, len(w.lastErr))
+	for name, err := range w.lastErr {
+		out[name] = err
+	}
+	return out
+}
+
+// connectionIDs returns the server thread IDs of the workload connections.
+func (w *workload) connectionIDs() []int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]int64(nil), w.connIDs...)
+}
+
+// workloadWorker is one workload connection with its prepared state.
+type workloadWorker struct {
+	w         *workload
+	conn      *sql.Conn
+	templates [][]*template.Template // per test, per source
+	stmts     []*sql.Stmt            // per test; nil unless prepared
+}
+
+func (w *workload) newWorker(ctx context.Context) (*workloadWorker, error) {
+	conn, err := w.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	worker := &workloadWorker{w: w, conn: conn}
+	id, err := connectionID(ctx, conn)
+	if err != nil {
+		worker.close()
+		return nil, err
+	}
+	w.mu.Lock()
+	w.connIDs = append(w.connIDs, id)
+	w.mu.Unlock()
+
+	fm := generator.FuncMap(gofakeit.New(0))
+	fm["SampleRow"] = makeSampleRowFunc(w.db, nil)
+	for _, tc := range w.tests {
+		sources := workloadSources(tc)
+		templates := make([]*template.Template, len(sources))
+		for si, src := range sources {
+			if !strings.Contains(src, "{{") {
+				continue
+			}
+			if templates[si], err = template.New(tc.Name).Funcs(fm).Parse(src); err != nil {
+				worker.close()
+				return nil, fmt.Errorf("%s: invalid query template: %w", tc.Name, err)
+			}
+		}
+		worker.templates = append(worker.templates, templates)
+
+		var stmt *sql.Stmt
+		if len(tc.Params) > 0 {
+			if stmt, err = conn.PrepareContext(ctx, tc.Query); err != nil {
+				worker.close()
+				return nil, fmt.Errorf("%s: preparing statement: %w", tc.Name, err)
+			}
+		}
+		worker.stmts = append(worker.stmts, stmt)
+	}
+	return worker, nil
+}
+
+// workloadSources returns the templates rendered for each iteration of tc,
+// as runTestCase does.
+func workloadSources(tc config.TestCase) []string {
+	switch {
+	case len(tc.Params) > 0:
+		return config.ParamValues(tc.Params)
+	case tc.EffectiveKind() == config.TestKindTransaction:
+		return tc.Statements
+	default:
+		return []string{tc.Query}
+	}
+}
+
+func (ww *workloadWorker) close() {
+	for _, stmt := range ww.stmts {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+	ww.conn.Close()
+}
+
+// run cycles through the tests until ctx is cancelled.
+func (ww *workloadWorker) run(ctx context.Context, offset int) {
+	var buf bytes.Buffer
+	tests := ww.w.tests
+	for i := offset; ctx.Err() == nil; i++ {
+		ti := i % len(tests)
+		tc := tests[ti]
+		phase := int(ww.w.phase.Load())
+
+		sources := workloadSources(tc)
+		statements := make([]string, len(sources))
+		var err error
+		for si, src := range sources {
+			statements[si] = src
+			if tmpl := ww.templates[ti][si]; tmpl != nil {
+				buf.Reset()
+				if err = tmpl.Execute(&buf, nil); err != nil {
+					break
+				}
+				statements[si] = buf.String()
+			}
+		}
+
+		var elapsed time.Duration
+		if err == nil {
+			if stmt := ww.stmts[ti]; stmt != nil {
+				var args []any
+				if args, err = bindArgs(tc.Params, statements); err == nil {
+					elapsed, _, err = runPreparedIteration(ctx, ww.conn, stmt, tc.EffectiveKind(), tc.Rollback, args)
+				}
+			} else {
+				elapsed, _, err = runIteration(ctx, ww.conn, tc.EffectiveKind(), tc.Rollback, statements)
+			}
+		}
+		if ctx.Err() != nil && (err == nil || errors.Is(err, context.Canceled)) {
+			return // interrupted by stop; the iteration is incomplete
+		}
+		ww.w.record(tc.Name, phase, elapsed, err)
+	}
+}
@@ -17,15 +17,25 @@ type CompareConfig struct {
 
 // CompareConfigEntry references a seed config file with a display label.
 type CompareConfigEntry struct {
-	Label string `yaml:"label"`
-	File  string `yaml:"file"`
+	Label   string `yaml:"label"`
+	File    string `yaml:"file"`
+	Profile string `yaml:"profile"` // profile of the seed config to apply
+	Keep    bool   `yaml:"keep"`    // leave this variant's tables in place after the run
 }
 
 // CompareTest defines a named test with per-config query variants.
 type CompareTest struct {
-	Name    string            `yaml:"name"`
-	Repeat  int               `yaml:"repeat"`
-	Queries map[string]string `yaml:"queries"` // label -> query
+	Name       string              `yaml:"name"`
+	Kind       string              `yaml:"kind"` // read (default), write, or transaction
+	Repeat     int                 `yaml:"repeat"`
+	Rollback   bool                `yaml:"rollback"`
+	Queries    map[string]string   `yaml:"queries"`    // label -> query
+	Params     map[string][]Param  `yaml:"params"`     // label -> bound parameters
+	Statements map[string][]string `yaml:"statements"` // label -> transaction statements
+	Setup      map[string][]string `yaml:"setup"`      // label -> setup statements
+	Teardown   map[string][]string `yaml:"teardown"`   // label -> teardown statements
+	Warmup     int                 `yaml:"warmup"`
+	Verify     bool                `yaml:"verify"` // check that every variant returns the same results
 }
 
 // LoadCompare reads and parses a comparison config YAML file.
@@ -64,6 +74,25 @@ func LoadCompare(path string) (*CompareConfig, error) {
 				return nil, fmt.Errorf("test %q references undefined label %q", test.Name, label)
 			}
 		}
+		for label := range test.Params {
+			if !seen[label] {
+				return nil, fmt.Errorf("test %q references undefined label %q", test.Name, label)
+			}
+		}
+		for _, byLabel := range []map[string][]string{test.Statements, test.Setup, test.Teardown} {
+			for label := range byLabel {
+				if !seen[label] {
+					return nil, fmt.Errorf("test %q references undefined label %q", test.Name, label)
+				}
+			}
+		}
+		for label := range seen {
+			if tc, ok := test.testCaseFor(label); ok {
+				if err := tc.Validate(); err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
 
 	// Resolve file paths relative to the comparison config file's directory.
@@ -94,24 +123,51 @@ func IsCompareConfig(path string) bool {
 	return len(probe.Configs) > 0
 }
 
+// HasVerify reports whether any test asks for result verification.
+func (cc *CompareConfig) HasVerify() bool {
+	for _, ct := range cc.Tests {
+		if ct.Verify {
+			return true
+		}
+	}
+	return false
+}
+
 // TestCasesForLabel converts comparison tests into a []TestCase for the given
 // config label. Tests without a query for the label are omitted.
 func (cc *CompareConfig) TestCasesForLabel(label string) []TestCase {
 	var cases []TestCase
 	for _, ct := range cc.Tests {
-		query, ok := ct.Queries[label]
-		if !ok || query == "" {
-			continue
-		}
-		repeat := ct.Repeat
-		if repeat <= 0 {
-			repeat = 1
+		if tc, ok := ct.testCaseFor(label); ok {
+			cases = append(cases, tc)
 		}
-		cases = append(cases, TestCase{
-			Name:   ct.Name,
-			Query:  query,
-			Repeat: repeat,
-		})
 	}
 	return cases
 }
+
+// testCaseFor builds the TestCase for one config label. It reports false when
+// the test has no query (or statements) for that label.
+func (ct CompareTest) testCaseFor(label string) (TestCase, bool) {
+	query := ct.Queries[label]
+	statements := ct.Statements[label]
+	if query == "" && len(statements) == 0 {
+		return TestCase{}, false
+	}
+	repeat := ct.Repeat
+	if repeat <= 0 {
+		repeat = 1
+	}
+	return TestCase{
+		Name:       ct.Name,
+		Kind:       ct.Kind,
+		Query:      query,
+		Params:     ct.Params[label],
+		Statements: statements,
+		Repeat:     repeat,
+		Rollback:   ct.Rollback,
+		Setup:      ct.Setup[label],
+		Teardown:   ct.Teardown[label],
+		Warmup:     ct.Warmup,
+		Verify:     ct.Verify,
+	}, true
+}
@@ -224,3 +224,112 @@ func TestTestCasesForLabel(t *testing.T) {
 		t.Errorf("expected 0 cases for nonexistent label, got %d", len(casesNone))
 	}
 }
+
+func TestTestCasesForLabel_Transaction(t *testing.T) {
+	cc := &CompareConfig{
+		Tests: []CompareTest{
+			{
+				Name:     "order",
+				Kind:     TestKindTransaction,
+				Rollback: true,
+				Statements: map[string][]string{
+					"a": {"INSERT INTO orders (id) VALUES (1)", "UPDATE stock SET qty = qty - 1"},
+				},
+			},
+		},
+	}
+
+	cases := cc.TestCasesForLabel("a")
+	if len(cases) != 1 {
+		t.Fatalf("expected 1 case, got %d", len(cases))
+	}
+	c := cases[0]
+	if c.Kind != TestKindTransaction || !c.Rollback || len(c.Statements) != 2 || c.Repeat != 1 {
+		t.Errorf("unexpected case: %+v", c)
+	}
+	if len(cc.TestCasesForLabel("b")) != 0 {
+		t.Error("expected no cases for label without statements")
+	}
+}
+
+func TestLoadCompare_InvalidKind(t *testing.T) {
+	dir := t.TempDir()
+	content := `configs:
+  - label: a
+    file: seed.yaml
+tests:
+  - name: bad
+    kind: transaction
+    queries:
+      a: "DELETE FROM t"
+`
+	configPath := filepath.Join(dir, "compare.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCompare(configPath); err == nil {
+		t.Fatal("expected error for transaction test with query instead of statements")
+	}
+}
+
+func TestTestCasesForLabel_SetupTeardown(t *testing.T) {
+	cc := &CompareConfig{
+		Tests: []CompareTest{
+			{
+				Name:     "t1",
+				Queries:  map[string]string{"a": "SELECT 1", "b": "SELECT 2"},
+				Setup:    map[string][]string{"a": {"SET SESSION optimizer_switch='index_merge=off'"}},
+				Teardown: map[string][]string{"a": {"SET SESSION optimizer_switch=DEFAULT"}},
+				Warmup:   5,
+			},
+		},
+	}
+
+	a := cc.TestCasesForLabel("a")[0]
+	if len(a.Setup) != 1 || len(a.Teardown) != 1 || a.Warmup != 5 {
+		t.Errorf("unexpected case for 'a': %+v", a)
+	}
+	b := cc.TestCasesForLabel("b")[0]
+	if len(b.Setup) != 0 || len(b.Teardown) != 0 || b.Warmup != 5 {
+		t.Errorf("unexpected case for 'b': %+v", b)
+	}
+}
+
+func TestLoadCompare_Verify(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+configs:
+  - label: a
+    file: a.yaml
+  - label: b
+    file: b.yaml
+tests:
+  - name: same answers
+    verify: true
+    queries:
+      a: "SELECT id FROM t WHERE x = 1"
+      b: "SELECT id FROM t2 WHERE x = 1"
+`
+	configPath := filepath.Join(dir, "compare.yaml")
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cc, err := LoadCompare(configPath)
+	if err != nil {
+		t.Fatalf("LoadCompare: %v", err)
+	}
+	if !cc.HasVerify() {
+		t.Error("expected HasVerify to be true")
+	}
+	if tc := cc.TestCasesForLabel("b")[0]; !tc.Verify {
+		t.Errorf("expected verify to carry over to the test case: %+v", tc)
+	}
+
+	bad := content + "    kind: write\n"
+	if err := os.WriteFile(configPath, []byte(bad), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCompare(configPath); err == nil {
+		t.Fatal("expected error for verify on a write test")
+	}
+}
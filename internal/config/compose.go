@@ -0,0 +1,456 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile is a named set of overrides selected with --profile. It is layered
+// over the rest of the config.
+type Profile struct {
+	Options Options                `yaml:"options"`
+	Tables  map[string]TableConfig `yaml:"tables"`
+	Tests   []TestCase             `yaml:"tests"`
+}
+
+// Source is one file of a composed config.
+type Source struct {
+	Path string
+	Root *yaml.Node // mapping node of the file, interpolated; nil for an empty file
+}
+
+// Document is a config file composed with the files it extends and includes,
+// with a profile applied and environment variables interpolated. Its nodes
+// keep the positions they have in their own files.
+type Document struct {
+	Root    *yaml.Node // composed mapping node; nil when every file is empty
+	Sources []Source   // files in layering order, the innermost base first
+	Profile string     // applied profile; empty for none
+
+	files map[*yaml.Node]string
+}
+
+// File returns the file node n was read from, or "" when n is not part of
+// the document.
+func (d *Document) File(n *yaml.Node) string {
+	return d.files[n]
+}
+
+// PositionError is a problem at a position in a config file. Line and Column
+// are 0 when Err does not belong to one value; YAML syntax errors carry their
+// line in Err.
+type PositionError struct {
+	File         string
+	Line, Column int
+	Err          error
+}
+
+func (e *PositionError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+	return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+}
+
+func (e *PositionError) Unwrap() error { return e.Err }
+
+// Keys consumed by composition; they are removed from the composed document.
+const (
+	keyExtends  = "extends"
+	keyInclude  = "include"
+	keyProfiles = "profiles"
+)
+
+// Compose reads the config file at path and layers it over the files it
+// extends and includes, in that order, then layers the named profile over the
+// result. Mappings merge key by key, lists of tests merge by test name, and
+// any other value in a later layer replaces the earlier one.
+//
+// Environment variables are interpolated in values: ${VAR} is replaced by the
+// variable or the empty string, ${VAR:-default} falls back to default when
+// VAR is unset or empty, ${VAR:?message} fails when it is, and $$ is a
+// literal $.
+//
+// Problems in the files are returned as *PositionError, joined when there are
+// several; an unreadable path is returned as is.
+func Compose(path, profile string) (*Document, error) {
+	c := &composer{
+		doc:     &Document{Profile: profile, files: make(map[*yaml.Node]string)},
+		profile: profile,
+	}
+	root, err := c.load(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := errors.Join(c.errs...); err != nil {
+		return nil, err
+	}
+
+	var profiles *yaml.Node
+	if root != nil {
+		profiles = valueOf(root, keyProfiles)
+	}
+	if profile != "" {
+		overlay := valueOf(profiles, profile)
+		if overlay == nil {
+			return nil, &PositionError{File: path, Err: fmt.Errorf("unknown profile %q%s", profile, available(profiles))}
+		}
+		root = c.merge(root, overlay)
+	}
+	c.doc.Root = root
+	return c.doc, nil
+}
+
+// ProfileNames returns the profiles defined by a composed document, sorted.
+func (d *Document) ProfileNames() []string {
+	var names []string
+	if d.Root == nil {
+		return nil
+	}
+	if profiles := valueOf(d.Root, keyProfiles); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			names = append(names, profiles.Content[i].Value)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func available(profiles *yaml.Node) string {
+	d := &Document{Root: &yaml.Node{Kind: yaml.MappingNode}}
+	if profiles != nil {
+		d.Root.Content = []*yaml.Node{{Kind: yaml.ScalarNode, Value: keyProfiles}, profiles}
+	}
+	names := d.ProfileNames()
+	if len(names) == 0 {
+		return " (the config defines no profiles)"
+	}
+	return fmt.Sprintf(" (available: %s)", strings.Join(names, ", "))
+}
+
+type composer struct {
+	doc     *Document
+	profile string
+	errs    []error
+}
+
+// load reads path and the files it extends and includes, and returns their
+// composed root. stack holds the absolute paths of the files being loaded, to
+// detect cycles.
+func (c *composer) load(path string, stack []string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &PositionError{File: path, Err: err}
+	}
+	var root *yaml.Node
+	if len(doc.Content) > 0 {
+		root = deref(doc.Content[0])
+		if root.Kind != yaml.MappingNode {
+			return nil, &PositionError{File: path, Line: root.Line, Column: root.Column, Err: errors.New("config must be a mapping with options, tables and tests keys")}
+		}
+		c.interpolateRoot(path, root)
+		c.register(path, root)
+	}
+
+	// Bases are loaded first so the innermost base comes first in Sources.
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	stack = append(stack, abs)
+	var composed *yaml.Node
+	for _, ref := range c.references(path, root) {
+		target := resolvePath(path, ref.Value)
+		if abs, err := filepath.Abs(target); err == nil && slices.Contains(stack, abs) {
+			return nil, c.errorAt(path, ref, fmt.Errorf("%s is already being loaded: extends and include must not form a cycle", ref.Value))
+		}
+		base, err := c.load(target, stack)
+		if err != nil {
+			var pe *PositionError
+			if !errors.As(err, &pe) {
+				err = c.errorAt(path, ref, err)
+			}
+			return nil, err
+		}
+		composed = c.merge(composed, base)
+	}
+	c.doc.Sources = append(c.doc.Sources, Source{Path: path, Root: root})
+	return c.merge(composed, c.withoutKeys(root, keyExtends, keyInclude)), nil
+}
+
+// references returns the extends and include values of root, in layering
+// order.
+func (c *composer) references(path string, root *yaml.Node) []*yaml.Node {
+	var refs []*yaml.Node
+	if n := valueOf(root, keyExtends); n != nil && n.Tag != "!!null" {
+		if n.Kind == yaml.ScalarNode {
+			refs = append(refs, n)
+		} else {
+			c.errs = append(c.errs, c.errorAt(path, n, errors.New("extends must be a file path")))
+		}
+	}
+	if n := valueOf(root, keyInclude); n != nil && n.Tag != "!!null" {
+		if n.Kind != yaml.SequenceNode {
+			c.errs = append(c.errs, c.errorAt(path, n, errors.New("include must be a list of file paths")))
+			return refs
+		}
+		for _, item := range n.Content {
+			if item = deref(item); item.Kind == yaml.ScalarNode {
+				refs = append(refs, item)
+			} else {
+				c.errs = append(c.errs, c.errorAt(path, item, errors.New("include entries must be file paths")))
+			}
+		}
+	}
+	return refs
+}
+
+// resolvePath resolves ref relative to the directory of the file that
+// references it.
+func resolvePath(from, ref string) string {
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(filepath.Dir(from), ref)
+}
+
+func (c *composer) errorAt(file string, n *yaml.Node, err error) *PositionError {
+	e := &PositionError{File: file, Err: err}
+	if n != nil {
+		e.Line, e.Column = n.Line, n.Column
+	}
+	return e
+}
+
+// register records path as the file of every node under n.
+func (c *composer) register(path string, n *yaml.Node) {
+	c.doc.files[n] = path
+	for _, child := range n.Content {
+		c.register(path, child)
+	}
+}
+
+// merge layers overlay over base and returns the result. Mapping nodes that
+// combine both layers are new nodes positioned at, and registered to the file
+// of, the overlay.
+func (c *composer) merge(base, overlay *yaml.Node) *yaml.Node {
+	base, overlay = deref(base), deref(overlay)
+	switch {
+	case base == nil:
+		return overlay
+	case overlay == nil:
+		return base
+	case base.Kind == yaml.MappingNode && overlay.Kind == yaml.MappingNode:
+		out := c.derive(overlay)
+		out.Content = slices.Clone(base.Content)
+		for i := 0; i+1 < len(overlay.Content); i += 2 {
+			key, value := overlay.Content[i], overlay.Content[i+1]
+			if j := indexOf(out, key.Value); j >= 0 {
+				out.Content[j+1] = c.merge(out.Content[j+1], value)
+			} else {
+				out.Content = append(out.Content, key, value)
+			}
+		}
+		return out
+	case base.Kind == yaml.SequenceNode && overlay.Kind == yaml.SequenceNode && named(base) && named(overlay):
+		out := c.derive(overlay)
+		out.Content = slices.Clone(base.Content)
+		for _, item := range overlay.Content {
+			name := valueOf(item, "name").Value
+			j := slices.IndexFunc(out.Content, func(n *yaml.Node) bool { return valueOf(n, "name").Value == name })
+			if j >= 0 {
+				out.Content[j] = c.merge(out.Content[j], item)
+			} else {
+				out.Content = append(out.Content, item)
+			}
+		}
+		return out
+	}
+	return overlay
+}
+
+// derive returns an empty node of n's kind at n's position.
+func (c *composer) derive(n *yaml.Node) *yaml.Node {
+	out := &yaml.Node{Kind: n.Kind, Tag: n.Tag, Style: n.Style, Line: n.Line, Column: n.Column}
+	c.doc.files[out] = c.doc.files[n]
+	return out
+}
+
+// named reports whether every item of a sequence is a mapping with a scalar
+// name, as tests are.
+func named(n *yaml.Node) bool {
+	for _, item := range n.Content {
+		name := valueOf(item, "name")
+		if name == nil || name.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
+
+// withoutKeys returns a copy of the mapping node n without keys.
+func (c *composer) withoutKeys(n *yaml.Node, keys ...string) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	out := c.derive(n)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if !slices.Contains(keys, n.Content[i].Value) {
+			out.Content = append(out.Content, n.Content[i], n.Content[i+1])
+		}
+	}
+	return out
+}
+
+// interpolateRoot replaces environment variable references in the values of
+// a file's root mapping. Errors inside profiles other than the selected one
+// are not reported, so each profile can require its own variables.
+func (c *composer) interpolateRoot(path string, root *yaml.Node) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], deref(root.Content[i+1])
+		if key.Value == keyProfiles && value.Kind == yaml.MappingNode {
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				c.interpolate(path, value.Content[j+1], value.Content[j].Value == c.profile)
+			}
+			continue
+		}
+		c.interpolate(path, value, true)
+	}
+}
+
+// interpolate replaces environment variable references in the scalar values
+// under n. Errors are dropped when report is false.
+func (c *composer) interpolate(path string, n *yaml.Node, report bool) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			c.interpolate(path, n.Content[i+1], report)
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			c.interpolate(path, item, report)
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(n.Value, "$") {
+			return
+		}
+		value, err := Interpolate(n.Value, os.LookupEnv)
+		if err != nil {
+			if report {
+				c.errs = append(c.errs, c.errorAt(path, n, err))
+			}
+			return
+		}
+		if value != n.Value {
+			n.Value = value
+			if n.Style == 0 {
+				n.Tag = "" // re-resolve, so ${ROWS} can be an integer
+			}
+		}
+	}
+}
+
+// Interpolate replaces ${VAR}, ${VAR:-default} and ${VAR:?message}
+// references in s using lookup, and $$ with $.
+func Interpolate(s string, lookup func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			s = s[i+2:]
+			continue
+		case '{':
+		default:
+			b.WriteByte('$')
+			s = s[i+1:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference %q", s[i:])
+		}
+		expr := s[i+2 : i+end]
+		s = s[i+end+1:]
+
+		name, op, arg := expr, "", ""
+		if j := strings.Index(expr, ":"); j >= 0 && j+1 < len(expr) && (expr[j+1] == '-' || expr[j+1] == '?') {
+			name, op, arg = expr[:j], expr[j:j+2], expr[j+2:]
+		}
+		if !validName(name) {
+			return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+		}
+		value, _ := lookup(name)
+		if value == "" {
+			switch op {
+			case ":-":
+				value = arg
+			case ":?":
+				if arg == "" {
+					arg = "not set"
+				}
+				return "", fmt.Errorf("environment variable %s: %s", name, arg)
+			}
+		}
+		b.WriteString(value)
+	}
+}
+
+func validName(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// valueOf returns the value of key in the mapping node n, or nil.
+func valueOf(n *yaml.Node, key string) *yaml.Node {
+	n = deref(n)
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	if i := indexOf(n, key); i >= 0 {
+		return deref(n.Content[i+1])
+	}
+	return nil
+}
+
+// indexOf returns the index of key's key node in the mapping node n, or -1.
+func indexOf(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// deref follows aliases to the node they refer to.
+func deref(n *yaml.Node) *yaml.Node {
+	for n != nil && n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes name -> contents into a temporary directory and returns
+// the directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const composeBase = `options:
+  rows: 1000
+  workers: 4
+tables:
+  users:
+    rows: 50
+    columns:
+      email: "{{Email}}"
+tests:
+  - name: by id
+    query: SELECT * FROM users WHERE id = 1
+    repeat: 10
+  - name: count
+    query: SELECT COUNT(*) FROM users
+`
+
+func TestLoad_Composition(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": composeBase,
+		"fragments/orders.yaml": `tables:
+  orders:
+    references:
+      user_id: users.id
+`,
+		"ci.yaml": `extends: base.yaml
+include: [fragments/orders.yaml]
+options:
+  dsn: ${COMPOSE_TEST_DSN}
+  rows: ${COMPOSE_TEST_ROWS:-200}
+tables:
+  users:
+    columns:
+      first_name: "{{FirstName}}"
+tests:
+  - name: count
+    repeat: 3
+  - name: recent
+    query: SELECT * FROM users ORDER BY id DESC LIMIT 10
+profiles:
+  perf:
+    options:
+      rows: 10000000
+    tests:
+      - name: by id
+        repeat: 1000
+  nightly:
+    options:
+      dsn: ${COMPOSE_TEST_NIGHTLY_DSN:?set it to the nightly database}
+`,
+	})
+	t.Setenv("COMPOSE_TEST_DSN", "root@tcp(db:3306)/app")
+	path := filepath.Join(dir, "ci.yaml")
+
+	cfg, err := Load(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Options.DSN != "root@tcp(db:3306)/app" || cfg.Options.Rows != 200 || cfg.Options.Workers != 4 {
+		t.Errorf("options = %+v, want the base's workers with dsn and rows from ci.yaml", cfg.Options)
+	}
+	users := cfg.Tables["users"]
+	if users.Rows != 50 || users.Columns["email"] != "{{Email}}" || users.Columns["first_name"] != "{{FirstName}}" {
+		t.Errorf("users = %+v, want the base's settings merged with ci.yaml's", users)
+	}
+	if cfg.Tables["orders"].References["user_id"] != "users.id" {
+		t.Errorf("orders = %+v, want the included fragment's references", cfg.Tables["orders"])
+	}
+	var tests []string
+	for _, tc := range cfg.Tests {
+		tests = append(tests, tc.Name+":"+tc.Query+":"+strings.Repeat("x", tc.Repeat))
+	}
+	want := []string{
+		"by id:SELECT * FROM users WHERE id = 1:xxxxxxxxxx",
+		"count:SELECT COUNT(*) FROM users:xxx",
+		"recent:SELECT * FROM users ORDER BY id DESC LIMIT 10:",
+	}
+	if strings.Join(tests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("tests =\n%s\nwant\n%s", strings.Join(tests, "\n"), strings.Join(want, "\n"))
+	}
+	if cfg.Extends != "" || cfg.Include != nil {
+		t.Errorf("extends = %q, include = %v; composition keys should be consumed", cfg.Extends, cfg.Include)
+	}
+
+	perf, err := Load(path, "perf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perf.Options.Rows != 10_000_000 || perf.Tests[0].Repeat != 1000 || perf.Tests[0].Query == "" || perf.Profile != "perf" {
+		t.Errorf("perf: rows = %d, tests[0] = %+v", perf.Options.Rows, perf.Tests[0])
+	}
+
+	_, err = Load(path, "nightly")
+	if err == nil || !strings.Contains(err.Error(), "ci.yaml:24:12: environment variable COMPOSE_TEST_NIGHTLY_DSN: set it to the nightly database") {
+		t.Errorf("nightly: err = %v, want the unset variable with its position", err)
+	}
+
+	_, err = Load(path, "smoke")
+	if err == nil || !strings.Contains(err.Error(), `unknown profile "smoke" (available: nightly, perf)`) {
+		t.Errorf("smoke: err = %v", err)
+	}
+}
+
+func TestCompose_Errors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.yaml":       "extends: b.yaml\n",
+		"b.yaml":       "options:\n  rows: 1\nextends: a.yaml\n",
+		"missing.yaml": "include:\n  - nope.yaml\n",
+		"syntax.yaml":  "extends: bad.yaml\n",
+		"bad.yaml":     "options:\n  rows: 1\n rows: 2\n",
+	})
+	tests := []struct {
+		file, want string
+	}{
+		{"a.yaml", "b.yaml:3:10: a.yaml is already being loaded"},
+		{"missing.yaml", "missing.yaml:2:5: open "},
+		{"syntax.yaml", "bad.yaml: yaml: line"},
+	}
+	for _, tt := range tests {
+		_, err := Compose(filepath.Join(dir, tt.file), "")
+		var pe *PositionError
+		if !errors.As(err, &pe) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want a PositionError containing %q", tt.file, err, tt.want)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"HOST": "db", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"tcp(${HOST}:3306)", "tcp(db:3306)", false},
+		{"${MISSING}", "", false},
+		{"${MISSING:-localhost}", "localhost", false},
+		{"${EMPTY:-fallback}", "fallback", false},
+		{"${HOST:-fallback}", "db", false},
+		{"${MISSING:?required}", "", true},
+		{"$$5 and $HOST", "$5 and $HOST", false},
+		{"${unterminated", "", true},
+		{"${1BAD}", "", true},
+		{"{{Number 1 10}}", "{{Number 1 10}}", false},
+	}
+	for _, tt := range tests {
+		got, err := Interpolate(tt.in, lookup)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Interpolate(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -1,17 +1,24 @@
 package config
 
 import (
+	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type DistributionConfig struct {
-	Type    string             `yaml:"type"`    // zipf | normal | weighted (default: uniform)
-	S       float64            `yaml:"s"`       // zipf exponent
-	Mean    float64            `yaml:"mean"`    // normal mean (0-1, default 0.5)
-	StdDev  float64            `yaml:"stddev"`  // normal stddev (default 0.15)
+	Type    string             `yaml:"type"`    // zipf | normal | lognormal | exponential | pareto | weighted (default: uniform)
+	S       float64            `yaml:"s"`       // zipf exponent, pareto shape
+	Mean    float64            `yaml:"mean"`    // normal/lognormal mean (0-1, default 0.5), exponential mean (default 0.1)
+	StdDev  float64            `yaml:"stddev"`  // normal/lognormal stddev (default 0.15)
 	Weights map[string]float64 `yaml:"weights"` // weighted: value -> weight
+	Min     string             `yaml:"min"`     // numeric and date/time columns: lower bound, e.g. 1, 2024-01-01 or -30d
+	Max     string             `yaml:"max"`     // numeric and date/time columns: upper bound, e.g. 1e6 or now
+	Reverse bool               `yaml:"reverse"` // lognormal/exponential/pareto: skew towards max instead of min
 }
 
 type CorrelationGroup struct {
@@ -26,12 +33,168 @@ type TableConfig struct {
 	Columns        map[string]string              `yaml:"columns"`
 	Distributions  map[string]DistributionConfig  `yaml:"distributions"`
 	Correlations   []CorrelationGroup             `yaml:"correlations"`
+	NullRate       *float64                       `yaml:"null_rate"`     // default for this table's nullable columns
+	NullRates      map[string]float64             `yaml:"null_rates"`    // column -> fraction of NULL values
+	DefaultRate    *float64                       `yaml:"default_rate"`  // default for this table's columns with a DEFAULT
+	DefaultRates   map[string]float64             `yaml:"default_rates"` // column -> fraction of rows taking the column's DEFAULT
+	TimeSeries     map[string]TimeSeriesConfig    `yaml:"time_series"`   // column -> monotonic timestamps in insertion order
+	Children       map[string]CardinalityConfig   `yaml:"children_per_parent"` // FK column -> number of rows per parent row
 }
 
+// CardinalityConfig sets how many child rows each parent row gets through a
+// foreign key column. The counts are drawn from a distribution over
+// min..max, after setting aside the parents without children.
+type CardinalityConfig struct {
+	Min     int     `yaml:"min"`     // fewest children (default 0)
+	Max     int     `yaml:"max"`     // most children
+	None    float64 `yaml:"none"`    // fraction of parents without children
+	Type    string  `yaml:"type"`    // uniform (default), zipf, normal, lognormal, exponential or pareto
+	S       float64 `yaml:"s"`       // zipf exponent, pareto shape
+	Mean    float64 `yaml:"mean"`    // normal/lognormal/exponential mean, as a fraction of min..max
+	StdDev  float64 `yaml:"stddev"`  // normal/lognormal stddev, as a fraction of min..max
+	Reverse bool    `yaml:"reverse"` // lognormal/exponential/pareto: skew towards max instead of min
+}
+
+// TimeSeriesConfig generates increasing timestamps for a date or time
+// column, spread over start..end or spaced by interval. The rate of rows
+// follows the daily and weekly cycles and bursts.
+type TimeSeriesConfig struct {
+	Start    string      `yaml:"start"`     // first timestamp: a date, now or an offset such as -30d (default: 30 days before end)
+	End      string      `yaml:"end"`       // last timestamp (default now)
+	Interval string      `yaml:"interval"`  // average gap between rows, e.g. 1m; replaces start or end
+	Jitter   *float64    `yaml:"jitter"`    // 0 = evenly spaced, 1 = random arrivals (default 1)
+	Diurnal  float64     `yaml:"diurnal"`   // 0-1 amplitude of the daily cycle
+	PeakHour *int        `yaml:"peak_hour"` // UTC hour with the most rows (default 14)
+	Weekend  *float64    `yaml:"weekend"`   // weekend rate relative to weekdays (default 1)
+	Bursts   BurstConfig `yaml:"bursts"`
+}
+
+// BurstConfig places periods of increased traffic at random in a time
+// series.
+type BurstConfig struct {
+	Count    int     `yaml:"count"`
+	Duration string  `yaml:"duration"` // default 1h
+	Factor   float64 `yaml:"factor"`   // rate multiplier (default 10)
+}
+
+// Test kinds select how a test case is executed.
+const (
+	TestKindRead        = "read"        // db.Query, rows drained and counted
+	TestKindWrite       = "write"       // db.Exec, affected rows counted
+	TestKindTransaction = "transaction" // Statements run inside BEGIN…COMMIT
+)
+
 type TestCase struct {
-	Name   string `yaml:"name"`
-	Query  string `yaml:"query"`
-	Repeat int    `yaml:"repeat"` // <= 0 treated as 1
+	Name       string   `yaml:"name"`
+	Kind       string   `yaml:"kind"` // read (default), write, or transaction
+	Query      string   `yaml:"query"`
+	Params     []Param  `yaml:"params"`     // bound to ? placeholders; query is prepared once
+	Statements []string `yaml:"statements"` // transaction kind only
+	Repeat     int      `yaml:"repeat"`     // <= 0 treated as 1
+	Rollback   bool     `yaml:"rollback"`   // roll back each iteration so the dataset stays stable
+	Setup      []string `yaml:"setup"`      // run once on the test's connection before the first iteration
+	Teardown   []string `yaml:"teardown"`   // run once after the last iteration, even on failure
+	Warmup     int      `yaml:"warmup"`     // untimed iterations run before the timed ones
+	Verify     bool     `yaml:"-"`          // checksum results for cross-variant comparison; set from compare configs
+}
+
+// Param types: how a prepared query's param is bound.
+const (
+	ParamString = "string" // default
+	ParamInt    = "int"
+	ParamFloat  = "float"
+)
+
+// Param is a template bound to a ? placeholder of a prepared query, and the
+// type it is bound as. In YAML a plain string is a string param.
+type Param struct {
+	Value string `yaml:"value"`
+	Type  string `yaml:"type"` // string (default), int or float
+}
+
+// UnmarshalYAML accepts a param as a plain string or as value and type.
+func (p *Param) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*p = Param{}
+		return n.Decode(&p.Value)
+	}
+	type plain Param
+	return n.Decode((*plain)(p))
+}
+
+// MarshalYAML writes a string param as a plain string.
+func (p Param) MarshalYAML() (any, error) {
+	if p.Type == "" || p.Type == ParamString {
+		return p.Value, nil
+	}
+	type plain Param
+	return plain(p), nil
+}
+
+// ParamValues returns the templates of params.
+func ParamValues(params []Param) []string {
+	values := make([]string, len(params))
+	for i, p := range params {
+		values[i] = p.Value
+	}
+	return values
+}
+
+// EffectiveKind returns the test kind, defaulting to read.
+func (tc TestCase) EffectiveKind() string {
+	if tc.Kind == "" {
+		return TestKindRead
+	}
+	return tc.Kind
+}
+
+// Validate checks that the fields required by the test kind are set.
+func (tc TestCase) Validate() error {
+	switch tc.EffectiveKind() {
+	case TestKindRead:
+		if tc.Rollback {
+			return fmt.Errorf("test %q: rollback only applies to write and transaction tests", tc.Name)
+		}
+		fallthrough
+	case TestKindWrite:
+		if len(tc.Statements) > 0 {
+			return fmt.Errorf("test %q: statements are only used by transaction tests; use query", tc.Name)
+		}
+		if strings.TrimSpace(tc.Query) == "" {
+			return fmt.Errorf("test %q: %s tests need a query", tc.Name, tc.EffectiveKind())
+		}
+	case TestKindTransaction:
+		if tc.Query != "" {
+			return fmt.Errorf("test %q: transaction tests use statements, not query", tc.Name)
+		}
+		if len(tc.Statements) == 0 {
+			return fmt.Errorf("test %q: transaction tests need at least one statement", tc.Name)
+		}
+	default:
+		return fmt.Errorf("test %q: unknown kind %q (expected read, write, or transaction)", tc.Name, tc.Kind)
+	}
+	if len(tc.Params) > 0 {
+		if tc.EffectiveKind() == TestKindTransaction {
+			return fmt.Errorf("test %q: params are not supported for transaction tests", tc.Name)
+		}
+		if strings.Contains(tc.Query, "{{") {
+			return fmt.Errorf("test %q: prepared queries must not contain templates; put them in params", tc.Name)
+		}
+		for i, p := range tc.Params {
+			switch p.Type {
+			case "", ParamString, ParamInt, ParamFloat:
+			default:
+				return fmt.Errorf("test %q: param %d has unknown type %q (expected string, int, or float)", tc.Name, i+1, p.Type)
+			}
+		}
+	}
+	if tc.Verify && tc.EffectiveKind() != TestKindRead {
+		return fmt.Errorf("test %q: verify only applies to read tests", tc.Name)
+	}
+	if tc.Warmup < 0 {
+		return fmt.Errorf("test %q: warmup must not be negative", tc.Name)
+	}
+	return nil
 }
 
 type ChildrenPerParent struct {
@@ -51,55 +214,133 @@ type Options struct {
 	LoadData          bool             `yaml:"load_data"`
 	DeferIndexes      bool             `yaml:"defer_indexes"`
 	FKSampleSize      int              `yaml:"fk_sample_size"`
+	Seed              int64            `yaml:"seed"` // non-zero makes generated data reproducible
+	Percentiles       []float64        `yaml:"percentiles"` // latency percentiles to report, e.g. [50, 99, 99.9]
+	NullRate          *float64         `yaml:"null_rate"`    // fraction of NULLs in nullable columns (default 0.1)
+	DefaultRate       *float64         `yaml:"default_rate"` // fraction of rows taking a column's DEFAULT (default 0)
 }
 
+// DefaultNullRate is the fraction of NULL values generated for nullable
+// columns without a configured null rate.
+const DefaultNullRate = 0.1
+
 type Config struct {
-	Options Options                `yaml:"options"`
-	Tables  map[string]TableConfig `yaml:"tables"`
-	Tests   []TestCase             `yaml:"tests"`
+	Extends  string                 `yaml:"extends"` // base config this file overrides, relative to this file
+	Include  []string               `yaml:"include"` // config fragments layered over the base, in order
+	Options  Options                `yaml:"options"`
+	Tables   map[string]TableConfig `yaml:"tables"`
+	Tests    []TestCase             `yaml:"tests"`
+	Profiles map[string]Profile     `yaml:"profiles"` // named overrides selected with --profile
+	Path     string                 `yaml:"-"`        // file the config was loaded from; empty for defaults
+	Profile  string                 `yaml:"-"`        // applied profile; empty for none
 }
 
-// Load reads and parses a YAML config file.
+// DefaultFile is the config file LoadOrDefault looks for in the current
+// directory.
+const DefaultFile = "go-test-my-db.yaml"
+
+// Load reads and parses a YAML config file, composed with the files it
+// extends and includes and with the named profile applied (see Compose).
 // If path is empty, it returns an empty Config.
 // If the file does not exist and path was auto-detected, the caller should
 // handle the error; use LoadOrDefault for auto-detection.
-func Load(path string) (*Config, error) {
+func Load(path, profile string) (*Config, error) {
 	if path == "" {
+		if profile != "" {
+			return nil, fmt.Errorf("profile %q needs a config file", profile)
+		}
 		return &Config{}, nil
 	}
 
-	data, err := os.ReadFile(path)
+	doc, err := Compose(path, profile)
 	if err != nil {
 		return nil, err
 	}
 
+	// Decode each file on its own first, so type errors name their file.
+	for _, src := range doc.Sources {
+		if src.Root == nil {
+			continue
+		}
+		if err := src.Root.Decode(&Config{}); err != nil {
+			return nil, fmt.Errorf("%s: %w", src.Path, err)
+		}
+	}
+
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+	if doc.Root != nil {
+		if err := doc.Root.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
 	}
 
 	if cfg.Tables == nil {
 		cfg.Tables = make(map[string]TableConfig)
 	}
+	cfg.Path = path
+	cfg.Profile = profile
+
+	for _, tc := range cfg.Tests {
+		if err := tc.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.validateRates(); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
 
+// validateRates checks that every null and default rate is a fraction.
+func (c *Config) validateRates() error {
+	check := func(where string, rate *float64) error {
+		if rate != nil && (*rate < 0 || *rate > 1) {
+			return fmt.Errorf("%s %v must be between 0 and 1", where, *rate)
+		}
+		return nil
+	}
+	if err := check("options.null_rate", c.Options.NullRate); err != nil {
+		return err
+	}
+	if err := check("options.default_rate", c.Options.DefaultRate); err != nil {
+		return err
+	}
+	for name, tc := range c.Tables {
+		if err := check("tables."+name+".null_rate", tc.NullRate); err != nil {
+			return err
+		}
+		if err := check("tables."+name+".default_rate", tc.DefaultRate); err != nil {
+			return err
+		}
+		for col, rate := range tc.NullRates {
+			if err := check("null rate of "+name+"."+col, &rate); err != nil {
+				return err
+			}
+		}
+		for col, rate := range tc.DefaultRates {
+			if err := check("default rate of "+name+"."+col, &rate); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // LoadOrDefault tries to load from the given path. If path is empty, it
 // attempts to auto-detect "go-test-my-db.yaml" in the current directory.
 // Returns an empty Config if no file is found at the auto-detect path.
-func LoadOrDefault(path string) (*Config, error) {
+func LoadOrDefault(path, profile string) (*Config, error) {
 	if path != "" {
-		return Load(path)
+		return Load(path, profile)
 	}
 
-	const defaultFile = "go-test-my-db.yaml"
-	if _, err := os.Stat(defaultFile); err != nil {
+	if _, err := os.Stat(DefaultFile); err != nil {
 		// File doesn't exist — that's fine, return empty config.
-		return &Config{}, nil
+		return Load("", profile)
 	}
 
-	return Load(defaultFile)
+	return Load(DefaultFile, profile)
 }
 
 // GetReferences returns all configured references as
@@ -136,6 +377,30 @@ func (c *Config) GetDistribution(table, column string) *DistributionConfig {
 	return nil
 }
 
+// GetTimeSeries returns the time series config for a given table and
+// column, or nil if none is configured.
+func (c *Config) GetTimeSeries(table, column string) *TimeSeriesConfig {
+	if c == nil {
+		return nil
+	}
+	if ts, ok := c.Tables[table].TimeSeries[column]; ok {
+		return &ts
+	}
+	return nil
+}
+
+// GetChildren returns the foreign key column of a table with a
+// children_per_parent config, and the config, or "" and nil if none is
+// configured. With several, it returns the first column by name.
+func (c *Config) GetChildren(table string) (string, *CardinalityConfig) {
+	if c == nil || len(c.Tables[table].Children) == 0 {
+		return "", nil
+	}
+	columns := slices.Sorted(maps.Keys(c.Tables[table].Children))
+	card := c.Tables[table].Children[columns[0]]
+	return columns[0], &card
+}
+
 // GetCorrelations returns the correlation groups for a given table,
 // or nil if none are configured.
 func (c *Config) GetCorrelations(table string) []CorrelationGroup {
@@ -149,6 +414,46 @@ func (c *Config) GetCorrelations(table string) []CorrelationGroup {
 	return tc.Correlations
 }
 
+// NullRate returns the fraction of NULL values to generate for a nullable
+// column: its null_rates entry, else the table's null_rate, else
+// options.null_rate, else DefaultNullRate.
+func (c *Config) NullRate(table, column string) float64 {
+	if c == nil {
+		return DefaultNullRate
+	}
+	tc := c.Tables[table]
+	if rate, ok := tc.NullRates[column]; ok {
+		return rate
+	}
+	if tc.NullRate != nil {
+		return *tc.NullRate
+	}
+	if c.Options.NullRate != nil {
+		return *c.Options.NullRate
+	}
+	return DefaultNullRate
+}
+
+// DefaultRate returns the fraction of rows that take a column's DEFAULT
+// instead of a generated value: its default_rates entry, else the table's
+// default_rate, else options.default_rate, else 0.
+func (c *Config) DefaultRate(table, column string) float64 {
+	if c == nil {
+		return 0
+	}
+	tc := c.Tables[table]
+	if rate, ok := tc.DefaultRates[column]; ok {
+		return rate
+	}
+	if tc.DefaultRate != nil {
+		return *tc.DefaultRate
+	}
+	if c.Options.DefaultRate != nil {
+		return *c.Options.DefaultRate
+	}
+	return 0
+}
+
 // GetTemplate returns the template string for a given table and column,
 // or empty string if none is configured.
 func (c *Config) GetTemplate(table, column string) string {
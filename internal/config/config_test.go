@@ -0,0 +1,111 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestTestCaseValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tc      TestCase
+		wantErr bool
+	}{
+		{"default read", TestCase{Name: "r", Query: "SELECT 1"}, false},
+		{"write", TestCase{Name: "w", Kind: TestKindWrite, Query: "DELETE FROM t", Rollback: true}, false},
+		{"transaction", TestCase{Name: "tx", Kind: TestKindTransaction, Statements: []string{"UPDATE t SET a = 1"}}, false},
+		{"read with rollback", TestCase{Name: "r", Query: "SELECT 1", Rollback: true}, true},
+		{"write with statements", TestCase{Name: "w", Kind: TestKindWrite, Statements: []string{"DELETE FROM t"}}, true},
+		{"transaction without statements", TestCase{Name: "tx", Kind: TestKindTransaction}, true},
+		{"transaction with query", TestCase{Name: "tx", Kind: TestKindTransaction, Query: "SELECT 1", Statements: []string{"SELECT 1"}}, true},
+		{"unknown kind", TestCase{Name: "x", Kind: "bulk", Query: "SELECT 1"}, true},
+		{"prepared read", TestCase{Name: "p", Query: "SELECT * FROM t WHERE id = ?", Params: []Param{{Value: "{{Number 1 10}}"}}}, false},
+		{"prepared transaction", TestCase{Name: "p", Kind: TestKindTransaction, Statements: []string{"SELECT 1"}, Params: []Param{{Value: "1"}}}, true},
+		{"typed params", TestCase{Name: "p", Query: "SELECT ?, ?", Params: []Param{{Value: "1", Type: ParamInt}, {Value: "1.5", Type: ParamFloat}}}, false},
+		{"unknown param type", TestCase{Name: "p", Query: "SELECT ?", Params: []Param{{Value: "1", Type: "integer"}}}, true},
+		{"prepared templated query", TestCase{Name: "p", Query: "SELECT * FROM {{.t}} WHERE id = ?", Params: []Param{{Value: "1"}}}, true},
+		{"read without query", TestCase{Name: "r"}, true},
+		{"write with blank query", TestCase{Name: "w", Kind: TestKindWrite, Query: "  "}, true},
+		{"negative warmup", TestCase{Name: "r", Query: "SELECT 1", Warmup: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tc.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_Params(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"c.yaml": "tests:\n  - name: p\n    query: SELECT * FROM t WHERE id = ? AND code = ?\n    params: ['{{Number 1 10}}', {value: '42', type: int}]\n",
+	})
+	cfg, err := Load(dir+"/c.yaml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Param{{Value: "{{Number 1 10}}"}, {Value: "42", Type: ParamInt}}
+	if got := cfg.Tests[0].Params; !reflect.DeepEqual(got, want) {
+		t.Errorf("Params = %#v, want %#v", got, want)
+	}
+	out, err := yaml.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out); got != "- '{{Number 1 10}}'\n- value: \"42\"\n  type: int\n" {
+		t.Errorf("Marshal(params) = %q", got)
+	}
+}
+
+func TestNullRate(t *testing.T) {
+	global, table := 0.3, 0.6
+	cfg := &Config{
+		Options: Options{NullRate: &global},
+		Tables: map[string]TableConfig{
+			"users":  {NullRate: &table, NullRates: map[string]float64{"deleted_at": 0.95, "bio": 0}},
+			"orders": {},
+		},
+	}
+	tests := []struct {
+		cfg           *Config
+		table, column string
+		want          float64
+	}{
+		{cfg, "users", "deleted_at", 0.95},
+		{cfg, "users", "bio", 0},
+		{cfg, "users", "nickname", 0.6},
+		{cfg, "orders", "note", 0.3},
+		{&Config{}, "orders", "note", DefaultNullRate},
+		{nil, "orders", "note", DefaultNullRate},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.NullRate(tt.table, tt.column); got != tt.want {
+			t.Errorf("NullRate(%s, %s) = %v, want %v", tt.table, tt.column, got, tt.want)
+		}
+	}
+	if got := cfg.DefaultRate("users", "status"); got != 0 {
+		t.Errorf("DefaultRate without config = %v, want 0", got)
+	}
+}
+
+func TestLoad_Rates(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ok.yaml":  "options:\n  null_rate: 0.2\ntables:\n  users:\n    null_rates: {deleted_at: 0.95}\n    default_rates: {status: 1}\n",
+		"bad.yaml": "tables:\n  users:\n    null_rates: {deleted_at: 95}\n",
+	})
+	cfg, err := Load(dir+"/ok.yaml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.NullRate("users", "deleted_at") != 0.95 || cfg.NullRate("users", "bio") != 0.2 || cfg.DefaultRate("users", "status") != 1 {
+		t.Errorf("rates not loaded: %+v", cfg.Tables["users"])
+	}
+	if _, err := Load(dir+"/bad.yaml", ""); err == nil || err.Error() != "null rate of users.deleted_at 95 must be between 0 and 1" {
+		t.Errorf("Load(bad) error = %v", err)
+	}
+}
@@ -0,0 +1,402 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// SchemaURL and CompareSchemaURL are where the published JSON Schemas of
+// config and comparison files live. Editors with the YAML language server
+// pick them up from a "# yaml-language-server: $schema=<url>" comment.
+const (
+	SchemaURL        = "https://raw.githubusercontent.com/tomfevang/go-test-my-db/main/schema/config.schema.json"
+	CompareSchemaURL = "https://raw.githubusercontent.com/tomfevang/go-test-my-db/main/schema/compare.schema.json"
+)
+
+// fieldDocs describes every YAML key of the config types, keyed by
+// "Type.key". TestFieldDocs keeps it complete.
+var fieldDocs = map[string]string{
+	"Config.extends":  "Base config this file overrides, relative to this file. Mappings merge key by key, tests merge by name, other values replace the base's.",
+	"Config.include":  "Config fragments layered over the base in order, relative to this file. This file overrides them.",
+	"Config.options":  "Connection and run options. Command-line flags take precedence.",
+	"Config.tables":   "Per-table generation settings, keyed by table name.",
+	"Config.tests":    "Test queries run by the test command after seeding.",
+	"Config.profiles": "Named overrides selected with --profile or SEED_PROFILE, e.g. smoke and perf.",
+
+	"Profile.options": "Options overriding the config's.",
+	"Profile.tables":  "Table settings merged into the config's.",
+	"Profile.tests":   "Tests merged into the config's by name; a test with a new name is added.",
+
+	"Options.dsn":                 "MySQL DSN, e.g. user:pass@tcp(localhost:3306)/mydb. The SEED_DSN environment variable takes precedence.",
+	"Options.schema":              "Path to the SQL DDL file the test command creates tables from.",
+	"Options.seed_tables":         "Tables to seed. Parent tables they reference are included automatically. Defaults to all tables.",
+	"Options.rows":                "Rows per root table (default 1000). Child tables get children_per_parent rows per parent row.",
+	"Options.batch_size":          "Rows per INSERT statement (default 1000).",
+	"Options.workers":             "Concurrent insert workers (default 4).",
+	"Options.children_per_parent": "Range of child rows generated per parent row.",
+	"Options.max_rows":            "Maximum rows per table, a safeguard for deep hierarchies (default 10000000).",
+	"Options.load_data":           "Use LOAD DATA LOCAL INFILE for faster bulk loading. Requires local_infile=ON on the server.",
+	"Options.defer_indexes":       "Drop secondary indexes before seeding and rebuild them afterwards.",
+	"Options.fk_sample_size":      "Maximum parent values cached per foreign key column (default 500000, 0 = unlimited).",
+	"Options.seed":                "Random seed. A non-zero seed makes the generated data reproducible; tables are then inserted by a single worker so auto-increment IDs are too.",
+	"Options.percentiles":         "Latency percentiles to report, e.g. [50, 95, 99.9]. Min and max are always shown.",
+	"Options.null_rate":           "Fraction of NULL values in nullable columns, from 0 to 1 (default 0.1).",
+	"Options.default_rate":        "Fraction of rows that take a column's DEFAULT instead of a generated value, from 0 to 1 (default 0).",
+
+	"ChildrenPerParent.min": "Minimum child rows per parent row (default 10).",
+	"ChildrenPerParent.max": "Maximum child rows per parent row (default 100).",
+
+	"TableConfig.rows":                "Rows to generate for this table, overriding the computed count.",
+	"TableConfig.references":          "Logical foreign keys without a database constraint: column -> \"table.column\".",
+	"TableConfig.columns":             "gofakeit templates generating column values, e.g. email: \"{{Email}}\". Templates read the row's other columns as {{.column}}, parent rows as {{.parent.table.column}}, and can use Add, Sub, Mul, Div, Round, AddDays, AddDuration, After and Before.",
+	"TableConfig.distributions":       "How enum, set and foreign key columns pick among their values, and numeric and date/time columns spread over a range, keyed by column.",
+	"TableConfig.correlations":        "Groups of columns generated together so their values are coherent.",
+	"TableConfig.null_rate":           "Fraction of NULL values in this table's nullable columns, overriding options.null_rate.",
+	"TableConfig.null_rates":          "Fraction of NULL values per nullable column, e.g. deleted_at: 0.95.",
+	"TableConfig.default_rate":        "Fraction of rows taking the DEFAULT of this table's columns, overriding options.default_rate.",
+	"TableConfig.default_rates":       "Fraction of rows taking the column's DEFAULT, per column.",
+	"TableConfig.time_series":         "Columns generated as increasing timestamps in insertion order, keyed by column. The table is inserted with a single worker so auto-increment IDs follow the timestamps.",
+	"TableConfig.children_per_parent": "Child rows per parent row, keyed by the foreign key column leading to the parent, e.g. user_id: {max: 500, type: zipf, none: 0.2}. Every parent gets its children, generated grouped by parent, and the table's rows are their total. Set it for one column per table.",
+
+	"CardinalityConfig.min":     "Fewest children of a parent that has children (default 0).",
+	"CardinalityConfig.max":     "Most children of a parent.",
+	"CardinalityConfig.none":    "Fraction of parents without children, from 0 to 1 (default 0).",
+	"CardinalityConfig.type":    "Distribution of the children per parent over min..max (default uniform).",
+	"CardinalityConfig.s":       "Zipf exponent; larger values give more parents few children (default 1). Pareto shape; smaller values give a longer tail (default 1.16).",
+	"CardinalityConfig.mean":    "Center of normal and lognormal distributions (default 0.5), or the mean of exponential ones (default 0.1), as a fraction of min..max.",
+	"CardinalityConfig.stddev":  "Standard deviation as a fraction of min..max (default 0.15).",
+	"CardinalityConfig.reverse": "Skew lognormal, exponential and pareto counts towards max instead of min.",
+
+	"TimeSeriesConfig.start":     "First timestamp: a date, a datetime, now, or an offset from now such as -30d (default: 30 days before end).",
+	"TimeSeriesConfig.end":       "Last timestamp, in the same forms as start (default now).",
+	"TimeSeriesConfig.interval":  "Average gap between rows, e.g. 30s, 1m or 1d. Set at most two of start, end and interval; with interval the series runs from start or up to end.",
+	"TimeSeriesConfig.jitter":    "Randomness of the gaps, from 0 (evenly spaced) to 1 (random arrivals, the default).",
+	"TimeSeriesConfig.diurnal":   "Strength of the daily cycle, from 0 (none, the default) to 1 (no rows at the quietest hour).",
+	"TimeSeriesConfig.peak_hour": "UTC hour of day with the most rows (default 14).",
+	"TimeSeriesConfig.weekend":   "Rate of rows on Saturdays and Sundays relative to weekdays (default 1).",
+	"TimeSeriesConfig.bursts":    "Periods of increased traffic placed at random.",
+
+	"BurstConfig.count":    "Number of bursts (default 0).",
+	"BurstConfig.duration": "Length of each burst, e.g. 1h or 2d (default 1h).",
+	"BurstConfig.factor":   "Rate of rows during a burst relative to the usual rate (default 10).",
+
+	"DistributionConfig.type":    "Distribution of picked or generated values (default uniform).",
+	"DistributionConfig.s":       "Zipf exponent; larger values concentrate picks on fewer values (default 1). Pareto shape; smaller values give a longer tail (default 1.16, the 80/20 rule).",
+	"DistributionConfig.mean":    "Center of normal and lognormal distributions (default 0.5), or the mean of exponential ones (default 0.1), as a fraction of the value range. Lognormal ranges use a log scale.",
+	"DistributionConfig.stddev":  "Standard deviation as a fraction of the value range (default 0.15).",
+	"DistributionConfig.weights": "Relative weight per value. Values without a weight get weight 1.",
+	"DistributionConfig.min":     "Lowest value of a numeric or date/time column: a number, a date, a time of day, now, or an offset from now such as -30d (default: the type-based range, or five years ago for dates).",
+	"DistributionConfig.max":     "Highest value of a numeric or date/time column, in the same forms as min (default: the type-based range, or now for dates).",
+	"DistributionConfig.reverse": "Skew towards max instead of min, e.g. for recent dates.",
+
+	"CorrelationGroup.columns":  "Columns generated together, in the order listed.",
+	"CorrelationGroup.source":   "Where the group's values come from.",
+	"CorrelationGroup.template": "Template per column for source: template. Each template sees the values of the columns before it, e.g. {{.first_name}}.",
+
+	"TestCase.name":       "Test name, shown in reports and used to match tests across runs.",
+	"TestCase.kind":       "How the test is executed (default read).",
+	"TestCase.query":      "SQL statement of read and write tests. May use templates such as {{(SampleRow \"users\" \"id\").id}}.",
+	"TestCase.params":     "Params bound to the query's ? placeholders; the query is prepared once. A param is a template, bound as a string, or a value with a type.",
+	"TestCase.statements": "Statements of a transaction test, run inside BEGIN ... COMMIT.",
+	"TestCase.repeat":     "Timed runs (default 1).",
+	"TestCase.rollback":   "Roll back each run of a write or transaction test so the dataset stays stable.",
+	"TestCase.setup":      "Statements run once on the test's connection before the first run.",
+	"TestCase.teardown":   "Statements run once after the last run, even when the test fails.",
+	"TestCase.warmup":     "Untimed runs before the timed ones.",
+
+	"Param.value": "Template rendered for each run.",
+	"Param.type":  "How the rendered value is bound (default string).",
+
+	"CompareConfig.configs": "Seed configs to compare, each with a label.",
+	"CompareConfig.tests":   "Tests with a query variant per config label.",
+
+	"CompareConfigEntry.label":   "Label identifying the config in tests and reports.",
+	"CompareConfigEntry.file":    "Path to the seed config, relative to this file.",
+	"CompareConfigEntry.profile": "Profile of the seed config to apply, so one file can be compared under several profiles.",
+	"CompareConfigEntry.keep":    "Leave this config's tables in place after the run.",
+
+	"CompareTest.name":       "Test name, shown in reports.",
+	"CompareTest.kind":       "How the test is executed (default read).",
+	"CompareTest.repeat":     "Timed runs per config (default 1).",
+	"CompareTest.rollback":   "Roll back each run of a write or transaction test.",
+	"CompareTest.queries":    "SQL statement per config label. Configs without one skip the test.",
+	"CompareTest.params":     "Params bound to ? placeholders, per config label.",
+	"CompareTest.statements": "Transaction statements per config label.",
+	"CompareTest.setup":      "Setup statements per config label.",
+	"CompareTest.teardown":   "Teardown statements per config label.",
+	"CompareTest.warmup":     "Untimed runs before the timed ones.",
+	"CompareTest.verify":     "Check that every variant returns the same rows.",
+}
+
+// testKinds documents the values of the kind key of tests.
+var testKinds = []enumValue{
+	{TestKindRead, "Query rows; the rows are drained and counted."},
+	{TestKindWrite, "Execute a statement and count the affected rows."},
+	{TestKindTransaction, "Run statements inside BEGIN ... COMMIT."},
+}
+
+// correlationSources documents the values of the source key of correlations.
+var correlationSources = []enumValue{
+	{"address", "A coherent address: country, state, city, street and zip columns."},
+	{"person", "A coherent person: first_name, last_name, email and phone columns."},
+	{"latlong", "A latitude/longitude pair."},
+	{"template", "User-defined templates per column, see template."},
+}
+
+// childrenTypes documents the values of the type key of children_per_parent.
+var childrenTypes = []enumValue{
+	{"uniform", "Every count from min to max is equally likely."},
+	{"zipf", "Most parents have few children and a few have many, like customers and orders."},
+	{"normal", "Counts cluster around mean."},
+	{"lognormal", "Like normal on a log scale: many parents with few children and a long tail of large families."},
+	{"exponential", "Counts decay away from min, or from max when reversed."},
+	{"pareto", "A heavy tail: most parents have close to min children and a few far more."},
+}
+
+// paramTypes documents the values of the type key of params.
+var paramTypes = []enumValue{
+	{ParamString, "Bind the value as a string."},
+	{ParamInt, "Bind the value as an integer; it must render as one."},
+	{ParamFloat, "Bind the value as a floating-point number; it must render as one."},
+}
+
+type enumValue struct {
+	value, description string
+}
+
+// JSONSchema returns the JSON Schema of seed config files
+// (go-test-my-db.yaml).
+func JSONSchema() *jsonschema.Schema {
+	s := schemaFor(reflect.TypeOf(Config{}))
+	s.Schema = "https://json-schema.org/draft/2020-12/schema"
+	s.ID = SchemaURL
+	s.Title = "go-test-my-db config"
+
+	optionsSchema(s.Properties["options"])
+	tableSchema(s.Properties["tables"].AdditionalProperties)
+	testSchema(s.Properties["tests"].Items)
+
+	// A profile's tests may override single keys of a test of the same name,
+	// so only their name is required.
+	profile := s.Properties["profiles"].AdditionalProperties
+	optionsSchema(profile.Properties["options"])
+	tableSchema(profile.Properties["tables"].AdditionalProperties)
+	test := profile.Properties["tests"].Items
+	test.Required = []string{"name"}
+	test.Properties["kind"] = enumSchema(test.Properties["kind"].Description, testKinds)
+	paramsSchema(test.Properties["params"])
+	return s
+}
+
+// optionsSchema adds the value ranges to the schema of options.
+func optionsSchema(opts *jsonschema.Schema) {
+	positive(opts.Properties["percentiles"].Items, 0).ExclusiveMaximum = ptr(100.0)
+	fraction(opts.Properties["null_rate"])
+	fraction(opts.Properties["default_rate"])
+	for _, key := range []string{"rows", "batch_size", "workers", "max_rows", "fk_sample_size"} {
+		nonNegative(opts.Properties[key])
+	}
+	for _, key := range []string{"min", "max"} {
+		nonNegative(opts.Properties["children_per_parent"].Properties[key])
+	}
+}
+
+// tableSchema adds the reference form, the distribution types, the time
+// series ranges, the children per parent and the correlation sources to the
+// schema of a table.
+func tableSchema(table *jsonschema.Schema) {
+	nonNegative(table.Properties["rows"])
+	fraction(table.Properties["null_rate"])
+	fraction(table.Properties["default_rate"])
+	fraction(table.Properties["null_rates"].AdditionalProperties)
+	fraction(table.Properties["default_rates"].AdditionalProperties)
+	table.Properties["references"].AdditionalProperties.Pattern = `^[^.]+\.[^.]+$`
+	table.Properties["distributions"].AdditionalProperties = distributionSchema()
+	series := table.Properties["time_series"].AdditionalProperties
+	fraction(series.Properties["jitter"])
+	fraction(series.Properties["diurnal"])
+	nonNegative(series.Properties["peak_hour"]).Maximum = ptr(23.0)
+	nonNegative(series.Properties["weekend"])
+	nonNegative(series.Properties["bursts"].Properties["count"])
+	nonNegative(series.Properties["bursts"].Properties["factor"])
+	children := table.Properties["children_per_parent"].AdditionalProperties
+	children.Required = []string{"max"}
+	children.Properties["type"] = enumSchema(children.Properties["type"].Description, childrenTypes)
+	nonNegative(children.Properties["min"])
+	positive(children.Properties["max"], 0)
+	fraction(children.Properties["none"])
+	positive(children.Properties["s"], 0)
+	nonNegative(children.Properties["mean"])
+	nonNegative(children.Properties["stddev"])
+	correlation := table.Properties["correlations"].Items
+	correlation.Properties["source"] = enumSchema(correlation.Properties["source"].Description, correlationSources)
+	correlation.Required = []string{"columns", "source"}
+	correlation.If = &jsonschema.Schema{Properties: map[string]*jsonschema.Schema{"source": {Const: ptr[any]("template")}}}
+	correlation.Then = &jsonschema.Schema{Required: []string{"template"}}
+}
+
+// CompareJSONSchema returns the JSON Schema of comparison config files.
+func CompareJSONSchema() *jsonschema.Schema {
+	s := schemaFor(reflect.TypeOf(CompareConfig{}))
+	s.Schema = "https://json-schema.org/draft/2020-12/schema"
+	s.ID = CompareSchemaURL
+	s.Title = "go-test-my-db comparison config"
+	s.Required = []string{"configs"}
+	s.Properties["configs"].MinItems = ptr(1)
+	s.Properties["configs"].Items.Required = []string{"label", "file"}
+
+	test := s.Properties["tests"].Items
+	test.Required = []string{"name"}
+	test.Properties["kind"] = enumSchema(test.Properties["kind"].Description, testKinds)
+	nonNegative(test.Properties["warmup"])
+	paramsSchema(test.Properties["params"].AdditionalProperties)
+	return s
+}
+
+// testSchema adds the kind values and the kind-specific keys to the schema
+// of a test.
+func testSchema(s *jsonschema.Schema) {
+	s.Required = []string{"name"}
+	s.Properties["kind"] = enumSchema(s.Properties["kind"].Description, testKinds)
+	nonNegative(s.Properties["warmup"])
+	paramsSchema(s.Properties["params"])
+	kind := func(k string) *jsonschema.Schema {
+		return &jsonschema.Schema{Properties: map[string]*jsonschema.Schema{"kind": {Const: ptr[any](k)}}, Required: []string{"kind"}}
+	}
+	s.AllOf = []*jsonschema.Schema{
+		{
+			If:   kind(TestKindTransaction),
+			Then: &jsonschema.Schema{Required: []string{"statements"}, Not: &jsonschema.Schema{Required: []string{"query"}}},
+			Else: &jsonschema.Schema{Required: []string{"query"}, Not: &jsonschema.Schema{Required: []string{"statements"}}},
+		},
+	}
+}
+
+// paramsSchema lets each param of a params list be a plain template or a
+// value with a type.
+func paramsSchema(params *jsonschema.Schema) {
+	typed := params.Items
+	typed.Required = []string{"value"}
+	typed.Properties["type"] = enumSchema(typed.Properties["type"].Description, paramTypes)
+	params.Items = &jsonschema.Schema{AnyOf: []*jsonschema.Schema{{Type: "string"}, typed}}
+}
+
+// distributionSchema describes each distribution type with the keys it
+// uses.
+func distributionSchema() *jsonschema.Schema {
+	base := schemaFor(reflect.TypeOf(DistributionConfig{}))
+	variant := func(typ, description string, keys ...string) *jsonschema.Schema {
+		v := &jsonschema.Schema{
+			Type:                 "object",
+			Description:          description,
+			Properties:           map[string]*jsonschema.Schema{"type": {Const: ptr[any](typ)}},
+			PropertyOrder:        append([]string{"type"}, keys...),
+			Required:             []string{"type"},
+			AdditionalProperties: falseSchema(),
+		}
+		for _, k := range keys {
+			v.Properties[k] = base.Properties[k].CloneSchemas()
+		}
+		return v
+	}
+
+	bounds := []string{"min", "max"}
+	for _, k := range bounds {
+		base.Properties[k].Type = ""
+		base.Properties[k].Types = []string{"number", "string"}
+	}
+	uniform := variant("uniform", "Every value is equally likely.", bounds...)
+	uniform.Required = nil
+	zipf := variant("zipf", "A few values are picked far more often than the rest, like popular products.", "s")
+	positive(zipf.Properties["s"], 0)
+	normal := variant("normal", "Values cluster around mean.", append([]string{"mean", "stddev"}, bounds...)...)
+	fraction(normal.Properties["mean"])
+	nonNegative(normal.Properties["stddev"])
+	lognormal := variant("lognormal", "Like normal on a log scale: many small values and a long tail of large ones, like order amounts.", append([]string{"mean", "stddev", "reverse"}, bounds...)...)
+	exponential := variant("exponential", "Values decay away from min, or from max when reversed, like recent timestamps.", append([]string{"mean", "reverse"}, bounds...)...)
+	positive(exponential.Properties["mean"], 0)
+	pareto := variant("pareto", "A heavy tail: most values sit near min and a few are far larger, like quantities.", append([]string{"s", "reverse"}, bounds...)...)
+	weighted := variant("weighted", "Each value is picked in proportion to its weight.", "weights")
+	weighted.Required = append(weighted.Required, "weights")
+	weighted.Properties["weights"].MinProperties = ptr(1)
+	nonNegative(weighted.Properties["weights"].AdditionalProperties)
+
+	return &jsonschema.Schema{
+		Description: "How enum, set and foreign key columns pick among their values, or numeric and date/time columns draw from min to max. The keys besides type depend on the type.",
+		OneOf:       []*jsonschema.Schema{uniform, zipf, normal, lognormal, exponential, pareto, weighted},
+	}
+}
+
+// schemaFor derives a schema from a config type using its YAML keys and
+// fieldDocs. Structs reject unknown keys, so editors flag typos.
+func schemaFor(t reflect.Type) *jsonschema.Schema {
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &jsonschema.Schema{
+			Type:                 "object",
+			Properties:           make(map[string]*jsonschema.Schema),
+			AdditionalProperties: falseSchema(),
+		}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if key == "-" || key == "" || !f.IsExported() {
+				continue
+			}
+			prop := schemaFor(f.Type)
+			prop.Description = fieldDocs[t.Name()+"."+key]
+			s.Properties[key] = prop
+			s.PropertyOrder = append(s.PropertyOrder, key)
+		}
+		return s
+	case reflect.Pointer:
+		return schemaFor(t.Elem())
+	case reflect.Map:
+		return &jsonschema.Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem())}
+	case reflect.Slice:
+		return &jsonschema.Schema{Type: "array", Items: schemaFor(t.Elem())}
+	case reflect.String:
+		return &jsonschema.Schema{Type: "string"}
+	case reflect.Bool:
+		return &jsonschema.Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &jsonschema.Schema{Type: "integer"}
+	case reflect.Float64:
+		return &jsonschema.Schema{Type: "number"}
+	}
+	panic(fmt.Sprintf("config schema: unsupported type %s", t))
+}
+
+// enumSchema returns a string schema accepting values, each documented.
+func enumSchema(description string, values []enumValue) *jsonschema.Schema {
+	s := &jsonschema.Schema{Type: "string", Description: description}
+	for _, v := range values {
+		s.AnyOf = append(s.AnyOf, &jsonschema.Schema{Const: ptr[any](v.value), Description: v.description})
+	}
+	return s
+}
+
+func nonNegative(s *jsonschema.Schema) *jsonschema.Schema {
+	s.Minimum = ptr(0.0)
+	return s
+}
+
+func fraction(s *jsonschema.Schema) *jsonschema.Schema {
+	s.Minimum = ptr(0.0)
+	s.Maximum = ptr(1.0)
+	return s
+}
+
+func positive(s *jsonschema.Schema, above float64) *jsonschema.Schema {
+	s.ExclusiveMinimum = ptr(above)
+	return s
+}
+
+func falseSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{Not: &jsonschema.Schema{}}
+}
+
+func ptr[T any](v T) *T { return &v }
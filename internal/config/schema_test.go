@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFieldDocs(t *testing.T) {
+	keys := make(map[string]bool)
+	var walk func(reflect.Type)
+	walk = func(rt reflect.Type) {
+		switch rt.Kind() {
+		case reflect.Map, reflect.Slice:
+			walk(rt.Elem())
+		case reflect.Struct:
+			for i := range rt.NumField() {
+				key, _, _ := strings.Cut(rt.Field(i).Tag.Get("yaml"), ",")
+				if key == "-" || key == "" {
+					continue
+				}
+				keys[rt.Name()+"."+key] = true
+				walk(rt.Field(i).Type)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}))
+	walk(reflect.TypeOf(CompareConfig{}))
+
+	for key := range keys {
+		if fieldDocs[key] == "" {
+			t.Errorf("fieldDocs has no description for %s", key)
+		}
+	}
+	for key := range fieldDocs {
+		if !keys[key] {
+			t.Errorf("fieldDocs describes %s, which is not a config key", key)
+		}
+	}
+}
+
+// resolve resolves a generated schema, failing the test if it is invalid.
+func resolve(t *testing.T, s *jsonschema.Schema) *jsonschema.Resolved {
+	t.Helper()
+	rs, err := s.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rs
+}
+
+func validateYAML(rs *jsonschema.Resolved, data string) error {
+	var instance any
+	if err := yaml.Unmarshal([]byte(data), &instance); err != nil {
+		return err
+	}
+	return rs.Validate(instance)
+}
+
+func TestJSONSchema_Examples(t *testing.T) {
+	config, compare := resolve(t, JSONSchema()), resolve(t, CompareJSONSchema())
+	paths, _ := filepath.Glob("../../examples/*.yaml")
+	if len(paths) == 0 {
+		t.Fatal("no example configs")
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs := config
+		if IsCompareConfig(path) {
+			rs = compare
+		}
+		if err := validateYAML(rs, string(data)); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestJSONSchema_Rejects(t *testing.T) {
+	rs := resolve(t, JSONSchema())
+	tests := []struct {
+		name, yaml string
+		valid      bool
+	}{
+		{"minimal", "options:\n  rows: 10\n", true},
+		{"unknown key", "options:\n  rowz: 10\n", false},
+		{"weighted", "tables:\n  t:\n    distributions:\n      c: {type: weighted, weights: {a: 2, b: 1}}\n", true},
+		{"uniform by default", "tables:\n  t:\n    distributions:\n      c: {}\n", true},
+		{"unknown distribution", "tables:\n  t:\n    distributions:\n      c: {type: zipfian}\n", false},
+		{"weights on zipf", "tables:\n  t:\n    distributions:\n      c: {type: zipf, weights: {a: 1}}\n", false},
+		{"weighted without weights", "tables:\n  t:\n    distributions:\n      c: {type: weighted}\n", false},
+		{"normal mean out of range", "tables:\n  t:\n    distributions:\n      c: {type: normal, mean: 50}\n", false},
+		{"lognormal range", "tables:\n  t:\n    distributions:\n      c: {type: lognormal, min: 1, max: 1e6}\n", true},
+		{"recent dates", "tables:\n  t:\n    distributions:\n      c: {type: exponential, min: -30d, max: now, reverse: true}\n", true},
+		{"reverse on normal", "tables:\n  t:\n    distributions:\n      c: {type: normal, reverse: true}\n", false},
+		{"time series", "tables:\n  t:\n    time_series:\n      created_at: {start: -30d, diurnal: 0.8, weekend: 0.3, bursts: {count: 3, duration: 2h}}\n", true},
+		{"time series peak hour", "tables:\n  t:\n    time_series:\n      created_at: {peak_hour: 24}\n", false},
+		{"range on zipf", "tables:\n  t:\n    distributions:\n      c: {type: zipf, min: 1}\n", false},
+		{"unknown source", "tables:\n  t:\n    correlations:\n      - {source: people, columns: [a]}\n", false},
+		{"template without templates", "tables:\n  t:\n    correlations:\n      - {source: template, columns: [a]}\n", false},
+		{"bad reference", "tables:\n  t:\n    references:\n      user_id: users\n", false},
+		{"transaction", "tests:\n  - {name: tx, kind: transaction, statements: [SELECT 1]}\n", true},
+		{"transaction with query", "tests:\n  - {name: tx, kind: transaction, query: SELECT 1}\n", false},
+		{"read without query", "tests:\n  - {name: r}\n", false},
+		{"percentile out of range", "options:\n  percentiles: [50, 100]\n", false},
+		{"extends", "extends: base.yaml\ninclude: [tables.yaml]\noptions:\n  dsn: ${SEED_DSN}\n", true},
+		{"profile", "profiles:\n  perf:\n    options: {rows: 10000000}\n    tests:\n      - {name: r, repeat: 100}\n", true},
+		{"unknown key in profile", "profiles:\n  perf:\n    option: {rows: 1}\n", false},
+		{"profile distribution", "profiles:\n  perf:\n    tables:\n      t:\n        distributions:\n          c: {type: zipfian}\n", false},
+	}
+	for _, tt := range tests {
+		err := validateYAML(rs, tt.yaml)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: valid = %v, want %v (%v)", tt.name, err == nil, tt.valid, err)
+		}
+	}
+}
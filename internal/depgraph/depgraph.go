@@ -11,6 +11,9 @@ import (
 type TableRelations struct {
 	// Parents maps each table name to its parent table names (via FK) within the seed set.
 	Parents map[string][]string
+	// ParentOf maps each table name to its FK columns and the parent table
+	// each references within the seed set.
+	ParentOf map[string]map[string]string
 }
 
 // Resolve takes a map of table name -> Table and returns tables in topological
@@ -99,6 +102,7 @@ func Resolve(tables map[string]*introspect.Table, allTables map[string]*introspe
 
 	// Build parent relationships (child -> deduplicated list of parents).
 	parents := make(map[string][]string)
+	parentOf := make(map[string]map[string]string)
 	for _, t := range tables {
 		for _, col := range t.Columns {
 			if col.FK == nil {
@@ -112,6 +116,10 @@ func Resolve(tables map[string]*introspect.Table, allTables map[string]*introspe
 				continue
 			}
 			parents[t.Name] = append(parents[t.Name], p)
+			if parentOf[t.Name] == nil {
+				parentOf[t.Name] = make(map[string]string)
+			}
+			parentOf[t.Name][col.Name] = p
 		}
 	}
 	// Deduplicate (a table might have multiple FK columns to the same parent).
@@ -127,7 +135,7 @@ func Resolve(tables map[string]*introspect.Table, allTables map[string]*introspe
 		parents[name] = deduped
 	}
 
-	return order, autoIncluded, &TableRelations{Parents: parents}, nil
+	return order, autoIncluded, &TableRelations{Parents: parents, ParentOf: parentOf}, nil
 }
 
 func detectCycle(tables map[string]*introspect.Table) []string {
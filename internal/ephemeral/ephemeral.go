@@ -73,6 +73,22 @@ func Start(ctx context.Context) (*DB, error) {
 	return edb, nil
 }
 
+// Attach returns a handle to a container started by an earlier run, so it
+// can be stopped later. runtime is the value reported by Runtime.
+func Attach(containerID, runtime string) *DB {
+	return &DB{ContainerID: containerID, runtime: runtime}
+}
+
+// Runtime returns the container runtime managing this container.
+func (edb *DB) Runtime() string {
+	return edb.runtime
+}
+
+// ClientCommand returns a mysql client invocation for connecting to the container.
+func (edb *DB) ClientCommand() string {
+	return fmt.Sprintf("mysql -h 127.0.0.1 -P %d -u root -p%s %s", edb.Port, mysqlRootPwd, mysqlDB)
+}
+
 // Stop removes the container.
 func (edb *DB) Stop() {
 	if edb == nil || edb.ContainerID == "" {
@@ -0,0 +1,196 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+// maxChildRetries is how many rows of one parent in a row a composite
+// unique index may reject before the parent's remaining children are
+// dropped. It leaves GenerateRow retries for the next parent.
+const maxChildRetries = maxUniqueRetries / 2
+
+// ChildrenTypes are the distributions of children per parent.
+var ChildrenTypes = []string{"uniform", "zipf", "normal", "lognormal", "exponential", "pareto"}
+
+// CheckChildren reports whether the parameters of a children_per_parent
+// config are valid. Errors are *ParamError naming the offending key.
+func CheckChildren(card config.CardinalityConfig) error {
+	switch {
+	case card.Min < 0:
+		return &ParamError{"min", fmt.Errorf("%d must not be negative", card.Min)}
+	case card.Max < 1:
+		return &ParamError{"max", errors.New("set max, the most children of a parent, to 1 or more")}
+	case card.Min > card.Max:
+		return &ParamError{"min", fmt.Errorf("min %d must not be above max %d", card.Min, card.Max)}
+	case card.None < 0 || card.None > 1:
+		return &ParamError{"none", fmt.Errorf("%v must be between 0 and 1", card.None)}
+	}
+	if card.Type != "" && !slices.Contains(ChildrenTypes, card.Type) {
+		return &ParamError{"type", fmt.Errorf("unknown type %q", card.Type)}
+	}
+	return nil
+}
+
+// PlanChildren returns the rows of table when each of parents parent rows
+// gets its children through column, as set in children_per_parent. With a
+// seed it matches the rows generated for the same number of parents.
+func PlanChildren(cfg *config.Config, table, column string, parents int) int {
+	card := cfg.Tables[table].Children[column]
+	if CheckChildren(card) != nil {
+		return 0
+	}
+	total := 0
+	for _, n := range childCounts(cfg.Options.Seed, table, column, &card, parents) {
+		total += n
+	}
+	return total
+}
+
+// childCounts returns the number of children of each of n parents through
+// column. The counts have a random source of their own, so planned and
+// generated rows agree for a seed.
+func childCounts(seed int64, table, column string, card *config.CardinalityConfig, n int) []int {
+	rng := NewRand(seed, table+"."+column+" children")
+	span := card.Max - card.Min + 1
+
+	var pick func() int
+	if card.Type == "zipf" {
+		pick = buildRankPicker(span, card.S, rng)
+	} else {
+		dist := &config.DistributionConfig{Type: card.Type, S: card.S, Mean: card.Mean, StdDev: card.StdDev, Reverse: card.Reverse}
+		pick = buildPositionPicker(span, newPosition(dist, float64(span), rng))
+	}
+
+	counts := make([]int, n)
+	for i := range counts {
+		counts[i] = card.Min + pick()
+	}
+	// Exactly the none fraction of parents have no children.
+	for _, i := range rng.Perm(n)[:int(math.Round(card.None*float64(n)))] {
+		counts[i] = 0
+	}
+	return counts
+}
+
+// MaxChildren returns the most children a parent can have through column
+// without repeating a row of a unique index over column and other columns,
+// and that index, or 0 and "" when no index limits them. An index limits
+// them when its other columns are enums or foreign keys whose parent rows
+// parents counts; parents returns 0 when it doesn't know.
+func MaxChildren(table *introspect.Table, column string, parents func(fk introspect.Column) int) (int, string) {
+	most, index := 0, ""
+	for _, idx := range table.UniqueIndexes {
+		if len(idx.Columns) < 2 || !slices.Contains(idx.Columns, column) {
+			continue
+		}
+		combos := 1
+		for _, name := range idx.Columns {
+			if name == column {
+				continue
+			}
+			i := slices.IndexFunc(table.Columns, func(c introspect.Column) bool { return c.Name == name })
+			if i < 0 || table.Columns[i].IsNullable {
+				combos = 0
+				break
+			}
+			switch col := table.Columns[i]; {
+			case col.FK != nil && parents(col) > 0:
+				combos *= parents(col)
+			case len(col.EnumValues) > 0 && strings.ToLower(col.DataType) == "enum":
+				combos *= len(col.EnumValues)
+			default:
+				combos = 0
+			}
+			if combos == 0 {
+				break
+			}
+		}
+		if combos > 0 && (most == 0 || combos < most) {
+			most, index = combos, idx.Name
+		}
+	}
+	return most, index
+}
+
+// childrenGenerator generates the values of FK column col grouped by
+// parent: each parent in keys is repeated for its number of children. Rows
+// past the planned ones pick parents with picker.
+func (rg *RowGenerator) childrenGenerator(col introspect.Column, keys []any, card *config.CardinalityConfig, picker *ValuePicker) (func() any, error) {
+	if first, _ := rg.config.GetChildren(rg.table.Name); first != col.Name {
+		return nil, fmt.Errorf("children_per_parent for %s is set for %s and %s; set it for one foreign key column", rg.table.Name, first, col.Name)
+	}
+	if err := CheckChildren(*card); err != nil {
+		return nil, fmt.Errorf("invalid children_per_parent for %s.%s: %w", rg.table.Name, col.Name, err)
+	}
+	counts := childCounts(rg.config.Options.Seed, rg.table.Name, col.Name, card, len(keys))
+	// A parent has no more children than a unique index over the column
+	// has rows for it.
+	parents := func(fk introspect.Column) int { return len(rg.fkValues[fk.Name]) }
+	if most, _ := MaxChildren(rg.table, col.Name, parents); most > 0 {
+		for i := range counts {
+			counts[i] = min(counts[i], most)
+		}
+	}
+	rg.childRows, rg.grouped = 0, true
+	for _, n := range counts {
+		rg.childRows += n
+	}
+
+	// A row rejected by a composite unique index hands its slot back, so
+	// the retry goes to the same parent and every parent keeps its count.
+	// After maxChildRetries rejections in a row the parent is taken to have
+	// no unused combinations left and its remaining slots are dropped.
+	i, left, took := -1, 0, false
+	retries, retry := 0, false
+	rg.returnChild = func() {
+		if !took {
+			return
+		}
+		if retry, retries = true, retries+1; retries < maxChildRetries {
+			left++
+		} else {
+			left = 0
+		}
+	}
+	return func() any {
+		if !retry {
+			retries = 0
+		}
+		retry = false
+		for left == 0 && i < len(keys)-1 {
+			i, left, retries = i+1, counts[i+1], 0
+		}
+		if took = left > 0; !took {
+			return picker.Pick()
+		}
+		left--
+		return keys[i]
+	}, nil
+}
+
+// ChildRows returns the number of rows to generate when children_per_parent
+// sets the children of each parent row, and whether it does. Generate that
+// many rows to give every parent its children.
+func (rg *RowGenerator) ChildRows() (int, bool) {
+	return rg.childRows, rg.grouped
+}
+
+// describeChildren describes a children_per_parent config for
+// DescribeGenerator.
+func describeChildren(card *config.CardinalityConfig) string {
+	s := fmt.Sprintf("%d-%d per parent", card.Min, card.Max)
+	if card.Type != "" && card.Type != "uniform" {
+		s += ", " + card.Type
+	}
+	if card.None > 0 {
+		s += fmt.Sprintf(", %.0f%% none", card.None*100)
+	}
+	return s
+}
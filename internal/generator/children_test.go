@@ -0,0 +1,221 @@
+package generator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+var orders = &introspect.Table{
+	Name: "orders",
+	Columns: []introspect.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", IsPrimaryKey: true, IsAutoInc: true},
+		{Name: "user_id", DataType: "int", ColumnType: "int", FK: &introspect.ForeignKey{ReferencedTable: "users", ReferencedColumn: "id"}},
+		{Name: "shop_id", DataType: "int", ColumnType: "int", FK: &introspect.ForeignKey{ReferencedTable: "shops", ReferencedColumn: "id"}},
+	},
+}
+
+func TestRowGenerator_Children(t *testing.T) {
+	users := make([]any, 1000)
+	for i := range users {
+		users[i] = int64(i + 1)
+	}
+	fkValues := map[string][]any{"user_id": users, "shop_id": {int64(1), int64(2)}}
+
+	for _, typ := range ChildrenTypes {
+		card := config.CardinalityConfig{Min: 1, Max: 50, None: 0.2, Type: typ}
+		cfg := &config.Config{
+			Options: config.Options{Seed: 1},
+			Tables:  map[string]config.TableConfig{"orders": {Children: map[string]config.CardinalityConfig{"user_id": card}}},
+		}
+		gen, err := NewRowGenerator(orders, fkValues, nil, nil, cfg, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, ok := gen.ChildRows()
+		if !ok {
+			t.Fatalf("%s: ChildRows not set", typ)
+		}
+		if planned := PlanChildren(cfg, "orders", "user_id", len(users)); planned != rows {
+			t.Errorf("%s: PlanChildren = %d, want the %d generated rows", typ, planned, rows)
+		}
+
+		// Rows come grouped by parent, each parent once.
+		counts := make(map[any]int)
+		var last any
+		for range rows {
+			user := gen.GenerateRow()[0] // user_id, shop_id
+			if user != last && counts[user] > 0 {
+				t.Fatalf("%s: rows of user %v are not grouped", typ, user)
+			}
+			counts[user]++
+			last = user
+		}
+		if none := len(users) - len(counts); none != 200 {
+			t.Errorf("%s: %d users without orders, want 200", typ, none)
+		}
+		for user, n := range counts {
+			if n < card.Min || n > card.Max {
+				t.Fatalf("%s: user %v has %d orders, want %d to %d", typ, user, n, card.Min, card.Max)
+			}
+		}
+	}
+}
+
+func TestRowGenerator_ChildrenCompositeUnique(t *testing.T) {
+	// Rows that repeat a (user_id, shop_id) pair are retried, and the
+	// retries must not use up the user's orders.
+	table := *orders
+	table.UniqueIndexes = []introspect.UniqueIndex{{Name: "user_shop", Columns: []string{"user_id", "shop_id"}}}
+	users := make([]any, 200)
+	for i := range users {
+		users[i] = int64(i + 1)
+	}
+	shops := make([]any, 12)
+	for i := range shops {
+		shops[i] = int64(i + 1)
+	}
+	card := config.CardinalityConfig{Min: 5, Max: 10}
+	cfg := &config.Config{
+		Options: config.Options{Seed: 1},
+		Tables:  map[string]config.TableConfig{"orders": {Children: map[string]config.CardinalityConfig{"user_id": card}}},
+	}
+	gen, err := NewRowGenerator(&table, map[string][]any{"user_id": users, "shop_id": shops}, nil, nil, cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := gen.ChildRows()
+	counts := make(map[any]int)
+	for range rows {
+		counts[gen.GenerateRow()[0]]++
+	}
+	for i, want := range childCounts(1, "orders", "user_id", &card, len(users)) {
+		if counts[users[i]] != want {
+			t.Fatalf("user %v has %d orders, want %d", users[i], counts[users[i]], want)
+		}
+	}
+}
+
+func TestRowGenerator_ChildrenUniqueLimit(t *testing.T) {
+	users := make([]any, 50)
+	for i := range users {
+		users[i] = int64(i + 1)
+	}
+	cfg := &config.Config{
+		Options: config.Options{Seed: 1},
+		Tables: map[string]config.TableConfig{"orders": {Children: map[string]config.CardinalityConfig{
+			"user_id": {Min: 5, Max: 5},
+		}}},
+	}
+
+	// Three shops allow three orders per user, so counts are capped at 3.
+	table := *orders
+	table.UniqueIndexes = []introspect.UniqueIndex{{Name: "user_shop", Columns: []string{"user_id", "shop_id"}}}
+	fkValues := map[string][]any{"user_id": users, "shop_id": {int64(1), int64(2), int64(3)}}
+	if most, index := MaxChildren(&table, "user_id", func(fk introspect.Column) int { return len(fkValues[fk.Name]) }); most != 3 || index != "user_shop" {
+		t.Errorf("MaxChildren = %d, %q; want 3, user_shop", most, index)
+	}
+	gen, err := NewRowGenerator(&table, fkValues, nil, nil, cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows, _ := gen.ChildRows(); rows != 3*len(users) {
+		t.Fatalf("ChildRows = %d, want %d", rows, 3*len(users))
+	}
+	counts := make(map[any]int)
+	for range 3 * len(users) {
+		counts[gen.GenerateRow()[0]]++
+	}
+	for _, user := range users {
+		if counts[user] != 3 {
+			t.Fatalf("user %v has %d orders, want 3", user, counts[user])
+		}
+	}
+
+	// A bit column, which MaxChildren doesn't bound, allows two orders per
+	// user. The rest of a user's orders are dropped rather than retried
+	// until GenerateRow gives up, and go to users with room to spare.
+	flagged := *orders
+	flagged.Columns = append(slices.Clone(orders.Columns[:2]), introspect.Column{Name: "flag", DataType: "bit", ColumnType: "bit(1)"})
+	flagged.UniqueIndexes = []introspect.UniqueIndex{{Name: "user_flag", Columns: []string{"user_id", "flag"}}}
+	cfg.Tables["orders"].Children["user_id"] = config.CardinalityConfig{Min: 1, Max: 3, None: 0.5}
+	gen, err = NewRowGenerator(&flagged, map[string][]any{"user_id": users}, nil, nil, cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, _ := gen.ChildRows()
+	for range rows {
+		gen.GenerateRow()
+	}
+}
+
+func TestRowGenerator_ChildrenSkew(t *testing.T) {
+	users := make([]any, 2000)
+	for i := range users {
+		users[i] = int64(i + 1)
+	}
+	mean := func(typ string) float64 {
+		cfg := &config.Config{
+			Options: config.Options{Seed: 1},
+			Tables: map[string]config.TableConfig{"orders": {Children: map[string]config.CardinalityConfig{
+				"user_id": {Max: 500, Type: typ},
+			}}},
+		}
+		gen, err := NewRowGenerator(orders, map[string][]any{"user_id": users, "shop_id": {int64(1)}}, nil, nil, cfg, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, _ := gen.ChildRows()
+		return float64(rows) / float64(len(users))
+	}
+	// Uniform averages 250 orders per user; zipf gives most users few.
+	if uniform, zipf := mean("uniform"), mean("zipf"); uniform < 225 || uniform > 275 || zipf > uniform/2 {
+		t.Errorf("mean orders per user: uniform %.1f, zipf %.1f; want about 250 and far fewer", uniform, zipf)
+	}
+}
+
+func TestRowGenerator_ChildrenErrors(t *testing.T) {
+	fkValues := map[string][]any{"user_id": {int64(1)}, "shop_id": {int64(1)}}
+	tests := []struct {
+		children map[string]config.CardinalityConfig
+		wantErr  string
+	}{
+		{map[string]config.CardinalityConfig{"user_id": {Min: 2}}, "invalid children_per_parent for orders.user_id: max: set max, the most children of a parent, to 1 or more"},
+		{map[string]config.CardinalityConfig{"user_id": {Max: 5, Type: "weighted"}}, `invalid children_per_parent for orders.user_id: type: unknown type "weighted"`},
+		{map[string]config.CardinalityConfig{"user_id": {Max: 5}, "shop_id": {Max: 5}}, "children_per_parent for orders is set for shop_id and user_id; set it for one foreign key column"},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{Tables: map[string]config.TableConfig{"orders": {Children: tt.children}}}
+		_, err := NewRowGenerator(orders, fkValues, nil, nil, cfg, nil, nil, nil)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%v: err = %v, want %q", tt.children, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckChildren(t *testing.T) {
+	tests := []struct {
+		card    config.CardinalityConfig
+		wantKey string
+	}{
+		{config.CardinalityConfig{Max: 1}, ""},
+		{config.CardinalityConfig{Min: 3, Max: 3, None: 1, Type: "zipf"}, ""},
+		{config.CardinalityConfig{Min: -1, Max: 3}, "min"},
+		{config.CardinalityConfig{Min: 4, Max: 3}, "min"},
+		{config.CardinalityConfig{Max: 3, None: 1.5}, "none"},
+		{config.CardinalityConfig{Max: 3, Type: "gauss"}, "type"},
+	}
+	for _, tt := range tests {
+		err := CheckChildren(tt.card)
+		var pe *ParamError
+		switch {
+		case tt.wantKey == "" && err != nil:
+			t.Errorf("%+v: unexpected error %v", tt.card, err)
+		case tt.wantKey != "" && (!errors.As(err, &pe) || pe.Key != tt.wantKey):
+			t.Errorf("%+v: err = %v, want a ParamError for %s", tt.card, err, tt.wantKey)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+// Every distribution type except zipf and weighted draws positions in
+// [0, 1]. Value pickers turn a position into an index, and numeric and
+// date/time columns scale it onto their min..max range.
+
+// Defaults of the distribution parameters.
+const (
+	defaultMean        = 0.5
+	defaultStdDev      = 0.15
+	defaultExpMean     = 0.1
+	defaultParetoShape = 1.16 // the 80/20 rule
+)
+
+// newPosition returns a function drawing positions in [0, 1] from dist, or
+// nil for distributions that only pick among values. n is the size of the
+// range in units (values to pick from, cents of a price, seconds of a
+// datetime range): lognormal and pareto distributions use a log scale over
+// the units.
+func newPosition(dist *config.DistributionConfig, n float64, rng *rand.Rand) func() float64 {
+	if dist == nil {
+		return rng.Float64
+	}
+
+	var pos func() float64
+	switch dist.Type {
+	case "", "uniform":
+		return rng.Float64
+	case "normal":
+		mean, stddev := orDefault(dist.Mean, defaultMean), orDefault(dist.StdDev, defaultStdDev)
+		return func() float64 { return truncNormal(rng, mean, stddev) }
+	case "lognormal":
+		// Normal on a log scale: mean 0.5 between 1 and 1e6 centers
+		// around 1000.
+		mean, stddev := orDefault(dist.Mean, defaultMean), orDefault(dist.StdDev, defaultStdDev)
+		logN := math.Log1p(n)
+		pos = func() float64 { return math.Expm1(truncNormal(rng, mean, stddev)*logN) / n }
+	case "exponential":
+		// Truncated to the range, so mean is only approximate for large
+		// means. tail is the probability mass within the range.
+		mean := orDefault(dist.Mean, defaultExpMean)
+		tail := -math.Expm1(-1 / mean)
+		pos = func() float64 { return -mean * math.Log1p(-rng.Float64()*tail) }
+	case "pareto":
+		// Bounded Pareto over units 1..n+1.
+		shape := orDefault(dist.S, defaultParetoShape)
+		tail := -math.Expm1(-shape * math.Log1p(n))
+		pos = func() float64 { return (math.Pow(1-rng.Float64()*tail, -1/shape) - 1) / n }
+	default:
+		return nil
+	}
+
+	if dist.Reverse {
+		skewed := pos
+		pos = func() float64 { return 1 - skewed() }
+	}
+	return pos
+}
+
+// buildPositionPicker creates a picker indexing n values by position.
+func buildPositionPicker(n int, pos func() float64) func() int {
+	return func() int {
+		return min(int(pos()*float64(n)), n-1)
+	}
+}
+
+// truncNormal draws from a normal distribution truncated to [0, 1].
+func truncNormal(rng *rand.Rand, mean, stddev float64) float64 {
+	for range 100 {
+		if v := rng.NormFloat64()*stddev + mean; v >= 0 && v <= 1 {
+			return v
+		}
+	}
+	return min(max(mean, 0), 1)
+}
+
+func orDefault(v, def float64) float64 {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// IsRangeColumn reports whether col takes values from a min..max range when
+// it has a distribution: numeric, date and time columns.
+func IsRangeColumn(col introspect.Column) bool {
+	dt := strings.ToLower(col.DataType)
+	return isNumericType(dt) || isDateType(dt) || dt == "time" || dt == "year"
+}
+
+// IsRangeDistribution reports whether dist can draw values from a range,
+// as opposed to zipf and weighted distributions, which only pick among
+// values.
+func IsRangeDistribution(dist *config.DistributionConfig) bool {
+	switch dist.Type {
+	case "", "uniform", "normal", "lognormal", "exponential", "pareto":
+		return true
+	default:
+		return false
+	}
+}
+
+// ParamError reports an invalid parameter of a distribution or time series.
+type ParamError struct {
+	Key string // the YAML key, e.g. "min"
+	Err error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Key, e.Err)
+}
+
+func (e *ParamError) Unwrap() error { return e.Err }
+
+// CheckRange reports whether the min and max of dist are valid for col: they
+// parse as the column's type, lie within its range and min is below max.
+func CheckRange(col introspect.Column, dist config.DistributionConfig) error {
+	_, err := columnRange(col, &dist, time.Now())
+	return err
+}
+
+// valueRange is the span a distribution scales its positions onto, in the
+// column's units: numbers as they are, dates and times in seconds.
+type valueRange struct {
+	min, max float64
+	unit     float64           // smallest step between values
+	numeric  bool              // numbers, not dates and times
+	format   func(float64) any // converts a point of the range to a column value
+	parse    func(string) (float64, error)
+}
+
+// columnRange resolves the range of col's distribution: its min and max, or
+// when they are not set, the bounds the type-based generator uses for
+// numbers and the five years up to now for dates. Relative dates are
+// resolved against now.
+func columnRange(col introspect.Column, dist *config.DistributionConfig, now time.Time) (valueRange, error) {
+	dt := strings.ToLower(col.DataType)
+	unsigned := strings.Contains(strings.ToLower(col.ColumnType), "unsigned")
+	r := valueRange{unit: 1, numeric: true, parse: parseNumber, format: formatInt}
+	var lo, hi float64 // limits of the column type
+
+	switch {
+	case dt == "date" || dt == "datetime" || dt == "timestamp":
+		layout := "2006-01-02 15:04:05"
+		first, last := time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+		if dt == "date" {
+			layout = "2006-01-02"
+			r.unit = 24 * 60 * 60
+		}
+		if dt == "timestamp" {
+			first, last = time.Unix(1, 0), time.Unix(math.MaxInt32, 0)
+		}
+		lo, hi = float64(first.Unix()), float64(last.Unix())
+		now = now.UTC().Truncate(time.Second)
+		r.min, r.max = float64(now.AddDate(-5, 0, 0).Unix()), float64(now.Unix())
+		r.parse = func(s string) (float64, error) {
+			t, err := parseTime(s, now)
+			return float64(t.Unix()), err
+		}
+		r.format = func(v float64) any { return time.Unix(int64(v), 0).UTC().Format(layout) }
+		r.numeric = false
+
+	case dt == "time":
+		lo, hi = -838*3600-59*60-59, 838*3600+59*60+59
+		r.min, r.max = 0, 24*3600-1
+		r.parse = parseClock
+		r.format = formatClock
+		r.numeric = false
+
+	case dt == "year":
+		lo, hi = 1901, 2155
+		r.min, r.max = 2000, 2025
+
+	case col.IsIntegerType():
+		bits := map[string]float64{"tinyint": 8, "smallint": 16, "mediumint": 24, "int": 32, "integer": 32, "bigint": 64}[dt]
+		if !unsigned {
+			bits--
+			lo = -math.Exp2(bits)
+		}
+		hi = math.Exp2(bits) - 1
+		if bits > 53 {
+			// Beyond float64 precision, Exp2(bits) - 1 rounds up out of range.
+			hi = math.Nextafter(math.Exp2(bits), 0)
+		}
+		r.max = hi
+
+	case dt == "decimal" || dt == "numeric":
+		precision, scale := int64(10), int64(2)
+		if col.Precision != nil {
+			precision = *col.Precision
+		}
+		if col.Scale != nil {
+			scale = *col.Scale
+		}
+		r.unit = math.Pow(10, -float64(scale))
+		hi = math.Pow(10, float64(precision-scale)) - r.unit
+		if !unsigned {
+			lo = -hi
+		}
+		r.max = math.Pow(10, float64(precision-scale)) - 1
+		if r.max <= 0 {
+			r.max = hi
+		}
+		r.format = roundTo(scale)
+
+	case dt == "float" || dt == "double":
+		hi, r.max = math.MaxFloat64, 10000
+		if dt == "float" {
+			hi, r.max = math.MaxFloat32, 1000
+		}
+		if !unsigned {
+			lo = -hi
+		}
+		r.unit = 0.01
+		r.format = func(v float64) any { return v }
+		if col.Scale != nil {
+			r.unit = math.Pow(10, -float64(*col.Scale))
+			r.format = roundTo(*col.Scale)
+		}
+
+	default:
+		return r, fmt.Errorf("%s columns have no value range", dt)
+	}
+
+	for _, b := range []struct {
+		key, value string
+		dst        *float64
+	}{{"min", dist.Min, &r.min}, {"max", dist.Max, &r.max}} {
+		if b.value == "" {
+			continue
+		}
+		v, err := r.parse(b.value)
+		if err != nil {
+			return r, &ParamError{b.key, err}
+		}
+		if v < lo || v > hi {
+			return r, &ParamError{b.key, fmt.Errorf("%s is out of range for %s (%s to %s)", b.value, typeName(col), r.describe(lo), r.describe(hi))}
+		}
+		*b.dst = v
+	}
+	if r.min >= r.max {
+		key := "max"
+		if dist.Max == "" {
+			key = "min"
+		}
+		return r, &ParamError{key, fmt.Errorf("min %s must be below max %s", r.describe(r.min), r.describe(r.max))}
+	}
+	return r, nil
+}
+
+// rangeGenerator draws the values of a numeric or date/time column from
+// dist, scaled onto the column's range.
+func (rg *RowGenerator) rangeGenerator(col introspect.Column, dist *config.DistributionConfig) (func() any, error) {
+	r, err := columnRange(col, dist, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid distribution for %s.%s: %w", rg.table.Name, col.Name, err)
+	}
+	// Log scales of positive numbers run from min, so lognormal 1 to 1e6
+	// centers around 1000. Others run from the first unit above min.
+	unit := r.unit
+	if r.numeric && r.min > 0 {
+		unit = r.min
+	}
+	pos := newPosition(dist, (r.max-r.min)/unit, rg.rng)
+	return func() any {
+		return r.format(r.min + pos()*(r.max-r.min))
+	}, nil
+}
+
+// describeRange describes the range distribution of a column for
+// DescribeGenerator.
+func describeRange(col introspect.Column, dist *config.DistributionConfig) string {
+	typ := dist.Type
+	if typ == "" {
+		typ = "uniform"
+	}
+	if dist.Reverse {
+		typ += " (reversed)"
+	}
+	r, err := columnRange(col, dist, time.Now())
+	if err != nil {
+		return fmt.Sprintf("distribution: %s (invalid %v)", typ, err)
+	}
+	return fmt.Sprintf("distribution: %s from %s to %s", typ, r.describe(r.min), r.describe(r.max))
+}
+
+// describe formats a point of the range for messages, without exponents
+// for decimals.
+func (r valueRange) describe(v float64) string {
+	if f, ok := r.format(v).(float64); ok && math.Abs(f) < 1e15 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(r.format(v))
+}
+
+func typeName(col introspect.Column) string {
+	if col.ColumnType != "" {
+		return col.ColumnType
+	}
+	return strings.ToLower(col.DataType)
+}
+
+func parseNumber(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return v, nil
+}
+
+func formatInt(v float64) any {
+	v = math.Round(v)
+	if v >= math.MaxInt64 {
+		return uint64(v)
+	}
+	return int64(v)
+}
+
+func roundTo(scale int64) func(float64) any {
+	factor := math.Pow(10, float64(scale))
+	return func(v float64) any { return math.Round(v*factor) / factor }
+}
+
+// parseTime parses a date bound: a date, a datetime, now, or an offset from
+// now such as -30d, now-6h or +1y.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	now = now.UTC().Truncate(time.Second)
+	offset, relative := strings.CutPrefix(s, "now")
+	if relative && offset == "" {
+		return now, nil
+	}
+	if relative || strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return addOffset(now, offset)
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date such as 2024-01-31, a datetime, now, or an offset from now such as -30d", s)
+}
+
+// addOffset adds a signed offset such as -30d to t. Days, weeks and years
+// follow the calendar; smaller units are Go durations such as -1h30m.
+func addOffset(t time.Time, offset string) (time.Time, error) {
+	invalid := fmt.Errorf("invalid offset %q: expected a sign, a number and a unit (s, m, h, d, w or y), e.g. -30d", offset)
+	if len(offset) < 3 || (offset[0] != '-' && offset[0] != '+') {
+		return time.Time{}, invalid
+	}
+	if n, err := strconv.Atoi(offset[:len(offset)-1]); err == nil {
+		switch offset[len(offset)-1] {
+		case 'd':
+			return t.AddDate(0, 0, n), nil
+		case 'w':
+			return t.AddDate(0, 0, 7*n), nil
+		case 'y':
+			return t.AddDate(n, 0, 0), nil
+		}
+	}
+	d, err := time.ParseDuration(offset)
+	if err != nil {
+		return time.Time{}, invalid
+	}
+	return t.Add(d), nil
+}
+
+// parseClock parses a TIME bound such as 08:30:00 or -12:00:00 into seconds.
+func parseClock(s string) (float64, error) {
+	clock, negative := strings.CutPrefix(s, "-")
+	parts := strings.Split(clock, ":")
+	var secs float64
+	for _, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 32)
+		if err != nil || len(parts) != 3 {
+			return 0, errors.New("expected a time such as 08:30:00")
+		}
+		secs = secs*60 + float64(n)
+	}
+	if negative {
+		secs = -secs
+	}
+	return secs, nil
+}
+
+func formatClock(v float64) any {
+	secs := int64(math.Round(v))
+	sign := ""
+	if secs < 0 {
+		sign, secs = "-", -secs
+	}
+	return fmt.Sprintf("%s%02d:%02d:%02d", sign, secs/3600, secs/60%60, secs%60)
+}
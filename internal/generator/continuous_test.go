@@ -0,0 +1,163 @@
+package generator
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+func TestRowGenerator_RangeDistributions(t *testing.T) {
+	ten, two := int64(10), int64(2)
+	table := &introspect.Table{
+		Name: "orders",
+		Columns: []introspect.Column{
+			{Name: "amount", DataType: "decimal", ColumnType: "decimal(10,2)", Precision: &ten, Scale: &two},
+			{Name: "created_at", DataType: "datetime", ColumnType: "datetime"},
+			{Name: "quantity", DataType: "int", ColumnType: "int unsigned"},
+			{Name: "status", DataType: "enum", ColumnType: "enum('a','b','c','d')", EnumValues: []string{"a", "b", "c", "d"}},
+			{Name: "discount", DataType: "tinyint", ColumnType: "tinyint"},
+		},
+	}
+	cfg := &config.Config{
+		Options: config.Options{Seed: 1},
+		Tables: map[string]config.TableConfig{"orders": {
+			Distributions: map[string]config.DistributionConfig{
+				"amount":     {Type: "lognormal", Min: "1", Max: "1e6"},
+				"created_at": {Type: "exponential", Min: "2024-01-01", Max: "2024-12-31 23:59:59", Reverse: true},
+				"quantity":   {Type: "pareto", Min: "1", Max: "1000"},
+				"status":     {Type: "exponential", Mean: 0.2},
+				"discount":   {Type: "normal", Min: "-50", Max: "50", StdDev: 0.1},
+			},
+		}},
+	}
+	gen, err := NewRowGenerator(table, nil, nil, nil, cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 4000
+	var amounts, quantities []float64
+	var recent, firstStatus, nearZero int
+	for range n {
+		row := gen.GenerateRow()
+		amount := row[0].(float64)
+		if amount < 1 || amount > 1e6 || math.Abs(math.Round(amount*100)-amount*100) > 1e-6 {
+			t.Fatalf("amount = %v, want 1 to 1e6 with two decimals", amount)
+		}
+		amounts = append(amounts, amount)
+
+		created, err := time.Parse("2006-01-02 15:04:05", row[1].(string))
+		if err != nil || created.Year() != 2024 {
+			t.Fatalf("created_at = %v, want a datetime in 2024", row[1])
+		}
+		if created.After(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)) {
+			recent++
+		}
+
+		quantity := row[2].(int64)
+		if quantity < 1 || quantity > 1000 {
+			t.Fatalf("quantity = %d, want 1 to 1000", quantity)
+		}
+		quantities = append(quantities, float64(quantity))
+
+		if row[3] == "a" {
+			firstStatus++
+		}
+		if d := row[4].(int64); d < -50 || d > 50 {
+			t.Fatalf("discount = %d, want -50 to 50", d)
+		} else if d >= -10 && d <= 10 {
+			nearZero++
+		}
+	}
+
+	median := func(v []float64) float64 { slices.Sort(v); return v[len(v)/2] }
+	if m := median(amounts); m < 300 || m > 3000 {
+		t.Errorf("amount median = %v, want about 1000 (the middle of 1 to 1e6 on a log scale)", m)
+	}
+	// Skewed towards max: the last month of the year holds about half the
+	// rows with a mean of a tenth of the range.
+	if share := float64(recent) / n; share < 0.45 || share > 0.7 {
+		t.Errorf("created_at: %.0f%% in December, want about 56%%", share*100)
+	}
+	if m := median(quantities); m > 3 {
+		t.Errorf("quantity median = %v, want most quantities near 1", m)
+	}
+	if share := float64(firstStatus) / n; share < 0.6 || share > 0.8 {
+		t.Errorf("status: %.0f%% a, want about 71%% for an exponential picker with mean 0.2", share*100)
+	}
+	if share := float64(nearZero) / n; share < 0.6 || share > 0.75 {
+		t.Errorf("discount: %.0f%% within 10 of 0, want about 68%% (one stddev)", share*100)
+	}
+}
+
+func TestColumnRange(t *testing.T) {
+	ten, two, three := int64(10), int64(2), int64(3)
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		col      introspect.Column
+		min, max string
+		want     [2]any // formatted min and max
+		wantErr  string
+	}{
+		{"int defaults", introspect.Column{DataType: "int"}, "", "", [2]any{int64(0), int64(math.MaxInt32)}, ""},
+		{"bigint unsigned", introspect.Column{DataType: "bigint", ColumnType: "bigint unsigned"}, "", "1.8e19", [2]any{int64(0), uint64(18000000000000000000)}, ""},
+		{"tinyint out of range", introspect.Column{DataType: "tinyint", ColumnType: "tinyint"}, "", "300", [2]any{}, "max: 300 is out of range for tinyint (-128 to 127)"},
+		{"unsigned negative", introspect.Column{DataType: "int", ColumnType: "int unsigned"}, "-1", "", [2]any{}, "min: -1 is out of range for int unsigned (0 to 4294967295)"},
+		{"decimal", introspect.Column{DataType: "decimal", ColumnType: "decimal(3,2)", Precision: &three, Scale: &two}, "0.005", "", [2]any{0.01, 9.0}, ""},
+		{"decimal precision", introspect.Column{DataType: "decimal", ColumnType: "decimal(10,2)", Precision: &ten, Scale: &two}, "", "1e8", [2]any{}, "max: 1e8 is out of range for decimal(10,2) (-99999999.99 to 99999999.99)"},
+		{"not a number", introspect.Column{DataType: "double"}, "lots", "", [2]any{}, `min: "lots" is not a number`},
+		{"dates", introspect.Column{DataType: "date"}, "2024-01-01", "now", [2]any{"2024-01-01", "2024-06-15"}, ""},
+		{"relative", introspect.Column{DataType: "datetime"}, "-30d", "now-1h", [2]any{"2024-05-16 12:00:00", "2024-06-15 11:00:00"}, ""},
+		{"last five years", introspect.Column{DataType: "timestamp"}, "", "", [2]any{"2019-06-15 12:00:00", "2024-06-15 12:00:00"}, ""},
+		{"timestamp range", introspect.Column{DataType: "timestamp"}, "1960-01-01", "", [2]any{}, "min: 1960-01-01 is out of range for timestamp"},
+		{"bad offset", introspect.Column{DataType: "datetime"}, "-30 days", "", [2]any{}, `invalid offset "-30 days"`},
+		{"time", introspect.Column{DataType: "time"}, "08:00:00", "17:30:00", [2]any{"08:00:00", "17:30:00"}, ""},
+		{"year", introspect.Column{DataType: "year"}, "1990", "", [2]any{int64(1990), int64(2025)}, ""},
+		{"inverted", introspect.Column{DataType: "int"}, "10", "5", [2]any{}, "max: min 10 must be below max 5"},
+		{"min above default max", introspect.Column{DataType: "year"}, "2030", "", [2]any{}, "min: min 2030 must be below max 2025"},
+	}
+	for _, tt := range tests {
+		r, err := columnRange(tt.col, &config.DistributionConfig{Min: tt.min, Max: tt.max}, now)
+		switch {
+		case tt.wantErr != "":
+			var pe *ParamError
+			if !errors.As(err, &pe) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want a ParamError containing %q", tt.name, err, tt.wantErr)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case r.format(r.min) != tt.want[0] || r.format(r.max) != tt.want[1]:
+			t.Errorf("%s: range = %v to %v, want %v to %v", tt.name, r.format(r.min), r.format(r.max), tt.want[0], tt.want[1])
+		}
+	}
+}
+
+func TestNewPosition(t *testing.T) {
+	rng := NewRand(1, "positions")
+	for _, dist := range []config.DistributionConfig{
+		{Type: "uniform"},
+		{Type: "normal", Mean: 0.9, StdDev: 0.5},
+		{Type: "lognormal"},
+		{Type: "exponential", Mean: 5},
+		{Type: "pareto", S: 0.5, Reverse: true},
+	} {
+		pos := newPosition(&dist, 1000, rng)
+		for range 1000 {
+			if p := pos(); p < 0 || p > 1 {
+				t.Fatalf("%s: position %v outside [0, 1]", dist.Type, p)
+			}
+		}
+	}
+	for _, typ := range []string{"zipf", "weighted"} {
+		if newPosition(&config.DistributionConfig{Type: typ}, 10, rng) != nil {
+			t.Errorf("%s: want no positions for a distribution that only picks values", typ)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package generator
 import (
 	"bytes"
 	"fmt"
-	"math/rand/v2"
 	"strings"
 	"text/template"
 
@@ -14,7 +13,6 @@ import (
 // The first column's generator populates all values; subsequent columns read from it.
 type correlationState struct {
 	values map[string]any
-	ready  bool
 }
 
 // buildCorrelationGenerators overlays correlated generators onto rg.generators.
@@ -52,12 +50,18 @@ func (rg *RowGenerator) applyCorrelationGroup(group config.CorrelationGroup, col
 
 	state := &correlationState{values: make(map[string]any)}
 
-	// Determine if any column in the group is nullable for all-or-nothing null
-	anyNullable := false
+	// The first column generates the group, so the others follow it.
+	for _, name := range group.Columns[1:] {
+		rg.deps[name] = []string{group.Columns[0]}
+	}
+	rg.deps[group.Columns[0]] = nil
+
+	// The group is NULL as a whole, at the highest null rate of its
+	// nullable columns.
+	nullRate := 0.0
 	for _, idx := range indices {
-		if rg.columns[idx].IsNullable && !rg.columns[idx].IsPrimaryKey {
-			anyNullable = true
-			break
+		if col := rg.columns[idx]; col.IsNullable && !col.IsPrimaryKey {
+			nullRate = max(nullRate, rg.config.NullRate(rg.table.Name, col.Name))
 		}
 	}
 
@@ -88,25 +92,20 @@ func (rg *RowGenerator) applyCorrelationGroup(group config.CorrelationGroup, col
 		if i == 0 {
 			// First column: generate all values, then return own value
 			rg.generators[colIdx] = func() any {
-				state.ready = false
-				if anyNullable && rand.Float64() < 0.1 {
+				if nullRate > 0 && rg.rng.Float64() < nullRate {
 					// All-or-nothing null for the group
 					for _, name := range group.Columns {
 						state.values[name] = nil
 					}
-					state.ready = true
 					return nil
 				}
 				generateGroup()
-				state.ready = true
 				return state.values[colName]
 			}
 		} else {
-			// Subsequent columns: read from state
+			// Subsequent columns: read from state, filled by the first column,
+			// which orderGenerators runs before them.
 			rg.generators[colIdx] = func() any {
-				if !state.ready {
-					panic(fmt.Sprintf("correlation: column %q read before group generated (check column order)", colName))
-				}
 				return state.values[colName]
 			}
 		}
@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// noValue is what a template prints for a NULL value.
+const noValue = "<no value>"
+
+// TemplateRefs returns the columns a column template reads, such as
+// created_at for {{AddDays .created_at 3}}, in order of appearance. Parent
+// columns, read through .parent, are returned by ParentRefs.
+func TemplateRefs(t *template.Template) []string {
+	var refs []string
+	for _, field := range templateFields(t) {
+		if field[0] != "parent" && !slices.Contains(refs, field[0]) {
+			refs = append(refs, field[0])
+		}
+	}
+	return refs
+}
+
+// templateFields returns the fields of the row a template reads, such as
+// [parent orders created_at] for .parent.orders.created_at.
+func templateFields(t *template.Template) [][]string {
+	var fields [][]string
+
+	// root reports whether dot is still the row; with and range move it.
+	var walk func(n parse.Node, root bool)
+	walk = func(n parse.Node, root bool) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, c := range n.Nodes {
+					walk(c, root)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, root)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, c := range n.Cmds {
+					walk(c, root)
+				}
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, root)
+			}
+		case *parse.ChainNode:
+			walk(n.Node, root)
+		case *parse.FieldNode:
+			if root {
+				fields = append(fields, n.Ident)
+			}
+		case *parse.VariableNode:
+			if n.Ident[0] == "$" && len(n.Ident) > 1 {
+				fields = append(fields, n.Ident[1:])
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, root)
+			walk(n.List, root)
+			walk(n.ElseList, root)
+		case *parse.WithNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.RangeNode:
+			walk(n.Pipe, root)
+			walk(n.List, false)
+			walk(n.ElseList, root)
+		case *parse.TemplateNode:
+			walk(n.Pipe, root)
+		}
+	}
+	if t.Tree != nil {
+		walk(t.Tree.Root, true)
+	}
+	return fields
+}
+
+// orderGenerators orders the generators so every column comes after the
+// columns its template reads and the column that generates its group,
+// keeping table order otherwise.
+func (rg *RowGenerator) orderGenerators() error {
+	index := make(map[string]int, len(rg.columns))
+	for i, col := range rg.columns {
+		index[col.Name] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(rg.columns))
+	var path []string
+	var visit func(i int) error
+	visit = func(i int) error {
+		name := rg.columns[i].Name
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			cycle := append(path[slices.Index(path, name):], name)
+			return fmt.Errorf("column templates of table %s depend on each other: %s", rg.table.Name, strings.Join(cycle, " -> "))
+		}
+		state[i] = visiting
+		path = append(path, name)
+		for _, dep := range rg.deps[name] {
+			j, ok := index[dep]
+			if !ok {
+				return rg.refError(name, dep)
+			}
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = done
+		rg.order = append(rg.order, i)
+		return nil
+	}
+
+	rg.order = make([]int, 0, len(rg.columns))
+	for i := range rg.columns {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refError explains why a template cannot read column ref.
+func (rg *RowGenerator) refError(name, ref string) error {
+	for _, col := range rg.table.Columns {
+		if col.Name != ref {
+			continue
+		}
+		kind := "a generated column"
+		if col.IsAutoInc {
+			kind = "auto-increment"
+		}
+		return fmt.Errorf("template for %s.%s reads %s, which is %s and has no value until the row is inserted", rg.table.Name, name, ref, kind)
+	}
+	return fmt.Errorf("template for %s.%s reads unknown column %q", rg.table.Name, name, ref)
+}
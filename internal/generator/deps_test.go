@@ -0,0 +1,218 @@
+package generator
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+func TestRowGenerator_DerivedColumns(t *testing.T) {
+	// Derived columns come before the columns they read, so generation
+	// must not follow table order.
+	table := &introspect.Table{
+		Name: "orders",
+		Columns: []introspect.Column{
+			{Name: "id", DataType: "int", ColumnType: "int", IsPrimaryKey: true, IsAutoInc: true},
+			{Name: "total", DataType: "decimal", ColumnType: "decimal(12,2)"},
+			{Name: "email", DataType: "varchar", ColumnType: "varchar(255)"},
+			{Name: "updated_at", DataType: "datetime", ColumnType: "datetime"},
+			{Name: "end_date", DataType: "date", ColumnType: "date"},
+			{Name: "note", DataType: "varchar", ColumnType: "varchar(50)"},
+			{Name: "quantity", DataType: "int", ColumnType: "int"},
+			{Name: "unit_price", DataType: "decimal", ColumnType: "decimal(10,2)"},
+			{Name: "first_name", DataType: "varchar", ColumnType: "varchar(50)"},
+			{Name: "last_name", DataType: "varchar", ColumnType: "varchar(50)"},
+			{Name: "created_at", DataType: "datetime", ColumnType: "datetime"},
+			{Name: "start_date", DataType: "date", ColumnType: "date"},
+			{Name: "deleted_at", DataType: "datetime", ColumnType: "datetime", IsNullable: true},
+		},
+	}
+	always := 1.0
+	cfg := &config.Config{
+		Options: config.Options{Seed: 1},
+		Tables: map[string]config.TableConfig{"orders": {
+			Columns: map[string]string{
+				"total":      "{{Round (Mul .quantity .unit_price) 2}}",
+				"email":      "{{ToLower .first_name}}.{{ToLower .last_name}}@example.com",
+				"updated_at": "{{After .created_at \"30d\"}}",
+				"end_date":   "{{AddDays .start_date 14}}",
+				"note":       "{{AddDuration .deleted_at \"1h\"}}",
+			},
+			NullRates: map[string]float64{"deleted_at": always},
+		}},
+	}
+	gen, err := NewRowGenerator(table, nil, nil, nil, cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols := make(map[string]int)
+	for i, name := range gen.Columns() {
+		cols[name] = i
+	}
+
+	for range 200 {
+		row := gen.GenerateRow()
+		get := func(name string) any { return row[cols[name]] }
+
+		quantity, _ := toFloat(get("quantity"))
+		price, _ := toFloat(get("unit_price"))
+		total, err := strconv.ParseFloat(get("total").(string), 64)
+		if err != nil || math.Abs(total-math.Round(quantity*price*100)/100) > 1e-9 {
+			t.Fatalf("total = %v, want %v * %v", get("total"), get("quantity"), get("unit_price"))
+		}
+
+		want := strings.ToLower(get("first_name").(string) + "." + get("last_name").(string) + "@example.com")
+		if get("email") != want {
+			t.Fatalf("email = %v, want %s", get("email"), want)
+		}
+
+		created, _, _ := toTime(get("created_at"))
+		updated, _, err := toTime(get("updated_at"))
+		if err != nil || updated.Before(created) || updated.Sub(created) > 30*24*time.Hour {
+			t.Fatalf("updated_at = %v, want within 30 days after created_at %v", get("updated_at"), get("created_at"))
+		}
+
+		start, layout, _ := toTime(get("start_date"))
+		if end := start.AddDate(0, 0, 14).Format(layout); get("end_date") != end {
+			t.Fatalf("end_date = %v, want %s", get("end_date"), end)
+		}
+
+		if get("note") != nil {
+			t.Fatalf("note = %v, want NULL from the NULL deleted_at", get("note"))
+		}
+	}
+}
+
+func TestRowGenerator_CorrelationOutOfTableOrder(t *testing.T) {
+	// The group's first column generates it, so it runs first even when
+	// the other columns come before it in the table.
+	table := &introspect.Table{
+		Name: "users",
+		Columns: []introspect.Column{
+			{Name: "first_name", DataType: "varchar", ColumnType: "varchar(50)"},
+			{Name: "email", DataType: "varchar", ColumnType: "varchar(255)"},
+			{Name: "last_name", DataType: "varchar", ColumnType: "varchar(50)"},
+		},
+	}
+	cfg := &config.Config{
+		Options: config.Options{Seed: 1},
+		Tables: map[string]config.TableConfig{"users": {
+			Correlations: []config.CorrelationGroup{{
+				Columns: []string{"last_name", "first_name", "email"},
+				Source:  "template",
+				Template: map[string]string{
+					"last_name":  "{{LastName}}",
+					"first_name": "{{FirstName}}",
+					"email":      "{{ToLower .first_name}}.{{ToLower .last_name}}@example.com",
+				},
+			}},
+		}},
+	}
+	gen, err := NewRowGenerator(table, nil, nil, nil, cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 100 {
+		row := gen.GenerateRow() // first_name, email, last_name
+		first, last := row[0].(string), row[2].(string)
+		if first == "" || last == "" {
+			t.Fatalf("row %v: names not generated", row)
+		}
+		if want := strings.ToLower(first) + "." + strings.ToLower(last) + "@example.com"; row[1] != want {
+			t.Fatalf("email = %v, want %s", row[1], want)
+		}
+	}
+}
+
+func TestRowGenerator_TemplateRefErrors(t *testing.T) {
+	table := &introspect.Table{
+		Name: "t",
+		Columns: []introspect.Column{
+			{Name: "id", DataType: "int", ColumnType: "int", IsPrimaryKey: true, IsAutoInc: true},
+			{Name: "a", DataType: "varchar", ColumnType: "varchar(50)"},
+			{Name: "b", DataType: "varchar", ColumnType: "varchar(50)"},
+			{Name: "c", DataType: "varchar", ColumnType: "varchar(50)"},
+		},
+	}
+	tests := []struct {
+		name    string
+		columns map[string]string
+		wantErr string
+	}{
+		{"cycle", map[string]string{"a": "{{.b}}", "b": "{{.c}}", "c": "{{.a}}"}, "column templates of table t depend on each other: a -> b -> c -> a"},
+		{"self", map[string]string{"a": "x{{.a}}"}, "column templates of table t depend on each other: a -> a"},
+		{"unknown", map[string]string{"a": "{{.missing}}"}, `template for t.a reads unknown column "missing"`},
+		{"auto-increment", map[string]string{"a": "{{.id}}"}, "template for t.a reads id, which is auto-increment and has no value until the row is inserted"},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{Tables: map[string]config.TableConfig{"t": {Columns: tt.columns}}}
+		_, err := NewRowGenerator(table, nil, nil, nil, cfg, nil, nil, nil)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTemplateRefs(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		want []string
+	}{
+		{"{{FirstName}}", nil},
+		{"{{.a}}-{{.b}}-{{.a}}", []string{"a", "b"}},
+		{"{{AddDays .start 3}}", []string{"start"}},
+		{"{{if .a}}{{.b}}{{else}}{{.c}}{{end}}", []string{"a", "b", "c"}},
+		{"{{with .a}}{{.inner}}{{$.b}}{{end}}", []string{"a", "b"}},
+		{"{{range $i, $v := .a}}{{.x}}{{end}}", []string{"a"}},
+		{"{{.a | printf \"%v\"}}", []string{"a"}},
+	}
+	for _, tt := range tests {
+		parsed := template.Must(template.New("t").Funcs(FuncMap(gofakeit.New(1))).Parse(tt.tmpl))
+		if got := TemplateRefs(parsed); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TemplateRefs(%q) = %v, want %v", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestRowFuncs(t *testing.T) {
+	fm := FuncMap(gofakeit.New(1))
+	tests := []struct {
+		tmpl string
+		data map[string]any
+		want string
+	}{
+		{"{{Add .a .b}}", map[string]any{"a": 2, "b": "0.5"}, "2.5"},
+		{"{{Sub .a 1}}", map[string]any{"a": int64(10)}, "9"},
+		{"{{Div .a 4}}", map[string]any{"a": uint8(10)}, "2.5"},
+		{"{{Round (Mul .a .b) 2}}", map[string]any{"a": 3, "b": "19.999"}, "60"},
+		{"{{Mul .a 1000000}}", map[string]any{"a": 123456}, "123456000000"},
+		{"{{Add .a 1}}", map[string]any{"a": nil}, noValue},
+		{"{{AddDays .d -1}}", map[string]any{"d": "2024-03-01"}, "2024-02-29"},
+		{"{{AddDuration .d \"-1h30m\"}}", map[string]any{"d": "2024-03-01 01:00:00"}, "2024-02-29 23:30:00"},
+		{"{{AddDuration .d \"1w\"}}", map[string]any{"d": "2024-03-01 00:00:00"}, "2024-03-08 00:00:00"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := template.Must(template.New("t").Funcs(fm).Parse(tt.tmpl)).Execute(&b, tt.data); err != nil {
+			t.Errorf("%s: %v", tt.tmpl, err)
+			continue
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s = %q, want %q", tt.tmpl, b.String(), tt.want)
+		}
+	}
+
+	for _, tmpl := range []string{`{{Add "x" 1}}`, `{{AddDays "tomorrow" 1}}`, `{{After "2024-01-01" "soon"}}`} {
+		if err := template.Must(template.New("t").Funcs(fm).Parse(tmpl)).Execute(&strings.Builder{}, nil); err == nil {
+			t.Errorf("%s: want an error", tmpl)
+		}
+	}
+}
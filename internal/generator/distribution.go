@@ -16,8 +16,8 @@ type ValuePicker struct {
 }
 
 // NewValuePicker creates a ValuePicker that selects from values using the given distribution.
-// If dist is nil, uniform random selection is used.
-func NewValuePicker(values []any, dist *config.DistributionConfig) *ValuePicker {
+// If dist is nil, uniform random selection is used. All choices are drawn from rng.
+func NewValuePicker(values []any, dist *config.DistributionConfig, rng *rand.Rand) *ValuePicker {
 	if len(values) == 0 {
 		panic("NewValuePicker: empty values slice")
 	}
@@ -25,20 +25,22 @@ func NewValuePicker(values []any, dist *config.DistributionConfig) *ValuePicker
 	vp := &ValuePicker{values: values}
 
 	if dist == nil {
-		vp.pick = func() int { return rand.IntN(len(values)) }
+		vp.pick = func() int { return rng.IntN(len(values)) }
 		return vp
 	}
 
 	switch dist.Type {
 	case "zipf":
-		vp.pick = buildZipfPicker(len(values), dist.S)
+		vp.pick = buildZipfPicker(len(values), dist.S, rng)
 	case "normal":
-		vp.pick = buildNormalPicker(len(values), dist.Mean, dist.StdDev)
+		vp.pick = buildNormalPicker(len(values), dist.Mean, dist.StdDev, rng)
 	case "weighted":
-		vp.pick = buildWeightedPicker(values, dist.Weights)
+		vp.pick = buildWeightedPicker(values, dist.Weights, rng)
+	case "lognormal", "exponential", "pareto":
+		vp.pick = buildPositionPicker(len(values), newPosition(dist, float64(len(values)), rng))
 	default:
 		// uniform (including explicit "uniform" or empty string)
-		vp.pick = func() int { return rand.IntN(len(values)) }
+		vp.pick = func() int { return rng.IntN(len(values)) }
 	}
 
 	return vp
@@ -51,14 +53,22 @@ func (vp *ValuePicker) Pick() any {
 
 // buildZipfPicker creates a picker using Zipf's law: weight[i] = 1/(i+1)^s.
 // Values are shuffled so popular values aren't always the lowest indices.
-func buildZipfPicker(n int, s float64) func() int {
+func buildZipfPicker(n int, s float64, rng *rand.Rand) func() int {
+	// Shuffle a mapping so the "popular" ranks map to random original indices.
+	perm := rng.Perm(n)
+	rank := buildRankPicker(n, s, rng)
+	return func() int {
+		return perm[rank()]
+	}
+}
+
+// buildRankPicker creates a picker of ranks 0..n-1 using Zipf's law, rank 0
+// being the most frequent.
+func buildRankPicker(n int, s float64, rng *rand.Rand) func() int {
 	if s <= 0 {
 		s = 1.0
 	}
 
-	// Shuffle a mapping so the "popular" ranks map to random original indices.
-	perm := rand.Perm(n)
-
 	// Build CDF: weight[rank] = 1/(rank+1)^s
 	cdf := make([]float64, n)
 	total := 0.0
@@ -73,18 +83,18 @@ func buildZipfPicker(n int, s float64) func() int {
 	}
 
 	return func() int {
-		r := rand.Float64()
+		r := rng.Float64()
 		rank := sort.SearchFloat64s(cdf, r)
 		if rank >= n {
 			rank = n - 1
 		}
-		return perm[rank]
+		return rank
 	}
 }
 
 // buildNormalPicker creates a picker using a normal distribution.
 // mean and stddev are fractions of n (e.g. mean=0.5 centers at the middle).
-func buildNormalPicker(n int, mean, stddev float64) func() int {
+func buildNormalPicker(n int, mean, stddev float64, rng *rand.Rand) func() int {
 	if mean == 0 {
 		mean = 0.5
 	}
@@ -93,7 +103,7 @@ func buildNormalPicker(n int, mean, stddev float64) func() int {
 	}
 
 	return func() int {
-		v := rand.NormFloat64()*stddev*float64(n) + mean*float64(n)
+		v := rng.NormFloat64()*stddev*float64(n) + mean*float64(n)
 		idx := int(v)
 		if idx < 0 {
 			idx = 0
@@ -107,7 +117,7 @@ func buildNormalPicker(n int, mean, stddev float64) func() int {
 
 // buildWeightedPicker creates a picker using explicit weights per value.
 // Values are matched to weights by fmt.Sprint(val) comparison.
-func buildWeightedPicker(values []any, weights map[string]float64) func() int {
+func buildWeightedPicker(values []any, weights map[string]float64, rng *rand.Rand) func() int {
 	n := len(values)
 
 	// Build per-index weights. Unmatched values get weight 1.0.
@@ -133,7 +143,7 @@ func buildWeightedPicker(values []any, weights map[string]float64) func() int {
 	}
 
 	return func() int {
-		r := rand.Float64()
+		r := rng.Float64()
 		idx := sort.SearchFloat64s(cdf, r)
 		if idx >= n {
 			idx = n - 1
@@ -61,7 +61,7 @@ func (rg *RowGenerator) applyFKCorrelations() {
 	// Apply generators. The first column in table order (for each group)
 	// triggers generation for the whole group, ensuring correct ordering
 	// regardless of which column (driver or derived) appears first.
-	triggered := make(map[string]bool) // driverCol → has trigger been assigned
+	triggered := make(map[string]string) // driverCol → column triggering the group
 
 	for i, col := range rg.columns {
 		colName := col.Name
@@ -77,9 +77,9 @@ func (rg *RowGenerator) applyFKCorrelations() {
 			continue
 		}
 
-		if !triggered[g.driverCol] {
+		if _, ok := triggered[g.driverCol]; !ok {
 			// First column in this group — triggers generation for all.
-			triggered[g.driverCol] = true
+			triggered[g.driverCol] = colName
 			grp := g // capture for closure
 			rg.generators[i] = func() any {
 				grp.ready = false
@@ -103,6 +103,7 @@ func (rg *RowGenerator) applyFKCorrelations() {
 			}
 		} else {
 			// Subsequent column — read from shared state.
+			rg.deps[colName] = append(rg.deps[colName], triggered[g.driverCol])
 			grp := g // capture for closure
 			rg.generators[i] = func() any {
 				return grp.current[colName]
@@ -40,7 +40,7 @@ func TestFKCorrelation_DerivedFromDriver(t *testing.T) {
 	}
 
 	cfg := &config.Config{}
-	gen, err := NewRowGenerator(table, fkValues, fkLookups, cfg, nil, nil, nil)
+	gen, err := NewRowGenerator(table, fkValues, fkLookups, nil, cfg, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("NewRowGenerator: %v", err)
 	}
@@ -85,7 +85,7 @@ func TestFKCorrelation_DerivedColumnFirst(t *testing.T) {
 		},
 	}
 
-	gen, err := NewRowGenerator(table, fkValues, fkLookups, &config.Config{}, nil, nil, nil)
+	gen, err := NewRowGenerator(table, fkValues, fkLookups, nil, &config.Config{}, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("NewRowGenerator: %v", err)
 	}
@@ -127,7 +127,7 @@ func TestFKCorrelation_DriverColumnFirst(t *testing.T) {
 		},
 	}
 
-	gen, err := NewRowGenerator(table, fkValues, fkLookups, &config.Config{}, nil, nil, nil)
+	gen, err := NewRowGenerator(table, fkValues, fkLookups, nil, &config.Config{}, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("NewRowGenerator: %v", err)
 	}
@@ -179,7 +179,7 @@ func TestFKCorrelation_MultipleDriverDerived(t *testing.T) {
 		},
 	}
 
-	gen, err := NewRowGenerator(table, fkValues, fkLookups, &config.Config{}, nil, nil, nil)
+	gen, err := NewRowGenerator(table, fkValues, fkLookups, nil, &config.Config{}, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("NewRowGenerator: %v", err)
 	}
@@ -218,7 +218,7 @@ func TestFKCorrelation_NoLookups(t *testing.T) {
 		"companyId": {int64(1), int64(2), int64(3)},
 	}
 
-	gen, err := NewRowGenerator(table, fkValues, nil, &config.Config{}, nil, nil, nil)
+	gen, err := NewRowGenerator(table, fkValues, nil, nil, &config.Config{}, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("NewRowGenerator: %v", err)
 	}
@@ -6,6 +6,8 @@ import (
 	"math"
 	"math/rand/v2"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"text/template"
@@ -90,7 +92,13 @@ func DescribeGenerator(col introspect.Column, tableName string, cfg *config.Conf
 	if col.IsGenerated {
 		return "generated (skipped)"
 	}
+	if LeavesDefault(cfg, tableName, col) {
+		return "default expression (skipped)"
+	}
 	if col.FK != nil {
+		if first, card := cfg.GetChildren(tableName); first == col.Name {
+			return fmt.Sprintf("fk -> %s.%s, %s", col.FK.ReferencedTable, col.FK.ReferencedColumn, describeChildren(card))
+		}
 		return fmt.Sprintf("fk -> %s.%s", col.FK.ReferencedTable, col.FK.ReferencedColumn)
 	}
 	if col.IsPrimaryKey && !col.IsAutoInc && col.IsIntegerType() {
@@ -103,9 +111,15 @@ func DescribeGenerator(col introspect.Column, tableName string, cfg *config.Conf
 		}
 		return fmt.Sprintf("enum: [%s]", strings.Join(vals, ", "))
 	}
+	if ts := cfg.GetTimeSeries(tableName, col.Name); ts != nil {
+		return describeTimeSeries(ts)
+	}
 	if tmpl := cfg.GetTemplate(tableName, col.Name); tmpl != "" {
 		return fmt.Sprintf("template: %s", tmpl)
 	}
+	if dist := cfg.GetDistribution(tableName, col.Name); dist != nil && IsRangeDistribution(dist) && IsRangeColumn(col) {
+		return describeRange(col, dist)
+	}
 	if label := NameBasedLabel(col); label != "" {
 		return fmt.Sprintf("heuristic: %s", label)
 	}
@@ -123,23 +137,36 @@ type RowGenerator struct {
 	table              *introspect.Table
 	columns            []introspect.Column // columns we actually generate (excludes auto-inc)
 	generators         []func() any
-	fkValues           map[string][]any // column name -> slice of valid FK values
-	fkLookups          []FKLookup       // correlated FK derivations
+	fkValues           map[string][]any      // column name -> slice of valid FK values
+	fkLookups          []FKLookup            // correlated FK derivations
+	parentRows         map[string]ParentRows // FK column -> parent rows read by templates
 	compositeUniques   []*compositeUniqueTracker
 	faker              *gofakeit.Faker
+	rng                *rand.Rand // source for faker and all other random choices
 	config             *config.Config
 	sequences          map[string]*atomic.Int64 // column name -> sequence counter for non-auto-inc PKs
 	existingUniques    map[string][]any         // column name -> existing values for unique constraint pre-population
-	existingComposites []ExistingCompositeTuple  // composite unique index existing tuples
+	existingComposites []ExistingCompositeTuple // composite unique index existing tuples
+	rows               int                      // rows planned with PlanRows, 0 if unknown
+	ordered            bool                     // a column is a time series
+	deps               map[string][]string      // column name -> columns generated before it
+	order              []int                    // indices of generators in generation order
+	values             map[string]any           // values of the current row, for templates
+	parents            map[string][]string      // FK column -> names templates read its parent row by
+	parentValues       map[string]any           // parent rows of the current row, by name
+	childRows          int                      // rows set by children_per_parent
+	grouped            bool                     // an FK column generates rows grouped by parent
+	returnChild        func()                   // hands back the child slot of a rejected row
 }
 
 // NewRowGenerator creates a generator for the given table.
 // fkValues maps column name -> available parent IDs for FK columns.
 // fkLookups specifies correlated FK derivations (nil to skip).
+// parentRows maps FK column -> parent rows read by templates through .parent (nil to skip).
 // pkStartValues maps column name -> starting value for non-auto-inc integer PKs.
 // existingUniques maps column name -> existing values for single-column unique constraints (nil to skip).
 // existingComposites provides existing tuples for composite unique indexes (nil to skip).
-func NewRowGenerator(table *introspect.Table, fkValues map[string][]any, fkLookups []FKLookup, cfg *config.Config, pkStartValues map[string]int64, existingUniques map[string][]any, existingComposites []ExistingCompositeTuple) (*RowGenerator, error) {
+func NewRowGenerator(table *introspect.Table, fkValues map[string][]any, fkLookups []FKLookup, parentRows map[string]ParentRows, cfg *config.Config, pkStartValues map[string]int64, existingUniques map[string][]any, existingComposites []ExistingCompositeTuple) (*RowGenerator, error) {
 	seqs := make(map[string]*atomic.Int64, len(pkStartValues))
 	for col, start := range pkStartValues {
 		seq := &atomic.Int64{}
@@ -147,19 +174,30 @@ func NewRowGenerator(table *introspect.Table, fkValues map[string][]any, fkLooku
 		seqs[col] = seq
 	}
 
+	var seed int64
+	if cfg != nil {
+		seed = cfg.Options.Seed
+	}
+	rng := NewRand(seed, table.Name)
+
 	rg := &RowGenerator{
 		table:              table,
 		fkValues:           fkValues,
 		fkLookups:          fkLookups,
-		faker:              gofakeit.New(0),
+		parentRows:         parentRows,
+		faker:              gofakeit.NewFaker(rng, false),
+		rng:                rng,
 		config:             cfg,
+		deps:               make(map[string][]string),
+		parents:            make(map[string][]string),
+		parentValues:       make(map[string]any),
 		sequences:          seqs,
 		existingUniques:    existingUniques,
 		existingComposites: existingComposites,
 	}
 
 	for _, col := range table.Columns {
-		if col.IsAutoInc || col.IsGenerated {
+		if col.IsAutoInc || col.IsGenerated || LeavesDefault(cfg, table.Name, col) {
 			continue
 		}
 		rg.columns = append(rg.columns, col)
@@ -179,11 +217,41 @@ func NewRowGenerator(table *introspect.Table, fkValues map[string][]any, fkLooku
 	if err := rg.buildCorrelationGenerators(); err != nil {
 		return nil, err
 	}
+	if err := rg.orderGenerators(); err != nil {
+		return nil, err
+	}
 	rg.initUniqueTracking()
 
 	return rg, nil
 }
 
+// PlanRows tells the generator how many rows it will produce, so time series
+// spread over their start..end range. Call it before the first GenerateRow.
+func (rg *RowGenerator) PlanRows(n int) {
+	rg.rows = n
+}
+
+// Ordered reports whether the order of the generated rows matters because a
+// column is a time series. Insert such rows in order, with a single worker,
+// so auto-increment IDs follow the timestamps.
+func (rg *RowGenerator) Ordered() bool {
+	return rg.ordered
+}
+
+// plannedRows returns the rows planned with PlanRows, or the configured rows
+// of the table.
+func (rg *RowGenerator) plannedRows() int {
+	switch {
+	case rg.rows > 0:
+		return rg.rows
+	case rg.config != nil && rg.config.Tables[rg.table.Name].Rows > 0:
+		return rg.config.Tables[rg.table.Name].Rows
+	case rg.config != nil && rg.config.Options.Rows > 0:
+		return rg.config.Options.Rows
+	}
+	return 1000
+}
+
 // Columns returns the column names that this generator produces values for.
 func (rg *RowGenerator) Columns() []string {
 	names := make([]string, len(rg.columns))
@@ -198,8 +266,12 @@ func (rg *RowGenerator) Columns() []string {
 func (rg *RowGenerator) GenerateRow() []any {
 	for attempt := range maxUniqueRetries {
 		row := make([]any, len(rg.generators))
-		for i, gen := range rg.generators {
-			row[i] = gen()
+		for _, i := range rg.order {
+			row[i] = rg.generators[i]()
+			if rg.values != nil {
+				rg.values[rg.columns[i].Name] = row[i]
+				rg.setParents(rg.columns[i].Name, row[i])
+			}
 		}
 
 		if len(rg.compositeUniques) == 0 {
@@ -210,6 +282,9 @@ func (rg *RowGenerator) GenerateRow() []any {
 			rg.recordCompositeUniques(row)
 			return row
 		}
+		if rg.returnChild != nil {
+			rg.returnChild()
+		}
 		_ = attempt
 	}
 	panic(fmt.Sprintf("unique constraint: exhausted %d retries for composite unique in table %s",
@@ -262,6 +337,7 @@ func FuncMap(f *gofakeit.Faker) template.FuncMap {
 	fm["SliceInt"] = func(args ...int) []int { return args }
 	fm["SliceUInt"] = func(args ...uint) []uint { return args }
 	fm["SliceF32"] = func(args ...float32) []float32 { return args }
+	addRowFuncs(fm, f)
 
 	return fm
 }
@@ -275,7 +351,12 @@ func (rg *RowGenerator) buildGenerator(col introspect.Column) (func() any, error
 	if col.FK != nil {
 		if vals, ok := rg.fkValues[col.Name]; ok && len(vals) > 0 {
 			dist := rg.config.GetDistribution(rg.table.Name, col.Name)
-			picker := NewValuePicker(vals, dist)
+			picker := NewValuePicker(vals, dist, rg.rng)
+			if rg.config != nil {
+				if card, ok := rg.config.Tables[rg.table.Name].Children[col.Name]; ok {
+					return rg.childrenGenerator(col, vals, &card, picker)
+				}
+			}
 			return func() any {
 				return picker.Pick()
 			}, nil
@@ -298,28 +379,61 @@ func (rg *RowGenerator) buildGenerator(col introspect.Column) (func() any, error
 		for i, v := range col.EnumValues {
 			enumVals[i] = v
 		}
-		picker := NewValuePicker(enumVals, dist)
+		picker := NewValuePicker(enumVals, dist, rg.rng)
 		return rg.wrapNullable(col, func() any {
 			return picker.Pick()
 		}), nil
 	}
 
-	// Config template override.
+	// Time series: increasing timestamps in generation order.
+	if ts := rg.config.GetTimeSeries(rg.table.Name, col.Name); ts != nil {
+		gen, err := rg.timeSeriesGenerator(col, ts)
+		if err != nil {
+			return nil, err
+		}
+		rg.ordered = true
+		return rg.wrapNullable(col, gen), nil
+	}
+
+	// Config template override. Templates see the values of the row's
+	// other columns, which are generated first.
 	if tmpl := rg.config.GetTemplate(rg.table.Name, col.Name); tmpl != "" {
 		parsed, err := template.New(col.Name).Funcs(rg.funcMap()).Parse(tmpl)
 		if err != nil {
 			return nil, fmt.Errorf("invalid template for %s.%s: %w", rg.table.Name, col.Name, err)
 		}
+		fkCols, err := rg.bindParents(col.Name, ParentRefs(parsed))
+		if err != nil {
+			return nil, err
+		}
+		if refs := append(TemplateRefs(parsed), fkCols...); len(refs) > 0 {
+			rg.deps[col.Name] = refs
+			rg.values = map[string]any{"parent": rg.parentValues}
+		}
 		var buf bytes.Buffer
 		return rg.wrapNullable(col, func() any {
 			buf.Reset()
-			if err := parsed.Execute(&buf, nil); err != nil {
+			if err := parsed.Execute(&buf, rg.values); err != nil {
 				panic(fmt.Sprintf("template exec failed for %s.%s: %v", rg.table.Name, col.Name, err))
 			}
+			// A template printing only a NULL value, such as a NULL
+			// column or a function of one, is NULL.
+			if buf.String() == noValue {
+				return nil
+			}
 			return buf.String()
 		}), nil
 	}
 
+	// Numeric and date/time columns with a distribution: draw from its range.
+	if dist := rg.config.GetDistribution(rg.table.Name, col.Name); dist != nil && IsRangeDistribution(dist) && IsRangeColumn(col) {
+		gen, err := rg.rangeGenerator(col, dist)
+		if err != nil {
+			return nil, err
+		}
+		return rg.wrapNullable(col, gen), nil
+	}
+
 	// Name-based heuristics.
 	if gen := rg.nameBasedGenerator(col); gen != nil {
 		return rg.wrapNullable(col, gen), nil
@@ -329,16 +443,123 @@ func (rg *RowGenerator) buildGenerator(col introspect.Column) (func() any, error
 	return rg.wrapNullable(col, rg.typeBasedGenerator(col)), nil
 }
 
+// wrapNullable makes gen return NULL at the column's null rate and the
+// column's DEFAULT at its default rate.
 func (rg *RowGenerator) wrapNullable(col introspect.Column, gen func() any) func() any {
+	var nullRate, defaultRate float64
 	if col.IsNullable && !col.IsPrimaryKey {
-		return func() any {
-			if rand.Float64() < 0.1 {
-				return nil
-			}
-			return gen()
+		nullRate = rg.config.NullRate(rg.table.Name, col.Name)
+	}
+	def := DefaultValue(col)
+	if def != nil {
+		defaultRate = rg.config.DefaultRate(rg.table.Name, col.Name)
+	}
+	if nullRate <= 0 && defaultRate <= 0 {
+		return gen
+	}
+	return func() any {
+		u := rg.rng.Float64()
+		switch {
+		case u < nullRate:
+			return nil
+		case u < nullRate+defaultRate:
+			return def()
+		}
+		return gen()
+	}
+}
+
+// LeavesDefault reports whether seeding leaves a column with a DEFAULT
+// expression, e.g. created_at DEFAULT CURRENT_TIMESTAMP, for the database to
+// fill. It does unless the config gives the column a template, distribution
+// or time series, or a non-zero default rate for a DEFAULT the generator can
+// reproduce, or puts it in a correlation group, or another column's template
+// reads it.
+func LeavesDefault(cfg *config.Config, table string, col introspect.Column) bool {
+	if !col.IsDefaultExpr {
+		return false
+	}
+	return cfg.GetTemplate(table, col.Name) == "" &&
+		cfg.GetDistribution(table, col.Name) == nil &&
+		cfg.GetTimeSeries(table, col.Name) == nil &&
+		(cfg.DefaultRate(table, col.Name) == 0 || DefaultValue(col) == nil) &&
+		!inCorrelation(cfg, table, col.Name) &&
+		!templatesRead(cfg, table, col.Name)
+}
+
+// inCorrelation reports whether a correlation group of table has column.
+func inCorrelation(cfg *config.Config, table, column string) bool {
+	for _, group := range cfg.GetCorrelations(table) {
+		if slices.Contains(group.Columns, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// templatesRead reports whether a column template of table reads column.
+// Templates that don't parse read nothing; building the generator reports
+// them.
+func templatesRead(cfg *config.Config, table, column string) bool {
+	if cfg == nil {
+		return false
+	}
+	var funcs template.FuncMap
+	for name, tmpl := range cfg.Tables[table].Columns {
+		if name == column || !strings.Contains(tmpl, column) {
+			continue
+		}
+		if funcs == nil {
+			funcs = FuncMap(gofakeit.New(0))
+		}
+		parsed, err := template.New(name).Funcs(funcs).Parse(tmpl)
+		if err == nil && slices.Contains(TemplateRefs(parsed), column) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultValue returns a generator of the column's DEFAULT, or nil when the
+// column has no default that can be reproduced client-side: no default, a
+// NULL default, an expression other than the current time, or a primary key
+// or unique column, where repeating one value would violate the index.
+func DefaultValue(col introspect.Column) func() any {
+	if col.Default == nil || col.IsPrimaryKey || col.IsUnique || col.IsAutoInc || col.IsGenerated {
+		return nil
+	}
+	def := *col.Default
+	upper := strings.ToUpper(def)
+	if col.IsDefaultExpr || strings.HasPrefix(upper, "CURRENT_TIMESTAMP") {
+		if !isDateType(col.DataType) || !isCurrentTime(upper) {
+			return nil
+		}
+		layout := "2006-01-02 15:04:05"
+		if strings.ToLower(col.DataType) == "date" {
+			layout = "2006-01-02"
+		}
+		return func() any { return time.Now().Format(layout) }
+	}
+	if strings.ToLower(col.DataType) == "bit" && strings.HasPrefix(def, "b'") {
+		n, err := strconv.ParseUint(strings.Trim(def[1:], "'"), 2, 64)
+		if err != nil {
+			return nil
+		}
+		return func() any { return n }
+	}
+	return func() any { return def }
+}
+
+// isCurrentTime reports whether a DEFAULT expression is the current date or
+// time, e.g. CURRENT_TIMESTAMP(3), now() or curdate().
+func isCurrentTime(expr string) bool {
+	expr = strings.Trim(expr, "()")
+	for _, fn := range []string{"CURRENT_TIMESTAMP", "NOW", "LOCALTIMESTAMP", "LOCALTIME", "CURRENT_DATE", "CURDATE"} {
+		if expr == fn || strings.HasPrefix(expr, fn+"(") {
+			return true
 		}
 	}
-	return gen
+	return false
 }
 
 func isStringType(dataType string) bool {
@@ -454,33 +675,33 @@ func (rg *RowGenerator) typeBasedGenerator(col introspect.Column) func() any {
 			return func() any { return rg.faker.Bool() }
 		}
 		if strings.Contains(ct, "unsigned") {
-			return func() any { return rand.IntN(256) }
+			return func() any { return rg.rng.IntN(256) }
 		}
-		return func() any { return rand.IntN(256) - 128 }
+		return func() any { return rg.rng.IntN(256) - 128 }
 
 	case "smallint":
 		if strings.Contains(ct, "unsigned") {
-			return func() any { return rand.IntN(65536) }
+			return func() any { return rg.rng.IntN(65536) }
 		}
-		return func() any { return rand.IntN(65536) - 32768 }
+		return func() any { return rg.rng.IntN(65536) - 32768 }
 
 	case "mediumint":
-		return func() any { return rand.IntN(16777216) }
+		return func() any { return rg.rng.IntN(16777216) }
 
 	case "int", "integer":
 		if strings.Contains(ct, "unsigned") {
-			return func() any { return rand.IntN(2147483647) }
+			return func() any { return rg.rng.IntN(2147483647) }
 		}
-		return func() any { return rand.IntN(2147483647) }
+		return func() any { return rg.rng.IntN(2147483647) }
 
 	case "bigint":
-		return func() any { return rand.Int64N(9223372036854775807) }
+		return func() any { return rg.rng.Int64N(9223372036854775807) }
 
 	case "float":
-		return func() any { return math.Round(rand.Float64()*1000*100) / 100 }
+		return func() any { return math.Round(rg.rng.Float64()*1000*100) / 100 }
 
 	case "double":
-		return func() any { return math.Round(rand.Float64()*10000*100) / 100 }
+		return func() any { return math.Round(rg.rng.Float64()*10000*100) / 100 }
 
 	case "decimal", "numeric":
 		precision := int64(10)
@@ -494,7 +715,7 @@ func (rg *RowGenerator) typeBasedGenerator(col introspect.Column) func() any {
 		maxVal := math.Pow(10, float64(precision-scale)) - 1
 		scaleFactor := math.Pow(10, float64(scale))
 		return func() any {
-			return math.Round(rand.Float64()*maxVal*scaleFactor) / scaleFactor
+			return math.Round(rg.rng.Float64()*maxVal*scaleFactor) / scaleFactor
 		}
 
 	case "varchar", "char":
@@ -531,11 +752,11 @@ func (rg *RowGenerator) typeBasedGenerator(col introspect.Column) func() any {
 
 	case "time":
 		return func() any {
-			return fmt.Sprintf("%02d:%02d:%02d", rand.IntN(24), rand.IntN(60), rand.IntN(60))
+			return fmt.Sprintf("%02d:%02d:%02d", rg.rng.IntN(24), rg.rng.IntN(60), rg.rng.IntN(60))
 		}
 
 	case "year":
-		return func() any { return 2000 + rand.IntN(26) }
+		return func() any { return 2000 + rg.rng.IntN(26) }
 
 	case "json":
 		return func() any { return "{}" }
@@ -544,13 +765,13 @@ func (rg *RowGenerator) typeBasedGenerator(col introspect.Column) func() any {
 		return func() any {
 			b := make([]byte, 16)
 			for i := range b {
-				b[i] = byte(rand.IntN(256))
+				b[i] = byte(rg.rng.IntN(256))
 			}
 			return b
 		}
 
 	case "bit":
-		return func() any { return rand.IntN(2) }
+		return func() any { return rg.rng.IntN(2) }
 
 	default:
 		// Unknown type: generate a short string.
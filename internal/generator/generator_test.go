@@ -1,7 +1,10 @@
 package generator
 
 import (
+	"math"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/tomfevang/go-test-my-db/internal/config"
 	"github.com/tomfevang/go-test-my-db/internal/introspect"
@@ -98,6 +101,18 @@ func TestDescribeGenerator(t *testing.T) {
 			cfg:       &config.Config{},
 			expected:  "fk -> users.id",
 		},
+		{
+			name: "foreign_key_children",
+			col: introspect.Column{
+				Name: "user_id", DataType: "int",
+				FK: &introspect.ForeignKey{ReferencedTable: "users", ReferencedColumn: "id"},
+			},
+			tableName: "orders",
+			cfg: &config.Config{Tables: map[string]config.TableConfig{"orders": {Children: map[string]config.CardinalityConfig{
+				"user_id": {Max: 500, Type: "zipf", None: 0.2},
+			}}}},
+			expected: "fk -> users.id, 0-500 per parent, zipf, 20% none",
+		},
 		{
 			name:      "sequential_pk",
 			col:       introspect.Column{Name: "id", DataType: "int", IsPrimaryKey: true},
@@ -178,6 +193,17 @@ func TestDescribeGenerator(t *testing.T) {
 			},
 			expected: "template: {{ Email }}@example.com",
 		},
+		{
+			name:      "distribution_beats_heuristic",
+			col:       introspect.Column{Name: "price", DataType: "int", ColumnType: "int unsigned"},
+			tableName: "products",
+			cfg: &config.Config{
+				Tables: map[string]config.TableConfig{
+					"products": {Distributions: map[string]config.DistributionConfig{"price": {Type: "lognormal", Min: "1", Max: "1e6"}}},
+				},
+			},
+			expected: "distribution: lognormal from 1 to 1000000",
+		},
 	}
 
 	for _, tt := range tests {
@@ -189,3 +215,162 @@ func TestDescribeGenerator(t *testing.T) {
 		})
 	}
 }
+
+func TestRowGenerator_Seed(t *testing.T) {
+	table := &introspect.Table{
+		Name: "events",
+		Columns: []introspect.Column{
+			{Name: "id", DataType: "int", ColumnType: "int", IsPrimaryKey: true, IsAutoInc: true},
+			{Name: "email", DataType: "varchar", ColumnType: "varchar(255)"},
+			{Name: "score", DataType: "int", ColumnType: "int"},
+			{Name: "status", DataType: "enum", ColumnType: "enum('a','b','c')", EnumValues: []string{"a", "b", "c"}},
+			{Name: "note", DataType: "varchar", ColumnType: "varchar(50)", IsNullable: true},
+		},
+	}
+	generate := func(seed int64) [][]any {
+		cfg := &config.Config{Options: config.Options{Seed: seed}}
+		gen, err := NewRowGenerator(table, nil, nil, nil, cfg, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("NewRowGenerator: %v", err)
+		}
+		rows := make([][]any, 50)
+		for i := range rows {
+			rows[i] = gen.GenerateRow()
+		}
+		return rows
+	}
+
+	if a, b := generate(7), generate(7); !reflect.DeepEqual(a, b) {
+		t.Error("same seed produced different rows")
+	}
+	if a, b := generate(7), generate(8); reflect.DeepEqual(a, b) {
+		t.Error("different seeds produced identical rows")
+	}
+	if a, b := generate(0), generate(0); reflect.DeepEqual(a, b) {
+		t.Error("seed 0 should produce random rows")
+	}
+}
+
+func TestLeavesDefault(t *testing.T) {
+	now, uuid := "CURRENT_TIMESTAMP", "uuid_to_bin(uuid())"
+	table := &introspect.Table{
+		Name: "events",
+		Columns: []introspect.Column{
+			{Name: "id", DataType: "binary", ColumnType: "binary(16)", IsPrimaryKey: true, Default: &uuid, IsDefaultExpr: true},
+			{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+			{Name: "created_at", DataType: "timestamp", ColumnType: "timestamp", Default: &now, IsDefaultExpr: true},
+			{Name: "updated_at", DataType: "timestamp", ColumnType: "timestamp", Default: &now, IsDefaultExpr: true},
+		},
+	}
+	half := 0.5
+	tests := []struct {
+		name  string
+		table config.TableConfig
+		want  []string
+	}{
+		{"untargeted", config.TableConfig{}, []string{"name"}},
+		{"template", config.TableConfig{Columns: map[string]string{"created_at": "2024-01-01 00:00:00"}}, []string{"name", "created_at"}},
+		{"time series", config.TableConfig{TimeSeries: map[string]config.TimeSeriesConfig{"updated_at": {Start: "2024-01-01", End: "2024-12-31"}}}, []string{"name", "updated_at"}},
+		{"default rate", config.TableConfig{DefaultRate: &half}, []string{"name", "created_at", "updated_at"}},
+		{"read by a template", config.TableConfig{Columns: map[string]string{"updated_at": `{{After .created_at "2d"}}`}}, []string{"name", "created_at", "updated_at"}},
+		{"correlation", config.TableConfig{Correlations: []config.CorrelationGroup{{Columns: []string{"name", "created_at"}, Source: "template", Template: map[string]string{"name": "{{Word}}", "created_at": "2024-01-01 00:00:00"}}}}, []string{"name", "created_at"}},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{Tables: map[string]config.TableConfig{"events": tt.table}}
+		gen, err := NewRowGenerator(table, nil, nil, nil, cfg, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := gen.Columns(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: columns = %v, want %v", tt.name, got, tt.want)
+		}
+		gen.GenerateRow()
+	}
+}
+
+func TestRowGenerator_Rates(t *testing.T) {
+	active, now := "active", "CURRENT_TIMESTAMP"
+	table := &introspect.Table{
+		Name: "users",
+		Columns: []introspect.Column{
+			{Name: "id", DataType: "int", ColumnType: "int", IsPrimaryKey: true, IsAutoInc: true},
+			{Name: "deleted_at", DataType: "datetime", ColumnType: "datetime", IsNullable: true},
+			{Name: "nickname", DataType: "varchar", ColumnType: "varchar(50)", IsNullable: true},
+			{Name: "bio", DataType: "varchar", ColumnType: "varchar(50)", IsNullable: true},
+			{Name: "status", DataType: "enum", ColumnType: "enum('active','banned')", EnumValues: []string{"active", "banned"}, Default: &active},
+			{Name: "created_at", DataType: "timestamp", ColumnType: "timestamp", Default: &now, IsDefaultExpr: true},
+		},
+	}
+	half, never := 0.5, 0.0
+	cfg := &config.Config{
+		Options: config.Options{Seed: 1, NullRate: &half},
+		Tables: map[string]config.TableConfig{"users": {
+			NullRates:    map[string]float64{"deleted_at": 0.95, "nickname": 0},
+			DefaultRate:  &never,
+			DefaultRates: map[string]float64{"status": 0.8, "created_at": 1},
+		}},
+	}
+	gen, err := NewRowGenerator(table, nil, nil, nil, cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 2000
+	nulls := make(map[string]int)
+	var activeRows int
+	for range n {
+		row := gen.GenerateRow()
+		for i, name := range gen.Columns() {
+			if row[i] == nil {
+				nulls[name]++
+			}
+		}
+		if row[3] == "active" {
+			activeRows++
+		}
+		if _, err := time.Parse("2006-01-02 15:04:05", row[4].(string)); err != nil {
+			t.Fatalf("created_at = %v, want the current time", row[4])
+		}
+	}
+	within := func(got int, want float64) bool { return math.Abs(float64(got)/n-want) < 0.04 }
+	if !within(nulls["deleted_at"], 0.95) {
+		t.Errorf("deleted_at: %d of %d NULL, want about 95%%", nulls["deleted_at"], n)
+	}
+	if nulls["nickname"] != 0 {
+		t.Errorf("nickname: %d NULLs, want none", nulls["nickname"])
+	}
+	if !within(nulls["bio"], 0.5) {
+		t.Errorf("bio: %d of %d NULL, want about 50%% from options.null_rate", nulls["bio"], n)
+	}
+	// 80% take the default and half of the rest pick active.
+	if !within(activeRows, 0.9) {
+		t.Errorf("status: %d of %d active, want about 90%%", activeRows, n)
+	}
+}
+
+func TestDefaultValue(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name string
+		col  introspect.Column
+		want any // nil when the default cannot be reproduced
+	}{
+		{"literal", introspect.Column{DataType: "varchar", Default: str("n/a")}, "n/a"},
+		{"number", introspect.Column{DataType: "int", Default: str("0")}, "0"},
+		{"bit", introspect.Column{DataType: "bit", Default: str("b'1'")}, uint64(1)},
+		{"no default", introspect.Column{DataType: "int"}, nil},
+		{"unique", introspect.Column{DataType: "varchar", Default: str("x"), IsUnique: true}, nil},
+		{"uuid expression", introspect.Column{DataType: "varchar", Default: str("uuid()"), IsDefaultExpr: true}, nil},
+		{"current date", introspect.Column{DataType: "date", Default: str("curdate()"), IsDefaultExpr: true}, time.Now().Format("2006-01-02")},
+	}
+	for _, tt := range tests {
+		gen := DefaultValue(tt.col)
+		if (gen == nil) != (tt.want == nil) {
+			t.Errorf("%s: DefaultValue() nil = %v, want %v", tt.name, gen == nil, tt.want == nil)
+			continue
+		}
+		if gen != nil && gen() != tt.want {
+			t.Errorf("%s: default = %v, want %v", tt.name, gen(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+// ParentRows holds the columns of the parent rows that child column
+// templates read through .parent, by the parent's key. Add rows with
+// ParentKey keys.
+type ParentRows map[any]map[string]any
+
+// ParentKey returns v as a key of ParentRows. Values scanned from the
+// database as []byte become strings.
+func ParentKey(v any) any {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+// ParentRef is a parent column read by a column template, such as
+// {{.parent.orders.created_at}}. Parent names the parent by its table, or
+// by the foreign key column when several reference the same table.
+type ParentRef struct {
+	Parent string
+	Column string // empty when the template reads the parent row as a whole
+}
+
+func (r ParentRef) String() string {
+	s := ".parent"
+	for _, part := range []string{r.Parent, r.Column} {
+		if part != "" {
+			s += "." + part
+		}
+	}
+	return s
+}
+
+// ParentRefs returns the parent columns a column template reads, in order of
+// appearance.
+func ParentRefs(t *template.Template) []ParentRef {
+	var refs []ParentRef
+	for _, field := range templateFields(t) {
+		if field[0] != "parent" {
+			continue
+		}
+		var ref ParentRef
+		if len(field) > 1 {
+			ref.Parent = field[1]
+		}
+		if len(field) > 2 {
+			ref.Column = field[2]
+		}
+		if !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// ResolveParent returns the foreign key column of table leading to the
+// parent a template names: the FK column of that name, or the only FK
+// column referencing a table of that name.
+func ResolveParent(table *introspect.Table, parent string) (*introspect.Column, error) {
+	var matches []*introspect.Column
+	for i := range table.Columns {
+		col := &table.Columns[i]
+		if col.FK == nil {
+			continue
+		}
+		if col.Name == parent {
+			return col, nil
+		}
+		if col.FK.ReferencedTable == parent {
+			matches = append(matches, col)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%s has no foreign key to a table or column named %q", table.Name, parent)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, col := range matches {
+		names[i] = col.Name
+	}
+	return nil, fmt.Errorf("%s has several foreign keys to %s (%s); name the column instead, as in .parent.%s", table.Name, parent, strings.Join(names, ", "), names[0])
+}
+
+// resolveParentRef returns the foreign key column of ref, which must name a
+// parent column.
+func resolveParentRef(table *introspect.Table, column string, ref ParentRef) (*introspect.Column, error) {
+	if ref.Parent == "" || ref.Column == "" {
+		return nil, fmt.Errorf("template for %s.%s reads %s; name a parent and its column, as in .parent.orders.created_at", table.Name, column, ref)
+	}
+	fk, err := ResolveParent(table, ref.Parent)
+	if err != nil {
+		return nil, fmt.Errorf("template for %s.%s reads %s: %w", table.Name, column, ref, err)
+	}
+	return fk, nil
+}
+
+// ParentColumns returns the parent columns the column templates of table
+// read, by foreign key column, so the seeder can fetch them with the
+// parent keys.
+func ParentColumns(table *introspect.Table, cfg *config.Config) (map[string][]string, error) {
+	needed := make(map[string][]string)
+	for _, col := range table.Columns {
+		tmpl := cfg.GetTemplate(table.Name, col.Name)
+		if tmpl == "" || col.FK != nil || col.IsAutoInc || col.IsGenerated {
+			continue
+		}
+		parsed, err := template.New(col.Name).Funcs(FuncMap(gofakeit.New(0))).Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for %s.%s: %w", table.Name, col.Name, err)
+		}
+		for _, ref := range ParentRefs(parsed) {
+			fk, err := resolveParentRef(table, col.Name, ref)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(needed[fk.Name], ref.Column) {
+				needed[fk.Name] = append(needed[fk.Name], ref.Column)
+			}
+		}
+	}
+	return needed, nil
+}
+
+// bindParents makes the parent rows read by the template of column
+// available to it as .parent, returning the FK columns it depends on.
+func (rg *RowGenerator) bindParents(column string, refs []ParentRef) ([]string, error) {
+	var fkCols []string
+	for _, ref := range refs {
+		fk, err := resolveParentRef(rg.table, column, ref)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(rg.parents[fk.Name], ref.Parent) {
+			rg.parents[fk.Name] = append(rg.parents[fk.Name], ref.Parent)
+		}
+		// A NULL foreign key has no parent, so its columns are NULL.
+		rg.parentValues[ref.Parent] = map[string]any(nil)
+		if !slices.Contains(fkCols, fk.Name) {
+			fkCols = append(fkCols, fk.Name)
+		}
+	}
+	return fkCols, nil
+}
+
+// setParents makes the parent row of value, generated for column, available
+// to templates.
+func (rg *RowGenerator) setParents(column string, value any) {
+	for _, name := range rg.parents[column] {
+		rg.parentValues[name] = rg.parentRows[column][ParentKey(value)]
+	}
+}
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+var orderItems = &introspect.Table{
+	Name: "order_items",
+	Columns: []introspect.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", IsPrimaryKey: true, IsAutoInc: true},
+		{Name: "created_at", DataType: "datetime", ColumnType: "datetime"},
+		{Name: "currency", DataType: "char", ColumnType: "char(3)"},
+		{Name: "order_id", DataType: "int", ColumnType: "int", FK: &introspect.ForeignKey{ReferencedTable: "orders", ReferencedColumn: "id"}},
+		{Name: "from_account", DataType: "int", ColumnType: "int", FK: &introspect.ForeignKey{ReferencedTable: "accounts", ReferencedColumn: "id"}},
+		{Name: "to_account", DataType: "int", ColumnType: "int", FK: &introspect.ForeignKey{ReferencedTable: "accounts", ReferencedColumn: "id"}},
+	},
+}
+
+func TestRowGenerator_ParentColumns(t *testing.T) {
+	cfg := &config.Config{
+		Options: config.Options{Seed: 1},
+		Tables: map[string]config.TableConfig{"order_items": {
+			Columns: map[string]string{
+				"created_at": `{{After .parent.orders.created_at "2d"}}`,
+				"currency":   "{{.parent.to_account.currency}}",
+			},
+		}},
+	}
+
+	needed, err := ParentColumns(orderItems, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"order_id": {"created_at"}, "to_account": {"currency"}}
+	if !reflect.DeepEqual(needed, want) {
+		t.Errorf("ParentColumns = %v, want %v", needed, want)
+	}
+
+	// Keys scanned from the database may be []byte.
+	orders, accounts := ParentRows{}, ParentRows{}
+	for i := range 10 {
+		created := time.Date(2024, 1, 1+i, 12, 0, 0, 0, time.UTC).Format(time.DateTime)
+		orders[ParentKey(int64(i))] = map[string]any{"created_at": created}
+		accounts[ParentKey([]byte{'a' + byte(i)})] = map[string]any{"currency": []string{"EUR", "USD"}[i%2]}
+	}
+	fkValues := map[string][]any{"order_id": {}, "from_account": {}, "to_account": {}}
+	for i := range 11 { // order 10 has no parent row
+		fkValues["order_id"] = append(fkValues["order_id"], int64(i))
+	}
+	for i := range 10 {
+		fkValues["from_account"] = append(fkValues["from_account"], []byte{'a' + byte(i)})
+		fkValues["to_account"] = append(fkValues["to_account"], []byte{'a' + byte(i)})
+	}
+	parentRows := map[string]ParentRows{"order_id": orders, "to_account": accounts}
+
+	gen, err := NewRowGenerator(orderItems, fkValues, nil, parentRows, cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orphans int
+	for range 500 {
+		row := gen.GenerateRow() // created_at, currency, order_id, from_account, to_account
+		if want := accounts[ParentKey(row[4])]["currency"]; row[1] != want {
+			t.Fatalf("currency = %v, want %v of account %s", row[1], want, row[4])
+		}
+		if row[2] == int64(10) {
+			if row[0] != nil {
+				t.Fatalf("created_at = %v without a parent row, want NULL", row[0])
+			}
+			orphans++
+			continue
+		}
+		created, _ := time.Parse(time.DateTime, orders[row[2]]["created_at"].(string))
+		got, err := time.Parse(time.DateTime, row[0].(string))
+		if err != nil || got.Before(created) || got.Sub(created) > 48*time.Hour {
+			t.Fatalf("created_at = %v, want within 2 days after the order's %v", row[0], created)
+		}
+	}
+	if orphans == 0 {
+		t.Error("no rows of order 10, which has no parent row")
+	}
+}
+
+func TestRowGenerator_ParentErrors(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		wantErr string
+	}{
+		{"{{.parent.accounts.currency}}", "template for order_items.currency reads .parent.accounts.currency: order_items has several foreign keys to accounts (from_account, to_account); name the column instead, as in .parent.from_account"},
+		{"{{.parent.users.currency}}", `template for order_items.currency reads .parent.users.currency: order_items has no foreign key to a table or column named "users"`},
+		{"{{.parent.orders}}", "template for order_items.currency reads .parent.orders; name a parent and its column, as in .parent.orders.created_at"},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{Tables: map[string]config.TableConfig{"order_items": {Columns: map[string]string{"currency": tt.tmpl}}}}
+		_, err := NewRowGenerator(orderItems, nil, nil, nil, cfg, nil, nil, nil)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s: err = %v, want %q", tt.tmpl, err, tt.wantErr)
+		}
+		if _, err := ParentColumns(orderItems, cfg); err == nil || err.Error() != tt.wantErr {
+			t.Errorf("ParentColumns for %s: err = %v, want %q", tt.tmpl, err, tt.wantErr)
+		}
+	}
+}
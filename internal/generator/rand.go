@@ -0,0 +1,20 @@
+package generator
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+)
+
+// NewRand returns the random source for one consumer of generated data,
+// identified by key (usually a table or table.column name). With a non-zero
+// seed the stream depends only on seed and key, so identical configs produce
+// identical data regardless of what else is seeded; seed 0 picks a random
+// seed.
+func NewRand(seed int64, key string) *rand.Rand {
+	if seed == 0 {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return rand.New(rand.NewPCG(uint64(seed), h.Sum64()))
+}
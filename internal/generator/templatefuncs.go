@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// Number is the result of template arithmetic. It prints without an
+// exponent, so DECIMAL and INT columns accept it.
+type Number float64
+
+func (n Number) String() string {
+	return strconv.FormatFloat(float64(n), 'f', -1, 64)
+}
+
+// addRowFuncs adds the functions templates use to derive a column from the
+// values of other columns: arithmetic and date offsets. NULL arguments give
+// NULL results.
+func addRowFuncs(fm template.FuncMap, f *gofakeit.Faker) {
+	arith := func(op func(a, b float64) float64) func(a, b any) (any, error) {
+		return func(a, b any) (any, error) {
+			if a == nil || b == nil {
+				return nil, nil
+			}
+			x, err := toFloat(a)
+			if err != nil {
+				return nil, err
+			}
+			y, err := toFloat(b)
+			if err != nil {
+				return nil, err
+			}
+			return Number(op(x, y)), nil
+		}
+	}
+	fm["Add"] = arith(func(a, b float64) float64 { return a + b })
+	fm["Sub"] = arith(func(a, b float64) float64 { return a - b })
+	fm["Mul"] = arith(func(a, b float64) float64 { return a * b })
+	fm["Div"] = arith(func(a, b float64) float64 { return a / b })
+	fm["Round"] = func(v any, places int) (any, error) {
+		if v == nil {
+			return nil, nil
+		}
+		x, err := toFloat(v)
+		if err != nil {
+			return nil, err
+		}
+		factor := math.Pow(10, float64(places))
+		return Number(math.Round(x*factor) / factor), nil
+	}
+
+	shift := func(v any, by func(time.Time) time.Time) (any, error) {
+		if v == nil {
+			return nil, nil
+		}
+		t, layout, err := toTime(v)
+		if err != nil {
+			return nil, err
+		}
+		return by(t).Format(layout), nil
+	}
+	fm["AddDays"] = func(v any, days int) (any, error) {
+		return shift(v, func(t time.Time) time.Time { return t.AddDate(0, 0, days) })
+	}
+	fm["AddDuration"] = func(v any, d string) (any, error) {
+		dur, err := parseSignedInterval(d)
+		if err != nil {
+			return nil, err
+		}
+		return shift(v, func(t time.Time) time.Time { return t.Add(dur) })
+	}
+	// After and Before return a random time up to d after or before v.
+	fm["After"] = func(v any, d string) (any, error) {
+		dur, err := parseInterval(d)
+		if err != nil {
+			return nil, err
+		}
+		return shift(v, func(t time.Time) time.Time { return t.Add(time.Duration(f.Float64() * float64(dur))) })
+	}
+	fm["Before"] = func(v any, d string) (any, error) {
+		dur, err := parseInterval(d)
+		if err != nil {
+			return nil, err
+		}
+		return shift(v, func(t time.Time) time.Time { return t.Add(-time.Duration(f.Float64() * float64(dur))) })
+	}
+}
+
+// toFloat converts a generated value or template literal to a number.
+func toFloat(v any) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		if x, err := strconv.ParseFloat(rv.String(), 64); err == nil {
+			return x, nil
+		}
+	}
+	return 0, fmt.Errorf("%v is not a number", v)
+}
+
+// toTime parses a generated date or datetime, returning the layout to
+// format the result with.
+func toTime(v any) (time.Time, string, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, time.DateTime, nil
+	case string:
+		for _, layout := range []string{time.DateTime, time.DateOnly, time.RFC3339} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, layout, nil
+			}
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("%v is not a date or datetime", v)
+}
+
+// parseSignedInterval parses a duration such as 2d or -1h30m.
+func parseSignedInterval(s string) (time.Duration, error) {
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		d, err := parseInterval(rest)
+		return -d, err
+	}
+	return parseInterval(s)
+}
@@ -0,0 +1,274 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+// Defaults of time series.
+const (
+	defaultSeriesSpan  = 30 * 24 * time.Hour
+	defaultPeakHour    = 14
+	defaultBurstLength = time.Hour
+	defaultBurstFactor = 10
+)
+
+// maxSeriesBuckets bounds the intensity buckets of a series, a bucket per
+// minute up to about three months.
+const maxSeriesBuckets = 1 << 17
+
+// seriesPlan is a validated time series config. One of start and end may
+// depend on the number of rows when interval is set.
+type seriesPlan struct {
+	start, end       time.Time
+	startSet, endSet bool
+	interval         time.Duration
+	jitter           float64
+	diurnal          float64
+	peakHour         int
+	weekend          float64
+	bursts           int
+	burstLength      time.Duration
+	burstFactor      float64
+}
+
+// IsTimeSeriesColumn reports whether col can hold a time series: date,
+// datetime and timestamp columns.
+func IsTimeSeriesColumn(col introspect.Column) bool {
+	return isDateType(col.DataType)
+}
+
+// CheckTimeSeries reports whether the parameters of ts are valid. Errors
+// are *ParamError naming the offending key.
+func CheckTimeSeries(ts config.TimeSeriesConfig) error {
+	_, err := planTimeSeries(&ts, time.Now())
+	return err
+}
+
+// planTimeSeries validates ts and resolves its bounds against now.
+func planTimeSeries(ts *config.TimeSeriesConfig, now time.Time) (seriesPlan, error) {
+	p := seriesPlan{
+		jitter:      1,
+		diurnal:     ts.Diurnal,
+		peakHour:    defaultPeakHour,
+		weekend:     1,
+		bursts:      ts.Bursts.Count,
+		burstLength: defaultBurstLength,
+		burstFactor: defaultBurstFactor,
+	}
+
+	var err error
+	if ts.Start != "" {
+		if p.start, err = parseTime(ts.Start, now); err != nil {
+			return p, &ParamError{"start", err}
+		}
+		p.startSet = true
+	}
+	if ts.End != "" {
+		if p.end, err = parseTime(ts.End, now); err != nil {
+			return p, &ParamError{"end", err}
+		}
+		p.endSet = true
+	}
+	if ts.Interval != "" {
+		if p.interval, err = parseInterval(ts.Interval); err != nil {
+			return p, &ParamError{"interval", err}
+		}
+		if p.startSet && p.endSet {
+			return p, &ParamError{"interval", errors.New("set at most two of start, end and interval")}
+		}
+	}
+	if !p.endSet {
+		p.end = now.UTC().Truncate(time.Second)
+	}
+	if !p.startSet && p.interval == 0 {
+		p.start = p.end.Add(-defaultSeriesSpan)
+	}
+	if p.interval == 0 && !p.start.Before(p.end) {
+		key := "end"
+		if !p.endSet {
+			key = "start"
+		}
+		return p, &ParamError{key, fmt.Errorf("start %s must be before end %s", p.start.Format(time.DateTime), p.end.Format(time.DateTime))}
+	}
+
+	if ts.Jitter != nil {
+		if p.jitter = *ts.Jitter; p.jitter < 0 || p.jitter > 1 {
+			return p, &ParamError{"jitter", fmt.Errorf("%v must be between 0 and 1", p.jitter)}
+		}
+	}
+	if p.diurnal < 0 || p.diurnal > 1 {
+		return p, &ParamError{"diurnal", fmt.Errorf("%v must be between 0 and 1", p.diurnal)}
+	}
+	if ts.PeakHour != nil {
+		if p.peakHour = *ts.PeakHour; p.peakHour < 0 || p.peakHour > 23 {
+			return p, &ParamError{"peak_hour", fmt.Errorf("%d must be between 0 and 23", p.peakHour)}
+		}
+	}
+	if ts.Weekend != nil {
+		if p.weekend = *ts.Weekend; p.weekend < 0 {
+			return p, &ParamError{"weekend", fmt.Errorf("%v must not be negative", p.weekend)}
+		}
+	}
+	if p.bursts < 0 {
+		return p, &ParamError{"bursts", fmt.Errorf("count %d must not be negative", p.bursts)}
+	}
+	if ts.Bursts.Duration != "" {
+		if p.burstLength, err = parseInterval(ts.Bursts.Duration); err != nil {
+			return p, &ParamError{"bursts", fmt.Errorf("duration: %w", err)}
+		}
+	}
+	if ts.Bursts.Factor < 0 {
+		return p, &ParamError{"bursts", fmt.Errorf("factor %v must not be negative", ts.Bursts.Factor)}
+	}
+	if ts.Bursts.Factor > 0 {
+		p.burstFactor = ts.Bursts.Factor
+	}
+	return p, nil
+}
+
+// span returns the first and last timestamp of a series of n rows.
+func (p seriesPlan) span(n int) (start, end time.Time) {
+	start, end = p.start, p.end
+	switch {
+	case p.interval == 0:
+	case p.startSet:
+		end = start.Add(time.Duration(n) * p.interval)
+	default:
+		start = end.Add(-time.Duration(n) * p.interval)
+	}
+	return start, end
+}
+
+// rate returns the relative rate of rows at t.
+func (p seriesPlan) rate(t time.Time, bursts []time.Time) float64 {
+	r := 1.0
+	if p.diurnal > 0 {
+		hour := float64(t.Hour()) + float64(t.Minute())/60
+		r *= 1 + p.diurnal*math.Cos(2*math.Pi*(hour-float64(p.peakHour))/24)
+	}
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		r *= p.weekend
+	}
+	for _, b := range bursts {
+		if !t.Before(b) && t.Before(b.Add(p.burstLength)) {
+			r *= p.burstFactor
+			break
+		}
+	}
+	return r
+}
+
+// series returns a function returning the timestamps of n rows in order.
+// Rows are spaced evenly, or with jitter at random, in the cumulative rate
+// of rows over time, so the series spans start..end while busy hours get
+// more rows. Rows past the nth stay at end.
+func (p seriesPlan) series(n int, rng *rand.Rand) func() time.Time {
+	start, end := p.span(n)
+	secs := end.Sub(start).Seconds()
+
+	bursts := make([]time.Time, p.bursts)
+	for i := range bursts {
+		room := max(secs-p.burstLength.Seconds(), 0)
+		bursts[i] = start.Add(time.Duration(rng.Float64() * room * float64(time.Second)))
+	}
+
+	// cum[i] is the cumulative rate up to bucket i.
+	buckets := int(min(max(secs/60, 1), maxSeriesBuckets))
+	width := secs / float64(buckets)
+	cum := make([]float64, buckets+1)
+	for i := range buckets {
+		mid := start.Add(time.Duration((float64(i) + 0.5) * width * float64(time.Second)))
+		cum[i+1] = cum[i] + p.rate(mid, bursts)*width
+	}
+	total := cum[buckets]
+	step := total / float64(max(n, 1))
+
+	var u float64
+	bucket := 0
+	return func() time.Time {
+		u = min(u+step*((1-p.jitter)+p.jitter*rng.ExpFloat64()), total)
+		for bucket < buckets-1 && cum[bucket+1] < u {
+			bucket++
+		}
+		frac := 0.0
+		if d := cum[bucket+1] - cum[bucket]; d > 0 {
+			frac = (u - cum[bucket]) / d
+		}
+		return start.Add(time.Duration((float64(bucket) + frac) * width * float64(time.Second)))
+	}
+}
+
+// timeSeriesGenerator generates the increasing timestamps of a date or time
+// column. The series is laid out on first use, for the rows planned with
+// PlanRows.
+func (rg *RowGenerator) timeSeriesGenerator(col introspect.Column, ts *config.TimeSeriesConfig) (func() any, error) {
+	if !IsTimeSeriesColumn(col) {
+		return nil, fmt.Errorf("time series for %s.%s: %s columns cannot hold timestamps", rg.table.Name, col.Name, strings.ToLower(col.DataType))
+	}
+	plan, err := planTimeSeries(ts, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid time series for %s.%s: %w", rg.table.Name, col.Name, err)
+	}
+	layout := time.DateTime
+	if strings.EqualFold(col.DataType, "date") {
+		layout = time.DateOnly
+	}
+
+	var next func() time.Time
+	return func() any {
+		if next == nil {
+			next = plan.series(rg.plannedRows(), rg.rng)
+		}
+		return next().UTC().Format(layout)
+	}, nil
+}
+
+// parseInterval parses a positive duration such as 30s, 1h30m, 2d or 1w.
+func parseInterval(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil && len(s) > 1 {
+		if n, nerr := strconv.Atoi(s[:len(s)-1]); nerr == nil {
+			switch s[len(s)-1] {
+			case 'd':
+				d, err = time.Duration(n)*24*time.Hour, nil
+			case 'w':
+				d, err = time.Duration(n)*7*24*time.Hour, nil
+			}
+		}
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%q is not a positive duration such as 30s, 1h30m or 2d", s)
+	}
+	return d, nil
+}
+
+// describeTimeSeries describes a time series for DescribeGenerator.
+func describeTimeSeries(ts *config.TimeSeriesConfig) string {
+	var params []string
+	add := func(key string, v any, set bool) {
+		if set {
+			params = append(params, fmt.Sprintf("%s %v", key, v))
+		}
+	}
+	add("from", ts.Start, ts.Start != "")
+	add("to", ts.End, ts.End != "")
+	add("every", ts.Interval, ts.Interval != "")
+	add("diurnal", ts.Diurnal, ts.Diurnal > 0)
+	if ts.Weekend != nil {
+		add("weekend", *ts.Weekend, true)
+	}
+	add("bursts", ts.Bursts.Count, ts.Bursts.Count > 0)
+	if len(params) == 0 {
+		return "time series"
+	}
+	return "time series: " + strings.Join(params, ", ")
+}
@@ -0,0 +1,141 @@
+package generator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+// seriesRows generates n rows of a table with a time series on created_at
+// and returns the timestamps.
+func seriesRows(t *testing.T, ts config.TimeSeriesConfig, n int) []time.Time {
+	t.Helper()
+	table := &introspect.Table{
+		Name: "events",
+		Columns: []introspect.Column{
+			{Name: "id", DataType: "bigint", ColumnType: "bigint", IsPrimaryKey: true, IsAutoInc: true},
+			{Name: "created_at", DataType: "datetime", ColumnType: "datetime"},
+		},
+	}
+	cfg := &config.Config{
+		Options: config.Options{Seed: 1},
+		Tables: map[string]config.TableConfig{"events": {
+			TimeSeries: map[string]config.TimeSeriesConfig{"created_at": ts},
+		}},
+	}
+	gen, err := NewRowGenerator(table, nil, nil, nil, cfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gen.Ordered() {
+		t.Error("Ordered() = false for a table with a time series")
+	}
+	gen.PlanRows(n)
+
+	times := make([]time.Time, n)
+	for i := range times {
+		times[i], err = time.Parse(time.DateTime, gen.GenerateRow()[0].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return times
+}
+
+func TestTimeSeries(t *testing.T) {
+	start, end := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	zero, half := 0.0, 0.5
+
+	t.Run("monotonic over start to end", func(t *testing.T) {
+		times := seriesRows(t, config.TimeSeriesConfig{Start: "2024-03-04", End: "2024-03-31", Jitter: &zero}, 2000)
+		for i := 1; i < len(times); i++ {
+			if times[i].Before(times[i-1]) {
+				t.Fatalf("row %d at %v is before row %d at %v", i, times[i], i-1, times[i-1])
+			}
+		}
+		if times[0].Before(start) || times[0].Sub(start) > 30*time.Minute || end.Sub(times[len(times)-1]) > time.Second {
+			t.Errorf("series runs from %v to %v, want %v to %v", times[0], times[len(times)-1], start, end)
+		}
+	})
+
+	t.Run("seasonality", func(t *testing.T) {
+		ts := config.TimeSeriesConfig{Start: "2024-03-04", End: "2024-03-31", Diurnal: 1, Weekend: &half, Jitter: &half}
+		var peak, night, weekend int
+		times := seriesRows(t, ts, 10000)
+		for _, tm := range times {
+			switch h := tm.Hour(); {
+			case h >= 12 && h < 16:
+				peak++
+			case h >= 0 && h < 4:
+				night++
+			}
+			if wd := tm.Weekday(); wd == time.Saturday || wd == time.Sunday {
+				weekend++
+			}
+		}
+		if peak < 10*night {
+			t.Errorf("%d rows from 12 to 16, %d from 0 to 4; want the afternoon peak far busier", peak, night)
+		}
+		// 8 weekend days at half the rate of 19 weekdays.
+		if share := float64(weekend) / float64(len(times)); share < 0.14 || share > 0.20 {
+			t.Errorf("%.0f%% of rows on weekends, want about 17%%", share*100)
+		}
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		times := seriesRows(t, config.TimeSeriesConfig{Start: "2024-03-04", Interval: "1m", Jitter: &zero}, 600)
+		if got := times[len(times)-1].Sub(times[0]); got < 599*time.Minute-time.Second || got > 599*time.Minute+time.Second {
+			t.Errorf("600 rows a minute apart span %v, want 9h59m", got)
+		}
+	})
+
+	t.Run("bursts", func(t *testing.T) {
+		ts := config.TimeSeriesConfig{Start: "2024-03-04", End: "2024-03-31", Bursts: config.BurstConfig{Count: 1, Duration: "1h", Factor: 200}}
+		perHour := make(map[time.Time]int)
+		busiest := 0
+		for _, tm := range seriesRows(t, ts, 10000) {
+			h := tm.Truncate(time.Hour)
+			perHour[h]++
+			busiest = max(busiest, perHour[h])
+		}
+		// Without the burst an hour holds about 15 rows.
+		if busiest < 1000 {
+			t.Errorf("busiest hour has %d rows, want a burst of over 1000", busiest)
+		}
+	})
+}
+
+func TestPlanTimeSeries(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	hour := 24
+	tests := []struct {
+		name    string
+		ts      config.TimeSeriesConfig
+		wantErr string // the key and message of the ParamError
+	}{
+		{"defaults", config.TimeSeriesConfig{}, ""},
+		{"relative", config.TimeSeriesConfig{Start: "-7d", Interval: "30s"}, ""},
+		{"weeks", config.TimeSeriesConfig{End: "2024-01-01", Interval: "2w"}, ""},
+		{"all three", config.TimeSeriesConfig{Start: "-7d", End: "now", Interval: "1m"}, "interval: set at most two of start, end and interval"},
+		{"inverted", config.TimeSeriesConfig{Start: "now", End: "-1d"}, "end: start 2024-06-15 12:00:00 must be before end 2024-06-14 12:00:00"},
+		{"bad start", config.TimeSeriesConfig{Start: "yesterday"}, `start: "yesterday" is not a date`},
+		{"bad interval", config.TimeSeriesConfig{Interval: "-1m"}, `interval: "-1m" is not a positive duration`},
+		{"peak hour", config.TimeSeriesConfig{PeakHour: &hour}, "peak_hour: 24 must be between 0 and 23"},
+		{"diurnal", config.TimeSeriesConfig{Diurnal: 2}, "diurnal: 2 must be between 0 and 1"},
+		{"burst duration", config.TimeSeriesConfig{Bursts: config.BurstConfig{Count: 1, Duration: "soon"}}, `bursts: duration: "soon" is not a positive duration`},
+	}
+	for _, tt := range tests {
+		_, err := planTimeSeries(&tt.ts, now)
+		var pe *ParamError
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr != "" && (!errors.As(err, &pe) || !strings.HasPrefix(err.Error(), tt.wantErr)):
+			t.Errorf("%s: err = %v, want a ParamError starting with %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
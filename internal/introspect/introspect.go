@@ -13,20 +13,21 @@ type ForeignKey struct {
 }
 
 type Column struct {
-	Name         string
-	DataType     string   // e.g. "varchar", "int", "enum"
-	ColumnType   string   // e.g. "enum('a','b')", "int unsigned"
-	IsNullable   bool
-	IsAutoInc    bool
-	IsGenerated  bool
-	IsPrimaryKey bool
-	IsUnique     bool
-	MaxLength    *int64
-	Precision    *int64
-	Scale        *int64
-	EnumValues   []string // parsed from ColumnType for enums
-	Default      *string
-	FK           *ForeignKey
+	Name          string
+	DataType      string // e.g. "varchar", "int", "enum"
+	ColumnType    string // e.g. "enum('a','b')", "int unsigned"
+	IsNullable    bool
+	IsAutoInc     bool
+	IsGenerated   bool
+	IsPrimaryKey  bool
+	IsUnique      bool
+	MaxLength     *int64
+	Precision     *int64
+	Scale         *int64
+	EnumValues    []string // parsed from ColumnType for enums
+	Default       *string  // nil when the column has no default or defaults to NULL
+	IsDefaultExpr bool     // Default is an expression such as CURRENT_TIMESTAMP
+	FK            *ForeignKey
 }
 
 // IsIntegerType returns true if the column's data type is an integer type.
@@ -148,7 +149,8 @@ func introspectColumns(db *sql.DB, schema, tableName string) ([]Column, error) {
 		col.IsPrimaryKey = colKey == "PRI"
 		col.IsUnique = colKey == "UNI"
 		col.IsAutoInc = strings.Contains(extra, "auto_increment")
-		col.IsGenerated = strings.Contains(extra, "GENERATED")
+		col.IsDefaultExpr = strings.Contains(extra, "DEFAULT_GENERATED")
+		col.IsGenerated = strings.Contains(extra, "GENERATED") && !col.IsDefaultExpr
 
 		if maxLen.Valid {
 			col.MaxLength = &maxLen.Int64
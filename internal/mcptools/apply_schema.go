@@ -0,0 +1,44 @@
+package mcptools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type applySchemaArgs struct {
+	SchemaPath string `json:"schema_path,omitempty" jsonschema:"Path to a SQL DDL file with CREATE TABLE statements."`
+	DDL        string `json:"ddl,omitempty" jsonschema:"DDL statements separated by semicolons, instead of schema_path."`
+	connectionArgs
+}
+
+func registerApplySchema(s *mcp.Server, engine Engine, dbs *databases) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "apply_schema",
+		Description: `Create tables from DDL in the database, so they can be seeded and queried across calls.
+
+Takes a DDL file (schema_path) or inline DDL (ddl). Tables with the same names as those in the DDL are dropped first; other statements, such as CREATE INDEX, run as written. Unlike the test tool, the tables are kept: pair this with start_database, then use seed_database and the query tools to iterate.`,
+	}, applySchemaHandler(engine, dbs))
+}
+
+func applySchemaHandler(engine Engine, dbs *databases) mcp.ToolHandlerFor[applySchemaArgs, *AppliedSchema] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args applySchemaArgs) (*mcp.CallToolResult, *AppliedSchema, error) {
+		if (args.SchemaPath == "") == (strings.TrimSpace(args.DDL) == "") {
+			return errResult("provide either schema_path or ddl"), nil, nil
+		}
+		dsn, err := dbs.require(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+
+		res, err := engine.ApplySchema(ctx, SchemaRequest{DSN: dsn, SchemaPath: args.SchemaPath, DDL: args.DDL})
+		if err != nil {
+			return errResult(failureMessage(ctx, "applying schema", err)), nil, nil
+		}
+		text := fmt.Sprintf("Ran %d statements in %s; created %d tables: %s\n",
+			res.Statements, res.Schema, len(res.Tables), strings.Join(res.Tables, ", "))
+		return textResult(text), res, nil
+	}
+}
@@ -0,0 +1,55 @@
+package mcptools
+
+import (
+	"context"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type benchmarkQueryArgs struct {
+	Query       string `json:"query" jsonschema:"SQL statement to time. Templates are rendered before each run, e.g. WHERE id = {{(SampleRow \"users\" \"id\").id}}."`
+	Repeat      int    `json:"repeat,omitempty" jsonschema:"Timed runs (default 100)."`
+	Warmup      int    `json:"warmup,omitempty" jsonschema:"Untimed runs before the timed ones (default 0)."`
+	AllowWrites bool   `json:"allow_writes,omitempty" jsonschema:"Allow write statements. Each run is rolled back."`
+	connectionArgs
+}
+
+const defaultBenchmarkRepeat = 100
+
+func registerBenchmarkQuery(s *mcp.Server, engine Engine, dbs *databases) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "benchmark_query",
+		Description: `Time one SQL statement against the data currently in the database, without writing a config file.
+
+Runs the query repeat times like a test in the test tool — rendering templates before each run so every run gets fresh parameter values — and returns latency statistics in milliseconds, row counts, server rows examined and the EXPLAIN plan. Write statements require allow_writes and are rolled back after every run.`,
+	}, benchmarkQueryHandler(engine, dbs))
+}
+
+func benchmarkQueryHandler(engine Engine, dbs *databases) mcp.ToolHandlerFor[benchmarkQueryArgs, *Benchmark] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args benchmarkQueryArgs) (*mcp.CallToolResult, *Benchmark, error) {
+		dsn, err := dbs.require(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+		if strings.TrimSpace(args.Query) == "" {
+			return errResult("query is required"), nil, nil
+		}
+		repeat := args.Repeat
+		if repeat <= 0 {
+			repeat = defaultBenchmarkRepeat
+		}
+
+		res, err := engine.BenchmarkQuery(ctx, BenchmarkRequest{
+			DSN:         dsn,
+			Query:       args.Query,
+			Repeat:      repeat,
+			Warmup:      max(args.Warmup, 0),
+			AllowWrites: args.AllowWrites,
+		})
+		if err != nil {
+			return errResult(failureMessage(ctx, "benchmark", err)), nil, nil
+		}
+		return textResult(res.Report), res, nil
+	}
+}
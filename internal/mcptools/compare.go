@@ -2,7 +2,6 @@ package mcptools
 
 import (
 	"context"
-	"strconv"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -12,46 +11,44 @@ type compareArgs struct {
 	Rows       int    `json:"rows,omitempty" jsonschema:"Override rows per table for all configs (0 = use each config's value)."`
 	BatchSize  int    `json:"batch_size,omitempty" jsonschema:"Override batch size for all configs (0 = use each config's value)."`
 	Workers    int    `json:"workers,omitempty" jsonschema:"Override worker count for all configs (0 = use each config's value)."`
+	connectionArgs
 }
 
-func registerCompare(s *mcp.Server) {
+func registerCompare(s *mcp.Server, engine Engine, dbs *databases) {
 	mcp.AddTool(s, &mcp.Tool{
 		Name: "compare",
 		Description: `Compare query performance across multiple schema configurations side-by-side.
 
 Takes a single comparison YAML file that references 2+ seed configs and defines per-config query variants. For each config: creates tables from DDL, seeds with fake data, runs test queries, drops the tables, then returns a side-by-side timing comparison.
 
+Sends a progress notification per seeded table and per finished test. The structured output holds one entry per config with the same per-test results as the test tool.
+
 For benchmarking a single schema without comparison, use the test tool instead.`,
-	}, handleCompare)
+	}, compareHandler(engine, dbs))
 }
 
-func handleCompare(ctx context.Context, _ *mcp.CallToolRequest, args compareArgs) (*mcp.CallToolResult, any, error) {
-	if args.ConfigPath == "" {
-		return errResult("config_path is required: provide a comparison YAML file"), nil, nil
-	}
-
-	cliArgs := []string{"compare", args.ConfigPath}
-
-	dsn := resolveDSN()
-	if dsn != "" {
-		cliArgs = append(cliArgs, "--dsn", dsn)
-	} else {
-		cliArgs = append(cliArgs, "--ephemeral")
-	}
-	if args.Rows > 0 {
-		cliArgs = append(cliArgs, "--rows", strconv.Itoa(args.Rows))
-	}
-	if args.BatchSize > 0 {
-		cliArgs = append(cliArgs, "--batch-size", strconv.Itoa(args.BatchSize))
-	}
-	if args.Workers > 0 {
-		cliArgs = append(cliArgs, "--workers", strconv.Itoa(args.Workers))
-	}
-
-	output, err := runSelf(ctx, cliArgs...)
-	if err != nil {
-		return errResult("compare failed: " + err.Error()), nil, nil
+func compareHandler(engine Engine, dbs *databases) mcp.ToolHandlerFor[compareArgs, *Comparison] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args compareArgs) (*mcp.CallToolResult, *Comparison, error) {
+		if args.ConfigPath == "" {
+			return errResult("config_path is required: provide a comparison YAML file"), nil, nil
+		}
+
+		dsn, err := dbs.resolve(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+
+		res, err := engine.Compare(ctx, CompareRequest{
+			DSN:        dsn,
+			ConfigPath: args.ConfigPath,
+			Rows:       args.Rows,
+			BatchSize:  args.BatchSize,
+			Workers:    args.Workers,
+		}, progressReporter(ctx, req))
+		if err != nil {
+			return errResult(failureMessage(ctx, "compare", err)), nil, nil
+		}
+
+		return textResult(res.Report), res, nil
 	}
-
-	return textResult(output), nil, nil
 }
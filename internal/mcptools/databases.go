@@ -0,0 +1,202 @@
+package mcptools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tomfevang/go-test-my-db/internal/ephemeral"
+)
+
+// noDatabase is the error of tools that need a database when none is
+// configured.
+const noDatabase = "no database: pass dsn, start one with start_database, or set the SEED_DSN environment variable"
+
+// connectionArgs selects the database a tool runs against. Tools embed it in
+// their arguments.
+type connectionArgs struct {
+	DSN      string `json:"dsn,omitempty" jsonschema:"MySQL DSN for this call, e.g. user:pass@tcp(localhost:3306)/mydb. Overrides the session database and SEED_DSN."`
+	Database string `json:"database,omitempty" jsonschema:"Handle of a database started with start_database, e.g. db1. Defaults to the most recently started one."`
+}
+
+// database is a MySQL container started with start_database.
+type database struct {
+	handle  string
+	dsn     string
+	session string // ID of the MCP session that started it
+	stop    func()
+}
+
+// databases tracks the containers started with start_database. They run
+// until stop_database, until the MCP session that started them ends, or until
+// the server exits, so an agent can create tables and seed once, then iterate
+// on queries. Each MCP session sees only
+// the containers it started, so clients of a shared HTTP server cannot use
+// or stop each other's.
+type databases struct {
+	mu      sync.Mutex
+	started map[string]int  // session -> containers started
+	watched map[string]bool // sessions whose end stops their containers
+	running []*database     // in start order; a session's last one is its default
+}
+
+// sessionID returns the ID of the MCP session making a request: empty over
+// stdio, which has a single session.
+func sessionID(req *mcp.CallToolRequest) string {
+	if req == nil || req.Session == nil {
+		return ""
+	}
+	return req.Session.ID()
+}
+
+// startEphemeral starts a MySQL container and returns its DSN and a function
+// that removes it. Tests replace it.
+var startEphemeral = func(ctx context.Context) (string, func(), error) {
+	edb, err := ephemeral.Start(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return edb.DSN, edb.Stop, nil
+}
+
+// start launches a container and makes it the default database of session.
+func (d *databases) start(ctx context.Context, session string) (*database, error) {
+	dsn, stop, err := startEphemeral(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started == nil {
+		d.started = make(map[string]int)
+	}
+	d.started[session]++
+	db := &database{handle: fmt.Sprintf("db%d", d.started[session]), dsn: dsn, session: session, stop: stop}
+	d.running = append(d.running, db)
+	return db, nil
+}
+
+// stop removes the container of session with the given handle, or its
+// default one when handle is empty. The container the session started before
+// becomes the default.
+func (d *databases) stop(session, handle string) (*database, error) {
+	d.mu.Lock()
+	i, err := d.find(session, handle)
+	var db *database
+	if err == nil {
+		db = d.running[i]
+		d.running = slices.Delete(d.running, i, i+1)
+	}
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	db.stop()
+	return db, nil
+}
+
+// watch stops the containers of ss when the session ends, so a client that
+// disconnects without calling stop_database leaves none running. Each session
+// is watched once.
+func (d *databases) watch(ss *mcp.ServerSession) {
+	if ss == nil {
+		return
+	}
+	session := ss.ID()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.watched[session] {
+		return
+	}
+	if d.watched == nil {
+		d.watched = make(map[string]bool)
+	}
+	d.watched[session] = true
+	go func() {
+		ss.Wait()
+		d.stopSession(session)
+	}()
+}
+
+// stopSession removes every container of session and forgets the session.
+func (d *databases) stopSession(session string) {
+	d.mu.Lock()
+	var stopped []*database
+	d.running = slices.DeleteFunc(d.running, func(db *database) bool {
+		if db.session == session {
+			stopped = append(stopped, db)
+			return true
+		}
+		return false
+	})
+	delete(d.started, session)
+	delete(d.watched, session)
+	d.mu.Unlock()
+	for _, db := range stopped {
+		db.stop()
+	}
+}
+
+// stopAll removes every container still running.
+func (d *databases) stopAll() {
+	d.mu.Lock()
+	running := d.running
+	d.running = nil
+	d.mu.Unlock()
+	for _, db := range running {
+		db.stop()
+	}
+}
+
+// find returns the index of the container of session with the given handle,
+// or of its default one when handle is empty. The caller holds d.mu.
+func (d *databases) find(session, handle string) (int, error) {
+	for i := len(d.running) - 1; i >= 0; i-- {
+		db := d.running[i]
+		if db.session == session && (handle == "" || db.handle == handle) {
+			return i, nil
+		}
+	}
+	if handle == "" {
+		return 0, fmt.Errorf("no database is running; start one with start_database")
+	}
+	return 0, fmt.Errorf("unknown database %q; start one with start_database", handle)
+}
+
+// hasRunning reports whether session has started a container that still runs.
+// The caller holds d.mu.
+func (d *databases) hasRunning(session string) bool {
+	return slices.ContainsFunc(d.running, func(db *database) bool { return db.session == session })
+}
+
+// resolve returns the DSN a call of session should use: its dsn argument,
+// the named session database, the default session database, or SEED_DSN, in
+// that order. It returns "" when none is set.
+func (d *databases) resolve(session string, args connectionArgs) (string, error) {
+	if args.DSN != "" {
+		return args.DSN, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if args.Database != "" || d.hasRunning(session) {
+		i, err := d.find(session, args.Database)
+		if err != nil {
+			return "", err
+		}
+		return d.running[i].dsn, nil
+	}
+	return os.Getenv("SEED_DSN"), nil
+}
+
+// require is resolve for tools that cannot run without a database.
+func (d *databases) require(session string, args connectionArgs) (string, error) {
+	dsn, err := d.resolve(session, args)
+	if err == nil && dsn == "" {
+		err = errors.New(noDatabase)
+	}
+	return dsn, err
+}
@@ -0,0 +1,187 @@
+package mcptools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// fakeContainers replaces startEphemeral for the duration of a test and
+// returns the DSNs of the containers stopped so far.
+func fakeContainers(t *testing.T) *[]string {
+	t.Helper()
+	var stopped []string
+	n := 0
+	saved := startEphemeral
+	startEphemeral = func(context.Context) (string, func(), error) {
+		n++
+		dsn := fmt.Sprintf("root:seedtest@tcp(127.0.0.1:%d)/seedtest", 33060+n)
+		return dsn, func() { stopped = append(stopped, dsn) }, nil
+	}
+	t.Cleanup(func() { startEphemeral = saved })
+	return &stopped
+}
+
+func TestSessionDatabases(t *testing.T) {
+	stopped := fakeContainers(t)
+	engine := &fakeEngine{}
+	cs := connect(t, engine, func(*mcp.ProgressNotificationParams) {})
+
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	queryDSN := func(args map[string]any) string {
+		t.Helper()
+		args["query"] = "SELECT 1"
+		if res := call("run_query", args); res.IsError {
+			t.Fatalf("run_query failed: %v", res.Content[0].(*mcp.TextContent).Text)
+		}
+		return engine.query.DSN
+	}
+
+	// Without a session database, SEED_DSN is used.
+	if dsn := queryDSN(map[string]any{}); dsn != "root@tcp(localhost:3306)/app" {
+		t.Errorf("dsn = %q, want SEED_DSN", dsn)
+	}
+
+	res := call("start_database", map[string]any{})
+	raw, _ := json.Marshal(res.StructuredContent)
+	var info DatabaseInfo
+	if err := json.Unmarshal(raw, &info); err != nil || info.Database != "db1" || info.Schema != "seedtest" {
+		t.Fatalf("unexpected start_database output: %s", raw)
+	}
+	db1 := info.DSN
+	call("start_database", map[string]any{})
+	db2 := "root:seedtest@tcp(127.0.0.1:33062)/seedtest"
+
+	if dsn := queryDSN(map[string]any{}); dsn != db2 {
+		t.Errorf("default dsn = %q, want the latest database %q", dsn, db2)
+	}
+	if dsn := queryDSN(map[string]any{"database": "db1"}); dsn != db1 {
+		t.Errorf("dsn = %q, want db1 %q", dsn, db1)
+	}
+	if dsn := queryDSN(map[string]any{"database": "db1", "dsn": "u@tcp(h:1)/x"}); dsn != "u@tcp(h:1)/x" {
+		t.Errorf("dsn = %q, want the dsn argument", dsn)
+	}
+	if res := call("run_query", map[string]any{"query": "SELECT 1", "database": "db9"}); !res.IsError {
+		t.Error("expected an error for an unknown database")
+	}
+
+	if res := call("apply_schema", map[string]any{"ddl": "CREATE TABLE users (id INT PRIMARY KEY)"}); res.IsError || engine.ddl.DSN != db2 {
+		t.Errorf("apply_schema: %v, dsn %q", res.Content[0].(*mcp.TextContent).Text, engine.ddl.DSN)
+	}
+	if res := call("apply_schema", map[string]any{}); !res.IsError {
+		t.Error("expected apply_schema to require schema_path or ddl")
+	}
+
+	// Stopping the default database falls back to the one started before it.
+	call("stop_database", map[string]any{})
+	if !slices.Equal(*stopped, []string{db2}) {
+		t.Errorf("stopped = %v, want [%s]", *stopped, db2)
+	}
+	if dsn := queryDSN(map[string]any{}); dsn != db1 {
+		t.Errorf("dsn after stop = %q, want %q", dsn, db1)
+	}
+	if res := call("stop_database", map[string]any{"database": "db2"}); !res.IsError {
+		t.Error("expected an error stopping a stopped database")
+	}
+}
+
+func TestDatabases_StopAll(t *testing.T) {
+	stopped := fakeContainers(t)
+	var dbs databases
+	for range 2 {
+		if _, err := dbs.start(context.Background(), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dbs.stopAll()
+	if len(*stopped) != 2 {
+		t.Errorf("stopped %d containers, want 2", len(*stopped))
+	}
+	t.Setenv("SEED_DSN", "")
+	if _, err := dbs.require("", connectionArgs{}); err == nil || err.Error() != noDatabase {
+		t.Errorf("require() error = %v, want %q", err, noDatabase)
+	}
+}
+
+func TestDatabases_Sessions(t *testing.T) {
+	stopped := fakeContainers(t)
+	t.Setenv("SEED_DSN", "")
+	var dbs databases
+	a, _ := dbs.start(context.Background(), "a")
+	b, _ := dbs.start(context.Background(), "b")
+	if a.handle != "db1" || b.handle != "db1" {
+		t.Errorf("handles = %s, %s; want db1 for each session", a.handle, b.handle)
+	}
+
+	// Each session resolves only the databases it started.
+	if dsn, _ := dbs.resolve("a", connectionArgs{}); dsn != a.dsn {
+		t.Errorf("session a resolves %q, want its own %q", dsn, a.dsn)
+	}
+	if dsn, _ := dbs.resolve("b", connectionArgs{Database: "db1"}); dsn != b.dsn {
+		t.Errorf("session b resolves db1 to %q, want its own %q", dsn, b.dsn)
+	}
+	if _, err := dbs.require("c", connectionArgs{}); err == nil || err.Error() != noDatabase {
+		t.Errorf("session c: require() error = %v, want %q", err, noDatabase)
+	}
+
+	// Stopping in one session leaves the other's database running.
+	if _, err := dbs.stop("b", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbs.stop("b", "db1"); err == nil {
+		t.Error("session b stopped a database it no longer has")
+	}
+	if !slices.Equal(*stopped, []string{b.dsn}) {
+		t.Errorf("stopped = %v, want [%s]", *stopped, b.dsn)
+	}
+	if dsn, _ := dbs.resolve("a", connectionArgs{}); dsn != a.dsn {
+		t.Errorf("session a resolves %q after b stopped its database, want %q", dsn, a.dsn)
+	}
+}
+
+func TestDatabases_SessionEnd(t *testing.T) {
+	stopped := make(chan string, 2)
+	n := 0
+	saved := startEphemeral
+	startEphemeral = func(context.Context) (string, func(), error) {
+		n++
+		dsn := fmt.Sprintf("root:seedtest@tcp(127.0.0.1:%d)/seedtest", 33060+n)
+		return dsn, func() { stopped <- dsn }, nil
+	}
+	t.Cleanup(func() { startEphemeral = saved })
+
+	cs := connect(t, &fakeEngine{}, func(*mcp.ProgressNotificationParams) {})
+	for range 2 {
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "start_database", Arguments: map[string]any{}})
+		if err != nil || res.IsError {
+			t.Fatalf("start_database: %v %v", err, res)
+		}
+	}
+	select {
+	case dsn := <-stopped:
+		t.Fatalf("%s stopped while the session is open", dsn)
+	default:
+	}
+
+	// Closing the session stops both of its databases.
+	cs.Close()
+	for range 2 {
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("database still running after the session ended")
+		}
+	}
+}
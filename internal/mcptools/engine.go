@@ -0,0 +1,258 @@
+package mcptools
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Engine runs seeding and benchmarks in process, so the tools can stream
+// progress, honour cancellation and return structured results. The cmd
+// package implements it and passes it to RegisterAll; mcptools cannot import
+// cmd.
+type Engine interface {
+	Seed(ctx context.Context, req SeedRequest, progress ProgressFunc) (*SeedResult, error)
+	Test(ctx context.Context, req TestRequest, progress ProgressFunc) (*TestRun, error)
+	Compare(ctx context.Context, req CompareRequest, progress ProgressFunc) (*Comparison, error)
+	RunQuery(ctx context.Context, req QueryRequest) (*QueryRows, error)
+	ExplainQuery(ctx context.Context, req QueryRequest) (*Explanation, error)
+	BenchmarkQuery(ctx context.Context, req BenchmarkRequest) (*Benchmark, error)
+	SuggestIndexes(ctx context.Context, req IndexRequest, progress ProgressFunc) (*IndexSuggestions, error)
+	ApplySchema(ctx context.Context, req SchemaRequest) (*AppliedSchema, error)
+}
+
+// ProgressFunc reports that done of total steps (tables seeded, tests run)
+// have finished. total is 0 when unknown.
+type ProgressFunc func(done, total int, message string)
+
+// SeedRequest holds the seed_database arguments. Zero values use the config
+// file's options or the CLI defaults.
+type SeedRequest struct {
+	DSN          string
+	ConfigPath   string
+	Profile      string
+	Tables       []string
+	Rows         int
+	BatchSize    int
+	Workers      int
+	Clear        bool
+	MinChildren  int
+	MaxChildren  int
+	MaxRows      int
+	DeferIndexes bool
+}
+
+// TestRequest holds the test tool arguments. An empty DSN starts an
+// ephemeral MySQL container.
+type TestRequest struct {
+	DSN        string
+	ConfigPath string
+	Profile    string
+	Rows       int
+	BatchSize  int
+	Workers    int
+}
+
+// CompareRequest holds the compare tool arguments. An empty DSN starts an
+// ephemeral MySQL container.
+type CompareRequest struct {
+	DSN        string
+	ConfigPath string
+	Rows       int
+	BatchSize  int
+	Workers    int
+}
+
+// QueryRequest holds the run_query and explain_query arguments. Query may
+// use the template functions of test queries, such as SampleRow.
+type QueryRequest struct {
+	DSN         string
+	Query       string
+	Limit       int  // rows returned by run_query
+	AllowWrites bool // let run_query execute statements other than reads
+}
+
+// BenchmarkRequest holds the benchmark_query arguments. Writes are rolled
+// back after each run.
+type BenchmarkRequest struct {
+	DSN         string
+	Query       string
+	Repeat      int
+	Warmup      int
+	AllowWrites bool
+}
+
+// IndexRequest holds the suggest_indexes arguments. An empty DSN starts an
+// ephemeral MySQL container.
+type IndexRequest struct {
+	DSN            string
+	ConfigPath     string
+	Profile        string
+	Rows           int
+	BatchSize      int
+	Workers        int
+	MaxCandidates  int
+	MinImprovement float64 // percent
+}
+
+// SchemaRequest holds the apply_schema arguments: a DDL file or inline DDL.
+type SchemaRequest struct {
+	DSN        string
+	SchemaPath string
+	DDL        string
+}
+
+// SeedResult is the structured output of seed_database.
+type SeedResult struct {
+	Schema     string        `json:"schema"`
+	Tables     []SeededTable `json:"tables"`
+	TotalRows  int           `json:"total_rows"`
+	DurationMS float64       `json:"duration_ms"`
+	Report     string        `json:"-"` // plain-text summary for the text content
+}
+
+// SeededTable is one table filled by seed_database.
+type SeededTable struct {
+	Name    string   `json:"name"`
+	Rows    int      `json:"rows" jsonschema:"Rows inserted (0 if the table already had enough)."`
+	Parents []string `json:"parents,omitempty" jsonschema:"FK parent tables the row count was derived from."`
+}
+
+// TestRun is the structured output of test, and of each config in compare.
+type TestRun struct {
+	Label      string        `json:"label,omitempty"`
+	ConfigPath string        `json:"config_path,omitempty"`
+	SchemaFile string        `json:"schema_file"`
+	Rows       int           `json:"rows" jsonschema:"Base rows per root table."`
+	Tables     int           `json:"tables" jsonschema:"Tables created from the schema file."`
+	DurationMS float64       `json:"duration_ms"`
+	Results    []QueryResult `json:"results"`
+	Error      string        `json:"error,omitempty"`
+	Report     string        `json:"-"` // plain-text results table for the text content
+}
+
+// QueryResult is the outcome of one benchmark test.
+type QueryResult struct {
+	Name         string     `json:"name"`
+	Kind         string     `json:"kind" jsonschema:"read, write or transaction."`
+	Query        string     `json:"query"`
+	Runs         int        `json:"runs" jsonschema:"Timed runs."`
+	Latency      *Latency   `json:"latency,omitempty"`
+	RowCount     int        `json:"row_count" jsonschema:"Rows returned by the first run (read tests)."`
+	RowsAffected int64      `json:"rows_affected,omitempty" jsonschema:"Rows affected across all runs (write and transaction tests)."`
+	RowsExamined *float64   `json:"rows_examined_per_run,omitempty" jsonschema:"Rows the server examined per run, if session status was readable."`
+	Plan         *QueryPlan `json:"plan,omitempty"`
+	Checksum     string     `json:"checksum,omitempty" jsonschema:"Checksum of the returned rows (verified compare tests)."`
+	Error        string     `json:"error,omitempty"`
+}
+
+// Latency summarises a test's timed runs, in milliseconds.
+type Latency struct {
+	MeanMS      float64            `json:"mean_ms"`
+	MinMS       float64            `json:"min_ms"`
+	MaxMS       float64            `json:"max_ms"`
+	StdDevMS    float64            `json:"stddev_ms"`
+	CV          float64            `json:"cv" jsonschema:"Coefficient of variation; above ~0.1 the runs are noisy."`
+	Percentiles map[string]float64 `json:"percentiles_ms" jsonschema:"Latency by percentile label, e.g. p99."`
+	Outliers    int                `json:"outliers"`
+}
+
+// QueryPlan is the EXPLAIN output of a test's last statement.
+type QueryPlan struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// Comparison is the structured output of compare.
+type Comparison struct {
+	Configs          []TestRun `json:"configs"`
+	VerifyMismatches []string  `json:"verify_mismatches,omitempty" jsonschema:"Verified tests whose variants returned different rows."`
+	Report           string    `json:"-"` // plain-text comparison report for the text content
+}
+
+// QueryRows is the structured output of run_query.
+type QueryRows struct {
+	Query        string   `json:"query" jsonschema:"The statement as executed, with templates rendered."`
+	Columns      []string `json:"columns"`
+	Rows         [][]any  `json:"rows" jsonschema:"Row values as strings; NULL is null."`
+	Truncated    bool     `json:"truncated" jsonschema:"More rows matched than the limit."`
+	RowsAffected int64    `json:"rows_affected,omitempty"`
+	DurationMS   float64  `json:"duration_ms"`
+}
+
+// Explanation is the structured output of explain_query.
+type Explanation struct {
+	Query   string    `json:"query" jsonschema:"The statement as explained, with templates rendered."`
+	Plan    QueryPlan `json:"plan" jsonschema:"Tabular EXPLAIN output."`
+	JSON    any       `json:"json" jsonschema:"EXPLAIN FORMAT=JSON output."`
+	Summary []string  `json:"summary" jsonschema:"One line per table access, flagging full scans, filesorts and temporary tables."`
+}
+
+// Benchmark is the structured output of benchmark_query.
+type Benchmark struct {
+	Result QueryResult `json:"result"`
+	Report string      `json:"-"` // plain-text results table for the text content
+}
+
+// IndexSuggestions is the structured output of suggest_indexes.
+type IndexSuggestions struct {
+	Baseline   TestRun          `json:"baseline" jsonschema:"The tests without any candidate index."`
+	Candidates []IndexCandidate `json:"candidates" jsonschema:"Tried indexes, largest improvement first."`
+	Skipped    int              `json:"skipped,omitempty" jsonschema:"Candidates beyond max_candidates that were not tried."`
+	Report     string           `json:"-"` // plain-text report for the text content
+}
+
+// IndexCandidate is one proposed index and its measured effect.
+type IndexCandidate struct {
+	Table       string        `json:"table"`
+	Columns     []string      `json:"columns"`
+	Statement   string        `json:"statement" jsonschema:"CREATE INDEX statement of the candidate."`
+	SuggestedBy []string      `json:"suggested_by" jsonschema:"Tests whose WHERE, JOIN, GROUP BY or ORDER BY columns produced the candidate."`
+	BeforeMS    float64       `json:"before_ms" jsonschema:"Sum of the affected tests' average latencies without the index."`
+	AfterMS     float64       `json:"after_ms" jsonschema:"Sum of the affected tests' average latencies with the index."`
+	ChangePct   float64       `json:"change_pct" jsonschema:"Relative latency change; negative is faster."`
+	Verdict     string        `json:"verdict" jsonschema:"recommended, no gain, slower, not used or failed."`
+	Tests       []IndexImpact `json:"tests" jsonschema:"Every test touching the table, rerun with the index."`
+	Error       string        `json:"error,omitempty"`
+}
+
+// IndexImpact compares one test without and with a candidate index.
+type IndexImpact struct {
+	Name      string  `json:"name"`
+	BeforeMS  float64 `json:"before_ms"`
+	AfterMS   float64 `json:"after_ms"`
+	UsedIndex bool    `json:"used_index" jsonschema:"The index appears as the key in the test's EXPLAIN plan."`
+	Error     string  `json:"error,omitempty"`
+}
+
+// DatabaseInfo is the structured output of start_database.
+type DatabaseInfo struct {
+	Database string `json:"database" jsonschema:"Handle to pass as database to other tools."`
+	DSN      string `json:"dsn"`
+	Schema   string `json:"schema"`
+}
+
+// AppliedSchema is the structured output of apply_schema.
+type AppliedSchema struct {
+	Schema     string   `json:"schema"`
+	Tables     []string `json:"tables" jsonschema:"Tables created, in DDL order."`
+	Statements int      `json:"statements"`
+}
+
+// progressReporter returns a ProgressFunc that sends MCP progress
+// notifications, or one that does nothing if the client did not ask for
+// progress.
+func progressReporter(ctx context.Context, req *mcp.CallToolRequest) ProgressFunc {
+	token := req.Params.GetProgressToken()
+	if token == nil || req.Session == nil {
+		return func(int, int, string) {}
+	}
+	return func(done, total int, message string) {
+		req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+			ProgressToken: token,
+			Progress:      float64(done),
+			Total:         float64(total),
+			Message:       message,
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package mcptools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// fakeEngine reports two progress steps per call and returns canned results.
+type fakeEngine struct {
+	err   error
+	query QueryRequest  // last RunQuery request
+	index IndexRequest  // last SuggestIndexes request
+	ddl   SchemaRequest // last ApplySchema request
+}
+
+func (f *fakeEngine) Seed(_ context.Context, req SeedRequest, progress ProgressFunc) (*SeedResult, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	progress(1, 2, "seeded users")
+	progress(2, 2, "seeded orders")
+	return &SeedResult{Schema: "app", Tables: []SeededTable{{Name: "users", Rows: req.Rows}}, TotalRows: req.Rows, Report: "Seeded 1 tables"}, nil
+}
+
+func (f *fakeEngine) Test(_ context.Context, req TestRequest, progress ProgressFunc) (*TestRun, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	progress(1, 2, "seeded users")
+	progress(2, 2, "by id: avg 1.00ms")
+	return &TestRun{
+		SchemaFile: "schema.sql",
+		Rows:       req.Rows,
+		Results: []QueryResult{{
+			Name:    "by id",
+			Kind:    "read",
+			Runs:    10,
+			Latency: &Latency{MeanMS: 1, Percentiles: map[string]float64{"p99": 2}},
+			Plan:    &QueryPlan{Columns: []string{"type"}, Rows: [][]string{{"const"}}},
+		}},
+		Report: "Test  Avg\nby id 1.00ms\n",
+	}, nil
+}
+
+func (f *fakeEngine) Compare(context.Context, CompareRequest, ProgressFunc) (*Comparison, error) {
+	return nil, f.err
+}
+
+func (f *fakeEngine) RunQuery(_ context.Context, req QueryRequest) (*QueryRows, error) {
+	f.query = req
+	return &QueryRows{Query: req.Query, Columns: []string{"id", "name"}, Rows: [][]any{{"1", nil}}, Truncated: true}, f.err
+}
+
+func (f *fakeEngine) ExplainQuery(context.Context, QueryRequest) (*Explanation, error) {
+	return nil, f.err
+}
+
+func (f *fakeEngine) BenchmarkQuery(context.Context, BenchmarkRequest) (*Benchmark, error) {
+	return nil, f.err
+}
+
+func (f *fakeEngine) SuggestIndexes(_ context.Context, req IndexRequest, _ ProgressFunc) (*IndexSuggestions, error) {
+	f.index = req
+	return &IndexSuggestions{Report: "=== Index Suggestions ===\n"}, f.err
+}
+
+func (f *fakeEngine) ApplySchema(_ context.Context, req SchemaRequest) (*AppliedSchema, error) {
+	f.ddl = req
+	return &AppliedSchema{Schema: "app", Tables: []string{"users"}, Statements: 1}, f.err
+}
+
+func connect(t *testing.T, engine Engine, onProgress func(*mcp.ProgressNotificationParams)) *mcp.ClientSession {
+	t.Helper()
+	t.Setenv("SEED_DSN", "root@tcp(localhost:3306)/app")
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	RegisterAll(server, fstest.MapFS{}, engine)
+
+	ct, st := mcp.NewInMemoryTransports()
+	ctx := context.Background()
+	if _, err := server.Connect(ctx, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "client"}, &mcp.ClientOptions{
+		ProgressNotificationHandler: func(_ context.Context, req *mcp.ProgressNotificationClientRequest) {
+			onProgress(req.Params)
+		},
+	})
+	cs, err := client.Connect(ctx, ct, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+func TestTestTool_ProgressAndStructuredOutput(t *testing.T) {
+	var mu sync.Mutex
+	var messages []string
+	cs := connect(t, &fakeEngine{}, func(p *mcp.ProgressNotificationParams) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p.ProgressToken == "tok" && p.Total == 2 {
+			messages = append(messages, p.Message)
+		}
+	})
+
+	params := &mcp.CallToolParams{
+		Meta:      mcp.Meta{"progressToken": "tok"},
+		Name:      "test",
+		Arguments: map[string]any{"config_path": "c.yaml", "rows": 500},
+	}
+	res, err := cs.CallTool(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("tool failed: %v", res.Content)
+	}
+	if text := res.Content[0].(*mcp.TextContent).Text; text != "Test  Avg\nby id 1.00ms\n" {
+		t.Errorf("text content = %q, want the plain report", text)
+	}
+
+	raw, _ := json.Marshal(res.StructuredContent)
+	var run TestRun
+	if err := json.Unmarshal(raw, &run); err != nil {
+		t.Fatal(err)
+	}
+	if run.Rows != 500 || len(run.Results) != 1 || run.Results[0].Latency.Percentiles["p99"] != 2 || run.Results[0].Plan.Rows[0][0] != "const" {
+		t.Errorf("unexpected structured output: %s", raw)
+	}
+
+	// Notifications may arrive just after the result.
+	for range 100 {
+		mu.Lock()
+		n := len(messages)
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		cs.Ping(context.Background(), nil)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) != 2 || messages[1] != "by id: avg 1.00ms" {
+		t.Errorf("progress messages = %q", messages)
+	}
+}
+
+func TestSeedTool_EngineError(t *testing.T) {
+	cs := connect(t, &fakeEngine{err: errors.New("boom")}, func(*mcp.ProgressNotificationParams) {})
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "seed_database", Arguments: map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsError || res.Content[0].(*mcp.TextContent).Text != "seeding failed: boom" {
+		t.Errorf("got %+v, want a tool error", res.Content[0])
+	}
+}
+
+func TestRunQueryTool_Limit(t *testing.T) {
+	engine := &fakeEngine{}
+	cs := connect(t, engine, func(*mcp.ProgressNotificationParams) {})
+
+	for _, tc := range []struct{ limit, want int }{{0, defaultQueryLimit}, {5, 5}, {1_000_000, maxQueryLimit}} {
+		res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+			Name:      "run_query",
+			Arguments: map[string]any{"query": "SELECT id, name FROM users", "limit": tc.limit},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if engine.query.Limit != tc.want {
+			t.Errorf("limit %d: engine got %d, want %d", tc.limit, engine.query.Limit, tc.want)
+		}
+		text := res.Content[0].(*mcp.TextContent).Text
+		if !regexp.MustCompile(`(?m)^1\s+NULL$`).MatchString(text) || !strings.Contains(text, "truncated") {
+			t.Errorf("unexpected text:\n%s", text)
+		}
+	}
+}
+
+func TestSuggestIndexesTool_Defaults(t *testing.T) {
+	engine := &fakeEngine{}
+	cs := connect(t, engine, func(*mcp.ProgressNotificationParams) {})
+
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "suggest_indexes",
+		Arguments: map[string]any{"config_path": "c.yaml"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Fatalf("tool failed: %v", res.Content)
+	}
+	if engine.index.MaxCandidates != defaultMaxCandidates || engine.index.MinImprovement != defaultMinImprovement || engine.index.ConfigPath != "c.yaml" {
+		t.Errorf("unexpected request: %+v", engine.index)
+	}
+
+	res, err = cs.CallTool(context.Background(), &mcp.CallToolParams{Name: "suggest_indexes", Arguments: map[string]any{"config_path": ""}})
+	if err != nil || !res.IsError {
+		t.Errorf("expected a tool error for an empty config_path, got %v, %v", res, err)
+	}
+}
@@ -0,0 +1,56 @@
+package mcptools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type explainQueryArgs struct {
+	Query string `json:"query" jsonschema:"SQL statement to explain. May use test query template functions, e.g. {{(SampleRow \"users\" \"id\").id}}."`
+	connectionArgs
+}
+
+func registerExplainQuery(s *mcp.Server, engine Engine, dbs *databases) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "explain_query",
+		Description: `Show the execution plan of a SQL statement without running it.
+
+Returns EXPLAIN FORMAT=JSON output, the tabular EXPLAIN, and a one-line summary per table access that flags full table scans, filesorts and temporary tables. Works for SELECT, UPDATE, DELETE and INSERT ... SELECT.`,
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, explainQueryHandler(engine, dbs))
+}
+
+func explainQueryHandler(engine Engine, dbs *databases) mcp.ToolHandlerFor[explainQueryArgs, *Explanation] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args explainQueryArgs) (*mcp.CallToolResult, *Explanation, error) {
+		dsn, err := dbs.require(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+		if strings.TrimSpace(args.Query) == "" {
+			return errResult("query is required"), nil, nil
+		}
+
+		res, err := engine.ExplainQuery(ctx, QueryRequest{DSN: dsn, Query: args.Query})
+		if err != nil {
+			return errResult(failureMessage(ctx, "explain", err)), nil, nil
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Query: %s\n\nSummary:\n", res.Query)
+		for _, line := range res.Summary {
+			fmt.Fprintf(&sb, "  %s\n", line)
+		}
+		sb.WriteString("\nPlan:\n")
+		w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  "+strings.Join(res.Plan.Columns, "\t"))
+		for _, row := range res.Plan.Rows {
+			fmt.Fprintln(w, "  "+strings.Join(row, "\t"))
+		}
+		w.Flush()
+		return textResult(sb.String()), res, nil
+	}
+}
@@ -9,60 +9,65 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
+	"github.com/tomfevang/go-test-my-db/internal/config"
 	"github.com/tomfevang/go-test-my-db/internal/generator"
 	"github.com/tomfevang/go-test-my-db/internal/introspect"
 )
 
-type generateConfigArgs struct{}
+type generateConfigArgs struct {
+	connectionArgs
+}
 
-func registerGenerateConfig(s *mcp.Server) {
+func registerGenerateConfig(s *mcp.Server, dbs *databases) {
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "generate_config",
 		Description: "Scaffold a go-test-my-db.yaml config file by introspecting the live database schema. Returns YAML content as text — does not write to disk. The output includes heuristic column generators, FK references, and commented-out test queries as a starting point.",
 		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
-	}, handleGenerateConfig)
+	}, generateConfigHandler(dbs))
 }
 
-func handleGenerateConfig(_ context.Context, _ *mcp.CallToolRequest, args generateConfigArgs) (*mcp.CallToolResult, any, error) {
-	dsn := resolveDSN()
-	if dsn == "" {
-		return errResult("SEED_DSN environment variable is not set"), nil, nil
-	}
-
-	schema := extractSchema(dsn)
-	if schema == "" {
-		return errResult("could not extract database name from DSN"), nil, nil
-	}
+func generateConfigHandler(dbs *databases) mcp.ToolHandlerFor[generateConfigArgs, any] {
+	return func(_ context.Context, req *mcp.CallToolRequest, args generateConfigArgs) (*mcp.CallToolResult, any, error) {
+		dsn, err := dbs.require(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
 
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return errResult(fmt.Sprintf("connecting: %v", err)), nil, nil
-	}
-	defer db.Close()
+		schema := extractSchema(dsn)
+		if schema == "" {
+			return errResult("could not extract database name from DSN"), nil, nil
+		}
 
-	if err := db.Ping(); err != nil {
-		return errResult(fmt.Sprintf("pinging: %v", err)), nil, nil
-	}
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return errResult(fmt.Sprintf("connecting: %v", err)), nil, nil
+		}
+		defer db.Close()
 
-	tableNames, err := introspect.ListTables(db, schema)
-	if err != nil {
-		return errResult(fmt.Sprintf("listing tables: %v", err)), nil, nil
-	}
-	if len(tableNames) == 0 {
-		return errResult(fmt.Sprintf("no tables found in schema %s", schema)), nil, nil
-	}
+		if err := db.Ping(); err != nil {
+			return errResult(fmt.Sprintf("pinging: %v", err)), nil, nil
+		}
 
-	tables := make([]*introspect.Table, 0, len(tableNames))
-	for _, name := range tableNames {
-		t, err := introspect.IntrospectTable(db, schema, name)
+		tableNames, err := introspect.ListTables(db, schema)
 		if err != nil {
-			return errResult(fmt.Sprintf("introspecting %s: %v", name, err)), nil, nil
+			return errResult(fmt.Sprintf("listing tables: %v", err)), nil, nil
+		}
+		if len(tableNames) == 0 {
+			return errResult(fmt.Sprintf("no tables found in schema %s", schema)), nil, nil
 		}
-		tables = append(tables, t)
-	}
 
-	yaml := buildInitYAML(dsn, tables)
-	return textResult(yaml), nil, nil
+		tables := make([]*introspect.Table, 0, len(tableNames))
+		for _, name := range tableNames {
+			t, err := introspect.IntrospectTable(db, schema, name)
+			if err != nil {
+				return errResult(fmt.Sprintf("introspecting %s: %v", name, err)), nil, nil
+			}
+			tables = append(tables, t)
+		}
+
+		yaml := buildInitYAML(dsn, tables)
+		return textResult(yaml), nil, nil
+	}
 }
 
 // buildInitYAML generates a starter go-test-my-db.yaml from introspected tables.
@@ -70,6 +75,7 @@ func handleGenerateConfig(_ context.Context, _ *mcp.CallToolRequest, args genera
 func buildInitYAML(dsnVal string, tables []*introspect.Table) string {
 	var sb strings.Builder
 
+	fmt.Fprintf(&sb, "# yaml-language-server: $schema=%s\n", config.SchemaURL)
 	sb.WriteString("# Generated by: go-test-my-db init\n")
 	sb.WriteString("# Review and uncomment sections as needed.\n\n")
 
@@ -101,7 +107,7 @@ func buildInitYAML(dsnVal string, tables []*introspect.Table) string {
 
 		var colLines []string
 		for _, col := range t.Columns {
-			if col.IsAutoInc || col.IsGenerated || col.FK != nil {
+			if col.IsAutoInc || col.IsGenerated || col.IsDefaultExpr || col.FK != nil {
 				continue
 			}
 			label := generator.NameBasedLabel(col)
@@ -11,11 +11,6 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// resolveDSN returns the DSN from the SEED_DSN environment variable.
-func resolveDSN() string {
-	return os.Getenv("SEED_DSN")
-}
-
 // extractSchema extracts the database name from a MySQL DSN.
 func extractSchema(dsn string) string {
 	idx := strings.LastIndex(dsn, "/")
@@ -48,6 +43,15 @@ func errResult(msg string) *mcp.CallToolResult {
 	}
 }
 
+// failureMessage describes why an operation failed, naming cancellation by
+// the client as such.
+func failureMessage(ctx context.Context, op string, err error) string {
+	if ctx.Err() != nil {
+		return op + " cancelled: " + context.Cause(ctx).Error()
+	}
+	return op + " failed: " + err.Error()
+}
+
 // runSelf executes the go-test-my-db binary (itself) with the given arguments
 // and returns its stdout output.
 func runSelf(ctx context.Context, args ...string) (string, error) {
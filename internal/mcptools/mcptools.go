@@ -7,15 +7,27 @@ import (
 )
 
 // RegisterAll registers all go-test-my-db tools and resources on the given MCP server.
-// The embeddedFS provides access to embedded skill files served as MCP resources.
-func RegisterAll(s *mcp.Server, embeddedFS fs.ReadFileFS) {
-	registerListTables(s)
-	registerDescribeTable(s)
-	registerPreviewData(s)
-	registerGenerateConfig(s)
-	registerSeedDatabase(s)
-	registerTest(s)
-	registerCompare(s)
+// The embeddedFS provides access to embedded skill files served as MCP resources,
+// and engine runs the seeding and benchmarking tools in process. The returned
+// function stops the databases started with start_database; call it when the
+// server exits.
+func RegisterAll(s *mcp.Server, embeddedFS fs.ReadFileFS, engine Engine) (stopDatabases func()) {
+	dbs := &databases{}
+	registerStartDatabase(s, dbs)
+	registerStopDatabase(s, dbs)
+	registerApplySchema(s, engine, dbs)
+	registerListTables(s, dbs)
+	registerDescribeTable(s, dbs)
+	registerPreviewData(s, dbs)
+	registerGenerateConfig(s, dbs)
+	registerSeedDatabase(s, engine, dbs)
+	registerTest(s, engine, dbs)
+	registerCompare(s, engine, dbs)
+	registerRunQuery(s, engine, dbs)
+	registerExplainQuery(s, engine, dbs)
+	registerBenchmarkQuery(s, engine, dbs)
+	registerSuggestIndexes(s, engine, dbs)
 
 	registerResources(s, embeddedFS)
+	return dbs.stopAll
 }
@@ -11,9 +11,11 @@ type previewDataArgs struct {
 	Tables     []string `json:"tables,omitempty" jsonschema:"Tables to preview. If omitted, previews all tables."`
 	SampleRows int      `json:"sample_rows,omitempty" jsonschema:"Number of sample rows per table (default 5, max 20)."`
 	ConfigPath string   `json:"config_path,omitempty" jsonschema:"Path to a go-test-my-db.yaml config file."`
+	Profile    string   `json:"profile,omitempty" jsonschema:"Profile of the config file to apply over its settings."`
+	connectionArgs
 }
 
-func registerPreviewData(s *mcp.Server) {
+func registerPreviewData(s *mcp.Server, dbs *databases) {
 	mcp.AddTool(s, &mcp.Tool{
 		Name: "preview_data",
 		Description: `Generate sample rows of fake data for the given tables without modifying the database.
@@ -23,30 +25,38 @@ When the config file contains options.schema pointing to a DDL file, preview cre
 temporary tables from that DDL, seeds them, queries sample data, then drops the tables.
 Without a schema file, preview generates sample rows in-memory using the live database schema.`,
 		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
-	}, handlePreviewData)
+	}, previewDataHandler(dbs))
 }
 
-func handlePreviewData(ctx context.Context, _ *mcp.CallToolRequest, args previewDataArgs) (*mcp.CallToolResult, any, error) {
-	cliArgs := []string{"preview"}
-
-	dsn := resolveDSN()
-	if dsn != "" {
-		cliArgs = append(cliArgs, "--dsn", dsn)
-	}
-	if args.ConfigPath != "" {
-		cliArgs = append(cliArgs, "--config", args.ConfigPath)
-	}
-	for _, t := range args.Tables {
-		cliArgs = append(cliArgs, "--table", t)
-	}
-	if args.SampleRows > 0 {
-		cliArgs = append(cliArgs, "--sample-rows", strconv.Itoa(args.SampleRows))
+func previewDataHandler(dbs *databases) mcp.ToolHandlerFor[previewDataArgs, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args previewDataArgs) (*mcp.CallToolResult, any, error) {
+		cliArgs := []string{"preview"}
+
+		dsn, err := dbs.resolve(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+		if dsn != "" {
+			cliArgs = append(cliArgs, "--dsn", dsn)
+		}
+		if args.ConfigPath != "" {
+			cliArgs = append(cliArgs, "--config", args.ConfigPath)
+		}
+		if args.Profile != "" {
+			cliArgs = append(cliArgs, "--profile", args.Profile)
+		}
+		for _, t := range args.Tables {
+			cliArgs = append(cliArgs, "--table", t)
+		}
+		if args.SampleRows > 0 {
+			cliArgs = append(cliArgs, "--sample-rows", strconv.Itoa(args.SampleRows))
+		}
+
+		output, err := runSelf(ctx, cliArgs...)
+		if err != nil {
+			return errResult("preview failed: " + err.Error()), nil, nil
+		}
+
+		return textResult(output), nil, nil
 	}
-
-	output, err := runSelf(ctx, cliArgs...)
-	if err != nil {
-		return errResult("preview failed: " + err.Error()), nil, nil
-	}
-
-	return textResult(output), nil, nil
 }
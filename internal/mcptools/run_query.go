@@ -0,0 +1,81 @@
+package mcptools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type runQueryArgs struct {
+	Query       string `json:"query" jsonschema:"SQL statement to run. May use test query template functions, e.g. {{(SampleRow \"users\" \"id\").id}}."`
+	Limit       int    `json:"limit,omitempty" jsonschema:"Maximum rows to return (default 100, max 10000)."`
+	AllowWrites bool   `json:"allow_writes,omitempty" jsonschema:"Allow statements other than SELECT, SHOW, DESCRIBE and EXPLAIN. Writes are committed."`
+	connectionArgs
+}
+
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 10_000
+)
+
+func registerRunQuery(s *mcp.Server, engine Engine, dbs *databases) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "run_query",
+		Description: `Run a single SQL statement against the connected database and return its rows.
+
+Read-only by default: only SELECT, WITH, SHOW, DESCRIBE, EXPLAIN, TABLE and VALUES statements without INTO are accepted, and they run in a READ ONLY transaction. Set allow_writes to run other statements. Returns at most limit rows.`,
+	}, runQueryHandler(engine, dbs))
+}
+
+func runQueryHandler(engine Engine, dbs *databases) mcp.ToolHandlerFor[runQueryArgs, *QueryRows] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args runQueryArgs) (*mcp.CallToolResult, *QueryRows, error) {
+		dsn, err := dbs.require(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+		if strings.TrimSpace(args.Query) == "" {
+			return errResult("query is required"), nil, nil
+		}
+		limit := args.Limit
+		if limit <= 0 {
+			limit = defaultQueryLimit
+		}
+		limit = min(limit, maxQueryLimit)
+
+		res, err := engine.RunQuery(ctx, QueryRequest{DSN: dsn, Query: args.Query, Limit: limit, AllowWrites: args.AllowWrites})
+		if err != nil {
+			return errResult(failureMessage(ctx, "query", err)), nil, nil
+		}
+		return textResult(formatRows(res)), res, nil
+	}
+}
+
+// formatRows renders query results as an aligned table.
+func formatRows(r *QueryRows) string {
+	if len(r.Columns) == 0 {
+		return fmt.Sprintf("OK, %d rows affected (%.2fms)\n", r.RowsAffected, r.DurationMS)
+	}
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(r.Columns, "\t"))
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, v := range row {
+			cells[i] = "NULL"
+			if v != nil {
+				cells[i] = fmt.Sprint(v)
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+	fmt.Fprintf(&sb, "\n%d rows (%.2fms)", len(r.Rows), r.DurationMS)
+	if r.Truncated {
+		sb.WriteString(", truncated at the limit")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -2,7 +2,6 @@ package mcptools
 
 import (
 	"context"
-	"strconv"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -18,59 +17,42 @@ type seedDatabaseArgs struct {
 	MaxRows      int      `json:"max_rows,omitempty" jsonschema:"Maximum rows per table safeguard (default 10000000)."`
 	DeferIndexes bool     `json:"defer_indexes,omitempty" jsonschema:"Drop secondary indexes before seeding and rebuild after (faster for large tables)."`
 	ConfigPath   string   `json:"config_path,omitempty" jsonschema:"Path to a go-test-my-db.yaml config file for custom column generators and references."`
+	Profile      string   `json:"profile,omitempty" jsonschema:"Profile of the config file to apply over its settings."`
+	connectionArgs
 }
 
-func registerSeedDatabase(s *mcp.Server) {
+func registerSeedDatabase(s *mcp.Server, engine Engine, dbs *databases) {
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "seed_database",
-		Description: "Insert realistic fake data into MySQL tables. Automatically introspects the schema, resolves FK dependencies, and seeds tables in topological order. Call with no arguments to seed all tables with 1000 rows each using auto-detected column heuristics.",
-	}, handleSeedDatabase)
+		Description: "Insert realistic fake data into MySQL tables. Automatically introspects the schema, resolves FK dependencies, and seeds tables in topological order. Call with no arguments to seed all tables with 1000 rows each using auto-detected column heuristics. Sends a progress notification per seeded table and returns the row count of each table as structured output.",
+	}, seedDatabaseHandler(engine, dbs))
 }
 
-func handleSeedDatabase(ctx context.Context, _ *mcp.CallToolRequest, args seedDatabaseArgs) (*mcp.CallToolResult, any, error) {
-	dsn := resolveDSN()
-	if dsn == "" {
-		return errResult("SEED_DSN environment variable is not set"), nil, nil
+func seedDatabaseHandler(engine Engine, dbs *databases) mcp.ToolHandlerFor[seedDatabaseArgs, *SeedResult] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args seedDatabaseArgs) (*mcp.CallToolResult, *SeedResult, error) {
+		dsn, err := dbs.require(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+
+		res, err := engine.Seed(ctx, SeedRequest{
+			DSN:          dsn,
+			ConfigPath:   args.ConfigPath,
+			Profile:      args.Profile,
+			Tables:       args.Tables,
+			Rows:         args.Rows,
+			BatchSize:    args.BatchSize,
+			Workers:      args.Workers,
+			Clear:        args.Clear,
+			MinChildren:  args.MinChildren,
+			MaxChildren:  args.MaxChildren,
+			MaxRows:      args.MaxRows,
+			DeferIndexes: args.DeferIndexes,
+		}, progressReporter(ctx, req))
+		if err != nil {
+			return errResult(failureMessage(ctx, "seeding", err)), nil, nil
+		}
+
+		return textResult(res.Report), res, nil
 	}
-
-	// Build CLI args.
-	cliArgs := []string{"--dsn", dsn}
-
-	for _, t := range args.Tables {
-		cliArgs = append(cliArgs, "--table", t)
-	}
-	if args.Rows > 0 {
-		cliArgs = append(cliArgs, "--rows", strconv.Itoa(args.Rows))
-	}
-	if args.BatchSize > 0 {
-		cliArgs = append(cliArgs, "--batch-size", strconv.Itoa(args.BatchSize))
-	}
-	if args.Workers > 0 {
-		cliArgs = append(cliArgs, "--workers", strconv.Itoa(args.Workers))
-	}
-	if args.Clear {
-		cliArgs = append(cliArgs, "--clear")
-	}
-	if args.MinChildren > 0 {
-		cliArgs = append(cliArgs, "--min-children", strconv.Itoa(args.MinChildren))
-	}
-	if args.MaxChildren > 0 {
-		cliArgs = append(cliArgs, "--max-children", strconv.Itoa(args.MaxChildren))
-	}
-	if args.MaxRows > 0 {
-		cliArgs = append(cliArgs, "--max-rows", strconv.Itoa(args.MaxRows))
-	}
-	if args.DeferIndexes {
-		cliArgs = append(cliArgs, "--defer-indexes")
-	}
-	if args.ConfigPath != "" {
-		cliArgs = append(cliArgs, "--config", args.ConfigPath)
-	}
-
-	output, err := runSelf(ctx, cliArgs...)
-	if err != nil {
-		return errResult("seeding failed: " + err.Error()), nil, nil
-	}
-
-	return textResult(output), nil, nil
 }
@@ -0,0 +1,34 @@
+package mcptools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type startDatabaseArgs struct{}
+
+func registerStartDatabase(s *mcp.Server, dbs *databases) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "start_database",
+		Description: `Start a MySQL container (Docker or Podman) that lives for the rest of this session.
+
+Every other tool uses the most recently started database unless a call passes dsn or database, so you can apply_schema and seed_database once, then iterate with run_query, explain_query, benchmark_query, test and suggest_indexes without reseeding. The container is removed by stop_database, when this session ends, or when the server exits. Takes up to a minute on first use while the image is pulled.`,
+	}, startDatabaseHandler(dbs))
+}
+
+func startDatabaseHandler(dbs *databases) mcp.ToolHandlerFor[startDatabaseArgs, *DatabaseInfo] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, _ startDatabaseArgs) (*mcp.CallToolResult, *DatabaseInfo, error) {
+		db, err := dbs.start(ctx, sessionID(req))
+		if err != nil {
+			return errResult(failureMessage(ctx, "starting database", err)), nil, nil
+		}
+		if req != nil {
+			dbs.watch(req.Session)
+		}
+		info := &DatabaseInfo{Database: db.handle, DSN: db.dsn, Schema: extractSchema(db.dsn)}
+		return textResult(fmt.Sprintf("Started database %s: %s\nOther tools use it until it is stopped; pass database %q to select it when several are running.\n",
+			info.Database, info.DSN, info.Database)), info, nil
+	}
+}
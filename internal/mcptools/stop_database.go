@@ -0,0 +1,29 @@
+package mcptools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type stopDatabaseArgs struct {
+	Database string `json:"database,omitempty" jsonschema:"Handle of the database to stop, e.g. db1. Defaults to the most recently started one."`
+}
+
+func registerStopDatabase(s *mcp.Server, dbs *databases) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "stop_database",
+		Description: "Stop a database started with start_database and remove its container and data. The previously started database, if any, becomes the default again.",
+	}, stopDatabaseHandler(dbs))
+}
+
+func stopDatabaseHandler(dbs *databases) mcp.ToolHandlerFor[stopDatabaseArgs, any] {
+	return func(_ context.Context, req *mcp.CallToolRequest, args stopDatabaseArgs) (*mcp.CallToolResult, any, error) {
+		db, err := dbs.stop(sessionID(req), args.Database)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+		return textResult(fmt.Sprintf("Stopped database %s\n", db.handle)), nil, nil
+	}
+}
@@ -0,0 +1,72 @@
+package mcptools
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type suggestIndexesArgs struct {
+	ConfigPath     string  `json:"config_path" jsonschema:"Path to a go-test-my-db.yaml config file with options.schema pointing to a DDL file and a tests section. Candidates are derived from the tests' queries."`
+	Profile        string  `json:"profile,omitempty" jsonschema:"Profile of the config file to apply over its settings."`
+	Rows           int     `json:"rows,omitempty" jsonschema:"Override rows per table (0 = use config value or default 1000)."`
+	BatchSize      int     `json:"batch_size,omitempty" jsonschema:"Rows per INSERT statement (0 = use config value or default 1000)."`
+	Workers        int     `json:"workers,omitempty" jsonschema:"Concurrent insert workers (0 = use config value or default 4)."`
+	MaxCandidates  int     `json:"max_candidates,omitempty" jsonschema:"Maximum candidate indexes to try (default 10)."`
+	MinImprovement float64 `json:"min_improvement,omitempty" jsonschema:"Percentage by which an index must lower the affected tests' latency to be recommended (default 10)."`
+	connectionArgs
+}
+
+const (
+	defaultMaxCandidates  = 10
+	defaultMinImprovement = 10
+)
+
+func registerSuggestIndexes(s *mcp.Server, engine Engine, dbs *databases) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "suggest_indexes",
+		Description: `Propose indexes for a config's test queries and measure each one on seeded data.
+
+Creates tables from DDL, seeds them and runs the tests as a baseline. The test queries are parsed for the columns they filter on (WHERE), join on (ON) and group or sort by (GROUP BY, ORDER BY), and composite indexes are proposed from them: equality columns first, then the sort columns or the first range column. Each candidate is tried on its own: every test touching its table is rerun without the index and again with it, and the index is dropped again. The tables are dropped at the end.
+
+Use this instead of guessing at indexes from EXPLAIN output: a candidate is only recommended when the server used it and it lowered latency by at least min_improvement percent. Write tests on the table are rerun too, so the cost of maintaining the index shows up; every write and transaction test is rolled back so the data stays the same. Candidates are measured one at a time; retest the recommended ones together with the test tool before adopting them.
+
+Sends a progress notification per seeded table, per baseline test and per tried candidate.`,
+	}, suggestIndexesHandler(engine, dbs))
+}
+
+func suggestIndexesHandler(engine Engine, dbs *databases) mcp.ToolHandlerFor[suggestIndexesArgs, *IndexSuggestions] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args suggestIndexesArgs) (*mcp.CallToolResult, *IndexSuggestions, error) {
+		if args.ConfigPath == "" {
+			return errResult("config_path is required: provide a seed config YAML file with tests"), nil, nil
+		}
+		maxCandidates := args.MaxCandidates
+		if maxCandidates <= 0 {
+			maxCandidates = defaultMaxCandidates
+		}
+		minImprovement := args.MinImprovement
+		if minImprovement <= 0 {
+			minImprovement = defaultMinImprovement
+		}
+
+		dsn, err := dbs.resolve(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+
+		res, err := engine.SuggestIndexes(ctx, IndexRequest{
+			DSN:            dsn,
+			ConfigPath:     args.ConfigPath,
+			Profile:        args.Profile,
+			Rows:           args.Rows,
+			BatchSize:      args.BatchSize,
+			Workers:        args.Workers,
+			MaxCandidates:  maxCandidates,
+			MinImprovement: minImprovement,
+		}, progressReporter(ctx, req))
+		if err != nil {
+			return errResult(failureMessage(ctx, "index suggestion", err)), nil, nil
+		}
+		return textResult(res.Report), res, nil
+	}
+}
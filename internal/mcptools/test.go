@@ -2,54 +2,53 @@ package mcptools
 
 import (
 	"context"
-	"strconv"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type testArgs struct {
 	ConfigPath string `json:"config_path" jsonschema:"Path to a go-test-my-db.yaml config file. Must have options.schema pointing to a DDL file and a tests section with benchmark queries."`
+	Profile    string `json:"profile,omitempty" jsonschema:"Profile of the config file to apply over its settings."`
 	Rows       int    `json:"rows,omitempty" jsonschema:"Override rows per table (0 = use config value or default 1000)."`
 	BatchSize  int    `json:"batch_size,omitempty" jsonschema:"Rows per INSERT statement (0 = use config value or default 1000)."`
 	Workers    int    `json:"workers,omitempty" jsonschema:"Concurrent insert workers (0 = use config value or default 4)."`
+	connectionArgs
 }
 
-func registerTest(s *mcp.Server) {
+func registerTest(s *mcp.Server, engine Engine, dbs *databases) {
 	mcp.AddTool(s, &mcp.Tool{
 		Name: "test",
 		Description: `Benchmark query performance for a single schema configuration.
 
-Creates tables from DDL, seeds with fake data, runs test queries, drops the tables, and returns timing results. Use this when benchmarking one schema. Use the compare tool instead when comparing two or more alternative schemas side-by-side.`,
-	}, handleTest)
-}
-
-func handleTest(ctx context.Context, _ *mcp.CallToolRequest, args testArgs) (*mcp.CallToolResult, any, error) {
-	if args.ConfigPath == "" {
-		return errResult("config_path is required: provide a seed config YAML file"), nil, nil
-	}
+Creates tables from DDL, seeds with fake data, runs test queries, drops the tables, and returns timing results. Use this when benchmarking one schema. Use the compare tool instead when comparing two or more alternative schemas side-by-side.
 
-	cliArgs := []string{"test", "--config", args.ConfigPath}
-
-	dsn := resolveDSN()
-	if dsn != "" {
-		cliArgs = append(cliArgs, "--dsn", dsn)
-	} else {
-		cliArgs = append(cliArgs, "--ephemeral")
-	}
-	if args.Rows > 0 {
-		cliArgs = append(cliArgs, "--rows", strconv.Itoa(args.Rows))
-	}
-	if args.BatchSize > 0 {
-		cliArgs = append(cliArgs, "--batch-size", strconv.Itoa(args.BatchSize))
-	}
-	if args.Workers > 0 {
-		cliArgs = append(cliArgs, "--workers", strconv.Itoa(args.Workers))
-	}
+Sends a progress notification per seeded table and per finished test. The structured output holds each test's latency statistics in milliseconds, row counts, server rows examined and EXPLAIN plan.`,
+	}, testHandler(engine, dbs))
+}
 
-	output, err := runSelf(ctx, cliArgs...)
-	if err != nil {
-		return errResult("test failed: " + err.Error()), nil, nil
+func testHandler(engine Engine, dbs *databases) mcp.ToolHandlerFor[testArgs, *TestRun] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, args testArgs) (*mcp.CallToolResult, *TestRun, error) {
+		if args.ConfigPath == "" {
+			return errResult("config_path is required: provide a seed config YAML file"), nil, nil
+		}
+
+		dsn, err := dbs.resolve(sessionID(req), args.connectionArgs)
+		if err != nil {
+			return errResult(err.Error()), nil, nil
+		}
+
+		res, err := engine.Test(ctx, TestRequest{
+			DSN:        dsn,
+			ConfigPath: args.ConfigPath,
+			Profile:    args.Profile,
+			Rows:       args.Rows,
+			BatchSize:  args.BatchSize,
+			Workers:    args.Workers,
+		}, progressReporter(ctx, req))
+		if err != nil {
+			return errResult(failureMessage(ctx, "test", err)), nil, nil
+		}
+
+		return textResult(res.Report), res, nil
 	}
-
-	return textResult(output), nil, nil
 }
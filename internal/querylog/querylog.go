@@ -0,0 +1,462 @@
+// Package querylog reads captured MySQL traffic — slow query logs, general
+// query logs and performance_schema digest exports — and groups statements
+// by normalised digest so the heaviest query shapes can be benchmarked.
+package querylog
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomfevang/go-test-my-db/internal/sqlscan"
+)
+
+// Format identifies the kind of log being read.
+type Format string
+
+const (
+	FormatAuto    Format = "auto"
+	FormatSlow    Format = "slow"    // slow query log
+	FormatGeneral Format = "general" // general query log
+	FormatDigest  Format = "digest"  // events_statements_summary_by_digest export (CSV or TSV)
+)
+
+// Entry is one captured statement. Digest exports already aggregate many
+// executions, reported through Count and TotalTime.
+type Entry struct {
+	SQL       string
+	Count     int64
+	TotalTime time.Duration
+}
+
+const maxLine = 16 << 20
+
+// Parse reads captured statements from r and calls fn for each one. With
+// FormatAuto the format is detected from the start of the input.
+func Parse(r io.Reader, format Format, fn func(Entry)) error {
+	br := bufio.NewReaderSize(r, 64<<10)
+	if format == FormatAuto || format == "" {
+		head, _ := br.Peek(64 << 10)
+		format = Detect(head)
+		if format == "" {
+			return errors.New("could not detect log format; pass --format slow, general or digest")
+		}
+	}
+
+	switch format {
+	case FormatSlow:
+		return parseSlow(br, fn)
+	case FormatGeneral:
+		return parseGeneral(br, fn)
+	case FormatDigest:
+		return parseDigest(br, fn)
+	default:
+		return fmt.Errorf("unknown log format %q (expected slow, general or digest)", format)
+	}
+}
+
+var generalLineRe = regexp.MustCompile(`^[^\t]*\t+\s*(\d+) ([A-Za-z][A-Za-z ]*?)\t(.*)$`)
+
+// Detect guesses the format from the first bytes of a log. It returns "" if
+// the input matches none of the known formats.
+func Detect(head []byte) Format {
+	if bytes.Contains(head, []byte("# Query_time:")) {
+		return FormatSlow
+	}
+	firstLine, _, _ := bytes.Cut(head, []byte("\n"))
+	if bytes.Contains(bytes.ToUpper(firstLine), []byte("DIGEST_TEXT")) {
+		return FormatDigest
+	}
+	for _, line := range bytes.Split(head, []byte("\n")) {
+		if generalLineRe.Match(bytes.TrimRight(line, "\r")) {
+			return FormatGeneral
+		}
+	}
+	return ""
+}
+
+func newScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLine)
+	return sc
+}
+
+// isServerHeader reports whether line is part of the banner mysqld writes at
+// the top of slow and general logs.
+func isServerHeader(line string) bool {
+	return strings.Contains(line, ", Version: ") ||
+		strings.HasPrefix(line, "Tcp port:") ||
+		strings.HasPrefix(line, "Time ") && strings.Contains(line, "Command")
+}
+
+// parseSlow reads a slow query log. Each statement follows its "# Query_time"
+// header and ends with a semicolon; "use db;" and "SET timestamp=...;" lines
+// are session bookkeeping and are skipped.
+func parseSlow(r io.Reader, fn func(Entry)) error {
+	sc := newScanner(r)
+	var buf strings.Builder
+	var queryTime time.Duration
+
+	flush := func() {
+		sql := strings.TrimSuffix(strings.TrimSpace(buf.String()), ";")
+		if sql != "" {
+			fn(Entry{SQL: sql, Count: 1, TotalTime: queryTime})
+		}
+		buf.Reset()
+	}
+
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "# Query_time:"):
+			flush()
+			queryTime = parseQueryTime(line)
+			continue
+		case strings.HasPrefix(line, "#"), isServerHeader(line):
+			flush()
+			continue
+		case buf.Len() == 0 && isSessionBookkeeping(trimmed):
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		if strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	flush()
+	return sc.Err()
+}
+
+// isSessionBookkeeping matches the "use db;" and "SET timestamp=...;" lines
+// mysqld writes before each slow-log statement.
+func isSessionBookkeeping(line string) bool {
+	lower := strings.ToLower(line)
+	if strings.HasPrefix(lower, "use ") {
+		return len(strings.Fields(lower)) == 2
+	}
+	return strings.HasPrefix(lower, "set timestamp=")
+}
+
+// parseQueryTime extracts the Query_time value (seconds) from a header line.
+func parseQueryTime(line string) time.Duration {
+	fields := strings.Fields(line)
+	for i, f := range fields {
+		if f == "Query_time:" && i+1 < len(fields) {
+			secs, err := strconv.ParseFloat(fields[i+1], 64)
+			if err == nil {
+				return time.Duration(secs * float64(time.Second))
+			}
+		}
+	}
+	return 0
+}
+
+// parseGeneral reads a general query log. Lines that don't start a new
+// event continue the previous statement (multi-line queries).
+func parseGeneral(r io.Reader, fn func(Entry)) error {
+	sc := newScanner(r)
+	var buf strings.Builder
+	inQuery := false
+
+	flush := func() {
+		sql := strings.TrimSuffix(strings.TrimSpace(buf.String()), ";")
+		if inQuery && sql != "" {
+			fn(Entry{SQL: sql, Count: 1})
+		}
+		buf.Reset()
+		inQuery = false
+	}
+
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if m := generalLineRe.FindStringSubmatch(line); m != nil {
+			flush()
+			switch strings.TrimSpace(m[2]) {
+			case "Query", "Execute":
+				inQuery = true
+				buf.WriteString(m[3])
+			}
+			continue
+		}
+		if isServerHeader(line) {
+			flush()
+			continue
+		}
+		if inQuery {
+			buf.WriteByte('\n')
+			buf.WriteString(line)
+		}
+	}
+	flush()
+	return sc.Err()
+}
+
+// parseDigest reads a CSV or TSV export of
+// performance_schema.events_statements_summary_by_digest. QUERY_SAMPLE_TEXT
+// (MySQL 8.0+) is preferred because it keeps literal values; DIGEST_TEXT
+// with ? placeholders is used otherwise.
+func parseDigest(r *bufio.Reader, fn func(Entry)) error {
+	head, _ := r.Peek(4096)
+	firstLine, _, _ := bytes.Cut(head, []byte("\n"))
+
+	// mysql -B writes TSV with backslash escapes and no quoting; anything
+	// else is treated as CSV.
+	var read func() ([]string, error)
+	if bytes.Count(firstLine, []byte("\t")) > bytes.Count(firstLine, []byte(",")) {
+		sc := newScanner(r)
+		read = func() ([]string, error) {
+			if !sc.Scan() {
+				if err := sc.Err(); err != nil {
+					return nil, err
+				}
+				return nil, io.EOF
+			}
+			fields := strings.Split(strings.TrimRight(sc.Text(), "\r"), "\t")
+			for i, f := range fields {
+				fields[i] = unescapeTSV(f)
+			}
+			return fields, nil
+		}
+	} else {
+		cr := csv.NewReader(r)
+		cr.LazyQuotes = true
+		cr.FieldsPerRecord = -1
+		read = cr.Read
+	}
+
+	header, err := read()
+	if err != nil {
+		return fmt.Errorf("reading digest header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.ToUpper(strings.TrimSpace(h))] = i
+	}
+	digestCol, ok := col["DIGEST_TEXT"]
+	if !ok {
+		return errors.New("digest export has no DIGEST_TEXT column")
+	}
+	field := func(rec []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		v := strings.TrimSpace(rec[i])
+		if v == "NULL" || v == `\N` {
+			return ""
+		}
+		return v
+	}
+
+	for {
+		rec, err := read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if digestCol >= len(rec) {
+			continue
+		}
+		sql := field(rec, "QUERY_SAMPLE_TEXT")
+		if sql == "" {
+			sql = field(rec, "DIGEST_TEXT")
+		}
+		if sql == "" {
+			continue
+		}
+		count, _ := strconv.ParseInt(field(rec, "COUNT_STAR"), 10, 64)
+		if count <= 0 {
+			count = 1
+		}
+		// performance_schema timers are in picoseconds.
+		picos, _ := strconv.ParseInt(field(rec, "SUM_TIMER_WAIT"), 10, 64)
+		fn(Entry{SQL: sql, Count: count, TotalTime: time.Duration(picos / 1000)})
+	}
+}
+
+// unescapeTSV reverses the backslash escaping mysql -B applies to values.
+// A bare \N (NULL) is left as is for the caller to recognise.
+func unescapeTSV(s string) string {
+	if s == `\N` || !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '0':
+			sb.WriteByte(0)
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// Digest is a group of statements that share the same normalised text.
+type Digest struct {
+	ID        string // short hash of Text
+	Text      string // normalised statement with literals replaced by ?
+	Sample    string // one original statement, preferring one with literals
+	Type      string // leading keyword: SELECT, UPDATE, ...
+	Count     int64
+	TotalTime time.Duration
+}
+
+// Normalize replaces literals with ?, collapses IN lists to IN (...),
+// upper-cases keywords, strips identifier quoting and normalises whitespace.
+func Normalize(sql string) string {
+	tokens := sqlscan.Tokenize(sql)
+	var sb strings.Builder
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		text := t.Text
+		switch {
+		case t.IsLiteral():
+			text = "?"
+		case t.Kind == sqlscan.QuotedIdent:
+			text = t.Value()
+		case t.Kind == sqlscan.Ident && sqlscan.IsKeyword(t.Text):
+			text = strings.ToUpper(t.Text)
+		}
+
+		// IN (?, ?, ...) → IN (...)
+		if t.Is("IN") && i+1 < len(tokens) && tokens[i+1].Text == "(" {
+			j := i + 2
+			for j < len(tokens) && (tokens[j].IsLiteral() || tokens[j].Text == ",") {
+				j++
+			}
+			if j > i+2 && j < len(tokens) && tokens[j].Text == ")" {
+				writeSpaced(&sb, "IN")
+				sb.WriteString(" (...)")
+				i = j
+				continue
+			}
+		}
+		if t.Text == ";" && i == len(tokens)-1 {
+			continue
+		}
+		writeSpaced(&sb, text)
+	}
+	return sb.String()
+}
+
+// writeSpaced appends text with a single separating space, except around
+// dots, before commas and closing parentheses, and after opening ones.
+func writeSpaced(sb *strings.Builder, text string) {
+	if sb.Len() > 0 {
+		s := sb.String()
+		last := s[len(s)-1]
+		if last != '(' && last != '.' && text != "," && text != ")" && text != "." && text != "(" {
+			sb.WriteByte(' ')
+		} else if text == "(" && last != '(' && last != '.' && !isWordByte(last) {
+			sb.WriteByte(' ')
+		}
+	}
+	sb.WriteString(text)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// DigestID returns a short stable identifier for normalised text.
+func DigestID(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Aggregator groups entries by digest.
+type Aggregator struct {
+	digests map[string]*Digest
+}
+
+// NewAggregator returns an empty Aggregator.
+func NewAggregator() *Aggregator {
+	return &Aggregator{digests: make(map[string]*Digest)}
+}
+
+// Add records one entry.
+func (a *Aggregator) Add(e Entry) {
+	text := Normalize(e.SQL)
+	if text == "" {
+		return
+	}
+	id := DigestID(text)
+	d, ok := a.digests[id]
+	if !ok {
+		d = &Digest{
+			ID:     id,
+			Text:   text,
+			Sample: e.SQL,
+			Type:   sqlscan.StatementType(sqlscan.Tokenize(text)),
+		}
+		a.digests[id] = d
+	} else if hasPlaceholders(d.Sample) && !hasPlaceholders(e.SQL) {
+		d.Sample = e.SQL
+	}
+	d.Count += e.Count
+	d.TotalTime += e.TotalTime
+}
+
+// hasPlaceholders reports whether sql contains digest placeholders rather
+// than literal values.
+func hasPlaceholders(sql string) bool {
+	for _, t := range sqlscan.Tokenize(sql) {
+		if t.Kind == sqlscan.Placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+// Top returns up to n digests whose statement type is in types (all types
+// if types is empty), ordered by total time, then count. n <= 0 means all.
+func (a *Aggregator) Top(n int, types ...string) []Digest {
+	var out []Digest
+	for _, d := range a.digests {
+		if len(types) > 0 && !slices.Contains(types, d.Type) {
+			continue
+		}
+		out = append(out, *d)
+	}
+	slices.SortFunc(out, func(x, y Digest) int {
+		switch {
+		case x.TotalTime != y.TotalTime:
+			return cmp.Compare(y.TotalTime, x.TotalTime)
+		case x.Count != y.Count:
+			return cmp.Compare(y.Count, x.Count)
+		default:
+			return strings.Compare(x.ID, y.ID)
+		}
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,166 @@
+package querylog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const slowLog = `/usr/sbin/mysqld, Version: 8.0.36 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+# Time: 2024-05-01T10:00:00.000000Z
+# User@Host: app[app] @ localhost []  Id:     8
+# Query_time: 0.250000  Lock_time: 0.000010 Rows_sent: 1  Rows_examined: 5000
+use shop;
+SET timestamp=1714557600;
+SELECT * FROM orders
+WHERE customer_id = 42;
+# Time: 2024-05-01T10:00:01.000000Z
+# User@Host: app[app] @ localhost []  Id:     8
+# Query_time: 0.750000  Lock_time: 0.000010 Rows_sent: 1  Rows_examined: 5000
+SET timestamp=1714557601;
+SELECT * FROM orders WHERE customer_id = 7;
+`
+
+const generalLog = `/usr/sbin/mysqld, Version: 8.0.36 (MySQL Community Server - GPL). started with:
+Tcp port: 3306  Unix socket: /var/run/mysqld/mysqld.sock
+Time                 Id Command    Argument
+2024-05-01T10:00:00.000000Z	    8 Connect	app@localhost on shop using Socket
+2024-05-01T10:00:00.100000Z	    8 Query	SELECT name
+FROM customers WHERE id = 1
+2024-05-01T10:00:00.200000Z	    8 Query	UPDATE customers SET name = 'x' WHERE id = 2
+2024-05-01T10:00:00.300000Z	    8 Quit
+`
+
+func collect(t *testing.T, input string, format Format) []Entry {
+	t.Helper()
+	var entries []Entry
+	if err := Parse(strings.NewReader(input), format, func(e Entry) { entries = append(entries, e) }); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return entries
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Format
+	}{
+		{"slow", slowLog, FormatSlow},
+		{"general", generalLog, FormatGeneral},
+		{"digest csv", "SCHEMA_NAME,DIGEST,DIGEST_TEXT,COUNT_STAR\n", FormatDigest},
+		{"unknown", "hello world\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.input)); got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSlow(t *testing.T) {
+	entries := collect(t, slowLog, FormatAuto)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %+v", len(entries), entries)
+	}
+	if got, want := entries[0].SQL, "SELECT * FROM orders\nWHERE customer_id = 42"; got != want {
+		t.Errorf("SQL = %q, want %q", got, want)
+	}
+	if entries[0].TotalTime != 250*time.Millisecond || entries[1].TotalTime != 750*time.Millisecond {
+		t.Errorf("unexpected query times: %v, %v", entries[0].TotalTime, entries[1].TotalTime)
+	}
+}
+
+func TestParseGeneral(t *testing.T) {
+	entries := collect(t, generalLog, FormatAuto)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %+v", len(entries), entries)
+	}
+	if got, want := entries[0].SQL, "SELECT name\nFROM customers WHERE id = 1"; got != want {
+		t.Errorf("SQL = %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(entries[1].SQL, "UPDATE customers") {
+		t.Errorf("second entry = %q, want UPDATE", entries[1].SQL)
+	}
+}
+
+func TestParseDigest(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		input := "DIGEST_TEXT,COUNT_STAR,SUM_TIMER_WAIT,QUERY_SAMPLE_TEXT\n" +
+			"\"SELECT * FROM `orders` WHERE `id` = ?\",10,2000000000,\"SELECT * FROM orders WHERE id = 5\"\n" +
+			"\"SELECT ? \",3,1000,NULL\n"
+		entries := collect(t, input, FormatAuto)
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, got %d", len(entries))
+		}
+		if entries[0].SQL != "SELECT * FROM orders WHERE id = 5" {
+			t.Errorf("expected sample text to be preferred, got %q", entries[0].SQL)
+		}
+		if entries[0].Count != 10 || entries[0].TotalTime != 2*time.Millisecond {
+			t.Errorf("count/time = %d/%v, want 10/2ms", entries[0].Count, entries[0].TotalTime)
+		}
+		if entries[1].SQL != "SELECT ?" {
+			t.Errorf("expected digest text fallback, got %q", entries[1].SQL)
+		}
+	})
+
+	t.Run("tsv", func(t *testing.T) {
+		input := "DIGEST_TEXT\tCOUNT_STAR\tSUM_TIMER_WAIT\n" +
+			"SELECT * FROM t WHERE s = \"a\\tb\"\\nLIMIT ?\t4\t0\n"
+		entries := collect(t, input, FormatDigest)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, got %d", len(entries))
+		}
+		if want := "SELECT * FROM t WHERE s = \"a\tb\"\nLIMIT ?"; entries[0].SQL != want {
+			t.Errorf("SQL = %q, want %q", entries[0].SQL, want)
+		}
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"select * from `orders` where id = 42", "SELECT * FROM orders WHERE id = ?"},
+		{"SELECT a.x FROM a  WHERE a.s='hi' AND a.n IN (1, 2, 3);", "SELECT a.x FROM a WHERE a.s = ? AND a.n IN (...)"},
+		{"SELECT COUNT(*) FROM t WHERE v > -1.5", "SELECT COUNT(*) FROM t WHERE v > ?"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if Normalize("SELECT * FROM t WHERE id = 1") != Normalize("select *  from `t` where id=99") {
+		t.Error("expected statements differing only in literals to share a digest")
+	}
+}
+
+func TestAggregatorTop(t *testing.T) {
+	agg := NewAggregator()
+	agg.Add(Entry{SQL: "SELECT * FROM a WHERE id = ?", Count: 1, TotalTime: time.Second})
+	agg.Add(Entry{SQL: "SELECT * FROM a WHERE id = 1", Count: 1, TotalTime: time.Second})
+	agg.Add(Entry{SQL: "UPDATE a SET x = 1 WHERE id = 2", Count: 5, TotalTime: 3 * time.Second})
+	agg.Add(Entry{SQL: "SHOW TABLES", Count: 100, TotalTime: time.Hour})
+
+	top := agg.Top(0, "SELECT", "UPDATE")
+	if len(top) != 2 {
+		t.Fatalf("expected 2 digests, got %d: %+v", len(top), top)
+	}
+	if top[0].Type != "UPDATE" || top[1].Type != "SELECT" {
+		t.Errorf("expected UPDATE before SELECT by total time, got %s, %s", top[0].Type, top[1].Type)
+	}
+	if top[1].Count != 2 || top[1].TotalTime != 2*time.Second {
+		t.Errorf("SELECT digest count/time = %d/%v, want 2/2s", top[1].Count, top[1].TotalTime)
+	}
+	if top[1].Sample != "SELECT * FROM a WHERE id = 1" {
+		t.Errorf("expected sample with literals, got %q", top[1].Sample)
+	}
+
+	if got := agg.Top(1); len(got) != 1 || got[0].Type != "SHOW" {
+		t.Errorf("Top(1) = %+v, want the SHOW digest", got)
+	}
+}
@@ -146,7 +146,7 @@ func appendFloat(buf []byte, v float64) []byte {
 	return fmt.Appendf(buf, "%g", v)
 }
 
-func seedTableLoadData(cfg Config, table *introspect.Table, fkValues map[string][]any, fkLookups []generator.FKLookup, existingUniques map[string][]any, existingComposites []generator.ExistingCompositeTuple) error {
+func seedTableLoadData(cfg Config, table *introspect.Table, fkValues map[string][]any, fkLookups []generator.FKLookup, parentRows map[string]generator.ParentRows, existingUniques map[string][]any, existingComposites []generator.ExistingCompositeTuple) error {
 	// Compute starting values for non-auto-increment integer PKs.
 	pkStartValues := make(map[string]int64)
 	for _, col := range table.Columns {
@@ -159,7 +159,7 @@ func seedTableLoadData(cfg Config, table *introspect.Table, fkValues map[string]
 		}
 	}
 
-	gen, err := generator.NewRowGenerator(table, fkValues, fkLookups, cfg.GenConfig, pkStartValues, existingUniques, existingComposites)
+	gen, err := generator.NewRowGenerator(table, fkValues, fkLookups, parentRows, cfg.GenConfig, pkStartValues, existingUniques, existingComposites)
 	if err != nil {
 		return err
 	}
@@ -171,13 +171,21 @@ func seedTableLoadData(cfg Config, table *introspect.Table, fkValues map[string]
 	}
 
 	totalRows := cfg.RowsPerTable[table.Name]
-	if totalRows <= 0 {
+	if n, ok := gen.ChildRows(); ok {
+		// children_per_parent sets the rows: the children of every parent,
+		// up to the row cap.
+		totalRows = cfg.capRows(n)
+		cfg.RowsPerTable[table.Name] = totalRows
+	} else if totalRows <= 0 {
 		totalRows = 1000
 	}
 	batchSize := cfg.BatchSize
 	if batchSize > totalRows {
 		batchSize = totalRows
 	}
+	gen.PlanRows(totalRows)
+
+	workers := cfg.insertWorkers(gen)
 
 	// Build the LOAD DATA statement.
 	quotedCols := make([]string, len(columns))
@@ -190,21 +198,21 @@ func seedTableLoadData(cfg Config, table *introspect.Table, fkValues map[string]
 	type batch struct {
 		rows [][]any
 	}
-	batches := make(chan batch, cfg.Workers*2)
+	batches := make(chan batch, workers*2)
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(cfg.ctx())
 	defer cancel()
 
 	var wg sync.WaitGroup
 	errCh := make(chan error, 1)
 	var errOnce sync.Once
 
-	for w := 0; w < cfg.Workers; w++ {
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for b := range batches {
-				if err := loadBatch(cfg.DB, table.Name, quotedCols, b.rows); err != nil {
+				if err := loadBatch(ctx, cfg.DB, table.Name, quotedCols, b.rows); err != nil {
 					errOnce.Do(func() {
 						errCh <- err
 						cancel()
@@ -244,13 +252,16 @@ func seedTableLoadData(cfg Config, table *introspect.Table, fkValues map[string]
 		return err
 	default:
 	}
+	if err := cfg.ctx().Err(); err != nil {
+		return err
+	}
 
 	printProgressDone(table.Name, totalRows)
 	return nil
 }
 
 // loadBatch streams rows via io.Pipe to MySQL's LOAD DATA LOCAL INFILE.
-func loadBatch(db *sql.DB, tableName string, quotedCols []string, rows [][]any) error {
+func loadBatch(ctx context.Context, db *sql.DB, tableName string, quotedCols []string, rows [][]any) error {
 	pr, pw := io.Pipe()
 
 	name := fmt.Sprintf("batch_%d", handlerCounter.Add(1))
@@ -282,6 +293,6 @@ func loadBatch(db *sql.DB, tableName string, quotedCols []string, rows [][]any)
 		name, tableName, colList,
 	)
 
-	_, err := db.Exec(query)
+	_, err := db.ExecContext(ctx, query)
 	return err
 }
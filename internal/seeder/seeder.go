@@ -60,6 +60,56 @@ type Config struct {
 	DeferIndexes bool
 	GenConfig    *config.Config
 	FKSampleSize int // max FK values to cache per column; 0 = unlimited
+	MaxRows      int // caps the rows children_per_parent sets; 0 = no cap
+
+	// Context cancels seeding between and during batches; nil never cancels.
+	Context context.Context
+	// OnTable, if set, is called after each table with the number of rows
+	// inserted into it (0 when the table already had enough rows).
+	OnTable func(table string, rows int)
+}
+
+// capRows returns n, at most MaxRows when it is set.
+func (cfg Config) capRows(n int) int {
+	if cfg.MaxRows > 0 {
+		return min(n, cfg.MaxRows)
+	}
+	return n
+}
+
+// ctx returns the configured context, or context.Background().
+func (cfg Config) ctx() context.Context {
+	if cfg.Context == nil {
+		return context.Background()
+	}
+	return cfg.Context
+}
+
+// tableDone reports a finished table to OnTable.
+func (cfg Config) tableDone(table string, rows int) {
+	if cfg.OnTable != nil {
+		cfg.OnTable(table, rows)
+	}
+}
+
+// seed returns the configured data seed, or 0 for random data.
+func (cfg Config) seed() int64 {
+	if cfg.GenConfig == nil {
+		return 0
+	}
+	return cfg.GenConfig.Options.Seed
+}
+
+// insertWorkers returns how many workers insert the rows of gen. Rows that
+// must go in in the order they were generated get a single worker: time
+// series, and all rows of a seeded run, whose auto-increment IDs (and the
+// rows later picked by them) would otherwise depend on which worker
+// finishes first.
+func (cfg Config) insertWorkers(gen *generator.RowGenerator) int {
+	if gen.Ordered() || cfg.seed() != 0 {
+		return 1
+	}
+	return cfg.Workers
 }
 
 // SeedAll seeds all tables in the configured order.
@@ -93,6 +143,9 @@ func SeedAll(cfg Config) error {
 	}
 
 	for i, table := range cfg.Tables {
+		if err := cfg.ctx().Err(); err != nil {
+			return err
+		}
 		targetRows := cfg.RowsPerTable[table.Name]
 		if targetRows <= 0 {
 			targetRows = 1000
@@ -114,13 +167,14 @@ func SeedAll(cfg Config) error {
 				// Still cache PKs for downstream FK references.
 				for _, col := range table.Columns {
 					if col.IsPrimaryKey {
-						vals, err := fetchColumnValues(cfg.DB, table.Name, col.Name, cfg.FKSampleSize)
+						vals, err := fetchColumnValues(cfg.DB, table.Name, col.Name, cfg.FKSampleSize, cfg.seed())
 						if err != nil {
 							return fmt.Errorf("caching PK values for %s.%s: %w", table.Name, col.Name, err)
 						}
 						fkCache[table.Name+"."+col.Name] = vals
 					}
 				}
+				cfg.tableDone(table.Name, 0)
 				continue
 			}
 			cfg.RowsPerTable[table.Name] = targetRows - currentCount
@@ -163,7 +217,7 @@ func SeedAll(cfg Config) error {
 			if vals, ok := fkCache[cacheKey]; ok {
 				tableFKValues[col.Name] = vals
 			} else {
-				vals, err := fetchColumnValues(cfg.DB, col.FK.ReferencedTable, col.FK.ReferencedColumn, cfg.FKSampleSize)
+				vals, err := fetchColumnValues(cfg.DB, col.FK.ReferencedTable, col.FK.ReferencedColumn, cfg.FKSampleSize, cfg.seed())
 				if err != nil {
 					return fmt.Errorf("fetching FK values for %s.%s: %w", table.Name, col.Name, err)
 				}
@@ -172,6 +226,21 @@ func SeedAll(cfg Config) error {
 			}
 		}
 
+		// children_per_parent gives every parent its children, so it needs
+		// all parent keys rather than a sample.
+		if name, _ := cfg.GenConfig.GetChildren(table.Name); name != "" {
+			for _, col := range table.Columns {
+				if col.Name != name || col.FK == nil {
+					continue
+				}
+				vals, err := fetchColumnValues(cfg.DB, col.FK.ReferencedTable, col.FK.ReferencedColumn, 0, cfg.seed())
+				if err != nil {
+					return fmt.Errorf("fetching parent keys for %s.%s: %w", table.Name, col.Name, err)
+				}
+				tableFKValues[col.Name] = vals
+			}
+		}
+
 		// Detect correlated FK columns and build lookup maps.
 		correlations := detectFKCorrelations(table, tableMap)
 		var fkLookups []generator.FKLookup
@@ -190,6 +259,23 @@ func SeedAll(cfg Config) error {
 				table.Name, corr.derivedCol, corr.driverCol, corr.parentTable, corr.parentFKCol)
 		}
 
+		// Fetch the parent columns that column templates read through
+		// .parent, for the sampled parent keys.
+		needed, err := generator.ParentColumns(table, cfg.GenConfig)
+		if err != nil {
+			return err
+		}
+		parentRows := make(map[string]generator.ParentRows, len(needed))
+		for _, col := range table.Columns {
+			if cols := needed[col.Name]; len(cols) > 0 {
+				rows, err := fetchParentRows(cfg.DB, col.FK.ReferencedTable, col.FK.ReferencedColumn, cols, tableFKValues[col.Name])
+				if err != nil {
+					return fmt.Errorf("fetching parent columns for %s.%s: %w", table.Name, col.Name, err)
+				}
+				parentRows[col.Name] = rows
+			}
+		}
+
 		// Pre-load existing unique values for incremental seeding.
 		var existingUniques map[string][]any
 		var existingComposites []generator.ExistingCompositeTuple
@@ -198,7 +284,7 @@ func SeedAll(cfg Config) error {
 		}
 
 		if cfg.LoadData {
-			if err := seedTableLoadData(cfg, table, tableFKValues, fkLookups, existingUniques, existingComposites); err != nil {
+			if err := seedTableLoadData(cfg, table, tableFKValues, fkLookups, parentRows, existingUniques, existingComposites); err != nil {
 				// Restore indexes even on seed failure.
 				if len(droppedIndexes) > 0 {
 					fmt.Printf("[%s] restoring %d secondary indexes after error...\n", table.Name, len(droppedIndexes))
@@ -207,7 +293,7 @@ func SeedAll(cfg Config) error {
 				return fmt.Errorf("seeding %s: %w", table.Name, err)
 			}
 		} else {
-			if err := seedTable(cfg, table, tableFKValues, fkLookups, existingUniques, existingComposites); err != nil {
+			if err := seedTable(cfg, table, tableFKValues, fkLookups, parentRows, existingUniques, existingComposites); err != nil {
 				// Restore indexes even on seed failure.
 				if len(droppedIndexes) > 0 {
 					fmt.Printf("[%s] restoring %d secondary indexes after error...\n", table.Name, len(droppedIndexes))
@@ -228,7 +314,7 @@ func SeedAll(cfg Config) error {
 		// Cache this table's primary key values for downstream FK references.
 		for _, col := range table.Columns {
 			if col.IsPrimaryKey {
-				vals, err := fetchColumnValues(cfg.DB, table.Name, col.Name, cfg.FKSampleSize)
+				vals, err := fetchColumnValues(cfg.DB, table.Name, col.Name, cfg.FKSampleSize, cfg.seed())
 				if err != nil {
 					return fmt.Errorf("caching PK values for %s.%s: %w", table.Name, col.Name, err)
 				}
@@ -236,6 +322,8 @@ func SeedAll(cfg Config) error {
 			}
 		}
 
+		cfg.tableDone(table.Name, cfg.RowsPerTable[table.Name])
+
 		// Evict FK cache entries whose last consumer is the current table.
 		for key, lastIdx := range lastConsumer {
 			if lastIdx == i {
@@ -251,7 +339,7 @@ func SeedAll(cfg Config) error {
 	return nil
 }
 
-func seedTable(cfg Config, table *introspect.Table, fkValues map[string][]any, fkLookups []generator.FKLookup, existingUniques map[string][]any, existingComposites []generator.ExistingCompositeTuple) error {
+func seedTable(cfg Config, table *introspect.Table, fkValues map[string][]any, fkLookups []generator.FKLookup, parentRows map[string]generator.ParentRows, existingUniques map[string][]any, existingComposites []generator.ExistingCompositeTuple) error {
 	// Compute starting values for non-auto-increment integer PKs.
 	pkStartValues := make(map[string]int64)
 	for _, col := range table.Columns {
@@ -264,7 +352,7 @@ func seedTable(cfg Config, table *introspect.Table, fkValues map[string][]any, f
 		}
 	}
 
-	gen, err := generator.NewRowGenerator(table, fkValues, fkLookups, cfg.GenConfig, pkStartValues, existingUniques, existingComposites)
+	gen, err := generator.NewRowGenerator(table, fkValues, fkLookups, parentRows, cfg.GenConfig, pkStartValues, existingUniques, existingComposites)
 	if err != nil {
 		return err
 	}
@@ -276,13 +364,21 @@ func seedTable(cfg Config, table *introspect.Table, fkValues map[string][]any, f
 	}
 
 	totalRows := cfg.RowsPerTable[table.Name]
-	if totalRows <= 0 {
+	if n, ok := gen.ChildRows(); ok {
+		// children_per_parent sets the rows: the children of every parent,
+		// up to the row cap.
+		totalRows = cfg.capRows(n)
+		cfg.RowsPerTable[table.Name] = totalRows
+	} else if totalRows <= 0 {
 		totalRows = 1000
 	}
 	batchSize := cfg.BatchSize
 	if batchSize > totalRows {
 		batchSize = totalRows
 	}
+	gen.PlanRows(totalRows)
+
+	workers := cfg.insertWorkers(gen)
 
 	// Build the INSERT prefix: INSERT INTO `table` (`col1`, `col2`, ...) VALUES
 	quotedCols := make([]string, len(columns))
@@ -300,10 +396,10 @@ func seedTable(cfg Config, table *introspect.Table, fkValues map[string][]any, f
 	type batch struct {
 		rows [][]any
 	}
-	batches := make(chan batch, cfg.Workers*2)
+	batches := make(chan batch, workers*2)
 
 	// Use a context so workers can signal the producer to stop on error.
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(cfg.ctx())
 	defer cancel()
 
 	// Worker pool.
@@ -311,12 +407,12 @@ func seedTable(cfg Config, table *introspect.Table, fkValues map[string][]any, f
 	errCh := make(chan error, 1)
 	var errOnce sync.Once
 
-	for w := 0; w < cfg.Workers; w++ {
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for b := range batches {
-				if err := insertBatch(cfg.DB, insertPrefix, singleRow, len(columns), b.rows); err != nil {
+				if err := insertBatch(ctx, cfg.DB, insertPrefix, singleRow, len(columns), b.rows); err != nil {
 					errOnce.Do(func() {
 						errCh <- err
 						cancel()
@@ -357,12 +453,15 @@ func seedTable(cfg Config, table *introspect.Table, fkValues map[string][]any, f
 		return err
 	default:
 	}
+	if err := cfg.ctx().Err(); err != nil {
+		return err
+	}
 
 	printProgressDone(table.Name, totalRows)
 	return nil
 }
 
-func insertBatch(db *sql.DB, insertPrefix, singleRow string, numCols int, rows [][]any) error {
+func insertBatch(ctx context.Context, db *sql.DB, insertPrefix, singleRow string, numCols int, rows [][]any) error {
 	placeholders := make([]string, len(rows))
 	for i := range placeholders {
 		placeholders[i] = singleRow
@@ -374,7 +473,7 @@ func insertBatch(db *sql.DB, insertPrefix, singleRow string, numCols int, rows [
 		args = append(args, row...)
 	}
 
-	_, err := db.Exec(query, args...)
+	_, err := db.ExecContext(ctx, query, args...)
 	return err
 }
 
@@ -396,8 +495,15 @@ func fetchMaxPK(db *sql.DB, table, column string) (int64, error) {
 	return maxVal.Int64, nil
 }
 
-func fetchColumnValues(db *sql.DB, table, column string, maxSample int) ([]any, error) {
-	rows, err := db.Query(fmt.Sprintf("SELECT `%s` FROM `%s`", column, table))
+// fetchColumnValues reads a column's values, sampling at most maxSample of
+// them. With a non-zero seed the values are read in column order and sampled
+// reproducibly, so FK picks don't depend on which index the server scans.
+func fetchColumnValues(db *sql.DB, table, column string, maxSample int, seed int64) ([]any, error) {
+	query := fmt.Sprintf("SELECT `%s` FROM `%s`", column, table)
+	if seed != 0 {
+		query += fmt.Sprintf(" ORDER BY `%s`", column)
+	}
+	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -414,20 +520,20 @@ func fetchColumnValues(db *sql.DB, table, column string, maxSample int) ([]any,
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return reservoirSample(values, maxSample), nil
+	return reservoirSample(values, maxSample, generator.NewRand(seed, table+"."+column)), nil
 }
 
 // reservoirSample returns a random subset of at most maxSample items using
 // Algorithm R. When maxSample <= 0 or len(values) <= maxSample, all values
 // are returned unchanged.
-func reservoirSample(values []any, maxSample int) []any {
+func reservoirSample(values []any, maxSample int, rng *rand.Rand) []any {
 	if maxSample <= 0 || len(values) <= maxSample {
 		return values
 	}
 	reservoir := make([]any, maxSample)
 	copy(reservoir, values[:maxSample])
 	for i := maxSample; i < len(values); i++ {
-		j := rand.IntN(i + 1)
+		j := rng.IntN(i + 1)
 		if j < maxSample {
 			reservoir[j] = values[i]
 		}
@@ -529,6 +635,60 @@ func fetchFKLookup(db *sql.DB, table, pkCol, fkCol string) (map[any]any, error)
 	return lookup, rows.Err()
 }
 
+// parentRowsChunk is the most parent keys fetchParentRows puts in one
+// WHERE ... IN list.
+const parentRowsChunk = 1000
+
+// fetchParentRows reads the columns cols of the parent rows whose key pkCol
+// is among keys, the sampled values of a child's FK column. It only reads
+// those rows, in chunks of parentRowsChunk keys.
+func fetchParentRows(db *sql.DB, table, pkCol string, cols []string, keys []any) (generator.ParentRows, error) {
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = "`" + col + "`"
+	}
+	selectCols := fmt.Sprintf("SELECT `%s`, %s FROM `%s` WHERE `%s` IN ", pkCol, strings.Join(quoted, ", "), table, pkCol)
+
+	parents := make(generator.ParentRows, len(keys))
+	for start := 0; start < len(keys); start += parentRowsChunk {
+		chunk := keys[start:min(start+parentRowsChunk, len(keys))]
+		query := selectCols + "(" + strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ") + ")"
+		if err := scanParentRows(db, parents, cols, query, chunk); err != nil {
+			return nil, err
+		}
+	}
+	return parents, nil
+}
+
+// scanParentRows runs one fetchParentRows query and adds its rows to parents.
+func scanParentRows(db *sql.DB, parents generator.ParentRows, cols []string, query string, args []any) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	dest := make([]any, len(cols)+1)
+	for rows.Next() {
+		vals := make([]any, len(cols)+1)
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		row := make(map[string]any, len(cols))
+		for i, col := range cols {
+			if b, ok := vals[i+1].([]byte); ok {
+				vals[i+1] = string(b)
+			}
+			row[col] = vals[i+1]
+		}
+		parents[generator.ParentKey(vals[0])] = row
+	}
+	return rows.Err()
+}
+
 // computeLastConsumers maps each FK cache key ("table.column") to the index
 // of the last table in the seeding order that references it. This lets SeedAll
 // evict cache entries as soon as they are no longer needed.
@@ -556,7 +716,7 @@ func fetchExistingUniques(db *sql.DB, table *introspect.Table) (map[string][]any
 		if !col.IsUnique || col.IsPrimaryKey || col.IsAutoInc {
 			continue
 		}
-		vals, err := fetchColumnValues(db, table.Name, col.Name, 0)
+		vals, err := fetchColumnValues(db, table.Name, col.Name, 0, 0)
 		if err != nil {
 			return nil, nil, err
 		}
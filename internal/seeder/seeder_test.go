@@ -1,14 +1,26 @@
 package seeder
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/generator"
 	"github.com/tomfevang/go-test-my-db/internal/introspect"
 )
 
 func TestReservoirSample_Unbounded(t *testing.T) {
 	values := []any{1, 2, 3, 4, 5}
-	result := reservoirSample(values, 0)
+	result := reservoirSample(values, 0, rand.New(rand.NewPCG(1, 2)))
 	if len(result) != len(values) {
 		t.Fatalf("expected %d items, got %d", len(values), len(result))
 	}
@@ -16,7 +28,7 @@ func TestReservoirSample_Unbounded(t *testing.T) {
 
 func TestReservoirSample_FewerThanLimit(t *testing.T) {
 	values := []any{1, 2, 3}
-	result := reservoirSample(values, 10)
+	result := reservoirSample(values, 10, rand.New(rand.NewPCG(1, 2)))
 	if len(result) != len(values) {
 		t.Fatalf("expected %d items, got %d", len(values), len(result))
 	}
@@ -27,7 +39,7 @@ func TestReservoirSample_ExactlyLimit(t *testing.T) {
 	for i := range values {
 		values[i] = i
 	}
-	result := reservoirSample(values, 100)
+	result := reservoirSample(values, 100, rand.New(rand.NewPCG(1, 2)))
 	if len(result) != 100 {
 		t.Fatalf("expected 100 items, got %d", len(result))
 	}
@@ -45,7 +57,7 @@ func TestReservoirSample_ExactlyLimit(t *testing.T) {
 }
 
 func TestReservoirSample_NilSlice(t *testing.T) {
-	result := reservoirSample(nil, 10)
+	result := reservoirSample(nil, 10, rand.New(rand.NewPCG(1, 2)))
 	if result != nil {
 		t.Fatalf("expected nil, got %v", result)
 	}
@@ -113,3 +125,154 @@ func TestComputeLastConsumers_NoFKs(t *testing.T) {
 		t.Errorf("expected empty map for table with no FKs, got %v", last)
 	}
 }
+
+// insertDriver is a database/sql driver recording the rows of INSERTs, each
+// batch after a random delay, numbering them like auto-increment IDs in the
+// order batches arrive.
+type insertDriver struct {
+	mu   sync.Mutex
+	rows map[string][]driver.Value // DSN -> values of every inserted row, flattened
+}
+
+func (d *insertDriver) Open(dsn string) (driver.Conn, error) {
+	return &insertConn{d: d, dsn: dsn}, nil
+}
+
+type insertConn struct {
+	d   *insertDriver
+	dsn string
+}
+
+func (c *insertConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *insertConn) Close() error                        { return nil }
+func (c *insertConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *insertConn) ExecContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Result, error) {
+	time.Sleep(time.Duration(rand.IntN(2000)) * time.Microsecond)
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	for _, a := range args {
+		c.d.rows[c.dsn] = append(c.d.rows[c.dsn], a.Value)
+	}
+	return driver.RowsAffected(len(args)), nil
+}
+
+var inserts = &insertDriver{rows: make(map[string][]driver.Value)}
+
+func init() {
+	sql.Register("seedertest", inserts)
+}
+
+func TestSeedTable_SeedReproducibleWithWorkers(t *testing.T) {
+	table := &introspect.Table{Name: "users", Columns: []introspect.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", IsPrimaryKey: true, IsAutoInc: true},
+		{Name: "email", DataType: "varchar", ColumnType: "varchar(255)"},
+		{Name: "score", DataType: "int", ColumnType: "int"},
+	}}
+
+	// checksum seeds the table and hashes its rows with their
+	// auto-increment IDs, as a verify checksum would.
+	checksum := func(run int) string {
+		dsn := fmt.Sprintf("run%d", run)
+		db, err := sql.Open("seedertest", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		cfg := Config{
+			DB:           db,
+			Tables:       []*introspect.Table{table},
+			RowsPerTable: map[string]int{"users": 500},
+			BatchSize:    10,
+			Workers:      8,
+			GenConfig:    &config.Config{Options: config.Options{Seed: 42}},
+		}
+		if err := seedTable(cfg, table, nil, nil, nil, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		h := sha256.New()
+		for i, v := range inserts.rows[dsn] {
+			if i%2 == 0 {
+				fmt.Fprintf(h, "%d", i/2+1) // auto-increment ID
+			}
+			fmt.Fprintf(h, "\t%v", v)
+		}
+		return fmt.Sprintf("%x", h.Sum(nil))
+	}
+
+	if a, b := checksum(1), checksum(2); a != b {
+		t.Errorf("seeded runs with 8 workers differ: checksums %s and %s", a, b)
+	}
+}
+
+// parentDriver is a database/sql driver answering the queries of
+// fetchParentRows: each key in the query's arguments is a parent row whose
+// name is "user<key>".
+type parentDriver struct {
+	queries int
+}
+
+func (d *parentDriver) Open(string) (driver.Conn, error) { return &parentConn{d}, nil }
+
+type parentConn struct{ d *parentDriver }
+
+func (c *parentConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *parentConn) Close() error                        { return nil }
+func (c *parentConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *parentConn) QueryContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.queries++
+	return &parentRowsResult{args: args}, nil
+}
+
+type parentRowsResult struct {
+	args []driver.NamedValue
+	i    int
+}
+
+func (r *parentRowsResult) Columns() []string { return []string{"id", "name"} }
+func (r *parentRowsResult) Close() error      { return nil }
+
+func (r *parentRowsResult) Next(dest []driver.Value) error {
+	if r.i == len(r.args) {
+		return io.EOF
+	}
+	key := r.args[r.i].Value
+	dest[0], dest[1] = key, []byte(fmt.Sprintf("user%v", key))
+	r.i++
+	return nil
+}
+
+var parents = &parentDriver{}
+
+func init() {
+	sql.Register("seederparents", parents)
+}
+
+func TestFetchParentRows_Chunked(t *testing.T) {
+	db, err := sql.Open("seederparents", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	keys := make([]any, 2500)
+	for i := range keys {
+		keys[i] = int64(i * 7)
+	}
+	rows, err := fetchParentRows(db, "users", "id", []string{"name"}, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parents.queries != 3 {
+		t.Errorf("%d queries for %d keys, want 3", parents.queries, len(keys))
+	}
+	if len(rows) != len(keys) {
+		t.Fatalf("%d parent rows, want %d", len(rows), len(keys))
+	}
+	for _, k := range keys {
+		if got, want := rows[generator.ParentKey(k)]["name"], fmt.Sprintf("user%v", k); got != want {
+			t.Fatalf("name of parent %v = %v, want %s", k, got, want)
+		}
+	}
+}
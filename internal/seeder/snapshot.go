@@ -0,0 +1,346 @@
+package seeder
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/generator"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+const (
+	snapshotManifest   = "manifest.json"
+	restoreBatchSize   = 1000
+	snapshotFileSuffix = ".tsv"
+)
+
+// SnapshotManifest describes a dataset dump stored on disk. Tables are listed
+// in seeding order so a restore can load parents before children.
+type SnapshotManifest struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Tables    []SnapshotTable `json:"tables"`
+}
+
+// SnapshotTable records the columns and row count dumped for one table.
+type SnapshotTable struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+}
+
+// SnapshotExists reports whether dir holds a complete snapshot.
+func SnapshotExists(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, snapshotManifest))
+	return err == nil
+}
+
+// SaveSnapshot dumps every table to a TSV file in LOAD DATA format and writes
+// a manifest. The snapshot is written to a temporary directory and renamed
+// into place, so an interrupted save never leaves a half-written snapshot.
+// Columns the seeder leaves to the database are not dumped, so a restore
+// fills them the same way.
+func SaveSnapshot(db *sql.DB, dir string, tables []*introspect.Table, cfg *config.Config) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	manifest := SnapshotManifest{CreatedAt: time.Now().UTC()}
+	for _, table := range tables {
+		var columns []string
+		for _, col := range table.Columns {
+			if col.IsGenerated || generator.LeavesDefault(cfg, table.Name, col) {
+				continue
+			}
+			columns = append(columns, col.Name)
+		}
+		n, err := dumpTable(db, table.Name, columns, filepath.Join(tmp, table.Name+snapshotFileSuffix))
+		if err != nil {
+			return fmt.Errorf("dumping %s: %w", table.Name, err)
+		}
+		manifest.Tables = append(manifest.Tables, SnapshotTable{Name: table.Name, Columns: columns, Rows: n})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, snapshotManifest), data, 0644); err != nil {
+		return err
+	}
+
+	// Replace any stale snapshot with the fresh one.
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+// dumpTable streams all rows of a table into a TSV file and returns the row count.
+func dumpTable(db *sql.DB, table string, columns []string, path string) (int64, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT %s FROM `%s`", quoteColumns(columns), table))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	w := bufio.NewWriterSize(f, 1<<20)
+
+	values := make([]sql.RawBytes, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	var n int64
+	buf := make([]byte, 0, 4096)
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, err
+		}
+		buf = buf[:0]
+		for i, v := range values {
+			if i > 0 {
+				buf = append(buf, '\t')
+			}
+			if v == nil {
+				buf = append(buf, '\\', 'N')
+			} else {
+				buf = appendEscapedBytes(buf, v)
+			}
+		}
+		buf = append(buf, '\n')
+		if _, err := w.Write(buf); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	if err := w.Flush(); err != nil {
+		return n, err
+	}
+	return n, f.Close()
+}
+
+// RestoreSnapshot loads a snapshot written by SaveSnapshot into existing,
+// empty tables. It uses LOAD DATA LOCAL INFILE when the server allows it and
+// falls back to batched INSERTs otherwise. onTable, if set, is called after
+// each table with the row count recorded in the manifest.
+func RestoreSnapshot(db *sql.DB, dir string, onTable func(table string, rows int)) error {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotManifest))
+	if err != nil {
+		return err
+	}
+	var manifest SnapshotManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("reading manifest: %w", err)
+	}
+
+	ctx := context.Background()
+	// Session variables only apply to one connection, so pin one for the restore.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var localInfile int
+	if err := conn.QueryRowContext(ctx, "SELECT @@local_infile").Scan(&localInfile); err != nil {
+		localInfile = 0
+	}
+
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS=0"); err != nil {
+		return fmt.Errorf("disabling FK checks: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SET UNIQUE_CHECKS=0"); err != nil {
+		return fmt.Errorf("disabling unique checks: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS=1")
+	defer conn.ExecContext(ctx, "SET UNIQUE_CHECKS=1")
+
+	for _, t := range manifest.Tables {
+		path := filepath.Join(dir, t.Name+snapshotFileSuffix)
+		if localInfile == 1 {
+			err = restoreTableLoadData(ctx, conn, t, path)
+		} else {
+			err = restoreTableInsert(ctx, conn, t, path)
+		}
+		if err != nil {
+			return fmt.Errorf("restoring %s: %w", t.Name, err)
+		}
+		printProgressDone(t.Name, int(t.Rows))
+		if onTable != nil {
+			onTable(t.Name, int(t.Rows))
+		}
+	}
+	return nil
+}
+
+// restoreTableLoadData streams a TSV dump through LOAD DATA LOCAL INFILE.
+func restoreTableLoadData(ctx context.Context, conn *sql.Conn, t SnapshotTable, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	name := fmt.Sprintf("snapshot_%d", handlerCounter.Add(1))
+	mysql.RegisterReaderHandler(name, func() io.Reader { return f })
+	defer mysql.DeregisterReaderHandler(name)
+
+	// The dump holds text as UTF-8, whatever the database's character set.
+	query := fmt.Sprintf(
+		"LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE `%s` CHARACTER SET utf8mb4 FIELDS TERMINATED BY '\\t' LINES TERMINATED BY '\\n' (%s)",
+		name, t.Name, quoteColumns(t.Columns),
+	)
+	_, err = conn.ExecContext(ctx, query)
+	return err
+}
+
+// restoreTableInsert parses a TSV dump and replays it as batched INSERTs.
+func restoreTableInsert(ctx context.Context, conn *sql.Conn, t SnapshotTable, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	insertPrefix := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES ", t.Name, quoteColumns(t.Columns))
+	singleRow := "(" + strings.Repeat("?, ", len(t.Columns)-1) + "?)"
+
+	flush := func(batch [][]any) error {
+		if len(batch) == 0 {
+			return nil
+		}
+		placeholders := make([]string, len(batch))
+		args := make([]any, 0, len(batch)*len(t.Columns))
+		for i, row := range batch {
+			placeholders[i] = singleRow
+			args = append(args, row...)
+		}
+		_, err := conn.ExecContext(ctx, insertPrefix+strings.Join(placeholders, ", "), args...)
+		return err
+	}
+
+	r := bufio.NewReaderSize(f, 1<<20)
+	batch := make([][]any, 0, restoreBatchSize)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			row, perr := parseTSVLine(line, len(t.Columns))
+			if perr != nil {
+				return perr
+			}
+			batch = append(batch, row)
+			if len(batch) == restoreBatchSize {
+				if err := flush(batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return flush(batch)
+}
+
+// parseTSVLine decodes one line written in LOAD DATA format back into values.
+// \N becomes nil; every other field is returned as []byte with escapes undone.
+func parseTSVLine(line []byte, numCols int) ([]any, error) {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+
+	row := make([]any, 0, numCols)
+	field := make([]byte, 0, 64)
+	escaped := false
+	endField := func() {
+		if len(field) == 2 && field[0] == '\\' && field[1] == 'N' {
+			row = append(row, nil)
+		} else {
+			row = append(row, unescapeField(field))
+		}
+		field = field[:0]
+	}
+	for _, c := range line {
+		switch {
+		case escaped:
+			field = append(field, '\\', c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '\t':
+			endField()
+		default:
+			field = append(field, c)
+		}
+	}
+	endField()
+
+	if len(row) != numCols {
+		return nil, fmt.Errorf("snapshot row has %d fields, expected %d", len(row), numCols)
+	}
+	return row, nil
+}
+
+// unescapeField reverses appendEscapedBytes for a single field.
+func unescapeField(field []byte) []byte {
+	out := make([]byte, 0, len(field))
+	for i := 0; i < len(field); i++ {
+		c := field[i]
+		if c != '\\' || i+1 == len(field) {
+			out = append(out, c)
+			continue
+		}
+		i++
+		switch field[i] {
+		case 't':
+			out = append(out, '\t')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case '0':
+			out = append(out, 0)
+		default:
+			out = append(out, field[i])
+		}
+	}
+	return out
+}
+
+func quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = "`" + c + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,88 @@
+package seeder
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseTSVLine_RoundTrip(t *testing.T) {
+	fields := [][]byte{
+		[]byte("plain"),
+		[]byte("tab\there"),
+		[]byte("line\nbreak\r"),
+		[]byte(`back\slash`),
+		{'n', 'u', 0, 'l'},
+		[]byte(`\N`), // literal backslash-N, not NULL
+		{},
+	}
+
+	var line []byte
+	for i, f := range fields {
+		if i > 0 {
+			line = append(line, '\t')
+		}
+		line = appendEscapedBytes(line, f)
+	}
+	line = append(line, '\t', '\\', 'N', '\n')
+
+	row, err := parseTSVLine(line, len(fields)+1)
+	if err != nil {
+		t.Fatalf("parseTSVLine: %v", err)
+	}
+	for i, want := range fields {
+		got, ok := row[i].([]byte)
+		if !ok {
+			t.Fatalf("field %d: expected []byte, got %T", i, row[i])
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("field %d: got %q, want %q", i, got, want)
+		}
+	}
+	if row[len(fields)] != nil {
+		t.Errorf("expected trailing NULL, got %v", row[len(fields)])
+	}
+}
+
+func TestParseTSVLine_FieldCountMismatch(t *testing.T) {
+	if _, err := parseTSVLine([]byte("a\tb\n"), 3); err == nil {
+		t.Fatal("expected error for wrong field count")
+	}
+}
+
+func TestRestoreSnapshot_OnTable(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		snapshotManifest: `{"tables": [{"name": "users", "columns": ["id"], "rows": 2}, {"name": "orders", "columns": ["id", "user_id"], "rows": 1}]}`,
+		"users.tsv":      "1\n2\n",
+		"orders.tsv":     "1\t2\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := sql.Open("seedertest", "restore-ontable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var got []string
+	err = RestoreSnapshot(db, dir, func(table string, rows int) {
+		got = append(got, fmt.Sprintf("%s:%d", table, rows))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"users:2", "orders:1"}; !slices.Equal(got, want) {
+		t.Errorf("OnTable calls = %v, want %v", got, want)
+	}
+	if n := len(inserts.rows["restore-ontable"]); n != 4 {
+		t.Errorf("restored %d values, want 4", n)
+	}
+}
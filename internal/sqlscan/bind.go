@@ -0,0 +1,266 @@
+package sqlscan
+
+import "strings"
+
+// ColumnRef is a column reference resolved to its table where possible.
+type ColumnRef struct {
+	Table  string // resolved table name; empty if ambiguous
+	Column string
+}
+
+// Binding ties a literal token to the column it is compared against.
+type Binding struct {
+	Index  int // index of the literal in the token slice
+	Column ColumnRef
+	InList bool // the literal is one element of an IN (...) list
+}
+
+// comparisonOps are the operators whose literal operand is worth replacing
+// with a sampled column value. Ranges (BETWEEN) and patterns (LIKE) keep
+// their literals, since a single sampled value would change their meaning.
+var comparisonOps = map[string]bool{
+	"=": true, "<=>": true, "<>": true, "!=": true,
+	"<": true, "<=": true, ">": true, ">=": true,
+}
+
+// tableRefEnd are keywords that end a FROM/JOIN/UPDATE table list.
+var tableRefEnd = map[string]bool{
+	"CROSS": true, "FOR": true, "FORCE": true, "GROUP": true, "HAVING": true,
+	"IGNORE": true, "INNER": true, "JOIN": true, "LEFT": true, "LIMIT": true,
+	"LOCK": true, "NATURAL": true, "ON": true, "ORDER": true, "OUTER": true,
+	"PARTITION": true, "RIGHT": true, "SET": true, "STRAIGHT_JOIN": true,
+	"UNION": true, "USE": true, "USING": true, "VALUES": true, "WHERE": true,
+	"WINDOW": true, "SELECT": true,
+}
+
+// Tables returns the tables referenced by FROM, JOIN, UPDATE and INTO clauses,
+// in order of appearance, plus a map from alias (and table name) to table.
+// Derived tables are skipped.
+func Tables(tokens []Token) (tables []string, aliases map[string]string) {
+	aliases = make(map[string]string)
+	add := func(table, alias string) {
+		if _, ok := aliases[table]; !ok {
+			tables = append(tables, table)
+		}
+		aliases[table] = table
+		if alias != "" {
+			aliases[alias] = table
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.Kind != Ident {
+			continue
+		}
+		switch strings.ToUpper(t.Text) {
+		case "FROM":
+			// EXTRACT(YEAR FROM col), TRIM(x FROM y) are not table lists.
+			if !inFunctionCall(tokens, i) {
+				i = scanTableList(tokens, i+1, true, add)
+			}
+		case "UPDATE":
+			// FOR UPDATE and ON DUPLICATE KEY UPDATE are not table lists.
+			if i == 0 || !(tokens[i-1].Is("FOR") || tokens[i-1].Is("KEY")) {
+				i = scanTableList(tokens, i+1, true, add)
+			}
+		case "JOIN", "STRAIGHT_JOIN", "INTO":
+			i = scanTableList(tokens, i+1, false, add)
+		}
+	}
+	return tables, aliases
+}
+
+// scanTableList reads table references starting at i and returns the index
+// of the last token consumed. With multi set, comma-separated lists are read.
+func scanTableList(tokens []Token, i int, multi bool, add func(table, alias string)) int {
+	for i < len(tokens) {
+		t := tokens[i]
+		if t.Text == "(" {
+			// Derived table or subquery: skip to the matching parenthesis
+			// and any alias after it.
+			i = skipParens(tokens, i)
+			i = skipAlias(tokens, i+1)
+		} else if t.Kind == Ident || t.Kind == QuotedIdent {
+			if t.Kind == Ident && (tableRefEnd[strings.ToUpper(t.Text)] || IsKeyword(t.Text)) {
+				return i - 1
+			}
+			name := t.Value()
+			i++
+			// db.table: keep the table part.
+			if i+1 < len(tokens) && tokens[i].Text == "." && isName(tokens[i+1]) {
+				name = tokens[i+1].Value()
+				i += 2
+			}
+			alias := ""
+			if i < len(tokens) && tokens[i].Is("AS") {
+				i++
+			}
+			if i < len(tokens) && isName(tokens[i]) {
+				alias = tokens[i].Value()
+				i++
+			}
+			add(name, alias)
+		} else {
+			return i - 1
+		}
+
+		if !multi || i >= len(tokens) || tokens[i].Text != "," {
+			return i - 1
+		}
+		i++
+	}
+	return i
+}
+
+// inFunctionCall reports whether tokens[i] is inside the argument list of a
+// function call, as opposed to a subquery or the top level.
+func inFunctionCall(tokens []Token, i int) bool {
+	depth := 0
+	for j := i - 1; j >= 0; j-- {
+		switch tokens[j].Text {
+		case ")":
+			depth++
+		case "(":
+			if depth > 0 {
+				depth--
+				continue
+			}
+			return j > 0 && isName(tokens[j-1])
+		}
+	}
+	return false
+}
+
+// skipParens returns the index of the parenthesis matching the one at i.
+func skipParens(tokens []Token, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i].Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+// skipAlias skips an optional [AS] alias at i and returns the next index.
+func skipAlias(tokens []Token, i int) int {
+	if i < len(tokens) && tokens[i].Is("AS") {
+		i++
+	}
+	if i < len(tokens) && isName(tokens[i]) {
+		i++
+	}
+	return i
+}
+
+// isName reports whether t can be an identifier (not a reserved word).
+func isName(t Token) bool {
+	if t.Kind == QuotedIdent {
+		return true
+	}
+	return t.Kind == Ident && !IsKeyword(t.Text) && !tableRefEnd[strings.ToUpper(t.Text)]
+}
+
+// columnEndingAt resolves a column reference whose last token is at i
+// (col, tbl.col or db.tbl.col). It returns false if tokens[i] is not a name.
+func columnEndingAt(tokens []Token, i int, tables []string, aliases map[string]string) (ColumnRef, bool) {
+	if i < 0 || !isName(tokens[i]) {
+		return ColumnRef{}, false
+	}
+	ref := ColumnRef{Column: tokens[i].Value()}
+	if i >= 2 && tokens[i-1].Text == "." && isName(tokens[i-2]) {
+		ref.Table = aliases[tokens[i-2].Value()]
+		return ref, true
+	}
+	if len(tables) == 1 {
+		ref.Table = tables[0]
+	}
+	return ref, true
+}
+
+// columnStartingAt resolves a column reference whose first token is at i.
+func columnStartingAt(tokens []Token, i int, tables []string, aliases map[string]string) (ColumnRef, bool) {
+	if i >= len(tokens) || !isName(tokens[i]) {
+		return ColumnRef{}, false
+	}
+	end := i
+	for end+2 < len(tokens) && tokens[end+1].Text == "." && isName(tokens[end+2]) {
+		end += 2
+	}
+	// A name followed by "(" is a function call, not a column.
+	if end+1 < len(tokens) && tokens[end+1].Text == "(" {
+		return ColumnRef{}, false
+	}
+	return columnEndingAt(tokens, end, tables, aliases)
+}
+
+// Bind finds literals compared against columns with =, <>, <, >, etc., or
+// listed in col IN (...). Literals in SET assignments, VALUES lists, LIMIT
+// and similar positions are not bound. Columns whose table cannot be
+// determined are returned with an empty Table.
+func Bind(tokens []Token) []Binding {
+	tables, aliases := Tables(tokens)
+
+	var bindings []Binding
+	inSet := false
+	for i, t := range tokens {
+		if t.Kind == Ident {
+			switch strings.ToUpper(t.Text) {
+			case "SET", "VALUES", "VALUE", "UPDATE":
+				// UPDATE also starts ON DUPLICATE KEY UPDATE assignments;
+				// a leading UPDATE statement keyword is harmless here.
+				inSet = true
+			case "WHERE", "ON", "HAVING", "SELECT", "FROM", "JOIN":
+				inSet = false
+			}
+			continue
+		}
+		if !t.IsLiteral() || inSet {
+			continue
+		}
+
+		// col <op> literal
+		if i >= 2 && tokens[i-1].Kind == Op && comparisonOps[tokens[i-1].Text] {
+			if ref, ok := columnEndingAt(tokens, i-2, tables, aliases); ok {
+				bindings = append(bindings, Binding{Index: i, Column: ref})
+				continue
+			}
+		}
+		// literal <op> col
+		if i+2 < len(tokens) && tokens[i+1].Kind == Op && comparisonOps[tokens[i+1].Text] {
+			if ref, ok := columnStartingAt(tokens, i+2, tables, aliases); ok {
+				bindings = append(bindings, Binding{Index: i, Column: ref})
+				continue
+			}
+		}
+		// col [NOT] IN (literal, literal, ...)
+		if ref, ok := inListColumn(tokens, i, tables, aliases); ok {
+			bindings = append(bindings, Binding{Index: i, Column: ref, InList: true})
+		}
+	}
+	return bindings
+}
+
+// inListColumn checks whether the literal at i sits in a list of literals
+// that follows "col [NOT] IN (".
+func inListColumn(tokens []Token, i int, tables []string, aliases map[string]string) (ColumnRef, bool) {
+	j := i - 1
+	for j >= 0 && (tokens[j].Text == "," || tokens[j].IsLiteral()) {
+		j--
+	}
+	if j < 1 || tokens[j].Text != "(" || !tokens[j-1].Is("IN") {
+		return ColumnRef{}, false
+	}
+	k := j - 2
+	if k >= 0 && tokens[k].Is("NOT") {
+		k--
+	}
+	return columnEndingAt(tokens, k, tables, aliases)
+}
@@ -0,0 +1,222 @@
+package sqlscan
+
+import "strings"
+
+// Use classifies how a statement uses a column, from an index's point of
+// view.
+type Use int
+
+const (
+	UseEquality Use = iota // col = value, col IN (...), col IS NULL
+	UseRange               // col < value, col BETWEEN ..., col LIKE 'prefix%'
+	UseJoin                // col = other.col
+	UseGroup               // GROUP BY col
+	UseOrder               // ORDER BY col
+)
+
+// ColumnUse is one column a statement filters, joins, groups or sorts on.
+type ColumnUse struct {
+	Column ColumnRef
+	Use    Use
+	Desc   bool // ORDER BY col DESC
+}
+
+// Clause states of ColumnUses.
+const (
+	clauseOther = iota
+	clauseFilter
+)
+
+// sortListEnd are keywords that end a GROUP BY or ORDER BY list.
+var sortListEnd = map[string]bool{
+	"FOR": true, "HAVING": true, "LIMIT": true, "LOCK": true, "ORDER": true,
+	"UNION": true, "WINDOW": true, "WITH": true,
+}
+
+// ColumnUses finds the columns compared in WHERE and ON clauses and listed
+// in GROUP BY and ORDER BY, in order of appearance. Only comparisons an index
+// can serve are reported: negations, function-wrapped columns and patterns
+// with a leading wildcard are skipped. Predicates are reported regardless of
+// AND/OR nesting. Columns whose table cannot be determined are returned with
+// an empty Table.
+func ColumnUses(tokens []Token) []ColumnUse {
+	tables, aliases := Tables(tokens)
+
+	var uses []ColumnUse
+	clause := clauseOther
+	var outer []int // clause of each enclosing parenthesis
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.Kind == Punct && t.Text == "(":
+			outer = append(outer, clause)
+			continue
+		case t.Kind == Punct && t.Text == ")":
+			if len(outer) > 0 {
+				clause = outer[len(outer)-1]
+				outer = outer[:len(outer)-1]
+			}
+			continue
+		case t.Kind == Ident:
+			switch strings.ToUpper(t.Text) {
+			case "WHERE":
+				clause = clauseFilter
+				continue
+			case "ON":
+				// ON DUPLICATE KEY UPDATE assigns rather than compares.
+				if i+1 < len(tokens) && tokens[i+1].Is("DUPLICATE") {
+					clause = clauseOther
+				} else {
+					clause = clauseFilter
+				}
+				continue
+			case "BY":
+				if i > 0 && (tokens[i-1].Is("GROUP") || tokens[i-1].Is("ORDER")) {
+					use := UseOrder
+					if tokens[i-1].Is("GROUP") {
+						use = UseGroup
+					}
+					var list []ColumnUse
+					list, i = sortList(tokens, i+1, use, tables, aliases)
+					uses = append(uses, list...)
+					clause = clauseOther
+					continue
+				}
+			case "SELECT", "FROM", "JOIN", "GROUP", "ORDER", "HAVING", "LIMIT",
+				"SET", "VALUES", "UPDATE", "UNION", "WINDOW", "USING":
+				clause = clauseOther
+				continue
+			}
+		}
+		if clause != clauseFilter {
+			continue
+		}
+
+		// A column starts here, unless this is the column part of tbl.col.
+		if isName(t) && !(i > 0 && tokens[i-1].Text == ".") {
+			ref, end, ok := columnAt(tokens, i, tables, aliases)
+			if !ok {
+				continue
+			}
+			found, next := predicate(tokens, ref, end, tables, aliases)
+			uses = append(uses, found...)
+			i = next
+			continue
+		}
+
+		// literal = col, literal < col, ...
+		if t.IsLiteral() && i+2 < len(tokens) && tokens[i+1].Kind == Op && !(i > 0 && tokens[i-1].Kind == Op) {
+			ref, end, ok := columnAt(tokens, i+2, tables, aliases)
+			if !ok || (end+1 < len(tokens) && tokens[end+1].Kind == Op) {
+				continue
+			}
+			switch tokens[i+1].Text {
+			case "=", "<=>":
+				uses = append(uses, ColumnUse{Column: ref, Use: UseEquality})
+			case "<", "<=", ">", ">=":
+				uses = append(uses, ColumnUse{Column: ref, Use: UseRange})
+			}
+			i = end
+		}
+	}
+	return uses
+}
+
+// columnAt resolves a column reference starting at i and returns the index
+// of its last token.
+func columnAt(tokens []Token, i int, tables []string, aliases map[string]string) (ColumnRef, int, bool) {
+	ref, ok := columnStartingAt(tokens, i, tables, aliases)
+	if !ok {
+		return ColumnRef{}, i, false
+	}
+	end := i
+	for end+2 < len(tokens) && tokens[end+1].Text == "." && isName(tokens[end+2]) {
+		end += 2
+	}
+	return ref, end, true
+}
+
+// predicate classifies the comparison that follows the column ref ending at
+// end. It returns the uses found and the index of the last token consumed.
+func predicate(tokens []Token, ref ColumnRef, end int, tables []string, aliases map[string]string) ([]ColumnUse, int) {
+	if end+1 >= len(tokens) {
+		return nil, end
+	}
+	op := tokens[end+1]
+	operand := end + 2
+	one := func(use Use) ([]ColumnUse, int) {
+		return []ColumnUse{{Column: ref, Use: use}}, end + 1
+	}
+
+	switch {
+	case op.Kind == Op && (op.Text == "=" || op.Text == "<=>"):
+		if other, otherEnd, ok := columnAt(tokens, operand, tables, aliases); ok {
+			if otherEnd+1 < len(tokens) && tokens[otherEnd+1].Kind == Op {
+				return nil, end + 1 // col = other.col + 1
+			}
+			return []ColumnUse{{Column: ref, Use: UseJoin}, {Column: other, Use: UseJoin}}, otherEnd
+		}
+		if operand < len(tokens) && tokens[operand].Text == "(" && operand+1 < len(tokens) && tokens[operand+1].Is("SELECT") {
+			return one(UseEquality) // col = (SELECT ...)
+		}
+		if operand < len(tokens) && !tokens[operand].IsLiteral() && !isCall(tokens, operand) {
+			return nil, end + 1
+		}
+		return one(UseEquality)
+	case op.Kind == Op && (op.Text == "<" || op.Text == "<=" || op.Text == ">" || op.Text == ">="):
+		if _, _, ok := columnAt(tokens, operand, tables, aliases); ok {
+			return nil, end + 1 // range joins are not worth an index of their own
+		}
+		return one(UseRange)
+	case op.Is("IN"):
+		return one(UseEquality)
+	case op.Is("BETWEEN"):
+		return one(UseRange)
+	case op.Is("LIKE"):
+		if operand < len(tokens) {
+			p := tokens[operand]
+			if p.Kind == Placeholder || (p.Kind == String && len(p.Text) > 1 && p.Text[1] != '%' && p.Text[1] != '_') {
+				return one(UseRange)
+			}
+		}
+	case op.Is("IS"):
+		if operand < len(tokens) && tokens[operand].Is("NULL") {
+			return one(UseEquality)
+		}
+	}
+	return nil, end
+}
+
+// isCall reports whether a function call such as NOW() starts at i.
+func isCall(tokens []Token, i int) bool {
+	return tokens[i].Kind == Ident && i+1 < len(tokens) && tokens[i+1].Text == "("
+}
+
+// sortList reads the GROUP BY or ORDER BY list starting at i. It stops at
+// the first item that is not a plain column, since an index cannot serve the
+// items after it. It returns the index of the last token consumed.
+func sortList(tokens []Token, i int, use Use, tables []string, aliases map[string]string) ([]ColumnUse, int) {
+	var uses []ColumnUse
+	for i < len(tokens) {
+		ref, end, ok := columnAt(tokens, i, tables, aliases)
+		if !ok {
+			return uses, i - 1
+		}
+		u := ColumnUse{Column: ref, Use: use}
+		next := end + 1
+		if next < len(tokens) && (tokens[next].Is("ASC") || tokens[next].Is("DESC")) {
+			u.Desc = tokens[next].Is("DESC")
+			next++
+		}
+		if next < len(tokens) && tokens[next].Text != "," && tokens[next].Text != ")" && tokens[next].Text != ";" &&
+			!(tokens[next].Kind == Ident && sortListEnd[strings.ToUpper(tokens[next].Text)]) {
+			return uses, i - 1 // an expression such as col + 1
+		}
+		uses = append(uses, u)
+		if next >= len(tokens) || tokens[next].Text != "," {
+			return uses, next - 1
+		}
+		i = next + 1
+	}
+	return uses, i - 1
+}
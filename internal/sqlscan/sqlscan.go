@@ -0,0 +1,282 @@
+// Package sqlscan provides a lightweight MySQL tokenizer and a heuristic
+// analysis of which table column each literal in a statement is compared to,
+// and of the columns a statement filters, joins and sorts on.
+// It is not a full parser: it understands enough of SELECT, UPDATE, DELETE
+// and INSERT to turn captured queries into parameterised benchmarks.
+package sqlscan
+
+import (
+	"strings"
+)
+
+// Kind classifies a token.
+type Kind int
+
+const (
+	Ident       Kind = iota // bare word: keyword, table, column, function
+	QuotedIdent             // `backtick quoted` identifier
+	String                  // 'single' or "double" quoted string literal
+	Number                  // numeric literal, including a leading sign
+	Placeholder             // ? or ... as found in digest text
+	Op                      // operator such as =, <=, <>, +
+	Punct                   // ( ) , ; .
+)
+
+// Token is a single lexical element of a statement.
+type Token struct {
+	Kind        Kind
+	Text        string // raw text as it appeared in the statement
+	SpaceBefore bool   // whitespace or a comment preceded the token
+}
+
+// Value returns the token text with identifier quoting removed.
+func (t Token) Value() string {
+	if t.Kind == QuotedIdent && len(t.Text) >= 2 {
+		return strings.ReplaceAll(t.Text[1:len(t.Text)-1], "``", "`")
+	}
+	return t.Text
+}
+
+// IsLiteral reports whether the token is a value that could be parameterised.
+func (t Token) IsLiteral() bool {
+	return t.Kind == String || t.Kind == Number || t.Kind == Placeholder
+}
+
+// Is reports whether the token is the given keyword, case-insensitively.
+func (t Token) Is(keyword string) bool {
+	return t.Kind == Ident && strings.EqualFold(t.Text, keyword)
+}
+
+// keywords are words that can never be column references. The list covers
+// the words that appear around comparisons; it is not exhaustive.
+var keywords = map[string]bool{
+	"ALL": true, "AND": true, "ANY": true, "AS": true, "ASC": true, "BETWEEN": true,
+	"BY": true, "CASE": true, "CROSS": true, "DELETE": true, "DESC": true,
+	"DISTINCT": true, "DUPLICATE": true, "ELSE": true, "END": true, "EXISTS": true,
+	"FALSE": true, "FOR": true, "FORCE": true, "FROM": true, "GROUP": true,
+	"HAVING": true, "IGNORE": true, "IN": true, "INNER": true, "INSERT": true,
+	"INTERVAL": true, "INTO": true, "IS": true, "JOIN": true, "KEY": true,
+	"LEFT": true, "LIKE": true, "LIMIT": true, "LOCK": true, "NATURAL": true,
+	"NOT": true, "NULL": true, "OFFSET": true, "ON": true, "OR": true,
+	"ORDER": true, "OUTER": true, "REGEXP": true, "REPLACE": true, "RIGHT": true,
+	"SELECT": true, "SET": true, "SHARE": true, "STRAIGHT_JOIN": true,
+	"THEN": true, "TRUE": true, "UNION": true, "UPDATE": true, "USE": true,
+	"USING": true, "VALUES": true, "WHEN": true, "WHERE": true, "WINDOW": true,
+	"WITH": true, "XOR": true,
+}
+
+// IsKeyword reports whether word is a reserved word that cannot name a column.
+func IsKeyword(word string) bool {
+	return keywords[strings.ToUpper(word)]
+}
+
+// signContext are keywords after which a + or - starts a signed number
+// rather than being a binary operator.
+var signContext = map[string]bool{
+	"AND": true, "BETWEEN": true, "ELSE": true, "IN": true, "LIKE": true,
+	"LIMIT": true, "NOT": true, "OFFSET": true, "OR": true, "SELECT": true,
+	"SET": true, "THEN": true, "VALUES": true, "WHEN": true, "WHERE": true,
+}
+
+// Tokenize splits a statement into tokens. Comments are dropped. Unterminated
+// quotes run to the end of the input rather than failing.
+func Tokenize(sql string) []Token {
+	var tokens []Token
+	space := false
+	emit := func(kind Kind, text string) {
+		tokens = append(tokens, Token{Kind: kind, Text: text, SpaceBefore: space})
+		space = false
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			space = true
+			i++
+
+		case c == '#' || (c == '-' && strings.HasPrefix(sql[i:], "-- ")):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			i += end
+			space = true
+
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+			space = true
+
+		case c == '\'' || c == '"':
+			end := scanQuoted(sql, i, c, true)
+			emit(String, sql[i:end])
+			i = end
+
+		case c == '`':
+			end := scanQuoted(sql, i, '`', false)
+			emit(QuotedIdent, sql[i:end])
+			i = end
+
+		case isDigit(c) || (c == '.' && i+1 < len(sql) && isDigit(sql[i+1]) && !afterValue(tokens)):
+			end := scanNumber(sql, i)
+			emit(Number, sql[i:end])
+			i = end
+
+		case (c == '-' || c == '+') && i+1 < len(sql) && (isDigit(sql[i+1]) || sql[i+1] == '.') && startsOperand(tokens):
+			end := scanNumber(sql, i+1)
+			emit(Number, sql[i:end])
+			i = end
+
+		case c == '?':
+			emit(Placeholder, "?")
+			i++
+
+		case c == '.' && strings.HasPrefix(sql[i:], "..."):
+			emit(Placeholder, "...")
+			i += 3
+
+		case isWordStart(c):
+			end := i + 1
+			for end < len(sql) && isWordPart(sql[end]) {
+				end++
+			}
+			emit(Ident, sql[i:end])
+			i = end
+
+		case c == '(' || c == ')' || c == ',' || c == ';' || c == '.':
+			emit(Punct, string(c))
+			i++
+
+		default:
+			end := i + 1
+			for _, op := range []string{"<=>", "<=", ">=", "<>", "!=", ":=", "||", "&&", "<<", ">>", "->>", "->"} {
+				if strings.HasPrefix(sql[i:], op) {
+					end = i + len(op)
+					break
+				}
+			}
+			emit(Op, sql[i:end])
+			i = end
+		}
+	}
+	return tokens
+}
+
+// scanQuoted returns the index just past the closing quote starting at i.
+// Doubled quotes are always an escape; backslash escapes apply to strings.
+func scanQuoted(sql string, i int, quote byte, backslash bool) int {
+	for j := i + 1; j < len(sql); j++ {
+		switch sql[j] {
+		case '\\':
+			if backslash {
+				j++
+			}
+		case quote:
+			if j+1 < len(sql) && sql[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(sql)
+}
+
+// scanNumber returns the index just past a numeric literal starting at i.
+func scanNumber(sql string, i int) int {
+	if strings.HasPrefix(sql[i:], "0x") || strings.HasPrefix(sql[i:], "0X") {
+		j := i + 2
+		for j < len(sql) && isHexDigit(sql[j]) {
+			j++
+		}
+		return j
+	}
+	j := i
+	for j < len(sql) && (isDigit(sql[j]) || sql[j] == '.') {
+		j++
+	}
+	if j < len(sql) && (sql[j] == 'e' || sql[j] == 'E') {
+		k := j + 1
+		if k < len(sql) && (sql[k] == '+' || sql[k] == '-') {
+			k++
+		}
+		if k < len(sql) && isDigit(sql[k]) {
+			j = k
+			for j < len(sql) && isDigit(sql[j]) {
+				j++
+			}
+		}
+	}
+	return j
+}
+
+// afterValue reports whether the previous token ends a value, in which case a
+// following '.' is a qualifier separator rather than the start of a number.
+func afterValue(tokens []Token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	prev := tokens[len(tokens)-1]
+	return prev.Kind == Ident || prev.Kind == QuotedIdent || prev.Text == ")"
+}
+
+// startsOperand reports whether a sign at this position belongs to a number.
+func startsOperand(tokens []Token) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	prev := tokens[len(tokens)-1]
+	switch prev.Kind {
+	case Op:
+		return true
+	case Punct:
+		return prev.Text == "(" || prev.Text == ","
+	case Ident:
+		return signContext[strings.ToUpper(prev.Text)]
+	}
+	return false
+}
+
+func isDigit(c byte) bool    { return c >= '0' && c <= '9' }
+func isHexDigit(c byte) bool { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
+func isWordStart(c byte) bool {
+	return c == '_' || c == '$' || c == '@' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+func isWordPart(c byte) bool { return isWordStart(c) || isDigit(c) }
+
+// StatementType returns the upper-cased leading keyword (SELECT, UPDATE, ...),
+// skipping opening parentheses. A WITH clause reports the main statement.
+func StatementType(tokens []Token) string {
+	depth := 0
+	sawWith := false
+	for _, t := range tokens {
+		switch {
+		case t.Text == "(":
+			depth++
+		case t.Text == ")":
+			depth--
+		case t.Kind == Ident:
+			word := strings.ToUpper(t.Text)
+			if !sawWith {
+				if word != "WITH" {
+					return word
+				}
+				sawWith = true
+				continue
+			}
+			if depth > 0 {
+				continue
+			}
+			switch word {
+			case "SELECT", "UPDATE", "DELETE", "INSERT", "REPLACE":
+				return word
+			}
+		}
+	}
+	return ""
+}
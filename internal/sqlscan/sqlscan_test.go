@@ -0,0 +1,155 @@
+package sqlscan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize("SELECT `o`.id, 'it''s' /* c */ FROM o WHERE x >= -1.5e3 AND y <> ? -- tail\n")
+	var kinds []Kind
+	var texts []string
+	for _, tok := range tokens {
+		kinds = append(kinds, tok.Kind)
+		texts = append(texts, tok.Text)
+	}
+	wantTexts := []string{"SELECT", "`o`", ".", "id", ",", "'it''s'", "FROM", "o", "WHERE", "x", ">=", "-1.5e3", "AND", "y", "<>", "?"}
+	wantKinds := []Kind{Ident, QuotedIdent, Punct, Ident, Punct, String, Ident, Ident, Ident, Ident, Op, Number, Ident, Ident, Op, Placeholder}
+	if !reflect.DeepEqual(texts, wantTexts) {
+		t.Fatalf("texts = %q\nwant    %q", texts, wantTexts)
+	}
+	if !reflect.DeepEqual(kinds, wantKinds) {
+		t.Errorf("kinds = %v, want %v", kinds, wantKinds)
+	}
+	if tokens[1].Value() != "o" {
+		t.Errorf("Value() = %q, want %q", tokens[1].Value(), "o")
+	}
+}
+
+func TestTokenize_MinusIsOperatorAfterValue(t *testing.T) {
+	tokens := Tokenize("a-1")
+	if len(tokens) != 3 || tokens[1].Kind != Op {
+		t.Errorf("expected a, -, 1; got %+v", tokens)
+	}
+}
+
+func TestStatementType(t *testing.T) {
+	tests := map[string]string{
+		"select 1":                                "SELECT",
+		"(SELECT 1) UNION (SELECT 2)":             "SELECT",
+		"WITH c AS (SELECT 1) UPDATE t SET a = 1": "UPDATE",
+		"delete from t":                           "DELETE",
+		"SET autocommit = 0":                      "SET",
+	}
+	for sql, want := range tests {
+		if got := StatementType(Tokenize(sql)); got != want {
+			t.Errorf("StatementType(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+func TestTables(t *testing.T) {
+	tokens := Tokenize("SELECT * FROM db.orders AS o JOIN users u ON u.id = o.user_id, (SELECT 1) d " +
+		"WHERE EXTRACT(YEAR FROM o.created_at) = 2024 FOR UPDATE")
+	tables, aliases := Tables(tokens)
+	if !reflect.DeepEqual(tables, []string{"orders", "users"}) {
+		t.Errorf("tables = %v", tables)
+	}
+	if aliases["o"] != "orders" || aliases["u"] != "users" || aliases["orders"] != "orders" {
+		t.Errorf("aliases = %v", aliases)
+	}
+}
+
+func TestBind(t *testing.T) {
+	sql := "SELECT * FROM orders o JOIN users u ON u.id = o.user_id " +
+		"WHERE o.company_id = 42 AND 'open' = o.status AND u.id IN (1, 2) AND o.total BETWEEN 5 AND 10 AND amount > 3 LIMIT 10"
+	tokens := Tokenize(sql)
+	got := map[string]Binding{}
+	for _, b := range Bind(tokens) {
+		got[tokens[b.Index].Text] = b
+	}
+
+	want := map[string]Binding{
+		"42":     {Column: ColumnRef{Table: "orders", Column: "company_id"}},
+		"'open'": {Column: ColumnRef{Table: "orders", Column: "status"}},
+		"1":      {Column: ColumnRef{Table: "users", Column: "id"}, InList: true},
+		"2":      {Column: ColumnRef{Table: "users", Column: "id"}, InList: true},
+		"3":      {Column: ColumnRef{Column: "amount"}}, // unqualified with two tables: ambiguous
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d bindings, want %d: %+v", len(got), len(want), got)
+	}
+	for lit, w := range want {
+		g, ok := got[lit]
+		if !ok {
+			t.Errorf("literal %s not bound", lit)
+			continue
+		}
+		if g.Column != w.Column || g.InList != w.InList {
+			t.Errorf("literal %s: got %+v, want %+v", lit, g, w)
+		}
+	}
+}
+
+func TestBind_SkipsAssignments(t *testing.T) {
+	tokens := Tokenize("UPDATE orders SET status = 'paid', total = 10 WHERE id = 7")
+	bindings := Bind(tokens)
+	if len(bindings) != 1 || tokens[bindings[0].Index].Text != "7" {
+		t.Fatalf("expected only the WHERE literal to be bound, got %+v", bindings)
+	}
+	if bindings[0].Column != (ColumnRef{Table: "orders", Column: "id"}) {
+		t.Errorf("unexpected column: %+v", bindings[0].Column)
+	}
+
+	tokens = Tokenize("INSERT INTO t (a, b) VALUES (1, 2) ON DUPLICATE KEY UPDATE b = 3")
+	if b := Bind(tokens); len(b) != 0 {
+		t.Errorf("expected no bindings for INSERT, got %+v", b)
+	}
+}
+
+func TestColumnUses(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want []string
+	}{
+		{
+			"SELECT * FROM orders o JOIN users u ON u.id = o.user_id " +
+				"WHERE o.status = 'open' AND o.total BETWEEN 5 AND 10 AND ? < o.created_at ORDER BY o.created_at DESC LIMIT 10",
+			[]string{"join users.id", "join orders.user_id", "eq orders.status", "range orders.total", "range orders.created_at", "order orders.created_at desc"},
+		},
+		{
+			"SELECT status, COUNT(*) FROM orders WHERE user_id IN (1, 2) AND note LIKE 'abc%' AND code LIKE '%x' GROUP BY status ORDER BY COUNT(*)",
+			[]string{"eq orders.user_id", "range orders.note", "group orders.status"},
+		},
+		{
+			"SELECT * FROM t WHERE LOWER(email) = ? AND a <> 1 AND b NOT IN (1) AND c IS NULL AND d = NOW() AND e + 1 = 2 ORDER BY f, g + 1, h",
+			[]string{"eq t.c", "eq t.d", "order t.f"},
+		},
+		{
+			"SELECT * FROM users WHERE id IN (SELECT user_id FROM orders WHERE total > 5)",
+			[]string{"eq .id", "range .total"}, // two tables: resolved by the caller
+		},
+		{
+			"UPDATE orders SET status = 'paid' WHERE id = 7",
+			[]string{"eq orders.id"},
+		},
+		{
+			"INSERT INTO t (a, b) VALUES (1, 2) ON DUPLICATE KEY UPDATE b = 3",
+			nil,
+		},
+	}
+	names := map[Use]string{UseEquality: "eq", UseRange: "range", UseJoin: "join", UseGroup: "group", UseOrder: "order"}
+	for _, tt := range tests {
+		var got []string
+		for _, u := range ColumnUses(Tokenize(tt.sql)) {
+			s := names[u.Use] + " " + u.Column.Table + "." + u.Column.Column
+			if u.Desc {
+				s += " desc"
+			}
+			got = append(got, s)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s\n got %q\nwant %q", tt.sql, got, tt.want)
+		}
+	}
+}
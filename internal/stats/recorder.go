@@ -0,0 +1,226 @@
+// Package stats summarises latency samples. A Recorder keeps a log-linear
+// histogram in the style of HdrHistogram instead of the samples themselves,
+// so memory stays bounded however long a run is, while percentiles keep a
+// fixed relative precision.
+package stats
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the precision: every power-of-two range of values is
+// split into 2^subBucketBits buckets, so a recorded value is off by less
+// than 1/256 (0.4%) of itself. Values below 2^subBucketBits ns are exact.
+const subBucketBits = 8
+
+const subBuckets = 1 << subBucketBits
+
+// Recorder accumulates durations. The zero value is ready to use; a
+// Recorder is not safe for concurrent use.
+type Recorder struct {
+	counts   []uint64 // per bucket, grown on demand
+	n        uint64
+	min, max time.Duration
+	sum      time.Duration
+	mean, m2 float64 // Welford's running mean and sum of squared deviations, in ns
+}
+
+// bucketIndex maps a non-negative value to its bucket.
+func bucketIndex(v uint64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits - 1
+	return (shift+1)*subBuckets + int(v>>shift) - subBuckets
+}
+
+// bucketValue returns the value a bucket stands for: the middle of its range.
+func bucketValue(i int) time.Duration {
+	if i < subBuckets {
+		return time.Duration(i)
+	}
+	shift := i/subBuckets - 1
+	lower := uint64(i%subBuckets+subBuckets) << shift
+	return time.Duration(lower + (uint64(1)<<shift)/2)
+}
+
+// Record adds one sample. Negative durations are recorded as zero.
+func (r *Recorder) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	i := bucketIndex(uint64(d))
+	if i >= len(r.counts) {
+		r.counts = append(r.counts, make([]uint64, i+1-len(r.counts))...)
+	}
+	r.counts[i]++
+
+	if r.n == 0 || d < r.min {
+		r.min = d
+	}
+	if d > r.max {
+		r.max = d
+	}
+	r.n++
+	r.sum += d
+	delta := float64(d) - r.mean
+	r.mean += delta / float64(r.n)
+	r.m2 += delta * (float64(d) - r.mean)
+}
+
+// Merge adds all samples of o to r.
+func (r *Recorder) Merge(o *Recorder) {
+	if o == nil || o.n == 0 {
+		return
+	}
+	if len(o.counts) > len(r.counts) {
+		r.counts = append(r.counts, make([]uint64, len(o.counts)-len(r.counts))...)
+	}
+	for i, c := range o.counts {
+		r.counts[i] += c
+	}
+	if r.n == 0 || o.min < r.min {
+		r.min = o.min
+	}
+	if o.max > r.max {
+		r.max = o.max
+	}
+	n := r.n + o.n
+	delta := o.mean - r.mean
+	r.m2 += o.m2 + delta*delta*float64(r.n)*float64(o.n)/float64(n)
+	r.mean += delta * float64(o.n) / float64(n)
+	r.n = n
+	r.sum += o.sum
+}
+
+// Count returns the number of samples.
+func (r *Recorder) Count() int {
+	if r == nil {
+		return 0
+	}
+	return int(r.n)
+}
+
+// Min returns the smallest sample, exactly.
+func (r *Recorder) Min() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return r.min
+}
+
+// Max returns the largest sample, exactly.
+func (r *Recorder) Max() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return r.max
+}
+
+// Mean returns the average sample, exactly.
+func (r *Recorder) Mean() time.Duration {
+	if r == nil || r.n == 0 {
+		return 0
+	}
+	return r.sum / time.Duration(r.n)
+}
+
+// StdDev returns the sample standard deviation, or 0 with fewer than two
+// samples.
+func (r *Recorder) StdDev() time.Duration {
+	if r == nil || r.n < 2 {
+		return 0
+	}
+	return time.Duration(math.Sqrt(r.m2 / float64(r.n-1)))
+}
+
+// CV returns the coefficient of variation: the standard deviation relative
+// to the mean. Values above about 0.1 mean the runs are too noisy for small
+// differences between them to be meaningful.
+func (r *Recorder) CV() float64 {
+	mean := r.Mean()
+	if mean == 0 {
+		return 0
+	}
+	return float64(r.StdDev()) / float64(mean)
+}
+
+// Percentile returns the value below which pct percent of the samples fall
+// (nearest rank). It is exact at 0 and 100 and within the recorder's
+// precision elsewhere.
+func (r *Recorder) Percentile(pct float64) time.Duration {
+	if r == nil || r.n == 0 {
+		return 0
+	}
+	if pct <= 0 {
+		return r.min
+	}
+	if pct >= 100 {
+		return r.max
+	}
+	rank := uint64(math.Ceil(pct / 100 * float64(r.n)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range r.counts {
+		seen += c
+		if seen >= rank {
+			return r.clamp(bucketValue(i))
+		}
+	}
+	return r.max
+}
+
+// clamp keeps bucket midpoints inside the observed range.
+func (r *Recorder) clamp(d time.Duration) time.Duration {
+	if d < r.min {
+		return r.min
+	}
+	if d > r.max {
+		return r.max
+	}
+	return d
+}
+
+// Outliers returns the number of samples above Q3 + k×IQR, the upper fence
+// of Tukey's method. k = 3 flags only extreme outliers.
+func (r *Recorder) Outliers(k float64) int {
+	if r == nil || r.n < 4 {
+		return 0
+	}
+	q1, q3 := r.Percentile(25), r.Percentile(75)
+	fence := float64(q3) + k*float64(q3-q1)
+	var n uint64
+	for i := len(r.counts) - 1; i >= 0; i-- {
+		if float64(r.clamp(bucketValue(i))) <= fence {
+			break
+		}
+		n += r.counts[i]
+	}
+	return int(n)
+}
+
+// Each calls fn for every non-empty bucket in ascending order with the
+// bucket's value and sample count.
+func (r *Recorder) Each(fn func(value time.Duration, count int)) {
+	if r == nil {
+		return
+	}
+	for i, c := range r.counts {
+		if c > 0 {
+			fn(r.clamp(bucketValue(i)), int(c))
+		}
+	}
+}
+
+// FromDurations returns a Recorder holding ds.
+func FromDurations(ds ...time.Duration) *Recorder {
+	r := &Recorder{}
+	for _, d := range ds {
+		r.Record(d)
+	}
+	return r
+}
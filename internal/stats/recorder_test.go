@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBucketIndex_RoundTrip(t *testing.T) {
+	for _, v := range []uint64{0, 1, 255, 256, 257, 511, 512, 1000, 123_456_789, 1 << 40, math.MaxInt64} {
+		got := uint64(bucketValue(bucketIndex(v)))
+		if diff := math.Abs(float64(got) - float64(v)); diff > float64(v)/256 {
+			t.Errorf("value %d lands in a bucket worth %d (off by %.0f)", v, got, diff)
+		}
+	}
+	// Buckets are ordered like the values they hold.
+	prev := -1
+	for v := uint64(0); v < 1<<20; v += 97 {
+		i := bucketIndex(v)
+		if i < prev {
+			t.Fatalf("bucketIndex(%d) = %d is below the previous index %d", v, i, prev)
+		}
+		prev = i
+	}
+}
+
+func TestRecorder_MatchesSortedSamples(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	var r Recorder
+	samples := make([]time.Duration, 10_000)
+	for i := range samples {
+		// Log-normal-ish latencies between ~100µs and ~1s.
+		samples[i] = time.Duration(math.Exp(rng.NormFloat64()*1.5+14)) * time.Nanosecond
+		r.Record(samples[i])
+	}
+	slices.Sort(samples)
+
+	if r.Count() != len(samples) {
+		t.Fatalf("Count() = %d, want %d", r.Count(), len(samples))
+	}
+	if r.Min() != samples[0] || r.Max() != samples[len(samples)-1] {
+		t.Errorf("Min/Max = %s/%s, want %s/%s", r.Min(), r.Max(), samples[0], samples[len(samples)-1])
+	}
+	for _, pct := range []float64{50, 90, 99, 99.9} {
+		want := samples[int(math.Ceil(pct/100*float64(len(samples))))-1]
+		got := r.Percentile(pct)
+		if diff := math.Abs(float64(got - want)); diff > float64(want)/200 {
+			t.Errorf("p%v = %s, want %s within 0.5%%", pct, got, want)
+		}
+	}
+
+	var sum, sq float64
+	for _, s := range samples {
+		sum += float64(s)
+	}
+	mean := sum / float64(len(samples))
+	for _, s := range samples {
+		sq += (float64(s) - mean) * (float64(s) - mean)
+	}
+	std := math.Sqrt(sq / float64(len(samples)-1))
+	if got := float64(r.StdDev()); math.Abs(got-std) > std*1e-6 {
+		t.Errorf("StdDev() = %v, want %v", got, std)
+	}
+	if got := r.CV(); math.Abs(got-std/mean) > 1e-3 {
+		t.Errorf("CV() = %v, want %v", got, std/mean)
+	}
+}
+
+func TestRecorder_Merge(t *testing.T) {
+	a := FromDurations(1*time.Millisecond, 2*time.Millisecond, 3*time.Millisecond)
+	b := FromDurations(10*time.Millisecond, 20*time.Millisecond)
+	all := FromDurations(1*time.Millisecond, 2*time.Millisecond, 3*time.Millisecond, 10*time.Millisecond, 20*time.Millisecond)
+	a.Merge(b)
+
+	if a.Count() != 5 || a.Min() != time.Millisecond || a.Max() != 20*time.Millisecond || a.Mean() != all.Mean() {
+		t.Errorf("merged count/min/max/mean = %d/%s/%s/%s", a.Count(), a.Min(), a.Max(), a.Mean())
+	}
+	if diff := a.StdDev() - all.StdDev(); diff < -time.Nanosecond || diff > time.Nanosecond {
+		t.Errorf("merged StdDev() = %s, want %s", a.StdDev(), all.StdDev())
+	}
+	if a.Percentile(80) != all.Percentile(80) {
+		t.Errorf("merged p80 = %s, want %s", a.Percentile(80), all.Percentile(80))
+	}
+}
+
+func TestRecorder_Outliers(t *testing.T) {
+	var r Recorder
+	for i := 0; i < 100; i++ {
+		r.Record(time.Duration(10+i%5) * time.Millisecond)
+	}
+	if n := r.Outliers(3); n != 0 {
+		t.Errorf("Outliers(3) = %d for tightly grouped runs", n)
+	}
+	r.Record(time.Second)
+	r.Record(2 * time.Second)
+	if n := r.Outliers(3); n != 2 {
+		t.Errorf("Outliers(3) = %d, want 2", n)
+	}
+}
+
+func TestRecorder_Empty(t *testing.T) {
+	var r *Recorder
+	if r.Count() != 0 || r.Mean() != 0 || r.Percentile(99) != 0 || r.StdDev() != 0 || r.CV() != 0 || r.Outliers(3) != 0 {
+		t.Error("a nil Recorder should report zeros")
+	}
+	one := FromDurations(5 * time.Millisecond)
+	if one.Percentile(50) != 5*time.Millisecond || one.StdDev() != 0 {
+		t.Errorf("single sample: p50 = %s, stddev = %s", one.Percentile(50), one.StdDev())
+	}
+}
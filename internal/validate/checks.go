@@ -0,0 +1,787 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"gopkg.in/yaml.v3"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/generator"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+// distributionTypes are the values of distributions.<column>.type.
+var distributionTypes = []string{"uniform", "zipf", "normal", "lognormal", "exponential", "pareto", "weighted"}
+
+// rangeTypes are the distribution types that also draw numbers and dates
+// from a min..max range; skewedTypes can be reversed.
+var (
+	rangeTypes  = []string{"uniform", "normal", "lognormal", "exponential", "pareto"}
+	skewedTypes = []string{"lognormal", "exponential", "pareto"}
+)
+
+// correlationSources are the values of correlations[].source.
+var correlationSources = []string{"address", "person", "latlong", "template"}
+
+// checker runs the semantic checks of one document.
+type checker struct {
+	doc      *Document
+	tables   map[string]*introspect.Table // nil skips the schema checks
+	funcs    template.FuncMap
+	problems []Problem
+}
+
+func (c *checker) add(n *yaml.Node, sev Severity, format string, args ...any) {
+	c.problems = append(c.problems, c.doc.problem(n, sev, format, args...))
+}
+
+func (c *checker) check() {
+	c.funcs = generator.FuncMap(gofakeit.New(0))
+	cfg := c.doc.Config
+
+	_, options := lookup(c.doc.root, "options")
+	c.checkOptions(options, cfg.Options)
+
+	_, tables := lookup(c.doc.root, "tables")
+	for _, e := range entries(tables) {
+		c.checkTable(e.key, e.value, cfg.Tables[e.key.Value])
+	}
+
+	_, tests := lookup(c.doc.root, "tests")
+	c.checkTests(items(tests), cfg.Tests)
+}
+
+func (c *checker) checkOptions(n *yaml.Node, opts config.Options) {
+	for _, key := range []string{"rows", "batch_size", "workers", "max_rows", "fk_sample_size"} {
+		if _, v := lookup(n, key); v != nil && strings.HasPrefix(v.Value, "-") {
+			c.add(v, Error, "options.%s must not be negative", key)
+		}
+	}
+	if _, v := lookup(n, "children_per_parent"); v != nil {
+		if cpp := opts.ChildrenPerParent; cpp.Min > 0 && cpp.Max > 0 && cpp.Min > cpp.Max {
+			c.add(v, Error, "children_per_parent min %d is greater than max %d", cpp.Min, cpp.Max)
+		}
+	}
+	for _, key := range []string{"null_rate", "default_rate"} {
+		if _, v := lookup(n, key); v != nil {
+			c.checkRate(v, "options."+key)
+		}
+	}
+	_, pcts := lookup(n, "percentiles")
+	for i, item := range items(pcts) {
+		if i < len(opts.Percentiles) && (opts.Percentiles[i] <= 0 || opts.Percentiles[i] >= 100) {
+			c.add(item, Error, "percentile %v is out of range: use values between 0 and 100", opts.Percentiles[i])
+		}
+	}
+	if c.tables == nil {
+		return
+	}
+	_, seedTables := lookup(n, "seed_tables")
+	for _, item := range items(seedTables) {
+		c.table(item)
+	}
+}
+
+// table returns the schema table named by n, reporting it when it does not
+// exist.
+func (c *checker) table(n *yaml.Node) *introspect.Table {
+	if t, ok := c.tables[n.Value]; ok {
+		return t
+	}
+	names := make([]string, 0, len(c.tables))
+	for name := range c.tables {
+		names = append(names, name)
+	}
+	c.add(n, Error, "unknown table %q%s", n.Value, suggest(n.Value, names))
+	return nil
+}
+
+// column returns the column of t named by n, reporting it when it does not
+// exist. t may be nil.
+func (c *checker) column(t *introspect.Table, n *yaml.Node) *introspect.Column {
+	if t == nil {
+		return nil
+	}
+	names := make([]string, len(t.Columns))
+	for i := range t.Columns {
+		if t.Columns[i].Name == n.Value {
+			return &t.Columns[i]
+		}
+		names[i] = t.Columns[i].Name
+	}
+	c.add(n, Error, "unknown column %q in table %s%s", n.Value, t.Name, suggest(n.Value, names))
+	return nil
+}
+
+func (c *checker) checkTable(key, n *yaml.Node, tc config.TableConfig) {
+	var t *introspect.Table
+	if c.tables != nil {
+		t = c.table(key)
+	}
+	name := key.Value
+
+	if _, v := lookup(n, "rows"); v != nil && tc.Rows < 0 {
+		c.add(v, Error, "tables.%s.rows must not be negative", name)
+	}
+
+	// Columns that get their values from a parent table, including the
+	// ones declared in references.
+	fkCols := make(map[string]bool)
+	if t != nil {
+		for _, col := range t.Columns {
+			if col.FK != nil {
+				fkCols[col.Name] = true
+			}
+		}
+	}
+
+	_, refs := lookup(n, "references")
+	for _, e := range entries(refs) {
+		fkCols[e.key.Value] = true
+		col := c.column(t, e.key)
+		refTable, refCol, ok := strings.Cut(e.value.Value, ".")
+		if !ok || refTable == "" || refCol == "" {
+			c.add(e.value, Error, "reference %q must have the form table.column", e.value.Value)
+			continue
+		}
+		if c.tables == nil {
+			continue
+		}
+		parent, exists := c.tables[refTable]
+		if !exists {
+			c.add(e.value, Error, "reference %q: unknown table %q", e.value.Value, refTable)
+			continue
+		}
+		if !slices.ContainsFunc(parent.Columns, func(pc introspect.Column) bool { return pc.Name == refCol }) {
+			c.add(e.value, Error, "reference %q: unknown column %q in table %s", e.value.Value, refCol, refTable)
+		}
+		if col != nil && (col.IsAutoInc || col.IsGenerated) {
+			c.add(e.key, Warning, "%s.%s is %s; its reference is ignored", name, col.Name, generatedKind(col))
+		}
+	}
+
+	_, cols := lookup(n, "columns")
+	linked := withReferences(t, tc.References)
+	row := c.sampleRow(linked, cols)
+	derived := make(map[string]*yaml.Node)
+	for _, e := range entries(cols) {
+		col := c.column(t, e.key)
+		c.checkTemplate(e.value, template.New(e.key.Value), row, fmt.Sprintf("%s.%s", name, e.key.Value))
+		switch {
+		case col == nil:
+		case col.IsAutoInc || col.IsGenerated:
+			c.add(e.key, Warning, "%s.%s is %s; its template is ignored", name, col.Name, generatedKind(col))
+		case fkCols[col.Name]:
+			c.add(e.key, Warning, "%s.%s is a foreign key and takes its values from the parent table; its template is ignored", name, col.Name)
+		case len(col.EnumValues) > 0:
+			c.add(e.key, Warning, "%s.%s is %s and takes one of its members; its template is ignored (use a weighted distribution instead)", name, col.Name, col.DataType)
+		case !slices.ContainsFunc(tc.Correlations, func(g config.CorrelationGroup) bool { return slices.Contains(g.Columns, col.Name) }) && tc.TimeSeries[col.Name] == (config.TimeSeriesConfig{}):
+			derived[col.Name] = e.value
+		}
+	}
+	c.checkTemplateRefs(derived, linked, name)
+
+	_, dists := lookup(n, "distributions")
+	for _, e := range entries(dists) {
+		col := c.column(t, e.key)
+		c.checkDistribution(e.key, e.value, tc.Distributions[e.key.Value], col, fkCols, tc.Columns[e.key.Value] != "", name)
+	}
+
+	_, series := lookup(n, "time_series")
+	for _, e := range entries(series) {
+		c.checkTimeSeries(e.key, e.value, tc, c.column(t, e.key), fkCols, name)
+	}
+
+	_, children := lookup(n, "children_per_parent")
+	for i, e := range entries(children) {
+		c.checkChildren(e.key, e.value, tc.Children[e.key.Value], c.column(t, e.key), fkCols, name)
+		c.checkChildrenUnique(e.key, e.value, tc.Children[e.key.Value], linked, name)
+		if i > 0 {
+			c.add(e.key, Error, "children_per_parent for %s is set for %s and %s; set it for one foreign key column", name, entries(children)[0].key.Value, e.key.Value)
+		}
+	}
+	if k, _ := lookup(n, "rows"); k != nil && len(tc.Children) > 0 {
+		c.add(k, Warning, "tables.%s.rows is ignored: children_per_parent sets the rows, the children of every parent row", name)
+	}
+
+	_, groups := lookup(n, "correlations")
+	for i, item := range items(groups) {
+		if i < len(tc.Correlations) {
+			c.checkCorrelation(item, tc.Correlations[i], t, name)
+		}
+	}
+
+	c.checkRates(n, t, name, fkCols)
+}
+
+// checkRates checks the null and default rates of a table.
+func (c *checker) checkRates(n *yaml.Node, t *introspect.Table, table string, fkCols map[string]bool) {
+	for _, key := range []string{"null_rate", "default_rate"} {
+		if _, v := lookup(n, key); v != nil {
+			c.checkRate(v, "tables."+table+"."+key)
+		}
+	}
+
+	_, nullRates := lookup(n, "null_rates")
+	for _, e := range entries(nullRates) {
+		c.checkRate(e.value, "null rate of "+table+"."+e.key.Value)
+		switch col := c.column(t, e.key); {
+		case col == nil:
+		case col.IsAutoInc || col.IsGenerated:
+			c.add(e.key, Warning, "%s.%s is %s; its null rate is ignored", table, col.Name, generatedKind(col))
+		case generator.LeavesDefault(c.doc.Config, table, *col):
+			c.add(e.key, Warning, "%s.%s is left to its DEFAULT expression unless it has a template, distribution, time series or default rate, or a template or correlation uses it; its null rate is ignored", table, col.Name)
+		case !col.IsNullable || col.IsPrimaryKey:
+			c.add(e.key, Warning, "%s.%s is NOT NULL; its null rate is ignored", table, col.Name)
+		case fkCols[col.Name]:
+			c.add(e.key, Warning, "%s.%s is a foreign key and takes its values from the parent table; its null rate is ignored", table, col.Name)
+		}
+	}
+
+	_, defaultRates := lookup(n, "default_rates")
+	for _, e := range entries(defaultRates) {
+		c.checkRate(e.value, "default rate of "+table+"."+e.key.Value)
+		col := c.column(t, e.key)
+		if col != nil && generator.DefaultValue(*col) == nil {
+			c.add(e.key, Warning, "%s.%s has no DEFAULT the generator can reproduce (none, NULL, an expression other than the current time, or a key column); its default rate is ignored", table, col.Name)
+		}
+		if _, nv := lookup(nullRates, e.key.Value); nv != nil {
+			nullRate, _ := strconv.ParseFloat(nv.Value, 64)
+			defaultRate, _ := strconv.ParseFloat(e.value.Value, 64)
+			if nullRate+defaultRate > 1 {
+				c.add(e.value, Error, "null rate %v and default rate %v of %s.%s add up to more than 1", nullRate, defaultRate, table, e.key.Value)
+			}
+		}
+	}
+}
+
+// checkRate reports a rate that is not a fraction between 0 and 1.
+func (c *checker) checkRate(n *yaml.Node, where string) {
+	rate, err := strconv.ParseFloat(n.Value, 64)
+	if err != nil {
+		return // reported by Decode
+	}
+	if rate < 0 || rate > 1 {
+		c.add(n, Error, "%s %v must be between 0 and 1", where, rate)
+	}
+}
+
+func generatedKind(col *introspect.Column) string {
+	if col.IsAutoInc {
+		return "auto-increment"
+	}
+	return "a generated column"
+}
+
+// list joins words as in "a, b and c".
+func list(words []string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+func (c *checker) checkDistribution(key, n *yaml.Node, dist config.DistributionConfig, col *introspect.Column, fkCols map[string]bool, templated bool, table string) {
+	where := table + "." + key.Value
+	typeKey, typeNode := lookup(n, "type")
+	if dist.Type != "" && !slices.Contains(distributionTypes, dist.Type) {
+		c.add(typeNode, Error, "unknown distribution type %q for %s (expected %s)%s", dist.Type, where, strings.Join(distributionTypes, ", "), suggest(dist.Type, distributionTypes))
+		return
+	}
+	typ := dist.Type
+	if typ == "" {
+		typ = "uniform"
+	}
+
+	// Parameters of other distribution types are ignored.
+	for _, param := range []struct {
+		key    string
+		usedBy []string
+	}{
+		{"s", []string{"zipf", "pareto"}},
+		{"mean", []string{"normal", "lognormal", "exponential"}},
+		{"stddev", []string{"normal", "lognormal"}},
+		{"weights", []string{"weighted"}},
+		{"min", rangeTypes},
+		{"max", rangeTypes},
+		{"reverse", skewedTypes},
+	} {
+		if k, _ := lookup(n, param.key); k != nil && !slices.Contains(param.usedBy, typ) {
+			c.add(k, Warning, "%s is only used by %s distributions; it is ignored for %s", param.key, list(param.usedBy), where)
+		}
+	}
+
+	c.checkShape(n, typ, dist.S, dist.Mean, dist.StdDev, where)
+	if typ == "weighted" {
+		c.checkWeights(typeKey, n, dist, col, where)
+	}
+
+	switch {
+	case col == nil:
+	case fkCols[col.Name] || len(col.EnumValues) > 0:
+		for _, bound := range []string{"min", "max"} {
+			if k, _ := lookup(n, bound); k != nil && slices.Contains(rangeTypes, typ) {
+				c.add(k, Warning, "%s only applies to numeric and date/time columns; it is ignored for %s", bound, where)
+			}
+		}
+	case !generator.IsRangeColumn(*col):
+		c.add(key, Warning, "distributions only apply to enum, set, foreign key, numeric and date/time columns; the distribution of %s is ignored", where)
+	case !slices.Contains(rangeTypes, typ):
+		c.add(typeNode, Warning, "%s distributions pick among values and only apply to enum, set and foreign key columns; the distribution of %s is ignored", typ, where)
+	case templated:
+		c.add(key, Warning, "%s has a template; its distribution is ignored", where)
+	default:
+		var be *generator.ParamError
+		if err := generator.CheckRange(*col, dist); errors.As(err, &be) {
+			at := key
+			if _, v := lookup(n, be.Key); v != nil {
+				at = v
+			}
+			c.add(at, Error, "invalid %s for %s: %v", be.Key, where, be.Err)
+		}
+	}
+}
+
+// checkShape checks the parameters shaping a distribution of type typ.
+func (c *checker) checkShape(n *yaml.Node, typ string, s, mean, stddev float64, where string) {
+	switch typ {
+	case "zipf":
+		if _, v := lookup(n, "s"); v != nil && s < 0 {
+			c.add(v, Error, "zipf exponent s must be positive for %s", where)
+		}
+	case "pareto":
+		if _, v := lookup(n, "s"); v != nil && s < 0 {
+			c.add(v, Error, "pareto shape s must be positive for %s", where)
+		}
+	case "normal", "lognormal":
+		if _, v := lookup(n, "mean"); v != nil && (mean < 0 || mean > 1) {
+			c.add(v, Error, "%s mean %v for %s must be between 0 and 1 (a fraction of the value range)", typ, mean, where)
+		}
+		if _, v := lookup(n, "stddev"); v != nil && stddev < 0 {
+			c.add(v, Error, "%s stddev for %s must not be negative", typ, where)
+		}
+	case "exponential":
+		if _, v := lookup(n, "mean"); v != nil && mean < 0 {
+			c.add(v, Error, "exponential mean for %s must be positive (a fraction of the value range)", where)
+		}
+	}
+}
+
+// checkChildren checks the children_per_parent config of FK column key.
+func (c *checker) checkChildren(key, n *yaml.Node, card config.CardinalityConfig, col *introspect.Column, fkCols map[string]bool, table string) {
+	where := "children_per_parent for " + table + "." + key.Value
+	var pe *generator.ParamError
+	if err := generator.CheckChildren(card); errors.As(err, &pe) {
+		at := key
+		if _, v := lookup(n, pe.Key); v != nil {
+			at = v
+		}
+		if pe.Key == "type" {
+			c.add(at, Error, "unknown type %q for %s (expected %s)%s", card.Type, where, strings.Join(generator.ChildrenTypes, ", "), suggest(card.Type, generator.ChildrenTypes))
+			return
+		}
+		c.add(at, Error, "invalid %s for %s: %v", pe.Key, where, pe.Err)
+	}
+	typ := card.Type
+	if typ == "" {
+		typ = "uniform"
+	}
+
+	for _, param := range []struct {
+		key    string
+		usedBy []string
+	}{
+		{"s", []string{"zipf", "pareto"}},
+		{"mean", []string{"normal", "lognormal", "exponential"}},
+		{"stddev", []string{"normal", "lognormal"}},
+		{"reverse", skewedTypes},
+	} {
+		if k, _ := lookup(n, param.key); k != nil && !slices.Contains(param.usedBy, typ) {
+			c.add(k, Warning, "%s is only used by %s distributions; it is ignored for %s", param.key, list(param.usedBy), where)
+		}
+	}
+	c.checkShape(n, typ, card.S, card.Mean, card.StdDev, where)
+
+	if col != nil && !fkCols[col.Name] {
+		c.add(key, Error, "%s.%s is not a foreign key; children_per_parent needs the column referencing the parent table (declare it in references if the schema has no foreign key)", table, col.Name)
+	}
+}
+
+// checkChildrenUnique reports a children_per_parent max above the rows a
+// parent can have in a unique index over the foreign key column, when the
+// index's other columns are enums or foreign keys to tables with set rows.
+func (c *checker) checkChildrenUnique(key, n *yaml.Node, card config.CardinalityConfig, t *introspect.Table, table string) {
+	if t == nil {
+		return
+	}
+	cfg := c.doc.Config
+	parents := func(fk introspect.Column) int {
+		ref := fk.FK.ReferencedTable
+		if rows := cfg.Tables[ref].Rows; rows > 0 {
+			return rows
+		}
+		if parent := c.tables[ref]; parent != nil && !slices.ContainsFunc(parent.Columns, func(col introspect.Column) bool { return col.FK != nil }) {
+			return cfg.Options.Rows
+		}
+		return 0
+	}
+	if most, index := generator.MaxChildren(t, key.Value, parents); most > 0 && card.Max > most {
+		at := key
+		if _, v := lookup(n, "max"); v != nil {
+			at = v
+		}
+		c.add(at, Error, "children_per_parent for %s.%s: max %d is above the %d rows a parent can have in unique index %s", table, key.Value, card.Max, most, index)
+	}
+}
+
+func (c *checker) checkTimeSeries(key, n *yaml.Node, tc config.TableConfig, col *introspect.Column, fkCols map[string]bool, table string) {
+	where := table + "." + key.Value
+	var pe *generator.ParamError
+	if err := generator.CheckTimeSeries(tc.TimeSeries[key.Value]); errors.As(err, &pe) {
+		at := key
+		if _, v := lookup(n, pe.Key); v != nil {
+			at = v
+		}
+		c.add(at, Error, "invalid %s for the time series of %s: %v", pe.Key, where, pe.Err)
+	}
+
+	switch {
+	case col == nil:
+	case col.IsAutoInc || col.IsGenerated:
+		c.add(key, Warning, "%s is %s; its time series is ignored", where, generatedKind(col))
+	case fkCols[col.Name] || len(col.EnumValues) > 0:
+		c.add(key, Warning, "%s takes its values from a parent table or its members; its time series is ignored", where)
+	case !generator.IsTimeSeriesColumn(*col):
+		c.add(key, Error, "%s is %s; time series need a date, datetime or timestamp column", where, col.DataType)
+	}
+	if _, ok := tc.Columns[key.Value]; ok {
+		c.add(key, Warning, "%s has a time series; its template is ignored", where)
+	}
+	if _, ok := tc.Distributions[key.Value]; ok {
+		c.add(key, Warning, "%s has a time series; its distribution is ignored", where)
+	}
+}
+
+func (c *checker) checkWeights(typeKey, n *yaml.Node, dist config.DistributionConfig, col *introspect.Column, where string) {
+	_, weights := lookup(n, "weights")
+	if len(dist.Weights) == 0 {
+		at := typeKey
+		if weights != nil {
+			at = weights
+		}
+		c.add(at, Error, "weighted distribution for %s needs weights", where)
+		return
+	}
+
+	total := 0.0
+	for _, e := range entries(weights) {
+		w := dist.Weights[e.key.Value]
+		if w < 0 {
+			c.add(e.value, Error, "weight of %q for %s must not be negative", e.key.Value, where)
+		}
+		total += max(w, 0)
+		if col != nil && len(col.EnumValues) > 0 && !slices.Contains(col.EnumValues, e.key.Value) {
+			c.add(e.key, Error, "%q is not a member of %s %s%s", e.key.Value, where, col.ColumnType, suggest(e.key.Value, col.EnumValues))
+		}
+	}
+
+	// Values without a weight get weight 1, so only a weight for every
+	// enum member can leave nothing to pick.
+	if col != nil && len(col.EnumValues) > 0 {
+		total = 0
+		for _, v := range col.EnumValues {
+			w, ok := dist.Weights[v]
+			if !ok {
+				w = 1
+			}
+			total += max(w, 0)
+		}
+	}
+	if total == 0 {
+		c.add(weights, Error, "all weights for %s are zero; no value can be picked", where)
+	}
+}
+
+func (c *checker) checkCorrelation(n *yaml.Node, group config.CorrelationGroup, t *introspect.Table, table string) {
+	_, source := lookup(n, "source")
+	switch {
+	case group.Source == "":
+		c.add(n, Error, "correlation in %s needs a source (%s)", table, strings.Join(correlationSources, ", "))
+	case !slices.Contains(correlationSources, group.Source):
+		c.add(source, Error, "unknown correlation source %q in %s (expected %s)%s", group.Source, table, strings.Join(correlationSources, ", "), suggest(group.Source, correlationSources))
+	}
+
+	columnsKey, columns := lookup(n, "columns")
+	if len(group.Columns) == 0 {
+		at := n
+		if columnsKey != nil {
+			at = columnsKey
+		}
+		c.add(at, Error, "correlation in %s needs columns", table)
+	}
+
+	templateKey, templates := lookup(n, "template")
+	if group.Source == "template" {
+		// Each template sees the values of the columns before it.
+		data := make(map[string]any, len(group.Columns))
+		for i, item := range items(columns) {
+			if i >= len(group.Columns) {
+				break
+			}
+			name := group.Columns[i]
+			if _, tmpl := lookup(templates, name); tmpl != nil {
+				c.checkTemplate(tmpl, template.New(name), data, table+"."+name)
+			} else {
+				c.add(item, Error, "correlation template in %s has no template for column %q", table, name)
+			}
+			data[name] = "x"
+		}
+		for _, e := range entries(templates) {
+			if !slices.Contains(group.Columns, e.key.Value) {
+				c.add(e.key, Warning, "template for %q is ignored: the column is not in the correlation's columns", e.key.Value)
+			}
+		}
+	} else if templateKey != nil && group.Source != "" {
+		c.add(templateKey, Warning, "template is only used by correlations with source: template; it is ignored for source %q", group.Source)
+	}
+
+	if t == nil {
+		return
+	}
+	for _, item := range items(columns) {
+		if col := c.column(t, item); col != nil && (col.IsAutoInc || col.IsGenerated) {
+			c.add(item, Error, "correlation column %s.%s is %s and cannot be generated", table, col.Name, generatedKind(col))
+		}
+	}
+}
+
+// withReferences returns t with the foreign keys declared in references.
+// t may be nil.
+func withReferences(t *introspect.Table, references map[string]string) *introspect.Table {
+	if t == nil || len(references) == 0 {
+		return t
+	}
+	linked := *t
+	linked.Columns = slices.Clone(t.Columns)
+	introspect.ApplyReferences(map[string]*introspect.Table{t.Name: &linked}, map[string]map[string]string{t.Name: references})
+	return &linked
+}
+
+// sampleRow returns a row for executing the column templates in cols, which
+// read the row's other columns and the columns of parent rows as .parent.
+// t may be nil.
+func (c *checker) sampleRow(t *introspect.Table, cols *yaml.Node) map[string]any {
+	row := sampleValues(t)
+	parents := make(map[string]any)
+	for _, e := range entries(cols) {
+		parsed, err := template.New(e.key.Value).Funcs(c.funcs).Parse(e.value.Value)
+		if err != nil {
+			continue // reported by checkTemplate
+		}
+		for _, ref := range generator.ParentRefs(parsed) {
+			parents[ref.Parent] = map[string]any{}
+			if t == nil {
+				continue
+			}
+			if fk, err := generator.ResolveParent(t, ref.Parent); err == nil {
+				parents[ref.Parent] = sampleValues(c.tables[fk.FK.ReferencedTable])
+			}
+		}
+	}
+	row["parent"] = parents
+	return row
+}
+
+// sampleValues returns a row of values like the generated ones. t may be
+// nil.
+func sampleValues(t *introspect.Table) map[string]any {
+	row := make(map[string]any)
+	if t == nil {
+		return row
+	}
+	for _, col := range t.Columns {
+		switch strings.ToLower(col.DataType) {
+		case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year":
+			row[col.Name] = 1
+		case "decimal", "numeric", "float", "double":
+			row[col.Name] = 1.5
+		case "date":
+			row[col.Name] = "2024-01-01"
+		case "datetime", "timestamp":
+			row[col.Name] = "2024-01-01 00:00:00"
+		default:
+			row[col.Name] = "x"
+		}
+	}
+	return row
+}
+
+// checkTemplateRefs checks the columns read by the templates of derived
+// columns, keyed by column: they must exist, have a value before insert and
+// not depend on each other in a cycle. Parent columns must exist in a parent
+// the template can name. t may be nil.
+func (c *checker) checkTemplateRefs(derived map[string]*yaml.Node, t *introspect.Table, table string) {
+	if t == nil {
+		return
+	}
+	names := make([]string, len(t.Columns))
+	for i := range t.Columns {
+		names[i] = t.Columns[i].Name
+	}
+	refs := make(map[string][]string, len(derived))
+	for name, n := range derived {
+		parsed, err := template.New(name).Funcs(c.funcs).Parse(n.Value)
+		if err != nil {
+			continue // reported by checkTemplate
+		}
+		refs[name] = generator.TemplateRefs(parsed)
+		for _, ref := range refs[name] {
+			i := slices.Index(names, ref)
+			switch {
+			case i < 0:
+				c.add(n, Error, "template for %s.%s reads unknown column %q%s", table, name, ref, suggest(ref, names))
+			case t.Columns[i].IsAutoInc || t.Columns[i].IsGenerated:
+				c.add(n, Error, "template for %s.%s reads %s, which is %s and has no value until the row is inserted", table, name, ref, generatedKind(&t.Columns[i]))
+			}
+		}
+		for _, ref := range generator.ParentRefs(parsed) {
+			c.checkParentRef(n, ref, t, name)
+		}
+	}
+
+	// Report each cycle once, at the first of its columns in table order.
+	done := make(map[string]bool, len(refs))
+	var path []string
+	var visit func(name string)
+	visit = func(name string) {
+		if i := slices.Index(path, name); i >= 0 {
+			cycle := append(slices.Clone(path[i:]), name)
+			c.add(derived[cycle[0]], Error, "column templates of table %s depend on each other: %s", table, strings.Join(cycle, " -> "))
+			return
+		}
+		if done[name] {
+			return
+		}
+		path = append(path, name)
+		for _, ref := range refs[name] {
+			visit(ref)
+		}
+		path = path[:len(path)-1]
+		done[name] = true
+	}
+	for _, col := range t.Columns {
+		if _, ok := refs[col.Name]; ok {
+			visit(col.Name)
+		}
+	}
+}
+
+// checkParentRef checks a parent column read by the template in n of column
+// name of t.
+func (c *checker) checkParentRef(n *yaml.Node, ref generator.ParentRef, t *introspect.Table, name string) {
+	where := fmt.Sprintf("template for %s.%s reads %s", t.Name, name, ref)
+	if ref.Parent == "" || ref.Column == "" {
+		c.add(n, Error, "%s; name a parent and its column, as in .parent.orders.created_at", where)
+		return
+	}
+	fk, err := generator.ResolveParent(t, ref.Parent)
+	if err != nil {
+		c.add(n, Error, "%s: %v", where, err)
+		return
+	}
+	parent, ok := c.tables[fk.FK.ReferencedTable]
+	if !ok {
+		return // reported with the reference
+	}
+	names := make([]string, len(parent.Columns))
+	for i := range parent.Columns {
+		names[i] = parent.Columns[i].Name
+	}
+	if !slices.Contains(names, ref.Column) {
+		c.add(n, Error, "%s: unknown column %q in table %s%s", where, ref.Column, parent.Name, suggest(ref.Column, names))
+	}
+}
+
+// checkTemplate parses and executes the template in n once, reporting the
+// first error.
+func (c *checker) checkTemplate(n *yaml.Node, tmpl *template.Template, data any, where string) {
+	parsed, err := tmpl.Funcs(c.funcs).Parse(n.Value)
+	if err == nil {
+		err = parsed.Execute(io.Discard, data)
+	}
+	if err != nil {
+		c.add(n, Error, "invalid template for %s: %v", where, err)
+	}
+}
+
+func (c *checker) checkTests(nodes []*yaml.Node, tests []config.TestCase) {
+	seen := make(map[string]bool, len(tests))
+	for i, n := range nodes {
+		if i >= len(tests) {
+			break
+		}
+		tc := tests[i]
+		if err := tc.Validate(); err != nil {
+			c.add(n, Error, "%v", err)
+		}
+		if tc.Name == "" {
+			c.add(n, Warning, "test %d has no name", i+1)
+		} else if seen[tc.Name] {
+			_, name := lookup(n, "name")
+			c.add(name, Warning, "duplicate test name %q; results are matched by name", tc.Name)
+		}
+		seen[tc.Name] = true
+
+		// Only the query, statements and params are rendered as templates.
+		for _, key := range []string{"query", "statements", "params"} {
+			_, v := lookup(n, key)
+			sources := []*yaml.Node{v}
+			if v != nil && v.Kind == yaml.SequenceNode {
+				sources = items(v)
+			}
+			for _, src := range sources {
+				if src != nil && src.Kind == yaml.MappingNode {
+					_, src = lookup(src, "value") // a param with a type
+				}
+				if src != nil && src.Kind == yaml.ScalarNode && strings.Contains(src.Value, "{{") {
+					c.checkQueryTemplate(src, tc.Name)
+				}
+			}
+		}
+	}
+}
+
+// checkQueryTemplate checks a test query template. SampleRow returns a
+// placeholder row, after checking its table and columns against the schema.
+func (c *checker) checkQueryTemplate(n *yaml.Node, test string) {
+	fm := template.FuncMap{"SampleRow": func(args ...string) (map[string]any, error) {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("SampleRow requires a table name and at least one column")
+		}
+		row := make(map[string]any, len(args)-1)
+		for _, col := range args[1:] {
+			row[col] = 1
+		}
+		if c.tables == nil {
+			return row, nil
+		}
+		t, ok := c.tables[args[0]]
+		if !ok {
+			return nil, fmt.Errorf("SampleRow: unknown table %q", args[0])
+		}
+		for _, col := range args[1:] {
+			if !slices.ContainsFunc(t.Columns, func(tc introspect.Column) bool { return tc.Name == col }) {
+				return nil, fmt.Errorf("SampleRow: unknown column %q in table %s", col, t.Name)
+			}
+		}
+		return row, nil
+	}}
+	c.checkTemplate(n, template.New(test).Funcs(fm), nil, fmt.Sprintf("test %q", test))
+}
@@ -0,0 +1,452 @@
+// Package validate checks a go-test-my-db.yaml config file and reports every
+// problem with its line and column: unknown keys, values of the wrong type,
+// settings the generator would silently ignore or fail on mid-seed, and, when
+// a schema is given, tables and columns that do not exist.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tomfevang/go-test-my-db/internal/config"
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+// Severity tells whether a problem stops a run.
+type Severity int
+
+const (
+	// Error is a mistake that fails the run or makes it generate other data
+	// than configured.
+	Error Severity = iota
+	// Warning is a setting that has no effect.
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Problem is one finding in a config file. File is the file of a composed
+// config the problem is in; it is empty for problems in the checked file
+// itself and for parsed data. Line and Column are 1-based; they are 0 when
+// the problem has no position.
+type Problem struct {
+	File     string
+	Line     int
+	Column   int
+	Severity Severity
+	Message  string
+}
+
+// String formats the problem as "line:col: severity: message".
+func (p Problem) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", p.Line, p.Column, p.Severity, p.Message)
+}
+
+// Document is a parsed config file.
+type Document struct {
+	// Config is the decoded config. Fields that could not be decoded keep
+	// their zero value.
+	Config *config.Config
+
+	root     *yaml.Node // mapping node of the document; nil for an empty file
+	problems []Problem  // syntax, type and unknown-key problems
+
+	path  string                  // checked file; its problems have no File
+	files []string                // files of a composed config, the base first
+	file  func(*yaml.Node) string // file of a node of a composed config
+}
+
+// Load reads the config file at path, composes it with the files it extends
+// and includes and applies profile, as seed and test do. Problems in the
+// files are reported by Check; the error is only set when path cannot be
+// read.
+func Load(path, profile string) (*Document, error) {
+	d := &Document{Config: &config.Config{}, path: path}
+	composed, err := config.Compose(path, profile)
+	if err != nil {
+		problems, ok := composeProblems(err)
+		if !ok {
+			return nil, err
+		}
+		for _, p := range problems {
+			d.problems = append(d.problems, d.inFile(p, p.File))
+		}
+		return d, nil
+	}
+	d.file = composed.File
+
+	// Keys and types are checked per file, where line numbers are
+	// unambiguous; the composed document is checked as a whole.
+	for _, src := range composed.Sources {
+		d.files = append(d.files, src.Path)
+		if src.Root == nil {
+			continue
+		}
+		d.checkKeys(src.Root, reflect.TypeOf(config.Config{}), "")
+		if err := src.Root.Decode(&config.Config{}); err != nil {
+			for _, p := range yamlProblems(err) {
+				if n := lastOnLine(src.Root, p.Line); n != nil {
+					p.Column = n.Column
+				}
+				d.problems = append(d.problems, d.inFile(p, src.Path))
+			}
+		}
+	}
+	if composed.Root != nil {
+		d.root = composed.Root
+		_ = d.root.Decode(d.Config) // type errors are reported per file above
+	}
+	return d, nil
+}
+
+// composeProblems converts the errors of config.Compose into problems. It
+// reports false when err is not about the contents of a file.
+func composeProblems(err error) ([]Problem, bool) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var problems []Problem
+		for _, e := range joined.Unwrap() {
+			ps, ok := composeProblems(e)
+			if !ok {
+				return nil, false
+			}
+			problems = append(problems, ps...)
+		}
+		return problems, true
+	}
+	var pe *config.PositionError
+	if !errors.As(err, &pe) {
+		return nil, false
+	}
+	if pe.Line == 0 {
+		problems := yamlProblems(pe.Err)
+		for i := range problems {
+			problems[i].File = pe.File
+		}
+		return problems, true
+	}
+	return []Problem{{File: pe.File, Line: pe.Line, Column: pe.Column, Severity: Error, Message: pe.Err.Error()}}, true
+}
+
+// inFile sets the File of p to file unless it is the checked file.
+func (d *Document) inFile(p Problem, file string) Problem {
+	if file != d.path {
+		p.File = file
+	}
+	return p
+}
+
+// Parse parses config file contents. extends and include are not followed
+// and environment variables are not interpolated; use Load for that.
+func Parse(data []byte) *Document {
+	d := &Document{Config: &config.Config{}}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		d.problems = append(d.problems, yamlProblems(err)...)
+		return d
+	}
+	if len(doc.Content) == 0 {
+		return d
+	}
+	d.root = resolve(doc.Content[0])
+	if d.root.Kind != yaml.MappingNode {
+		d.add(d.root, Error, "config must be a mapping with options, tables and tests keys")
+		d.root = nil
+		return d
+	}
+
+	d.checkKeys(d.root, reflect.TypeOf(config.Config{}), "")
+	if err := doc.Decode(d.Config); err != nil {
+		// Type errors only carry a line; point them at the value on it.
+		for _, p := range yamlProblems(err) {
+			if n := lastOnLine(d.root, p.Line); n != nil {
+				p.Column = n.Column
+			}
+			d.problems = append(d.problems, p)
+		}
+	}
+	return d
+}
+
+// File loads the config file at path with profile applied and checks it
+// against tables.
+func File(path, profile string, tables map[string]*introspect.Table) ([]Problem, error) {
+	d, err := Load(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	return d.Check(tables), nil
+}
+
+// Check returns the document's problems in file order, the problems of base
+// files first. tables is the schema
+// the config will be used with; when it is nil, the checks that need a
+// schema are skipped.
+func (d *Document) Check(tables map[string]*introspect.Table) []Problem {
+	c := &checker{doc: d, tables: tables}
+	c.problems = append(c.problems, d.problems...)
+	if d.root != nil {
+		c.check()
+	}
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		a, b := c.problems[i], c.problems[j]
+		if ra, rb := d.rank(a.File), d.rank(b.File); ra != rb {
+			return ra < rb
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.problems
+}
+
+// Errors counts the problems with Error severity.
+func Errors(problems []Problem) int {
+	n := 0
+	for _, p := range problems {
+		if p.Severity == Error {
+			n++
+		}
+	}
+	return n
+}
+
+// rank orders problems by the layering order of their files. Problems
+// without a position in a file come first.
+func (d *Document) rank(file string) int {
+	if file == "" {
+		file = d.path
+	}
+	for i, f := range d.files {
+		if f == file {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// problem returns a problem positioned at n.
+func (d *Document) problem(n *yaml.Node, sev Severity, format string, args ...any) Problem {
+	p := Problem{Severity: sev, Message: fmt.Sprintf(format, args...)}
+	if n != nil {
+		p.Line, p.Column = n.Line, n.Column
+		if d.file != nil {
+			p = d.inFile(p, d.file(n))
+		}
+	}
+	return p
+}
+
+func (d *Document) add(n *yaml.Node, sev Severity, format string, args ...any) {
+	d.problems = append(d.problems, d.problem(n, sev, format, args...))
+}
+
+// checkKeys reports mapping keys that have no field in t, recursing into
+// nested structs, maps and slices. path names n in messages.
+func (d *Document) checkKeys(n *yaml.Node, t reflect.Type, path string) {
+	n = resolve(n)
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return // reported by Decode
+		}
+		fields := yamlFields(t)
+		for _, e := range entries(n) {
+			key := e.key.Value
+			if key == "<<" {
+				continue // merge key
+			}
+			f, ok := fields[key]
+			if !ok {
+				names := make([]string, 0, len(fields))
+				for name := range fields {
+					names = append(names, name)
+				}
+				d.add(e.key, Error, "unknown key %q in %s%s", key, describePath(path), suggest(key, names))
+				continue
+			}
+			d.checkKeys(e.value, f.Type, joinPath(path, key))
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		for _, e := range entries(n) {
+			d.checkKeys(e.value, t.Elem(), joinPath(path, e.key.Value))
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range n.Content {
+			d.checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of a struct type to their fields.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "the config"
+	}
+	return path
+}
+
+// yamlLine matches the position prefix of yaml.v3 error messages.
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlProblems converts a yaml.v3 syntax or type error into problems.
+func yamlProblems(err error) []Problem {
+	msgs := []string{err.Error()}
+	var te *yaml.TypeError
+	if errors.As(err, &te) {
+		msgs = te.Errors
+	}
+	problems := make([]Problem, 0, len(msgs))
+	for _, msg := range msgs {
+		p := Problem{Severity: Error, Message: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			p.Line, _ = strconv.Atoi(m[1])
+			p.Column = 1
+			p.Message = m[2]
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+// entry is a key/value pair of a mapping node.
+type entry struct {
+	key, value *yaml.Node
+}
+
+// entries returns the pairs of a mapping node in file order, with aliases
+// resolved.
+func entries(n *yaml.Node) []entry {
+	n = resolve(n)
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	out := make([]entry, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		out = append(out, entry{n.Content[i], resolve(n.Content[i+1])})
+	}
+	return out
+}
+
+// lookup returns the key and value nodes of key in a mapping node, or nils.
+func lookup(n *yaml.Node, key string) (k, v *yaml.Node) {
+	for _, e := range entries(n) {
+		if e.key.Value == key {
+			return e.key, e.value
+		}
+	}
+	return nil, nil
+}
+
+// items returns the items of a sequence node, with aliases resolved.
+func items(n *yaml.Node) []*yaml.Node {
+	n = resolve(n)
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+	out := make([]*yaml.Node, len(n.Content))
+	for i, item := range n.Content {
+		out[i] = resolve(item)
+	}
+	return out
+}
+
+// lastOnLine returns the last node under n that starts on line, or nil.
+func lastOnLine(n *yaml.Node, line int) *yaml.Node {
+	var last *yaml.Node
+	if n.Line == line {
+		last = n
+	}
+	for _, child := range n.Content {
+		if found := lastOnLine(child, line); found != nil {
+			last = found
+		}
+	}
+	return last
+}
+
+func resolve(n *yaml.Node) *yaml.Node {
+	for n != nil && n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+// suggest returns ` (did you mean "x"?)` for the candidate closest to name,
+// or "" when none is close.
+func suggest(name string, candidates []string) string {
+	best, bestDist := "", len(name)/3+2
+	sort.Strings(candidates)
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(c)); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,452 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tomfevang/go-test-my-db/internal/introspect"
+)
+
+var testTables = map[string]*introspect.Table{
+	"users": {Name: "users", Columns: []introspect.Column{
+		{Name: "id", DataType: "int", IsAutoInc: true, IsPrimaryKey: true},
+		{Name: "email", DataType: "varchar"},
+		{Name: "first_name", DataType: "varchar"},
+		{Name: "last_name", DataType: "varchar"},
+		{Name: "status", DataType: "enum", ColumnType: "enum('active','banned')", EnumValues: []string{"active", "banned"}},
+	}},
+	"orders": {Name: "orders", Columns: []introspect.Column{
+		{Name: "id", DataType: "int", IsAutoInc: true, IsPrimaryKey: true},
+		{Name: "user_id", DataType: "int"},
+		{Name: "total", DataType: "decimal"},
+	}},
+}
+
+const badConfig = `options:
+  rows: 100
+  batchsize: 10
+  seed_tables: [users, order]
+tables:
+  users:
+    columns:
+      email: "{{Email}}"
+      first_name: "{{NoSuchFunc}}"
+      id: "{{Number 1 10}}"
+    distributions:
+      status:
+        type: weighted
+        weights:
+          active: 9
+          baned: 1
+      email:
+        type: zipfian
+    correlations:
+      - source: person
+        columns: [last_name, first_name]
+      - source: template
+        columns: [id, email]
+        template:
+          email: "{{.id}}@example.com"
+  orders:
+    rows: lots
+    references:
+      user_id: user.id
+      total: orders
+tests:
+  - name: by user
+    query: SELECT * FROM orders WHERE user_id = {{(SampleRow "users" "uid").uid}}
+  - name: close
+    kind: transaction
+    query: UPDATE orders SET total = 0
+  - name: by user
+    query: SELECT 1
+    verify: true
+`
+
+func TestCheck(t *testing.T) {
+	got := Parse([]byte(badConfig)).Check(testTables)
+	want := []string{
+		`3:3: error: unknown key "batchsize" in options (did you mean "batch_size"?)`,
+		`4:24: error: unknown table "order" (did you mean "orders"?)`,
+		`9:19: error: invalid template for users.first_name: template: first_name:1: function "NoSuchFunc" not defined`,
+		`10:7: warning: users.id is auto-increment; its template is ignored`,
+		`16:11: error: "baned" is not a member of users.status enum('active','banned') (did you mean "banned"?)`,
+		`18:15: error: unknown distribution type "zipfian" for users.email (expected uniform, zipf, normal, lognormal, exponential, pareto, weighted) (did you mean "zipf"?)`,
+		`23:19: error: correlation template in users has no template for column "id"`,
+		`23:19: error: correlation column users.id is auto-increment and cannot be generated`,
+		`27:11: error: cannot unmarshal !!str ` + "`lots`" + ` into int`,
+		`29:16: error: reference "user.id": unknown table "user"`,
+		`30:14: error: reference "orders" must have the form table.column`,
+		`33:12: error: invalid template for test "by user": template: by user:1:40: executing "by user" at <SampleRow "users" "uid">: error calling SampleRow: SampleRow: unknown column "uid" in table users`,
+		`34:5: error: test "close": transaction tests use statements, not query`,
+		`37:11: warning: duplicate test name "by user"; results are matched by name`,
+		`39:5: error: unknown key "verify" in tests[2]`,
+	}
+	assertProblems(t, got, want)
+	if n := Errors(got); n != 13 {
+		t.Errorf("Errors() = %d, want 13", n)
+	}
+}
+
+func TestCheck_WithoutSchema(t *testing.T) {
+	got := Parse([]byte(badConfig)).Check(nil)
+	for _, p := range got {
+		if strings.Contains(p.Message, "unknown table") || strings.Contains(p.Message, "unknown column") || strings.Contains(p.Message, "enum") {
+			t.Errorf("schema check ran without a schema: %s", p)
+		}
+	}
+	if n := Errors(got); n != 8 {
+		t.Errorf("Errors() = %d, want 8: %v", n, got)
+	}
+}
+
+func TestCheck_Weights(t *testing.T) {
+	cfg := `tables:
+  users:
+    distributions:
+      status:
+        type: weighted
+        weights: {active: 0, banned: 0}
+        mean: 0.5
+`
+	got := Parse([]byte(cfg)).Check(testTables)
+	want := []string{
+		"6:18: error: all weights for users.status are zero; no value can be picked",
+		"7:9: warning: mean is only used by normal, lognormal and exponential distributions; it is ignored for users.status",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("problem %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCheck_Ranges(t *testing.T) {
+	cfg := `tables:
+  users:
+    distributions:
+      email:
+        type: lognormal
+      status:
+        type: exponential
+        min: 1
+  orders:
+    columns:
+      user_id: "{{Number 1 10}}"
+    distributions:
+      total:
+        type: lognormal
+        min: 0
+        max: 1e20
+        reverse: true
+      user_id:
+        type: normal
+      id:
+        type: zipf
+        mean: 0.2
+`
+	got := Parse([]byte(cfg)).Check(testTables)
+	want := []string{
+		"4:7: warning: distributions only apply to enum, set, foreign key, numeric and date/time columns; the distribution of users.email is ignored",
+		"8:9: warning: min only applies to numeric and date/time columns; it is ignored for users.status",
+		"16:14: error: invalid max for orders.total: 1e20 is out of range for decimal (-99999999.99 to 99999999.99)",
+		"18:7: warning: orders.user_id has a template; its distribution is ignored",
+		"21:15: warning: zipf distributions pick among values and only apply to enum, set and foreign key columns; the distribution of orders.id is ignored",
+		"22:9: warning: mean is only used by normal, lognormal and exponential distributions; it is ignored for orders.id",
+	}
+	assertProblems(t, got, want)
+}
+
+func TestCheck_TimeSeries(t *testing.T) {
+	cfg := `tables:
+  users:
+    time_series:
+      email:
+        start: -30d
+  orders:
+    distributions:
+      created_at:
+        type: exponential
+    time_series:
+      created_at:
+        start: 2024-01-01
+        end: 2024-02-01
+        interval: 1m
+      shipped_at:
+        diurnal: 1.5
+        bursts: {count: 2, duration: 1 hour}
+`
+	orders := *testTables["orders"]
+	orders.Columns = append(slices.Clone(orders.Columns),
+		introspect.Column{Name: "created_at", DataType: "datetime"},
+		introspect.Column{Name: "shipped_at", DataType: "timestamp"})
+	tables := map[string]*introspect.Table{"users": testTables["users"], "orders": &orders}
+
+	got := Parse([]byte(cfg)).Check(tables)
+	want := []string{
+		"4:7: error: users.email is varchar; time series need a date, datetime or timestamp column",
+		"11:7: warning: orders.created_at has a time series; its distribution is ignored",
+		"14:19: error: invalid interval for the time series of orders.created_at: set at most two of start, end and interval",
+		`16:18: error: invalid diurnal for the time series of orders.shipped_at: 1.5 must be between 0 and 1`,
+	}
+	assertProblems(t, got, want)
+}
+
+func TestCheck_TemplateRefs(t *testing.T) {
+	cfg := `tables:
+  users:
+    columns:
+      email: "{{ToLower .first_name}}.{{.lastname}}@example.com"
+      first_name: "{{.last_name}}"
+      last_name: "{{.first_name}}"
+  orders:
+    columns:
+      total: "{{Mul .quantity .price}}"
+      shipped_at: "{{AddDays .created_at .id}}"
+      created_at: "{{AddDays .shipped_at -1}}"
+`
+	orders := *testTables["orders"]
+	orders.Columns = append(slices.Clone(orders.Columns),
+		introspect.Column{Name: "quantity", DataType: "int"},
+		introspect.Column{Name: "price", DataType: "decimal"},
+		introspect.Column{Name: "created_at", DataType: "datetime"},
+		introspect.Column{Name: "shipped_at", DataType: "date"})
+	tables := map[string]*introspect.Table{"users": testTables["users"], "orders": &orders}
+
+	got := Parse([]byte(cfg)).Check(tables)
+	want := []string{
+		`4:14: error: template for users.email reads unknown column "lastname" (did you mean "last_name"?)`,
+		"5:19: error: column templates of table users depend on each other: first_name -> last_name -> first_name",
+		"10:19: error: template for orders.shipped_at reads id, which is auto-increment and has no value until the row is inserted",
+		"11:19: error: column templates of table orders depend on each other: created_at -> shipped_at -> created_at",
+	}
+	assertProblems(t, got, want)
+}
+
+func TestCheck_ParentRefs(t *testing.T) {
+	cfg := `tables:
+  orders:
+    references:
+      user_id: users.id
+    columns:
+      total: "{{.parent.users.emial}}"
+      note: "{{ToUpper .parent.user_id.first_name}} for {{.parent.accounts.id}}"
+      created_at: "{{.parent.users}}"
+      shipped_at: '{{AddDays .parent.users.id 1}}'
+`
+	orders := *testTables["orders"]
+	orders.Columns = append(slices.Clone(orders.Columns),
+		introspect.Column{Name: "note", DataType: "varchar"},
+		introspect.Column{Name: "created_at", DataType: "datetime"},
+		introspect.Column{Name: "shipped_at", DataType: "date"})
+	tables := map[string]*introspect.Table{"users": testTables["users"], "orders": &orders}
+
+	got := Parse([]byte(cfg)).Check(tables)
+	want := []string{
+		`6:14: error: template for orders.total reads .parent.users.emial: unknown column "emial" in table users (did you mean "email"?)`,
+		`7:13: error: template for orders.note reads .parent.accounts.id: orders has no foreign key to a table or column named "accounts"`,
+		"8:19: error: template for orders.created_at reads .parent.users; name a parent and its column, as in .parent.orders.created_at",
+		`9:19: error: invalid template for orders.shipped_at: template: shipped_at:1:2: executing "shipped_at" at <AddDays .parent.users.id 1>: error calling AddDays: 1 is not a date or datetime`,
+	}
+	assertProblems(t, got, want)
+}
+
+func TestCheck_SyntaxError(t *testing.T) {
+	got := Parse([]byte("options:\n  rows: 1\n rows: 2\n")).Check(nil)
+	if len(got) != 1 || got[0].Line == 0 || got[0].Severity != Error {
+		t.Errorf("got %v, want one positioned error", got)
+	}
+}
+
+func TestCheck_Examples(t *testing.T) {
+	paths, err := filepath.Glob("../../examples/config-*.yaml")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no example configs: %v", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range Parse(data).Check(nil) {
+			t.Errorf("%s:%s", path, p)
+		}
+	}
+}
+
+func TestLoad_Composed(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml": "options:\n  rows: 10\ntables:\n  users:\n    colums:\n      email: \"{{Email}}\"\n",
+		"ci.yaml": "extends: base.yaml\noptions:\n  workers: many\nprofiles:\n  perf:\n    tables:\n      user:\n        rows: 5\n" +
+			"  other:\n    options:\n      dsn: ${VALIDATE_TEST_UNSET:?required}\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "ci.yaml")
+
+	d, err := Load(path, "perf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, p := range d.Check(testTables) {
+		lines = append(lines, strings.TrimPrefix(p.File, dir+"/")+":"+p.String())
+	}
+	want := []string{
+		`base.yaml:5:5: error: unknown key "colums" in tables.users (did you mean "columns"?)`,
+		":3:12: error: cannot unmarshal !!str `many` into int",
+		`:7:7: error: unknown table "user" (did you mean "users"?)`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\n\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	d, err = Load(path, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := d.Check(nil)
+	if len(got) != 1 || got[0].String() != "11:12: error: environment variable VALIDATE_TEST_UNSET: required" {
+		t.Errorf("got %v, want the unset variable", got)
+	}
+}
+
+func TestCheck_Params(t *testing.T) {
+	cfg := `tests:
+  - name: by user
+    query: SELECT * FROM orders WHERE user_id = ? AND total > ?
+    params:
+      - value: '{{(SampleRow "users" "uid").uid}}'
+        type: int
+      - {value: "10", type: decimal}
+`
+	got := Parse([]byte(cfg)).Check(testTables)
+	want := []string{
+		`2:5: error: test "by user": param 2 has unknown type "decimal" (expected string, int, or float)`,
+		`5:16: error: invalid template for test "by user": template: by user:1:3: executing "by user" at <SampleRow "users" "uid">: error calling SampleRow: SampleRow: unknown column "uid" in table users`,
+	}
+	assertProblems(t, got, want)
+}
+
+func TestCheck_Rates(t *testing.T) {
+	cfg := `options:
+  null_rate: 1.5
+tables:
+  users:
+    null_rates:
+      email: 0.5
+      nickname: 0.2
+    default_rates:
+      status: 0.7
+      first_name: 0.5
+  orders:
+    null_rates:
+      total: 0.6
+    default_rates:
+      total: 0.6
+`
+	active, zero := "active", "0"
+	users := *testTables["users"]
+	users.Columns = slices.Clone(users.Columns)
+	users.Columns[4].Default = &active
+	tables := map[string]*introspect.Table{
+		"users": &users,
+		"orders": {Name: "orders", Columns: []introspect.Column{
+			{Name: "total", DataType: "decimal", IsNullable: true, Default: &zero},
+		}},
+	}
+
+	got := Parse([]byte(cfg)).Check(tables)
+	want := []string{
+		"2:14: error: options.null_rate 1.5 must be between 0 and 1",
+		"6:7: warning: users.email is NOT NULL; its null rate is ignored",
+		`7:7: error: unknown column "nickname" in table users`,
+		"10:7: warning: users.first_name has no DEFAULT the generator can reproduce (none, NULL, an expression other than the current time, or a key column); its default rate is ignored",
+		"15:14: error: null rate 0.6 and default rate 0.6 of orders.total add up to more than 1",
+	}
+	assertProblems(t, got, want)
+}
+
+func TestCheck_Children(t *testing.T) {
+	cfg := `tables:
+  users:
+    children_per_parent:
+      email: {max: 3}
+  orders:
+    rows: 500
+    references:
+      user_id: users.id
+    children_per_parent:
+      user_id:
+        max: 500
+        none: 0.2
+        type: zipff
+      total:
+        min: 5
+        max: 2
+        mean: 0.5
+`
+	got := Parse([]byte(cfg)).Check(testTables)
+	want := []string{
+		"4:7: error: users.email is not a foreign key; children_per_parent needs the column referencing the parent table (declare it in references if the schema has no foreign key)",
+		"6:5: warning: tables.orders.rows is ignored: children_per_parent sets the rows, the children of every parent row",
+		`13:15: error: unknown type "zipff" for children_per_parent for orders.user_id (expected uniform, zipf, normal, lognormal, exponential, pareto) (did you mean "zipf"?)`,
+		"14:7: error: orders.total is not a foreign key; children_per_parent needs the column referencing the parent table (declare it in references if the schema has no foreign key)",
+		"14:7: error: children_per_parent for orders is set for user_id and total; set it for one foreign key column",
+		"15:14: error: invalid min for children_per_parent for orders.total: min 5 must not be above max 2",
+		"17:9: warning: mean is only used by normal, lognormal and exponential distributions; it is ignored for children_per_parent for orders.total",
+	}
+	assertProblems(t, got, want)
+}
+
+func TestCheck_ChildrenUnique(t *testing.T) {
+	tables := map[string]*introspect.Table{
+		"users": {Name: "users", Columns: []introspect.Column{{Name: "id", DataType: "int", IsPrimaryKey: true}}},
+		"shops": {Name: "shops", Columns: []introspect.Column{{Name: "id", DataType: "int", IsPrimaryKey: true}}},
+		"orders": {Name: "orders", Columns: []introspect.Column{
+			{Name: "user_id", DataType: "int", FK: &introspect.ForeignKey{ReferencedTable: "users", ReferencedColumn: "id"}},
+			{Name: "shop_id", DataType: "int", FK: &introspect.ForeignKey{ReferencedTable: "shops", ReferencedColumn: "id"}},
+		}, UniqueIndexes: []introspect.UniqueIndex{{Name: "user_shop", Columns: []string{"user_id", "shop_id"}}}},
+		"reviews": {Name: "reviews", Columns: []introspect.Column{
+			{Name: "user_id", DataType: "int", FK: &introspect.ForeignKey{ReferencedTable: "users", ReferencedColumn: "id"}},
+			{Name: "stars", DataType: "enum", ColumnType: "enum('1','2','3','4','5')", EnumValues: []string{"1", "2", "3", "4", "5"}},
+		}, UniqueIndexes: []introspect.UniqueIndex{{Name: "user_stars", Columns: []string{"user_id", "stars"}}}},
+	}
+	cfg := `tables:
+  shops:
+    rows: 3
+  orders:
+    children_per_parent:
+      user_id: {min: 5, max: 5}
+  reviews:
+    children_per_parent:
+      user_id: {max: 5}
+`
+	got := Parse([]byte(cfg)).Check(tables)
+	want := []string{
+		"6:30: error: children_per_parent for orders.user_id: max 5 is above the 3 rows a parent can have in unique index user_shop",
+	}
+	assertProblems(t, got, want)
+}
+
+// assertProblems fails the test unless got, as strings, is want.
+func assertProblems(t *testing.T, got []Problem, want []string) {
+	t.Helper()
+	var lines []string
+	for _, p := range got {
+		lines = append(lines, p.String())
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\n\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}